@@ -0,0 +1,70 @@
+package api
+
+import (
+	"application/service"
+	"application/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AppraiserHandler struct {
+	appraiserService *service.AppraiserService
+}
+
+func NewAppraiserHandler() *AppraiserHandler {
+	return &AppraiserHandler{
+		appraiserService: &service.AppraiserService{},
+	}
+}
+
+// RegisterAppraiser 登记评估机构（需交易平台审核通过后才能提交评估报告）
+func (h *AppraiserHandler) RegisterAppraiser(c *gin.Context) {
+	var req struct {
+		ID        string `json:"id"`        // 评估机构ID
+		Name      string `json:"name"`      // 评估机构名称
+		LicenseNo string `json:"licenseNo"` // 评估资质证书编号
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "评估机构信息格式错误")
+		return
+	}
+
+	err := h.appraiserService.RegisterAppraiser(req.ID, req.Name, req.LicenseNo)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评估机构登记成功，等待交易平台审核", nil)
+}
+
+// SubmitAppraisal 提交评估报告
+func (h *AppraiserHandler) SubmitAppraisal(c *gin.Context) {
+	var req service.Appraisal
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "评估报告格式错误")
+		return
+	}
+
+	err := h.appraiserService.SubmitAppraisal(req)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评估报告提交成功", nil)
+}
+
+// QueryAppraisals 查询汽车的全部评估报告
+func (h *AppraiserHandler) QueryAppraisals(c *gin.Context) {
+	carID := c.Param("carId")
+	appraisals, err := h.appraiserService.QueryAppraisals(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, appraisals)
+}
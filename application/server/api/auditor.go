@@ -0,0 +1,91 @@
+package api
+
+import (
+	"application/service"
+	"application/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditorHandler struct {
+	auditorService *service.AuditorService
+}
+
+func NewAuditorHandler() *AuditorHandler {
+	return &AuditorHandler{
+		auditorService: &service.AuditorService{},
+	}
+}
+
+// QueryIdentity 查询审计人员身份的客户端身份标识（交由监管机构登记）
+func (h *AuditorHandler) QueryIdentity(c *gin.Context) {
+	clientID, err := h.auditorService.QueryIdentity()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"clientId": clientID})
+}
+
+// QueryCarAudit 查询单辆汽车的审计视图：汽车信息、涉及的交易、证书和所有权变更历史
+func (h *AuditorHandler) QueryCarAudit(c *gin.Context) {
+	audit, err := h.auditorService.QueryCarAudit(c.Param("id"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, audit)
+}
+
+// QueryCarHistory 查询汽车记录的账本历史
+func (h *AuditorHandler) QueryCarHistory(c *gin.Context) {
+	records, err := h.auditorService.QueryCarHistory(c.Param("id"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QueryTransactionHistory 查询交易记录的账本历史
+func (h *AuditorHandler) QueryTransactionHistory(c *gin.Context) {
+	records, err := h.auditorService.QueryTransactionHistory(c.Param("txId"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QueryCertificateHistory 查询证书记录的账本历史
+func (h *AuditorHandler) QueryCertificateHistory(c *gin.Context) {
+	records, err := h.auditorService.QueryCertificateHistory(c.Param("certId"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QueryPrivateDataHash 查询私有数据集合中记录的哈希
+func (h *AuditorHandler) QueryPrivateDataHash(c *gin.Context) {
+	collection := c.Query("collection")
+	key := c.Query("key")
+	if collection == "" || key == "" {
+		utils.BadRequest(c, "集合名称和键不能为空")
+		return
+	}
+
+	hash, err := h.auditorService.QueryPrivateDataHash(collection, key)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"collection": collection, "key": key, "hash": hash})
+}
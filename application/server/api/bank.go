@@ -3,6 +3,7 @@ package api
 import (
 	"application/service"
 	"application/utils"
+	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -30,6 +31,287 @@ func (h *BankHandler) CompleteTransaction(c *gin.Context) {
 	utils.SuccessWithMessage(c, "交易完成", nil)
 }
 
+// Reconcile 导入银行对账单（CSV 或 camt.053），精确匹配的入账流水自动完成交易，返回需人工核对的流水
+func (h *BankHandler) Reconcile(c *gin.Context) {
+	file, err := c.FormFile("statementFile")
+	if err != nil {
+		utils.BadRequest(c, "获取对账单文件失败: "+err.Error())
+		return
+	}
+	format := c.PostForm("format") // 可选：csv / camt053，为空时自动识别
+
+	report, err := h.bankService.Reconcile(file, format)
+	if err != nil {
+		utils.ServerError(c, "对账失败："+err.Error())
+		return
+	}
+
+	if len(report.Review) > 0 {
+		utils.SuccessWithMessage(c, fmt.Sprintf("对账完成：已完成 %d 笔交易，%d 条流水需人工核对", len(report.Completed), len(report.Review)), report)
+		return
+	}
+	utils.SuccessWithMessage(c, fmt.Sprintf("对账完成：已完成 %d 笔交易", len(report.Completed)), report)
+}
+
+// ConfirmReservationDeposit 确认已收到预订定金（仅银行组织可以调用）
+func (h *BankHandler) ConfirmReservationDeposit(c *gin.Context) {
+	reservationID := c.Param("id")
+	var req struct {
+		DepositRef string `json:"depositRef"` // 定金收款凭证号
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "定金确认信息格式错误")
+		return
+	}
+
+	err := h.bankService.ConfirmReservationDeposit(reservationID, req.DepositRef)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "定金已确认", nil)
+}
+
+// QueryReservation 查询预订信息
+func (h *BankHandler) QueryReservation(c *gin.Context) {
+	reservation, err := h.bankService.QueryReservation(c.Param("id"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, reservation)
+}
+
+// QueryReservationList 分页查询预订列表
+func (h *BankHandler) QueryReservationList(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	bookmark := c.DefaultQuery("bookmark", "")
+	status := c.DefaultQuery("status", "")
+
+	result, err := h.bankService.QueryReservationList(int32(pageSize), bookmark, status)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// CreateLoan 登记购车贷款及还款计划（仅银行组织可以调用）
+func (h *BankHandler) CreateLoan(c *gin.Context) {
+	var req struct {
+		LoanID    string                    `json:"loanId"`
+		TxID      string                    `json:"txId"`      // 关联的交易ID，借款人为交易买家
+		Principal float64                   `json:"principal"` // 贷款本金
+		Schedule  []service.LoanInstallment `json:"schedule"`  // 还款计划
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "贷款信息格式错误")
+		return
+	}
+	if len(req.Schedule) == 0 {
+		utils.BadRequest(c, "还款计划至少包含一期")
+		return
+	}
+
+	err := h.bankService.CreateLoan(req.LoanID, req.TxID, req.Principal, req.Schedule)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "贷款登记成功", nil)
+}
+
+// RecordLoanPayment 记录一笔还款（仅银行组织可以调用）
+func (h *BankHandler) RecordLoanPayment(c *gin.Context) {
+	loanID := c.Param("id")
+	var req struct {
+		PaymentRef string  `json:"paymentRef"` // 银行还款凭证号
+		Amount     float64 `json:"amount"`     // 还款金额
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "还款信息格式错误")
+		return
+	}
+
+	err := h.bankService.RecordLoanPayment(loanID, req.PaymentRef, req.Amount)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "还款已记录", nil)
+}
+
+// MarkLoanDefault 将逾期未还的贷款标记为违约（仅银行组织可以调用）
+func (h *BankHandler) MarkLoanDefault(c *gin.Context) {
+	loanID := c.Param("id")
+	var req struct {
+		Reason string `json:"reason"` // 违约原因
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "违约信息格式错误")
+		return
+	}
+
+	err := h.bankService.MarkLoanDefault(loanID, req.Reason)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "贷款已标记为违约", nil)
+}
+
+// RepossessCar 收回违约贷款的抵押汽车（仅银行组织可以调用）
+func (h *BankHandler) RepossessCar(c *gin.Context) {
+	loanID := c.Param("id")
+	var req struct {
+		BankParty string `json:"bankParty"` // 接收汽车的银行参与方ID
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "收回信息格式错误")
+		return
+	}
+
+	err := h.bankService.RepossessCar(loanID, req.BankParty)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "汽车已收回", nil)
+}
+
+// QueryLoan 查询贷款信息
+func (h *BankHandler) QueryLoan(c *gin.Context) {
+	loan, err := h.bankService.QueryLoan(c.Param("id"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, loan)
+}
+
+// QueryLoanList 分页查询贷款列表
+func (h *BankHandler) QueryLoanList(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	bookmark := c.DefaultQuery("bookmark", "")
+	status := c.DefaultQuery("status", "")
+
+	result, err := h.bankService.QueryLoanList(int32(pageSize), bookmark, status)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// SetPaymentSettlement 设置链上代币结算使用的代币链码（仅银行组织可以调用）
+func (h *BankHandler) SetPaymentSettlement(c *gin.Context) {
+	var req struct {
+		TokenChaincode string `json:"tokenChaincode"` // 代币链码名称，为空时恢复链下付款
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "结算配置格式错误")
+		return
+	}
+
+	err := h.bankService.SetPaymentSettlement(req.TokenChaincode)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "结算方式已更新", nil)
+}
+
+// QueryPaymentSettlement 查询链上代币结算配置
+func (h *BankHandler) QueryPaymentSettlement(c *gin.Context) {
+	config, err := h.bankService.QueryPaymentSettlement()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, config)
+}
+
+// MintToken 向参与方账户发行代币（仅银行组织可以调用）
+func (h *BankHandler) MintToken(c *gin.Context) {
+	var req struct {
+		Account string `json:"account"` // 参与方ID
+		Amount  int64  `json:"amount"`  // 发行金额（分）
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "发行信息格式错误")
+		return
+	}
+
+	err := h.bankService.MintToken(req.Account, req.Amount)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "代币发行成功", nil)
+}
+
+// SetTokenSettlementChaincode 设置代币链码的结算链码（仅银行组织可以调用）
+func (h *BankHandler) SetTokenSettlementChaincode(c *gin.Context) {
+	var req struct {
+		Chaincode string `json:"chaincode"` // 结算链码名称，为空时禁止批量划转
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "结算链码格式错误")
+		return
+	}
+
+	err := h.bankService.SetTokenSettlementChaincode(req.Chaincode)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "结算链码已更新", nil)
+}
+
+// QueryTokenSettlementChaincode 查询代币链码的结算链码
+func (h *BankHandler) QueryTokenSettlementChaincode(c *gin.Context) {
+	chaincode, err := h.bankService.QueryTokenSettlementChaincode()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"chaincode": chaincode})
+}
+
+// QueryTokenBalance 查询参与方账户的代币余额
+func (h *BankHandler) QueryTokenBalance(c *gin.Context) {
+	account := c.Param("account")
+	balance, err := h.bankService.QueryTokenBalance(account)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"account": account, "balance": balance})
+}
+
 // QueryTransaction 查询交易信息
 func (h *BankHandler) QueryTransaction(c *gin.Context) {
 	txID := c.Param("txId")
@@ -57,6 +339,92 @@ func (h *BankHandler) QueryTransactionList(c *gin.Context) {
 	utils.Success(c, result)
 }
 
+// SetPartyKYCStatus 设置参与方的 KYC 状态（仅银行组织可以调用）
+func (h *BankHandler) SetPartyKYCStatus(c *gin.Context) {
+	partyID := c.Param("id")
+	var req struct {
+		Status string `json:"status"` // KYC 状态：PENDING / VERIFIED / REJECTED
+		Remark string `json:"remark"` // 审核备注
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "KYC 信息格式错误")
+		return
+	}
+
+	err := h.bankService.SetPartyKYCStatus(partyID, req.Status, req.Remark)
+	if err != nil {
+		utils.ServerError(c, "设置 KYC 状态失败："+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "KYC 状态已更新", nil)
+}
+
+// BindPartyIdentity 将客户端身份绑定到参与方（仅银行组织可以调用）
+func (h *BankHandler) BindPartyIdentity(c *gin.Context) {
+	partyID := c.Param("id")
+	var req struct {
+		MSPID    string `json:"mspId"`    // 客户端身份所属组织 MSP ID
+		ClientID string `json:"clientId"` // 客户端身份标识（由客户端调用链码 ClientIdentityID 获得）
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "身份绑定信息格式错误")
+		return
+	}
+
+	err := h.bankService.BindPartyIdentity(partyID, req.MSPID, req.ClientID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "参与方身份绑定成功", nil)
+}
+
+// QueryParty 查询参与方信息
+func (h *BankHandler) QueryParty(c *gin.Context) {
+	partyID := c.Param("id")
+	party, err := h.bankService.QueryParty(partyID)
+	if err != nil {
+		utils.ServerError(c, "查询参与方信息失败："+err.Error())
+		return
+	}
+
+	utils.Success(c, party)
+}
+
+// QueryPartyList 分页查询参与方列表
+func (h *BankHandler) QueryPartyList(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	bookmark := c.DefaultQuery("bookmark", "")
+	kycStatus := c.DefaultQuery("kycStatus", "")
+
+	result, err := h.bankService.QueryPartyList(int32(pageSize), bookmark, kycStatus)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// QueryMarketStats 查询市场统计数据（period: day/week/month/year，start/end: RFC3339）
+func (h *BankHandler) QueryMarketStats(c *gin.Context) {
+	period := c.DefaultQuery("period", "month")
+	startTime := c.DefaultQuery("start", "")
+	endTime := c.DefaultQuery("end", "")
+
+	result, err := h.bankService.QueryMarketStats(period, startTime, endTime)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
 // QueryBlockList 分页查询区块列表
 func (h *BankHandler) QueryBlockList(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
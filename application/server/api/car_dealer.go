@@ -5,6 +5,7 @@ import (
 	"application/utils"
 	"strconv"
 	"strings" // Import strings package
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,10 +25,10 @@ func NewCarDealerHandler() *CarDealerHandler {
 // CreateCar 创建汽车信息（仅汽车经销商组织可以调用）
 func (h *CarDealerHandler) CreateCar(c *gin.Context) {
 	var req struct {
-		ID    string `json:"id"`    // 车辆唯一标识，例如车牌号
+		Plate string `json:"plate"` // 车牌号（汽车ID由链码生成）
 		Model string `json:"model"` // 车型
 		VIN   string `json:"vin"`   // 车辆识别代号 (Vehicle Identification Number)
-		Owner string `json:"owner"` // 车主
+		Owner string `json:"owner"` // 车主（参与方ID）
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,13 +36,36 @@ func (h *CarDealerHandler) CreateCar(c *gin.Context) {
 		return
 	}
 
-	err := h.carService.CreateCar(req.ID, req.Model, req.VIN, req.Owner)
+	id, err := h.carService.CreateCar(req.Plate, req.Model, req.VIN, req.Owner)
 	if err != nil {
 		utils.ServerError(c, "创建汽车信息失败："+err.Error())
 		return
 	}
 
-	utils.SuccessWithMessage(c, "汽车信息创建成功", nil)
+	utils.SuccessWithMessage(c, "汽车信息创建成功", gin.H{"id": id})
+}
+
+// CreateCarsBatch 批量创建汽车信息（仅汽车经销商组织可以调用），全部成功或全部失败
+func (h *CarDealerHandler) CreateCarsBatch(c *gin.Context) {
+	var req []service.CarBatchItem
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "汽车批次格式错误，应为汽车信息数组")
+		return
+	}
+
+	results, created, err := h.carService.CreateCarsBatch(req)
+	if err != nil {
+		utils.ServerError(c, "批量创建汽车信息失败："+err.Error())
+		return
+	}
+
+	if !created {
+		utils.BadRequestWithData(c, "汽车批次校验失败，未写入任何记录", results)
+		return
+	}
+
+	utils.SuccessWithMessage(c, "汽车信息批量创建成功", results)
 }
 
 // QueryCar 查询汽车信息
@@ -88,6 +112,29 @@ func (h *CarDealerHandler) UploadCertificate(c *gin.Context) {
 		return
 	}
 
+	// Get optional validity period from form data (RFC3339)
+	var validFrom, validUntil time.Time
+	if v := c.PostForm("validFrom"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.BadRequest(c, "生效时间 (validFrom) 格式错误，应为 RFC3339")
+			return
+		}
+		validFrom = t
+	}
+	if v := c.PostForm("validUntil"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.BadRequest(c, "失效时间 (validUntil) 格式错误，应为 RFC3339")
+			return
+		}
+		validUntil = t
+	}
+
+	// Get optional issuer signature from form data
+	issuerID := c.PostForm("issuerId")
+	signature := c.PostForm("signature")
+
 	// Get file from form data
 	file, err := c.FormFile("certificateFile") // "certificateFile" is the expected form field name
 	if err != nil {
@@ -96,7 +143,7 @@ func (h *CarDealerHandler) UploadCertificate(c *gin.Context) {
 	}
 
 	// Call the service to handle upload and chaincode interaction
-	certPayload, err := h.certificateService.AddCertificate(carId, certType, file)
+	certPayload, err := h.certificateService.AddCertificate(carId, certType, validFrom, validUntil, issuerID, signature, file)
 	if err != nil {
 		utils.ServerError(c, "上传证书失败: "+err.Error())
 		return
@@ -130,24 +177,23 @@ func (h *CarDealerHandler) VerifyCertificateHandler(c *gin.Context) {
 		return
 	}
 
-	match, storedHash, currentHash, err := h.certificateService.VerifyCertificate(certId)
+	result, err := h.certificateService.VerifyCertificate(certId)
 	if err != nil {
 		utils.ServerError(c, "验证证书失败: "+err.Error())
 		return
 	}
 
-	if match {
-		utils.SuccessWithMessage(c, "验证成功：文件哈希与链上记录一致", gin.H{
-			"match":       true,
-			"storedHash":  storedHash,
-			"currentHash": currentHash,
-		})
-	} else {
-		utils.SuccessWithMessage(c, "验证失败：文件哈希与链上记录不一致", gin.H{
-			"match":       false,
-			"storedHash":  storedHash,
-			"currentHash": currentHash,
-		})
+	switch {
+	case !result.Match:
+		utils.SuccessWithMessage(c, "验证失败：文件哈希与链上记录不一致", result)
+	case result.Revoked:
+		utils.SuccessWithMessage(c, "验证失败：文件哈希与链上记录一致，但证书已被吊销", result)
+	case result.Expired:
+		utils.SuccessWithMessage(c, "验证失败：文件哈希与链上记录一致，但证书已过期", result)
+	case result.Signed && !result.SignatureValid:
+		utils.SuccessWithMessage(c, "验证失败：文件哈希与链上记录一致，但签发机构签名无效", result)
+	default:
+		utils.SuccessWithMessage(c, "验证成功：文件哈希与链上记录一致", result)
 	}
 }
 
@@ -167,7 +213,7 @@ func (h *CarDealerHandler) VerifyUploadedCertificateHandler(c *gin.Context) {
 	}
 
 	// Call the service to handle comparison
-	match, storedHash, currentHash, err := h.certificateService.VerifyUploadedCertificate(carId, file)
+	result, err := h.certificateService.VerifyUploadedCertificate(carId, file)
 	if err != nil {
 		// Handle specific errors like "no original certificate" differently if needed
 		if strings.Contains(err.Error(), "没有已上传的原始证书记录") {
@@ -179,19 +225,170 @@ func (h *CarDealerHandler) VerifyUploadedCertificateHandler(c *gin.Context) {
 	}
 
 	// Return result
-	if match {
-		utils.SuccessWithMessage(c, "验证成功：上传文件与原始证书哈希一致", gin.H{
-			"match":       true,
-			"storedHash":  storedHash,
-			"currentHash": currentHash,
-		})
-	} else {
-		utils.SuccessWithMessage(c, "验证失败：上传文件与原始证书哈希不一致", gin.H{
-			"match":       false,
-			"storedHash":  storedHash,
-			"currentHash": currentHash,
-		})
+	switch {
+	case !result.Match:
+		utils.SuccessWithMessage(c, "验证失败：上传文件与原始证书哈希不一致", result)
+	case result.Revoked:
+		utils.SuccessWithMessage(c, "验证失败：上传文件与原始证书哈希一致，但证书已被吊销", result)
+	case result.Expired:
+		utils.SuccessWithMessage(c, "验证失败：上传文件与原始证书哈希一致，但证书已过期", result)
+	case result.Signed && !result.SignatureValid:
+		utils.SuccessWithMessage(c, "验证失败：上传文件与原始证书哈希一致，但签发机构签名无效", result)
+	default:
+		utils.SuccessWithMessage(c, "验证成功：上传文件与原始证书哈希一致", result)
+	}
+}
+
+// QueryTrustedIssuers 查询受信任的证件签发机构列表
+func (h *CarDealerHandler) QueryTrustedIssuers(c *gin.Context) {
+	issuers, err := h.certificateService.QueryTrustedIssuers()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, issuers)
+}
+
+// QueryExpiringCertificates 分页查询 days 天内到期的证书（包含已过期的证书）
+func (h *CarDealerHandler) QueryExpiringCertificates(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 {
+		utils.BadRequest(c, "天数 (days) 必须为非负整数")
+		return
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	bookmark := c.DefaultQuery("bookmark", "")
+
+	result, err := h.certificateService.QueryExpiringCertificates(days, int32(pageSize), bookmark)
+	if err != nil {
+		utils.ServerError(c, "查询即将到期的证书失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// RegisterParty 登记参与方
+func (h *CarDealerHandler) RegisterParty(c *gin.Context) {
+	var req struct {
+		ID         string `json:"id"`         // 参与方ID
+		Type       string `json:"type"`       // 参与方类型：INDIVIDUAL / COMPANY
+		Name       string `json:"name"`       // 姓名或企业名称
+		NationalID string `json:"nationalId"` // 身份证号或统一社会信用代码（仅用于计算哈希，不上链）
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "参与方信息格式错误")
+		return
 	}
+
+	err := h.carService.RegisterParty(req.ID, req.Type, req.Name, req.NationalID)
+	if err != nil {
+		utils.ServerError(c, "登记参与方失败："+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "参与方登记成功，等待银行 KYC 认证", nil)
+}
+
+// QueryParty 查询参与方信息
+func (h *CarDealerHandler) QueryParty(c *gin.Context) {
+	partyID := c.Param("id")
+	party, err := h.carService.QueryParty(partyID)
+	if err != nil {
+		utils.ServerError(c, "查询参与方信息失败："+err.Error())
+		return
+	}
+
+	utils.Success(c, party)
+}
+
+// UpdateCarAttributes 更正汽车属性或变更车牌号，须填写变更原因
+func (h *CarDealerHandler) UpdateCarAttributes(c *gin.Context) {
+	carID := c.Param("id")
+	var req struct {
+		Plate  string `json:"plate"`  // 新车牌号（为空表示不修改）
+		Color  string `json:"color"`  // 新颜色（为空表示不修改）
+		Model  string `json:"model"`  // 新车型（为空表示不修改）
+		Reason string `json:"reason"` // 变更原因
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "汽车属性信息格式错误")
+		return
+	}
+
+	err := h.carService.UpdateCarAttributes(carID, req.Plate, req.Color, req.Model, req.Reason)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "汽车属性修改成功", nil)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (h *CarDealerHandler) QueryCarByPlate(c *gin.Context) {
+	plate := c.Param("plate")
+	car, err := h.carService.QueryCarByPlate(plate)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, car)
+}
+
+// QueryCarChangeLog 查询汽车的属性变更记录
+func (h *CarDealerHandler) QueryCarChangeLog(c *gin.Context) {
+	carID := c.Param("id")
+	records, err := h.carService.QueryCarChangeLog(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QuerySaleReadiness 检查汽车的证件是否满足销售所需证件策略
+func (h *CarDealerHandler) QuerySaleReadiness(c *gin.Context) {
+	carID := c.Param("id")
+	readiness, err := h.carService.QuerySaleReadiness(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, readiness)
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史
+func (h *CarDealerHandler) QueryOwnershipHistory(c *gin.Context) {
+	carID := c.Param("id")
+	records, err := h.carService.QueryOwnershipHistory(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QueryMarketStats 查询市场统计数据（period: day/week/month/year，start/end: RFC3339）
+func (h *CarDealerHandler) QueryMarketStats(c *gin.Context) {
+	period := c.DefaultQuery("period", "month")
+	startTime := c.DefaultQuery("start", "")
+	endTime := c.DefaultQuery("end", "")
+
+	result, err := h.carService.QueryMarketStats(period, startTime, endTime)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
 }
 
 // QueryBlockList 分页查询区块列表
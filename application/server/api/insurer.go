@@ -0,0 +1,190 @@
+package api
+
+import (
+	"application/service"
+	"application/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InsurerHandler struct {
+	insurerService *service.InsurerService
+}
+
+func NewInsurerHandler() *InsurerHandler {
+	return &InsurerHandler{
+		insurerService: &service.InsurerService{},
+	}
+}
+
+// RegisterPolicy 登记保单（仅保险公司组织可以调用）
+func (h *InsurerHandler) RegisterPolicy(c *gin.Context) {
+	var req struct {
+		PolicyNo       string  `json:"policyNo"`       // 保单号
+		CarID          string  `json:"carId"`          // 汽车ID
+		InsuredParty   string  `json:"insuredParty"`   // 被保险人（参与方ID）
+		CoverageType   string  `json:"coverageType"`   // 险种
+		CoverageAmount float64 `json:"coverageAmount"` // 保险金额
+		ValidFrom      string  `json:"validFrom"`      // 保险起期（RFC3339）
+		ValidUntil     string  `json:"validUntil"`     // 保险止期（RFC3339）
+		OnOwnerChange  string  `json:"onOwnerChange"`  // 过户处理规则：LAPSE/TRANSFER，为空使用默认规则
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "保单信息格式错误")
+		return
+	}
+
+	err := h.insurerService.RegisterPolicy(req.PolicyNo, req.CarID, req.InsuredParty, req.CoverageType, req.CoverageAmount, req.ValidFrom, req.ValidUntil, req.OnOwnerChange)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "保单登记成功", nil)
+}
+
+// QueryPolicy 通过保单号查询保单
+func (h *InsurerHandler) QueryPolicy(c *gin.Context) {
+	policyNo := c.Param("policyNo")
+	policy, err := h.insurerService.QueryPolicy(policyNo)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, policy)
+}
+
+// QueryCarPolicies 查询汽车的全部保单
+func (h *InsurerHandler) QueryCarPolicies(c *gin.Context) {
+	carID := c.Param("carId")
+	policies, err := h.insurerService.QueryCarPolicies(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, policies)
+}
+
+// LogClaim 登记理赔（仅承保该保单的保险公司可以调用）
+func (h *InsurerHandler) LogClaim(c *gin.Context) {
+	var req struct {
+		ClaimID      string  `json:"claimId"`      // 理赔编号
+		PolicyNo     string  `json:"policyNo"`     // 保单号
+		IncidentTime string  `json:"incidentTime"` // 出险时间（RFC3339）
+		DamageLevel  string  `json:"damageLevel"`  // 损伤程度：MINOR/MODERATE/SEVERE/TOTAL_LOSS
+		Description  string  `json:"description"`  // 事故及损伤描述
+		ClaimAmount  float64 `json:"claimAmount"`  // 报损金额
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "理赔信息格式错误")
+		return
+	}
+
+	err := h.insurerService.LogClaim(req.ClaimID, req.PolicyNo, req.IncidentTime, req.DamageLevel, req.Description, req.ClaimAmount)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "理赔登记成功", nil)
+}
+
+// UpdateClaimStatus 更新理赔状态
+func (h *InsurerHandler) UpdateClaimStatus(c *gin.Context) {
+	carID := c.Param("carId")
+	claimID := c.Param("claimId")
+	var req struct {
+		Status     string  `json:"status"`     // FILED/APPROVED/REJECTED/PAID
+		PaidAmount float64 `json:"paidAmount"` // 实际赔付金额（状态为 PAID 时有效）
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "理赔状态信息格式错误")
+		return
+	}
+
+	err := h.insurerService.UpdateClaimStatus(carID, claimID, req.Status, req.PaidAmount)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "理赔状态已更新", nil)
+}
+
+// QueryDamageHistory 查询汽车的损伤历史
+func (h *InsurerHandler) QueryDamageHistory(c *gin.Context) {
+	carID := c.Param("carId")
+	claims, err := h.insurerService.QueryDamageHistory(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, claims)
+}
+
+// SetPolicyTransferRule 设置车辆过户时保单的默认处理规则
+func (h *InsurerHandler) SetPolicyTransferRule(c *gin.Context) {
+	var req struct {
+		Rule string `json:"rule"` // LAPSE/TRANSFER
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "过户规则格式错误")
+		return
+	}
+
+	err := h.insurerService.SetPolicyTransferRule(req.Rule)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "保单过户规则已更新", nil)
+}
+
+// QueryPolicyTransferRule 查询车辆过户时保单的默认处理规则
+func (h *InsurerHandler) QueryPolicyTransferRule(c *gin.Context) {
+	rule, err := h.insurerService.QueryPolicyTransferRule()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, rule)
+}
+
+// QueryMarketStats 查询市场统计数据（period: day/week/month/year，start/end: RFC3339）
+func (h *InsurerHandler) QueryMarketStats(c *gin.Context) {
+	period := c.DefaultQuery("period", "month")
+	startTime := c.DefaultQuery("start", "")
+	endTime := c.DefaultQuery("end", "")
+
+	result, err := h.insurerService.QueryMarketStats(period, startTime, endTime)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// QueryBlockList 分页查询区块列表
+func (h *InsurerHandler) QueryBlockList(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+
+	result, err := h.insurerService.QueryBlockList(pageSize, pageNum)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
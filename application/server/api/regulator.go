@@ -0,0 +1,359 @@
+package api
+
+import (
+	"application/service"
+	"application/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RegulatorHandler struct {
+	regulatorService *service.RegulatorService
+}
+
+func NewRegulatorHandler() *RegulatorHandler {
+	return &RegulatorHandler{
+		regulatorService: &service.RegulatorService{},
+	}
+}
+
+// FlagCarStolen 标记汽车为被盗车辆（仅监管机构组织可以调用）
+func (h *RegulatorHandler) FlagCarStolen(c *gin.Context) {
+	carID := c.Param("id")
+	var req struct {
+		CaseRef string `json:"caseRef"` // 案件编号
+		Remark  string `json:"remark"`  // 备注
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "被盗标记信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.FlagCarStolen(carID, req.CaseRef, req.Remark)
+	if err != nil {
+		utils.ServerError(c, "标记被盗车辆失败："+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已标记为被盗车辆", nil)
+}
+
+// ClearStolenFlag 解除汽车的被盗标记（仅监管机构组织可以调用）
+func (h *RegulatorHandler) ClearStolenFlag(c *gin.Context) {
+	carID := c.Param("id")
+	var req struct {
+		CaseRef string `json:"caseRef"` // 案件编号
+		Remark  string `json:"remark"`  // 备注
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "解除标记信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.ClearStolenFlag(carID, req.CaseRef, req.Remark)
+	if err != nil {
+		utils.ServerError(c, "解除被盗标记失败："+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "已解除被盗标记", nil)
+}
+
+// QueryCar 查询汽车信息
+func (h *RegulatorHandler) QueryCar(c *gin.Context) {
+	id := c.Param("id")
+	car, err := h.regulatorService.QueryCar(id)
+	if err != nil {
+		utils.ServerError(c, "查询汽车信息失败："+err.Error())
+		return
+	}
+
+	utils.Success(c, car)
+}
+
+// QueryStolenFlagHistory 查询汽车的被盗标记历史
+func (h *RegulatorHandler) QueryStolenFlagHistory(c *gin.Context) {
+	carID := c.Param("id")
+	records, err := h.regulatorService.QueryStolenFlagHistory(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// TransferOwnership 办理非交易过户：继承、赠与、法院判决（仅监管机构组织可以调用）
+func (h *RegulatorHandler) TransferOwnership(c *gin.Context) {
+	carID := c.Param("id")
+	var req struct {
+		NewOwner       string `json:"newOwner"`       // 新所有者（参与方ID）
+		TransferType   string `json:"transferType"`   // 过户类型：INHERITANCE/GIFT/COURT_ORDER
+		DocumentCertID string `json:"documentCertId"` // 证明文件的证书ID
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "过户信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.TransferOwnership(carID, req.NewOwner, req.TransferType, req.DocumentCertID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "过户办理成功", nil)
+}
+
+// RegisterTrustedIssuer 登记受信任的证件签发机构
+func (h *RegulatorHandler) RegisterTrustedIssuer(c *gin.Context) {
+	var req struct {
+		ID      string `json:"id"`      // 签发机构ID
+		Name    string `json:"name"`    // 签发机构名称
+		CertPEM string `json:"certPem"` // 签发机构 X.509 证书（PEM 格式，ECDSA 公钥）
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "签发机构信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.RegisterTrustedIssuer(req.ID, req.Name, req.CertPEM)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "签发机构登记成功", nil)
+}
+
+// RevokeTrustedIssuer 撤销对签发机构的信任
+func (h *RegulatorHandler) RevokeTrustedIssuer(c *gin.Context) {
+	issuerID := c.Param("id")
+	var req struct {
+		Remark string `json:"remark"` // 撤销原因
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "撤销信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.RevokeTrustedIssuer(issuerID, req.Remark)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "签发机构已撤销", nil)
+}
+
+// QueryTrustedIssuers 查询受信任的签发机构列表
+func (h *RegulatorHandler) QueryTrustedIssuers(c *gin.Context) {
+	issuers, err := h.regulatorService.QueryTrustedIssuers()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, issuers)
+}
+
+// RegisterAuditor 登记审计人员的客户端身份
+func (h *RegulatorHandler) RegisterAuditor(c *gin.Context) {
+	var req struct {
+		MSPID    string `json:"mspId"`    // 客户端身份所属组织
+		ClientID string `json:"clientId"` // 客户端身份标识
+		Name     string `json:"name"`     // 审计人员名称
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "审计人员信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.RegisterAuditor(req.MSPID, req.ClientID, req.Name)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "审计人员登记成功", nil)
+}
+
+// RevokeAuditor 撤销审计人员身份
+func (h *RegulatorHandler) RevokeAuditor(c *gin.Context) {
+	var req struct {
+		MSPID    string `json:"mspId"`    // 客户端身份所属组织
+		ClientID string `json:"clientId"` // 客户端身份标识
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "审计人员信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.RevokeAuditor(req.MSPID, req.ClientID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "审计人员已撤销", nil)
+}
+
+// QueryAuditors 查询全部审计人员
+func (h *RegulatorHandler) QueryAuditors(c *gin.Context) {
+	auditors, err := h.regulatorService.QueryAuditors()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, auditors)
+}
+
+// RevokeCertificate 吊销证书
+func (h *RegulatorHandler) RevokeCertificate(c *gin.Context) {
+	certID := c.Param("certId")
+	var req struct {
+		Reason string `json:"reason"` // 吊销原因
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "吊销信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.RevokeCertificate(certID, req.Reason)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "证书已吊销", nil)
+}
+
+// SetRequiredDocumentsPolicy 设置销售所需证件策略
+func (h *RegulatorHandler) SetRequiredDocumentsPolicy(c *gin.Context) {
+	var req struct {
+		CertTypes []string `json:"certTypes"` // 必需的证书类型，例如 ["REGISTRATION", "INSPECTION"]
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "策略信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.SetRequiredDocumentsPolicy(req.CertTypes)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "销售所需证件策略已更新", nil)
+}
+
+// QueryRequiredDocumentsPolicy 查询销售所需证件策略
+func (h *RegulatorHandler) QueryRequiredDocumentsPolicy(c *gin.Context) {
+	policy, err := h.regulatorService.QueryRequiredDocumentsPolicy()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, policy)
+}
+
+// UpdateCarAttributes 更正汽车属性或变更车牌号，须填写变更原因
+func (h *RegulatorHandler) UpdateCarAttributes(c *gin.Context) {
+	carID := c.Param("id")
+	var req struct {
+		Plate  string `json:"plate"`  // 新车牌号（为空表示不修改）
+		Color  string `json:"color"`  // 新颜色（为空表示不修改）
+		Model  string `json:"model"`  // 新车型（为空表示不修改）
+		Reason string `json:"reason"` // 变更原因
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "汽车属性信息格式错误")
+		return
+	}
+
+	err := h.regulatorService.UpdateCarAttributes(carID, req.Plate, req.Color, req.Model, req.Reason)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "汽车属性修改成功", nil)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (h *RegulatorHandler) QueryCarByPlate(c *gin.Context) {
+	plate := c.Param("plate")
+	car, err := h.regulatorService.QueryCarByPlate(plate)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, car)
+}
+
+// QueryCarChangeLog 查询汽车的属性变更记录
+func (h *RegulatorHandler) QueryCarChangeLog(c *gin.Context) {
+	carID := c.Param("id")
+	records, err := h.regulatorService.QueryCarChangeLog(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史
+func (h *RegulatorHandler) QueryOwnershipHistory(c *gin.Context) {
+	carID := c.Param("id")
+	records, err := h.regulatorService.QueryOwnershipHistory(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// QueryMarketStats 查询市场统计数据（period: day/week/month/year，start/end: RFC3339）
+func (h *RegulatorHandler) QueryMarketStats(c *gin.Context) {
+	period := c.DefaultQuery("period", "month")
+	startTime := c.DefaultQuery("start", "")
+	endTime := c.DefaultQuery("end", "")
+
+	result, err := h.regulatorService.QueryMarketStats(period, startTime, endTime)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// QueryBlockList 分页查询区块列表
+func (h *RegulatorHandler) QueryBlockList(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	pageNum, _ := strconv.Atoi(c.DefaultQuery("pageNum", "1"))
+
+	result, err := h.regulatorService.QueryBlockList(pageSize, pageNum)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
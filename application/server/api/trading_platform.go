@@ -22,9 +22,9 @@ func NewTradingPlatformHandler() *TradingPlatformHandler {
 func (h *TradingPlatformHandler) CreateTransaction(c *gin.Context) {
 	var req struct {
 		TxID   string  `json:"txId"`
-		CarID  string  `json:"carId"` // 修改为 CarID
-		Seller string  `json:"seller"`
-		Buyer  string  `json:"buyer"`
+		CarID  string  `json:"carId"`  // 修改为 CarID
+		Seller string  `json:"seller"` // 卖家（参与方ID）
+		Buyer  string  `json:"buyer"`  // 买家（参与方ID）
 		Price  float64 `json:"price"`
 	}
 
@@ -34,15 +34,312 @@ func (h *TradingPlatformHandler) CreateTransaction(c *gin.Context) {
 	}
 
 	// 修改为 CarID
-	err := h.tradingService.CreateTransaction(req.TxID, req.CarID, req.Seller, req.Buyer, req.Price)
+	warning, err := h.tradingService.CreateTransaction(req.TxID, req.CarID, req.Seller, req.Buyer, req.Price)
 	if err != nil {
-		utils.ServerError(c, "生成交易失败："+err.Error())
+		h.respondCreateTransactionError(c, err, req.CarID)
 		return
 	}
 
+	if warning != "" {
+		utils.SuccessWithMessage(c, "交易创建成功，但"+warning, gin.H{"priceWarning": warning})
+		return
+	}
 	utils.SuccessWithMessage(c, "交易创建成功", nil)
 }
 
+// CreateTradeInTransaction 生成置换交易：两辆车同时锁定，银行完成交易时一并过户
+func (h *TradingPlatformHandler) CreateTradeInTransaction(c *gin.Context) {
+	var req struct {
+		TxID         string  `json:"txId"`
+		CarID        string  `json:"carId"`        // 买家购买的汽车ID
+		Seller       string  `json:"seller"`       // 卖家（参与方ID）
+		Buyer        string  `json:"buyer"`        // 买家（参与方ID）
+		Price        float64 `json:"price"`        // 所购汽车成交价
+		TradeInCarID string  `json:"tradeInCarId"` // 买家交回的旧车ID
+		TradeInValue float64 `json:"tradeInValue"` // 旧车折价金额
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "置换交易信息格式错误")
+		return
+	}
+
+	warning, err := h.tradingService.CreateTradeInTransaction(req.TxID, req.CarID, req.Seller, req.Buyer, req.Price, req.TradeInCarID, req.TradeInValue)
+	if err != nil {
+		h.respondCreateTransactionError(c, err, req.CarID, req.TradeInCarID)
+		return
+	}
+
+	data := gin.H{"netAmount": req.Price - req.TradeInValue}
+	if warning != "" {
+		data["priceWarning"] = warning
+		utils.SuccessWithMessage(c, "置换交易创建成功，但"+warning, data)
+		return
+	}
+	utils.SuccessWithMessage(c, "置换交易创建成功", data)
+}
+
+// CreateBundleTransaction 生成打包交易：多辆汽车一并锁定、一并过户
+func (h *TradingPlatformHandler) CreateBundleTransaction(c *gin.Context) {
+	var req struct {
+		TxID       string               `json:"txId"`
+		Seller     string               `json:"seller"`     // 卖家（参与方ID）
+		Buyer      string               `json:"buyer"`      // 买家（参与方ID）
+		Items      []service.BundleItem `json:"items"`      // 汽车ID及单车成交价
+		TotalPrice float64              `json:"totalPrice"` // 总价，须等于各车成交价之和
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "打包交易信息格式错误")
+		return
+	}
+	if len(req.Items) == 0 {
+		utils.BadRequest(c, "打包交易至少包含一辆汽车")
+		return
+	}
+
+	warning, err := h.tradingService.CreateBundleTransaction(req.TxID, req.Seller, req.Buyer, req.Items, req.TotalPrice)
+	if err != nil {
+		carIDs := make([]string, 0, len(req.Items))
+		for _, item := range req.Items {
+			carIDs = append(carIDs, item.CarID)
+		}
+		h.respondCreateTransactionError(c, err, carIDs...)
+		return
+	}
+
+	if warning != "" {
+		utils.SuccessWithMessage(c, "打包交易创建成功，但"+warning, gin.H{"priceWarning": warning})
+		return
+	}
+	utils.SuccessWithMessage(c, "打包交易创建成功", nil)
+}
+
+// CreateReservation 创建预订：汽车转为已预订状态，定金由银行确认后可转为交易
+func (h *TradingPlatformHandler) CreateReservation(c *gin.Context) {
+	var req struct {
+		ReservationID string  `json:"reservationId"`
+		CarID         string  `json:"carId"`
+		Seller        string  `json:"seller"`   // 卖家（参与方ID）
+		Buyer         string  `json:"buyer"`    // 买家（参与方ID）
+		Deposit       float64 `json:"deposit"`  // 定金金额
+		HoldDays      int     `json:"holdDays"` // 保留天数
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "预订信息格式错误")
+		return
+	}
+
+	err := h.tradingService.CreateReservation(req.ReservationID, req.CarID, req.Seller, req.Buyer, req.Deposit, req.HoldDays)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "预订创建成功，等待银行确认定金", nil)
+}
+
+// ConvertReservation 将预订转为待付款交易，定金抵扣车款
+func (h *TradingPlatformHandler) ConvertReservation(c *gin.Context) {
+	reservationID := c.Param("id")
+	var req struct {
+		TxID  string  `json:"txId"`
+		Price float64 `json:"price"` // 成交价格
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "交易信息格式错误")
+		return
+	}
+
+	warning, err := h.tradingService.ConvertReservation(reservationID, req.TxID, req.Price)
+	if err != nil {
+		reservation, queryErr := h.tradingService.QueryReservation(reservationID)
+		if queryErr != nil {
+			utils.ServerError(c, err.Error())
+			return
+		}
+		carID, _ := reservation["carId"].(string)
+		h.respondCreateTransactionError(c, err, carID)
+		return
+	}
+
+	if warning != "" {
+		utils.SuccessWithMessage(c, "预订已转为交易，但"+warning, gin.H{"priceWarning": warning})
+		return
+	}
+	utils.SuccessWithMessage(c, "预订已转为交易", nil)
+}
+
+// CancelReservation 取消预订，汽车恢复为待售状态
+func (h *TradingPlatformHandler) CancelReservation(c *gin.Context) {
+	reservationID := c.Param("id")
+	var req struct {
+		Settlement string `json:"settlement"` // 定金处理方式：REFUNDED / CREDITED（定金未确认时留空）
+		Reason     string `json:"reason"`     // 取消原因
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "取消预订信息格式错误")
+		return
+	}
+
+	err := h.tradingService.CancelReservation(reservationID, req.Settlement, req.Reason)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "预订已取消", nil)
+}
+
+// ExpireReservation 使已过保留截止时间的预订失效，汽车恢复为待售状态
+func (h *TradingPlatformHandler) ExpireReservation(c *gin.Context) {
+	err := h.tradingService.ExpireReservation(c.Param("id"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "预订已失效", nil)
+}
+
+// QueryReservation 查询预订信息
+func (h *TradingPlatformHandler) QueryReservation(c *gin.Context) {
+	reservation, err := h.tradingService.QueryReservation(c.Param("id"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, reservation)
+}
+
+// QueryReservationList 分页查询预订列表
+func (h *TradingPlatformHandler) QueryReservationList(c *gin.Context) {
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	bookmark := c.DefaultQuery("bookmark", "")
+	status := c.DefaultQuery("status", "")
+
+	result, err := h.tradingService.QueryReservationList(int32(pageSize), bookmark, status)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// SubmitReview 代买家提交交易评价
+func (h *TradingPlatformHandler) SubmitReview(c *gin.Context) {
+	var req struct {
+		TxID         string `json:"txId"`
+		Reviewer     string `json:"reviewer"`     // 评价人（交易买家的参与方ID）
+		SellerRating int    `json:"sellerRating"` // 卖家评分（1-5）
+		DealerRating int    `json:"dealerRating"` // 经销商评分（1-5）
+		Comment      string `json:"comment"`      // 评价内容
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "评价信息格式错误")
+		return
+	}
+
+	err := h.tradingService.SubmitReview(req.TxID, req.Reviewer, req.SellerRating, req.DealerRating, req.Comment)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评价提交成功", nil)
+}
+
+// QueryReview 查询交易的评价
+func (h *TradingPlatformHandler) QueryReview(c *gin.Context) {
+	review, err := h.tradingService.QueryReview(c.Param("txId"))
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, review)
+}
+
+// QueryPartyReviews 查询参与方作为卖家或经销商收到的全部评价
+func (h *TradingPlatformHandler) QueryPartyReviews(c *gin.Context) {
+	reviews, err := h.tradingService.QueryPartyReviews(c.Param("partyId"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, reviews)
+}
+
+// QueryReputation 查询参与方作为卖家和经销商的信誉汇总
+func (h *TradingPlatformHandler) QueryReputation(c *gin.Context) {
+	reputation, err := h.tradingService.QueryReputation(c.Param("partyId"))
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, reputation)
+}
+
+// QueryTokenOwner 通过通证接口查询汽车的所有者及当前的被授权方
+func (h *TradingPlatformHandler) QueryTokenOwner(c *gin.Context) {
+	tokenID := c.Param("tokenId")
+	owner, err := h.tradingService.OwnerOf(tokenID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+	approved, err := h.tradingService.GetApproved(tokenID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"tokenId": tokenID, "owner": owner, "approved": approved})
+}
+
+// QueryTokenBalance 通过通证接口查询参与方名下的汽车数量
+func (h *TradingPlatformHandler) QueryTokenBalance(c *gin.Context) {
+	owner := c.Param("owner")
+	balance, err := h.tradingService.BalanceOf(owner)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"owner": owner, "balance": balance})
+}
+
+// respondCreateTransactionError 返回生成交易失败的响应，证件不满足销售要求时附带各车不满足要求的证件明细
+func (h *TradingPlatformHandler) respondCreateTransactionError(c *gin.Context, err error, carIDs ...string) {
+	notReady := make([]map[string]interface{}, 0)
+	for _, carID := range carIDs {
+		readiness, readinessErr := h.tradingService.QuerySaleReadiness(carID)
+		if readinessErr != nil {
+			continue
+		}
+		if ready, _ := readiness["ready"].(bool); !ready {
+			notReady = append(notReady, readiness)
+		}
+	}
+
+	switch {
+	case len(notReady) == 0:
+		utils.ServerError(c, "生成交易失败："+err.Error())
+	case len(carIDs) == 1:
+		utils.BadRequestWithData(c, "生成交易失败："+err.Error(), notReady[0])
+	default:
+		utils.BadRequestWithData(c, "生成交易失败："+err.Error(), notReady)
+	}
+}
+
 // QueryCar 查询汽车信息
 func (h *TradingPlatformHandler) QueryCar(c *gin.Context) {
 	id := c.Param("id")
@@ -83,6 +380,222 @@ func (h *TradingPlatformHandler) QueryTransactionList(c *gin.Context) {
 	utils.Success(c, result)
 }
 
+// RegisterParty 登记参与方
+func (h *TradingPlatformHandler) RegisterParty(c *gin.Context) {
+	var req struct {
+		ID         string `json:"id"`         // 参与方ID
+		Type       string `json:"type"`       // 参与方类型：INDIVIDUAL / COMPANY
+		Name       string `json:"name"`       // 姓名或企业名称
+		NationalID string `json:"nationalId"` // 身份证号或统一社会信用代码（仅用于计算哈希，不上链）
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "参与方信息格式错误")
+		return
+	}
+
+	err := h.tradingService.RegisterParty(req.ID, req.Type, req.Name, req.NationalID)
+	if err != nil {
+		utils.ServerError(c, "登记参与方失败："+err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "参与方登记成功，等待银行 KYC 认证", nil)
+}
+
+// QueryParty 查询参与方信息
+func (h *TradingPlatformHandler) QueryParty(c *gin.Context) {
+	partyID := c.Param("id")
+	party, err := h.tradingService.QueryParty(partyID)
+	if err != nil {
+		utils.ServerError(c, "查询参与方信息失败："+err.Error())
+		return
+	}
+
+	utils.Success(c, party)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (h *TradingPlatformHandler) QueryCarByPlate(c *gin.Context) {
+	plate := c.Param("plate")
+	car, err := h.tradingService.QueryCarByPlate(plate)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, car)
+}
+
+// QuerySaleReadiness 检查汽车的证件是否满足销售所需证件策略
+func (h *TradingPlatformHandler) QuerySaleReadiness(c *gin.Context) {
+	carID := c.Param("id")
+	readiness, err := h.tradingService.QuerySaleReadiness(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, readiness)
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史
+func (h *TradingPlatformHandler) QueryOwnershipHistory(c *gin.Context) {
+	carID := c.Param("id")
+	records, err := h.tradingService.QueryOwnershipHistory(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, records)
+}
+
+// SetAppraiserStatus 审核评估机构资质
+func (h *TradingPlatformHandler) SetAppraiserStatus(c *gin.Context) {
+	appraiserID := c.Param("id")
+	var req struct {
+		Status string `json:"status"` // PENDING/APPROVED/REVOKED
+		Remark string `json:"remark"` // 审核备注
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "审核信息格式错误")
+		return
+	}
+
+	err := h.tradingService.SetAppraiserStatus(appraiserID, req.Status, req.Remark)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评估机构状态已更新", nil)
+}
+
+// QueryAppraiser 查询评估机构信息
+func (h *TradingPlatformHandler) QueryAppraiser(c *gin.Context) {
+	appraiserID := c.Param("id")
+	appraiser, err := h.tradingService.QueryAppraiser(appraiserID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, appraiser)
+}
+
+// SetAppraisalPolicy 设置成交价与评估价偏离检查策略
+func (h *TradingPlatformHandler) SetAppraisalPolicy(c *gin.Context) {
+	var req struct {
+		Mode                string  `json:"mode"`                // OFF/WARN/REJECT
+		MaxDeviationPercent float64 `json:"maxDeviationPercent"` // 允许偏离估值区间的最大百分比
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "策略信息格式错误")
+		return
+	}
+
+	err := h.tradingService.SetAppraisalPolicy(req.Mode, req.MaxDeviationPercent)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "评估价检查策略已更新", nil)
+}
+
+// QueryAppraisalPolicy 查询成交价与评估价偏离检查策略
+func (h *TradingPlatformHandler) QueryAppraisalPolicy(c *gin.Context) {
+	policy, err := h.tradingService.QueryAppraisalPolicy()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, policy)
+}
+
+// SetFeeSchedule 设置费用与税费表
+func (h *TradingPlatformHandler) SetFeeSchedule(c *gin.Context) {
+	var req service.FeeSchedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "费用表格式错误")
+		return
+	}
+
+	err := h.tradingService.SetFeeSchedule(req)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "费用表已更新", nil)
+}
+
+// QueryFeeSchedule 查询费用与税费表
+func (h *TradingPlatformHandler) QueryFeeSchedule(c *gin.Context) {
+	schedule, err := h.tradingService.QueryFeeSchedule()
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, schedule)
+}
+
+// QueryAppraisals 查询汽车的全部评估报告
+func (h *TradingPlatformHandler) QueryAppraisals(c *gin.Context) {
+	carID := c.Param("carId")
+	appraisals, err := h.tradingService.QueryAppraisals(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, appraisals)
+}
+
+// QueryLatestAppraisal 查询汽车当前有效的最新评估报告
+func (h *TradingPlatformHandler) QueryLatestAppraisal(c *gin.Context) {
+	carID := c.Param("carId")
+	appraisal, err := h.tradingService.QueryLatestAppraisal(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, appraisal)
+}
+
+// QueryDamageHistory 查询汽车的损伤历史（保险理赔记录）
+func (h *TradingPlatformHandler) QueryDamageHistory(c *gin.Context) {
+	carID := c.Param("id")
+	claims, err := h.tradingService.QueryDamageHistory(carID)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, claims)
+}
+
+// QueryMarketStats 查询市场统计数据（period: day/week/month/year，start/end: RFC3339）
+func (h *TradingPlatformHandler) QueryMarketStats(c *gin.Context) {
+	period := c.DefaultQuery("period", "month")
+	startTime := c.DefaultQuery("start", "")
+	endTime := c.DefaultQuery("end", "")
+
+	result, err := h.tradingService.QueryMarketStats(period, startTime, endTime)
+	if err != nil {
+		utils.ServerError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
 // QueryBlockList 分页查询区块列表
 func (h *TradingPlatformHandler) QueryBlockList(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
@@ -23,6 +23,8 @@ type FabricConfig struct {
 	ChannelName   string                        `yaml:"channelName"`
 	ChaincodeName string                        `yaml:"chaincodeName"`
 	Organizations map[string]OrganizationConfig `yaml:"organizations"`
+
+	TokenChaincodeName string `yaml:"tokenChaincodeName"` // 代币链码名称（为空时不连接代币链码）
 }
 
 // OrganizationConfig 组织配置
@@ -31,20 +31,39 @@ func main() {
 	carDealerHandler := api.NewCarDealerHandler()
 	tradingPlatformHandler := api.NewTradingPlatformHandler()
 	bankHandler := api.NewBankHandler()
+	regulatorHandler := api.NewRegulatorHandler()
+	appraiserHandler := api.NewAppraiserHandler()
+	auditorHandler := api.NewAuditorHandler()
+	insurerHandler := api.NewInsurerHandler()
 
 	// 汽车经销商的接口
 	car := apiGroup.Group("/car-dealer")
 	{
 		// 创建汽车信息
 		car.POST("/car/create", carDealerHandler.CreateCar)
+		// 批量创建汽车信息
+		car.POST("/car/batch", carDealerHandler.CreateCarsBatch)
+		// 参与方接口
+		car.POST("/party/register", carDealerHandler.RegisterParty)
+		car.GET("/party/:id", carDealerHandler.QueryParty)
 		// 查询汽车接口
 		car.GET("/car/:id", carDealerHandler.QueryCar)
 		car.GET("/car/list", carDealerHandler.QueryCarList)
+		car.GET("/car/ownership/:id", carDealerHandler.QueryOwnershipHistory)
+		car.GET("/car/readiness/:id", carDealerHandler.QuerySaleReadiness)
+		car.GET("/car/plate/:plate", carDealerHandler.QueryCarByPlate)
+		// 汽车属性变更接口
+		car.POST("/car/attributes/:id", carDealerHandler.UpdateCarAttributes)
+		car.GET("/car/changes/:id", carDealerHandler.QueryCarChangeLog)
 		// 证书接口 (修改路径以避免冲突)
 		car.POST("/certificates/:carId", carDealerHandler.UploadCertificate)                              // 上传证书
 		car.GET("/certificates/:carId", carDealerHandler.ListCertificates)                                // 获取证书列表
 		car.GET("/certificates/verify/:certId", carDealerHandler.VerifyCertificateHandler)                // 验证证书 (修改路径)
 		car.POST("/certificates/verify-upload/:carId", carDealerHandler.VerifyUploadedCertificateHandler) // 上传文件进行验证 (新增)
+		car.GET("/certificates/expiring", carDealerHandler.QueryExpiringCertificates)                     // 即将到期的证书
+		car.GET("/issuer/list", carDealerHandler.QueryTrustedIssuers)                                     // 受信任的证件签发机构
+		// 市场统计接口
+		car.GET("/stats", carDealerHandler.QueryMarketStats)
 		// 查询区块接口
 		car.GET("/block/list", carDealerHandler.QueryBlockList)
 	}
@@ -54,11 +73,47 @@ func main() {
 	{
 		// 生成交易
 		trading.POST("/transaction/create", tradingPlatformHandler.CreateTransaction)
+		trading.POST("/transaction/trade-in", tradingPlatformHandler.CreateTradeInTransaction)
+		trading.POST("/transaction/bundle", tradingPlatformHandler.CreateBundleTransaction)
+		// 预订接口
+		trading.POST("/reservation/create", tradingPlatformHandler.CreateReservation)
+		trading.POST("/reservation/convert/:id", tradingPlatformHandler.ConvertReservation)
+		trading.POST("/reservation/cancel/:id", tradingPlatformHandler.CancelReservation)
+		trading.POST("/reservation/expire/:id", tradingPlatformHandler.ExpireReservation)
+		trading.GET("/reservation/list", tradingPlatformHandler.QueryReservationList)
+		trading.GET("/reservation/:id", tradingPlatformHandler.QueryReservation)
+		// 交易评价与信誉接口
+		trading.POST("/review/submit", tradingPlatformHandler.SubmitReview)
+		trading.GET("/review/:txId", tradingPlatformHandler.QueryReview)
+		trading.GET("/reputation/:partyId", tradingPlatformHandler.QueryReputation)
+		trading.GET("/reputation/:partyId/reviews", tradingPlatformHandler.QueryPartyReviews)
+		// 通证（ERC-721 风格）查询接口
+		trading.GET("/nft/owner/:tokenId", tradingPlatformHandler.QueryTokenOwner)
+		trading.GET("/nft/balance/:owner", tradingPlatformHandler.QueryTokenBalance)
+		// 参与方接口
+		trading.POST("/party/register", tradingPlatformHandler.RegisterParty)
+		trading.GET("/party/:id", tradingPlatformHandler.QueryParty)
 		// 查询汽车接口
 		trading.GET("/car/:id", tradingPlatformHandler.QueryCar)
+		trading.GET("/car/ownership/:id", tradingPlatformHandler.QueryOwnershipHistory)
+		trading.GET("/car/readiness/:id", tradingPlatformHandler.QuerySaleReadiness)
+		trading.GET("/car/plate/:plate", tradingPlatformHandler.QueryCarByPlate)
+		trading.GET("/car/damage/:id", tradingPlatformHandler.QueryDamageHistory)
+		// 评估机构与评估报告接口
+		trading.POST("/appraiser/status/:id", tradingPlatformHandler.SetAppraiserStatus)
+		trading.GET("/appraiser/:id", tradingPlatformHandler.QueryAppraiser)
+		trading.POST("/appraisal/policy", tradingPlatformHandler.SetAppraisalPolicy)
+		trading.GET("/appraisal/policy", tradingPlatformHandler.QueryAppraisalPolicy)
+		trading.GET("/appraisal/list/:carId", tradingPlatformHandler.QueryAppraisals)
+		trading.GET("/appraisal/latest/:carId", tradingPlatformHandler.QueryLatestAppraisal)
+		// 费用与税费表接口
+		trading.POST("/fee/schedule", tradingPlatformHandler.SetFeeSchedule)
+		trading.GET("/fee/schedule", tradingPlatformHandler.QueryFeeSchedule)
 		// 查询交易接口
 		trading.GET("/transaction/:txId", tradingPlatformHandler.QueryTransaction)
 		trading.GET("/transaction/list", tradingPlatformHandler.QueryTransactionList)
+		// 市场统计接口
+		trading.GET("/stats", tradingPlatformHandler.QueryMarketStats)
 		// 查询区块接口
 		trading.GET("/block/list", tradingPlatformHandler.QueryBlockList)
 	}
@@ -68,13 +123,112 @@ func main() {
 	{
 		// 完成交易
 		bank.POST("/transaction/complete/:txId", bankHandler.CompleteTransaction)
+		// 导入银行对账单自动完成交易
+		bank.POST("/reconcile", bankHandler.Reconcile)
+		// 预订定金接口
+		bank.POST("/reservation/deposit/:id", bankHandler.ConfirmReservationDeposit)
+		bank.GET("/reservation/list", bankHandler.QueryReservationList)
+		bank.GET("/reservation/:id", bankHandler.QueryReservation)
+		// 参与方 KYC 接口
+		bank.POST("/party/kyc/:id", bankHandler.SetPartyKYCStatus)
+		bank.POST("/party/identity/:id", bankHandler.BindPartyIdentity)
+		bank.GET("/party/:id", bankHandler.QueryParty)
+		bank.GET("/party/list", bankHandler.QueryPartyList)
 		// 查询交易接口
 		bank.GET("/transaction/:txId", bankHandler.QueryTransaction)
 		bank.GET("/transaction/list", bankHandler.QueryTransactionList)
+		// 购车贷款接口
+		bank.POST("/loan/create", bankHandler.CreateLoan)
+		bank.POST("/loan/payment/:id", bankHandler.RecordLoanPayment)
+		bank.POST("/loan/default/:id", bankHandler.MarkLoanDefault)
+		bank.POST("/loan/repossess/:id", bankHandler.RepossessCar)
+		bank.GET("/loan/list", bankHandler.QueryLoanList)
+		bank.GET("/loan/:id", bankHandler.QueryLoan)
+		// 链上代币结算接口
+		bank.POST("/payment/settlement", bankHandler.SetPaymentSettlement)
+		bank.GET("/payment/settlement", bankHandler.QueryPaymentSettlement)
+		bank.POST("/token/mint", bankHandler.MintToken)
+		bank.POST("/token/settlement", bankHandler.SetTokenSettlementChaincode)
+		bank.GET("/token/settlement", bankHandler.QueryTokenSettlementChaincode)
+		bank.GET("/token/balance/:account", bankHandler.QueryTokenBalance)
+		// 市场统计接口
+		bank.GET("/stats", bankHandler.QueryMarketStats)
 		// 查询区块接口
 		bank.GET("/block/list", bankHandler.QueryBlockList)
 	}
 
+	// 监管机构（公安）的接口
+	regulator := apiGroup.Group("/regulator")
+	{
+		// 被盗标记
+		regulator.POST("/car/stolen/flag/:id", regulatorHandler.FlagCarStolen)
+		regulator.POST("/car/stolen/clear/:id", regulatorHandler.ClearStolenFlag)
+		regulator.GET("/car/stolen/history/:id", regulatorHandler.QueryStolenFlagHistory)
+		// 非交易过户（继承、赠与、法院判决）
+		regulator.POST("/car/transfer/:id", regulatorHandler.TransferOwnership)
+		// 证件签发机构接口
+		regulator.POST("/issuer/register", regulatorHandler.RegisterTrustedIssuer)
+		regulator.POST("/issuer/revoke/:id", regulatorHandler.RevokeTrustedIssuer)
+		regulator.GET("/issuer/list", regulatorHandler.QueryTrustedIssuers)
+		// 审计人员登记接口
+		regulator.POST("/auditor/register", regulatorHandler.RegisterAuditor)
+		regulator.POST("/auditor/revoke", regulatorHandler.RevokeAuditor)
+		regulator.GET("/auditor/list", regulatorHandler.QueryAuditors)
+		// 证书吊销与销售所需证件策略接口
+		regulator.POST("/certificates/revoke/:certId", regulatorHandler.RevokeCertificate)
+		regulator.POST("/documents/policy", regulatorHandler.SetRequiredDocumentsPolicy)
+		regulator.GET("/documents/policy", regulatorHandler.QueryRequiredDocumentsPolicy)
+		// 查询汽车接口
+		regulator.GET("/car/:id", regulatorHandler.QueryCar)
+		regulator.GET("/car/ownership/:id", regulatorHandler.QueryOwnershipHistory)
+		regulator.GET("/car/plate/:plate", regulatorHandler.QueryCarByPlate)
+		// 汽车属性变更接口（含车牌号变更）
+		regulator.POST("/car/attributes/:id", regulatorHandler.UpdateCarAttributes)
+		regulator.GET("/car/changes/:id", regulatorHandler.QueryCarChangeLog)
+		// 市场统计接口
+		regulator.GET("/stats", regulatorHandler.QueryMarketStats)
+		// 查询区块接口
+		regulator.GET("/block/list", regulatorHandler.QueryBlockList)
+	}
+
+	// 保险公司的接口
+	insurer := apiGroup.Group("/insurer")
+	{
+		// 保单接口
+		insurer.POST("/policy/create", insurerHandler.RegisterPolicy)
+		insurer.GET("/policy/:policyNo", insurerHandler.QueryPolicy)
+		insurer.GET("/policy/car/:carId", insurerHandler.QueryCarPolicies)
+		insurer.POST("/policy/transfer-rule", insurerHandler.SetPolicyTransferRule)
+		insurer.GET("/policy/transfer-rule", insurerHandler.QueryPolicyTransferRule)
+		// 理赔接口
+		insurer.POST("/claim/create", insurerHandler.LogClaim)
+		insurer.POST("/claim/status/:carId/:claimId", insurerHandler.UpdateClaimStatus)
+		insurer.GET("/claim/damage/:carId", insurerHandler.QueryDamageHistory)
+		// 市场统计接口
+		insurer.GET("/stats", insurerHandler.QueryMarketStats)
+		// 查询区块接口
+		insurer.GET("/block/list", insurerHandler.QueryBlockList)
+	}
+
+	// 第三方评估机构的接口
+	appraiser := apiGroup.Group("/appraiser")
+	{
+		appraiser.POST("/register", appraiserHandler.RegisterAppraiser)
+		appraiser.POST("/appraisal/submit", appraiserHandler.SubmitAppraisal)
+		appraiser.GET("/appraisal/list/:carId", appraiserHandler.QueryAppraisals)
+	}
+
+	// 审计人员的只读接口
+	auditor := apiGroup.Group("/auditor")
+	{
+		auditor.GET("/identity", auditorHandler.QueryIdentity)
+		auditor.GET("/car/:id", auditorHandler.QueryCarAudit)
+		auditor.GET("/car/history/:id", auditorHandler.QueryCarHistory)
+		auditor.GET("/transaction/history/:txId", auditorHandler.QueryTransactionHistory)
+		auditor.GET("/certificate/history/:certId", auditorHandler.QueryCertificateHistory)
+		auditor.GET("/private-data/hash", auditorHandler.QueryPrivateDataHash)
+	}
+
 	// 配置静态文件服务 (新增)
 	// 将 URL 路径 /api/files/ 映射到服务器本地的 ./data/ 目录
 	// 例如: 访问 /api/files/certificates/car1/cert1.pdf 会读取 ./data/certificates/car1/cert1.pdf
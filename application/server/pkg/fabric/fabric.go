@@ -20,6 +20,8 @@ import (
 var (
 	// 组织对应的合约客户端
 	contracts = make(map[string]*client.Contract)
+	// 组织对应的代币合约客户端
+	tokenContracts = make(map[string]*client.Contract)
 )
 
 // InitFabric 初始化 Fabric 客户端
@@ -66,6 +68,9 @@ func InitFabric() error {
 
 		network := gw.GetNetwork(config.GlobalConfig.Fabric.ChannelName)
 		contracts[orgName] = network.GetContract(config.GlobalConfig.Fabric.ChaincodeName)
+		if config.GlobalConfig.Fabric.TokenChaincodeName != "" {
+			tokenContracts[orgName] = network.GetContract(config.GlobalConfig.Fabric.TokenChaincodeName)
+		}
 
 		// 添加网络到区块监听器
 		if err := addNetwork(orgName, network); err != nil {
@@ -81,6 +86,11 @@ func GetContract(orgName string) *client.Contract {
 	return contracts[orgName]
 }
 
+// GetTokenContract 获取指定组织的代币合约客户端，未配置代币链码时返回 nil
+func GetTokenContract(orgName string) *client.Contract {
+	return tokenContracts[orgName]
+}
+
 // ExtractErrorMessage 从错误中提取详细信息
 func ExtractErrorMessage(err error) string {
 	if err == nil {
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+type AppraiserService struct{}
+
+const APPRAISER_ORG = "appraiser" // 第三方评估机构身份
+
+// ConditionGrade 单项车况等级
+type ConditionGrade struct {
+	Item  string `json:"item"`
+	Grade string `json:"grade"`
+}
+
+// Appraisal 评估报告提交内容
+type Appraisal struct {
+	ID              string           `json:"id"`
+	CarID           string           `json:"carId"`
+	ConditionGrades []ConditionGrade `json:"conditionGrades"`
+	EstimatedMin    float64          `json:"estimatedMin"`
+	EstimatedMax    float64          `json:"estimatedMax"`
+	ReportHash      string           `json:"reportHash"`
+	ValidDays       int              `json:"validDays"`
+}
+
+// RegisterAppraiser 登记评估机构，登记后需交易平台审核通过才能提交评估报告
+func (s *AppraiserService) RegisterAppraiser(appraiserID, name, licenseNo string) error {
+	contract := fabric.GetContract(APPRAISER_ORG)
+	_, err := contract.SubmitTransaction("RegisterAppraiser", appraiserID, name, licenseNo)
+	if err != nil {
+		return fmt.Errorf("登记评估机构失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// SubmitAppraisal 提交评估报告
+func (s *AppraiserService) SubmitAppraisal(appraisal Appraisal) error {
+	contract := fabric.GetContract(APPRAISER_ORG)
+	appraisalJson, err := json.Marshal(appraisal)
+	if err != nil {
+		return fmt.Errorf("序列化评估报告失败：%v", err)
+	}
+
+	_, err = contract.SubmitTransaction("SubmitAppraisal", string(appraisalJson))
+	if err != nil {
+		return fmt.Errorf("提交评估报告失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryAppraisals 查询汽车的全部评估报告
+func (s *AppraiserService) QueryAppraisals(carID string) ([]map[string]interface{}, error) {
+	return queryAppraisals(APPRAISER_ORG, carID)
+}
+
+// queryAppraisals 以指定组织身份查询汽车的全部评估报告
+func queryAppraisals(orgName string, carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryAppraisals", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评估报告失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var appraisals []map[string]interface{}
+	if err := json.Unmarshal(result, &appraisals); err != nil {
+		return nil, fmt.Errorf("解析评估报告失败：%v", err)
+	}
+
+	return appraisals, nil
+}
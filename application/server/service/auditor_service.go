@@ -0,0 +1,175 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// AuditorService 审计人员只读服务：使用登记为审计人员的身份调用链码，链码拒绝该身份的一切写入
+type AuditorService struct{}
+
+const AUDITOR_ORG = "auditor" // 审计人员身份
+
+// auditPageSize 汇总汽车交易时每页读取的交易数
+const auditPageSize = 100
+
+// CarAudit 单辆汽车的审计视图
+type CarAudit struct {
+	Car              map[string]interface{}   `json:"car"`              // 汽车信息
+	Transactions     []map[string]interface{} `json:"transactions"`     // 涉及该车的全部交易（含打包交易和置换交回的旧车）
+	Certificates     []map[string]interface{} `json:"certificates"`     // 该车的全部证书（含已吊销、已过期的）
+	OwnershipHistory []map[string]interface{} `json:"ownershipHistory"` // 所有权变更历史
+}
+
+// QueryIdentity 查询审计人员身份的客户端身份标识，交由监管机构登记
+func (s *AuditorService) QueryIdentity() (string, error) {
+	contract := fabric.GetContract(AUDITOR_ORG)
+	result, err := contract.EvaluateTransaction("ClientIdentityID")
+	if err != nil {
+		return "", fmt.Errorf("查询客户端身份失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return string(result), nil
+}
+
+// QueryCarAudit 汇总单辆汽车的汽车信息、交易、证书和所有权变更历史
+func (s *AuditorService) QueryCarAudit(carID string) (*CarAudit, error) {
+	contract := fabric.GetContract(AUDITOR_ORG)
+	result, err := contract.EvaluateTransaction("QueryCar", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询汽车信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	var car map[string]interface{}
+	if err := json.Unmarshal(result, &car); err != nil {
+		return nil, fmt.Errorf("解析汽车数据失败：%v", err)
+	}
+	// 支持按车牌号查询，后续按汽车ID汇总
+	if id, ok := car["id"].(string); ok && id != "" {
+		carID = id
+	}
+
+	transactions, err := s.queryCarTransactions(carID)
+	if err != nil {
+		return nil, err
+	}
+	certificates, err := s.queryCarCertificates(carID)
+	if err != nil {
+		return nil, err
+	}
+	ownershipHistory, err := queryOwnershipHistory(AUDITOR_ORG, carID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CarAudit{
+		Car:              car,
+		Transactions:     transactions,
+		Certificates:     certificates,
+		OwnershipHistory: ownershipHistory,
+	}, nil
+}
+
+// QueryCarHistory 查询汽车记录在账本上的全部历史版本
+func (s *AuditorService) QueryCarHistory(carID string) ([]map[string]interface{}, error) {
+	return s.queryHistory("QueryCarHistory", carID)
+}
+
+// QueryTransactionHistory 查询交易记录在账本上的全部历史版本
+func (s *AuditorService) QueryTransactionHistory(txID string) ([]map[string]interface{}, error) {
+	return s.queryHistory("QueryTransactionHistory", txID)
+}
+
+// QueryCertificateHistory 查询证书记录在账本上的全部历史版本
+func (s *AuditorService) QueryCertificateHistory(certID string) ([]map[string]interface{}, error) {
+	return s.queryHistory("QueryCertificateHistory", certID)
+}
+
+// QueryPrivateDataHash 查询私有数据集合中记录的哈希
+func (s *AuditorService) QueryPrivateDataHash(collection, key string) (string, error) {
+	contract := fabric.GetContract(AUDITOR_ORG)
+	result, err := contract.EvaluateTransaction("QueryPrivateDataHash", collection, key)
+	if err != nil {
+		return "", fmt.Errorf("查询私有数据哈希失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return string(result), nil
+}
+
+// queryHistory 调用链码的历史查询函数
+func (s *AuditorService) queryHistory(function string, id string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(AUDITOR_ORG)
+	result, err := contract.EvaluateTransaction(function, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询账本历史失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(result, &records); err != nil {
+		return nil, fmt.Errorf("解析账本历史失败：%v", err)
+	}
+	return records, nil
+}
+
+// queryCarTransactions 分页读取全部交易，筛选出售出该车或以该车置换的交易
+func (s *AuditorService) queryCarTransactions(carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(AUDITOR_ORG)
+	transactions := make([]map[string]interface{}, 0)
+	bookmark := ""
+	for {
+		result, err := contract.EvaluateTransaction("QueryTransactionList", fmt.Sprintf("%d", auditPageSize), bookmark, "")
+		if err != nil {
+			return nil, fmt.Errorf("查询交易列表失败：%s", fabric.ExtractErrorMessage(err))
+		}
+
+		var page struct {
+			Records  []map[string]interface{} `json:"records"`
+			Bookmark string                   `json:"bookmark"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("解析查询结果失败：%v", err)
+		}
+		for _, transaction := range page.Records {
+			if transactionInvolvesCar(transaction, carID) {
+				transactions = append(transactions, transaction)
+			}
+		}
+		if page.Bookmark == "" || len(page.Records) == 0 {
+			return transactions, nil
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+// transactionInvolvesCar 判断交易是否涉及指定汽车
+func transactionInvolvesCar(transaction map[string]interface{}, carID string) bool {
+	if transaction["carId"] == carID || transaction["tradeInCarId"] == carID {
+		return true
+	}
+	items, _ := transaction["items"].([]interface{})
+	for _, item := range items {
+		if fields, ok := item.(map[string]interface{}); ok && fields["carId"] == carID {
+			return true
+		}
+	}
+	return false
+}
+
+// queryCarCertificates 查询汽车的全部证书
+func (s *AuditorService) queryCarCertificates(carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(AUDITOR_ORG)
+	result, err := contract.EvaluateTransaction("GetAllCertificates")
+	if err != nil {
+		return nil, fmt.Errorf("查询证书失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var certificates []map[string]interface{}
+	if err := json.Unmarshal(result, &certificates); err != nil {
+		return nil, fmt.Errorf("解析证书数据失败：%v", err)
+	}
+	carCertificates := make([]map[string]interface{}, 0)
+	for _, certificate := range certificates {
+		if certificate["carId"] == carID {
+			carCertificates = append(carCertificates, certificate)
+		}
+	}
+	return carCertificates, nil
+}
@@ -4,7 +4,7 @@ import (
 	"application/pkg/fabric"
 	"encoding/json"
 	"fmt"
-	"time"
+	"strconv"
 )
 
 type BankService struct{}
@@ -14,14 +14,199 @@ const BANK_ORG = "org2" // 银行组织
 // CompleteTransaction 完成交易
 func (s *BankService) CompleteTransaction(txID string) error {
 	contract := fabric.GetContract(BANK_ORG)
-	now := time.Now().Format(time.RFC3339)
-	_, err := contract.SubmitTransaction("CompleteTransaction", txID, now)
+	_, err := contract.SubmitTransaction("CompleteTransaction", txID)
 	if err != nil {
 		return fmt.Errorf("完成交易失败：%s", fabric.ExtractErrorMessage(err))
 	}
 	return nil
 }
 
+// ConfirmReservationDeposit 确认已收到预订定金
+func (s *BankService) ConfirmReservationDeposit(reservationID, depositRef string) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("ConfirmReservationDeposit", reservationID, depositRef)
+	if err != nil {
+		return fmt.Errorf("确认定金失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryReservation 查询预订信息
+func (s *BankService) QueryReservation(reservationID string) (map[string]interface{}, error) {
+	return queryReservation(BANK_ORG, reservationID)
+}
+
+// QueryReservationList 分页查询预订列表
+func (s *BankService) QueryReservationList(pageSize int32, bookmark string, status string) (map[string]interface{}, error) {
+	return queryReservationList(BANK_ORG, pageSize, bookmark, status)
+}
+
+// LoanInstallment 还款计划中的一期
+type LoanInstallment struct {
+	DueDate string  `json:"dueDate"` // 应还日期（RFC3339）
+	Amount  float64 `json:"amount"`  // 应还金额（本息合计）
+}
+
+// CreateLoan 登记购车贷款及还款计划
+func (s *BankService) CreateLoan(loanID, txID string, principal float64, schedule []LoanInstallment) error {
+	scheduleJson, err := json.Marshal(schedule)
+	if err != nil {
+		return fmt.Errorf("序列化还款计划失败：%v", err)
+	}
+
+	contract := fabric.GetContract(BANK_ORG)
+	_, err = contract.SubmitTransaction("CreateLoan", loanID, txID, fmt.Sprintf("%f", principal), string(scheduleJson))
+	if err != nil {
+		return fmt.Errorf("登记贷款失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// RecordLoanPayment 记录一笔还款，还清后贷款自动结清
+func (s *BankService) RecordLoanPayment(loanID, paymentRef string, amount float64) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("RecordLoanPayment", loanID, paymentRef, fmt.Sprintf("%f", amount))
+	if err != nil {
+		return fmt.Errorf("记录还款失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// MarkLoanDefault 将逾期未还的贷款标记为违约
+func (s *BankService) MarkLoanDefault(loanID, reason string) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("MarkLoanDefault", loanID, reason)
+	if err != nil {
+		return fmt.Errorf("标记违约失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// RepossessCar 收回违约贷款的抵押汽车，过户给银行指定的参与方
+func (s *BankService) RepossessCar(loanID, bankParty string) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("RepossessCar", loanID, bankParty)
+	if err != nil {
+		return fmt.Errorf("收回汽车失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryLoan 查询贷款信息
+func (s *BankService) QueryLoan(loanID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(BANK_ORG)
+	result, err := contract.EvaluateTransaction("QueryLoan", loanID)
+	if err != nil {
+		return nil, fmt.Errorf("查询贷款信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var loan map[string]interface{}
+	if err := json.Unmarshal(result, &loan); err != nil {
+		return nil, fmt.Errorf("解析贷款数据失败：%v", err)
+	}
+
+	return loan, nil
+}
+
+// QueryLoanList 分页查询贷款列表
+func (s *BankService) QueryLoanList(pageSize int32, bookmark string, status string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(BANK_ORG)
+	result, err := contract.EvaluateTransaction("QueryLoanList", fmt.Sprintf("%d", pageSize), bookmark, status)
+	if err != nil {
+		return nil, fmt.Errorf("查询贷款列表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var queryResult map[string]interface{}
+	if err := json.Unmarshal(result, &queryResult); err != nil {
+		return nil, fmt.Errorf("解析查询结果失败：%v", err)
+	}
+
+	return queryResult, nil
+}
+
+// SetPaymentSettlement 设置链上代币结算使用的代币链码，为空时恢复链下付款
+func (s *BankService) SetPaymentSettlement(tokenChaincode string) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("SetPaymentSettlement", tokenChaincode)
+	if err != nil {
+		return fmt.Errorf("设置结算方式失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryPaymentSettlement 查询链上代币结算配置
+func (s *BankService) QueryPaymentSettlement() (map[string]interface{}, error) {
+	contract := fabric.GetContract(BANK_ORG)
+	result, err := contract.EvaluateTransaction("QueryPaymentSettlement")
+	if err != nil {
+		return nil, fmt.Errorf("查询结算配置失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(result, &config); err != nil {
+		return nil, fmt.Errorf("解析结算配置失败：%v", err)
+	}
+
+	return config, nil
+}
+
+// MintToken 向参与方账户发行代币，金额以分计
+func (s *BankService) MintToken(account string, amount int64) error {
+	contract := fabric.GetTokenContract(BANK_ORG)
+	if contract == nil {
+		return fmt.Errorf("未配置代币链码")
+	}
+	_, err := contract.SubmitTransaction("Mint", account, strconv.FormatInt(amount, 10))
+	if err != nil {
+		return fmt.Errorf("发行代币失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// SetTokenSettlementChaincode 设置代币链码的结算链码（批量划转的发起方和账户身份的来源）
+func (s *BankService) SetTokenSettlementChaincode(chaincode string) error {
+	contract := fabric.GetTokenContract(BANK_ORG)
+	if contract == nil {
+		return fmt.Errorf("未配置代币链码")
+	}
+	_, err := contract.SubmitTransaction("SetSettlementChaincode", chaincode)
+	if err != nil {
+		return fmt.Errorf("设置结算链码失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryTokenSettlementChaincode 查询代币链码的结算链码
+func (s *BankService) QueryTokenSettlementChaincode() (string, error) {
+	contract := fabric.GetTokenContract(BANK_ORG)
+	if contract == nil {
+		return "", fmt.Errorf("未配置代币链码")
+	}
+	result, err := contract.EvaluateTransaction("SettlementChaincode")
+	if err != nil {
+		return "", fmt.Errorf("查询结算链码失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return string(result), nil
+}
+
+// QueryTokenBalance 查询参与方账户的代币余额（分）
+func (s *BankService) QueryTokenBalance(account string) (int64, error) {
+	contract := fabric.GetTokenContract(BANK_ORG)
+	if contract == nil {
+		return 0, fmt.Errorf("未配置代币链码")
+	}
+	result, err := contract.EvaluateTransaction("BalanceOf", account)
+	if err != nil {
+		return 0, fmt.Errorf("查询代币余额失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	balance, err := strconv.ParseInt(string(result), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析代币余额失败：%v", err)
+	}
+	return balance, nil
+}
+
 // QueryTransaction 查询交易信息
 func (s *BankService) QueryTransaction(txID string) (map[string]interface{}, error) {
 	contract := fabric.GetContract(BANK_ORG)
@@ -54,6 +239,52 @@ func (s *BankService) QueryTransactionList(pageSize int32, bookmark string, stat
 	return queryResult, nil
 }
 
+// SetPartyKYCStatus 设置参与方的 KYC 状态
+func (s *BankService) SetPartyKYCStatus(partyID, status, remark string) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("SetPartyKYCStatus", partyID, status, remark)
+	if err != nil {
+		return fmt.Errorf("设置 KYC 状态失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// BindPartyIdentity 将客户端身份绑定到已通过 KYC 认证的参与方，绑定后该身份可通过通证接口操作参与方名下的汽车
+func (s *BankService) BindPartyIdentity(partyID, mspID, clientID string) error {
+	contract := fabric.GetContract(BANK_ORG)
+	_, err := contract.SubmitTransaction("BindPartyIdentity", partyID, mspID, clientID)
+	if err != nil {
+		return fmt.Errorf("绑定参与方身份失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryParty 查询参与方信息
+func (s *BankService) QueryParty(partyID string) (map[string]interface{}, error) {
+	return queryParty(BANK_ORG, partyID)
+}
+
+// QueryPartyList 分页查询参与方列表
+func (s *BankService) QueryPartyList(pageSize int32, bookmark string, kycStatus string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(BANK_ORG)
+	result, err := contract.EvaluateTransaction("QueryPartyList", fmt.Sprintf("%d", pageSize), bookmark, kycStatus)
+	if err != nil {
+		return nil, fmt.Errorf("查询参与方列表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var queryResult map[string]interface{}
+	if err := json.Unmarshal(result, &queryResult); err != nil {
+		return nil, fmt.Errorf("解析查询结果失败：%v", err)
+	}
+
+	return queryResult, nil
+}
+
+// QueryMarketStats 查询市场统计数据
+func (s *BankService) QueryMarketStats(period, startTime, endTime string) (map[string]interface{}, error) {
+	return queryMarketStats(BANK_ORG, period, startTime, endTime)
+}
+
 // QueryBlockList 分页查询区块列表
 func (s *BankService) QueryBlockList(pageSize int, pageNum int) (*fabric.BlockQueryResult, error) {
 	result, err := fabric.GetBlockListener().GetBlocksByOrg(BANK_ORG, pageSize, pageNum)
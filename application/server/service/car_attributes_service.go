@@ -0,0 +1,49 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// updateCarAttributes 以指定组织身份修改汽车属性（车牌号、颜色、车型），空值表示不修改
+func updateCarAttributes(orgName string, carID, plate, color, model, reason string) error {
+	contract := fabric.GetContract(orgName)
+	_, err := contract.SubmitTransaction("UpdateCarAttributes", carID, plate, color, model, reason)
+	if err != nil {
+		return fmt.Errorf("修改汽车属性失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// queryCarByPlate 以指定组织身份通过车牌号查询汽车信息
+func queryCarByPlate(orgName string, plate string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryCarByPlate", plate)
+	if err != nil {
+		return nil, fmt.Errorf("查询汽车信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var car map[string]interface{}
+	if err := json.Unmarshal(result, &car); err != nil {
+		return nil, fmt.Errorf("解析汽车数据失败：%v", err)
+	}
+
+	return car, nil
+}
+
+// queryCarChangeLog 以指定组织身份查询汽车的属性变更记录
+func queryCarChangeLog(orgName string, carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryCarChangeLog", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询汽车属性变更记录失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(result, &records); err != nil {
+		return nil, fmt.Errorf("解析汽车属性变更记录失败：%v", err)
+	}
+
+	return records, nil
+}
@@ -4,23 +4,67 @@ import (
 	"application/pkg/fabric"
 	"encoding/json"
 	"fmt"
-	"time"
 )
 
 type CarDealerService struct{}
 
 const CAR_DEALER_ORG = "org1" // 汽车经销商组织
 
-// CreateCar 创建汽车信息
-func (s *CarDealerService) CreateCar(id, model, vin, owner string) error {
+// CreateCar 创建汽车信息，返回链码生成的汽车ID
+func (s *CarDealerService) CreateCar(plate, model, vin, owner string) (string, error) {
 	contract := fabric.GetContract(CAR_DEALER_ORG)
-	now := time.Now().Format(time.RFC3339)
 	// 注意：链码函数名也需要修改为 CreateCar
-	_, err := contract.SubmitTransaction("CreateCar", id, model, vin, owner, now)
+	result, err := contract.SubmitTransaction("CreateCar", plate, model, vin, owner)
 	if err != nil {
-		return fmt.Errorf("创建汽车信息失败：%s", fabric.ExtractErrorMessage(err))
+		return "", fmt.Errorf("创建汽车信息失败：%s", fabric.ExtractErrorMessage(err))
 	}
-	return nil
+	return string(result), nil
+}
+
+// CarBatchItem 批量创建汽车的单条输入
+type CarBatchItem struct {
+	Plate string `json:"plate"`
+	Model string `json:"model"`
+	VIN   string `json:"vin"`
+	Owner string `json:"owner"`
+	Color string `json:"color"`
+}
+
+// CreateCarsBatch 批量创建汽车信息，先预校验整个批次，全部通过后再一次性提交
+// 返回每一条的处理结果，以及整个批次是否已写入账本
+func (s *CarDealerService) CreateCarsBatch(cars []CarBatchItem) ([]map[string]interface{}, bool, error) {
+	contract := fabric.GetContract(CAR_DEALER_ORG)
+	carsJson, err := json.Marshal(cars)
+	if err != nil {
+		return nil, false, fmt.Errorf("序列化汽车批次失败：%v", err)
+	}
+
+	// 预校验，不消耗一次背书提交
+	result, err := contract.EvaluateTransaction("ValidateCarsBatch", string(carsJson))
+	if err != nil {
+		return nil, false, fmt.Errorf("校验汽车批次失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var itemResults []map[string]interface{}
+	if err := json.Unmarshal(result, &itemResults); err != nil {
+		return nil, false, fmt.Errorf("解析校验结果失败：%v", err)
+	}
+	for _, item := range itemResults {
+		if success, _ := item["success"].(bool); !success {
+			return itemResults, false, nil
+		}
+	}
+
+	result, err = contract.SubmitTransaction("CreateCarsBatch", string(carsJson))
+	if err != nil {
+		return nil, false, fmt.Errorf("批量创建汽车信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	if err := json.Unmarshal(result, &itemResults); err != nil {
+		return nil, false, fmt.Errorf("解析创建结果失败：%v", err)
+	}
+
+	return itemResults, true, nil
 }
 
 // QueryCar 查询汽车信息
@@ -57,6 +101,46 @@ func (s *CarDealerService) QueryCarList(pageSize int32, bookmark string, status
 	return queryResult, nil
 }
 
+// RegisterParty 登记参与方（证件号在服务端哈希后上链）
+func (s *CarDealerService) RegisterParty(partyID, partyType, name, nationalID string) error {
+	return registerParty(CAR_DEALER_ORG, partyID, partyType, name, nationalID)
+}
+
+// QueryParty 查询参与方信息
+func (s *CarDealerService) QueryParty(partyID string) (map[string]interface{}, error) {
+	return queryParty(CAR_DEALER_ORG, partyID)
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史
+func (s *CarDealerService) QueryOwnershipHistory(carID string) ([]map[string]interface{}, error) {
+	return queryOwnershipHistory(CAR_DEALER_ORG, carID)
+}
+
+// QuerySaleReadiness 检查汽车的证件是否满足销售所需证件策略
+func (s *CarDealerService) QuerySaleReadiness(carID string) (map[string]interface{}, error) {
+	return querySaleReadiness(CAR_DEALER_ORG, carID)
+}
+
+// UpdateCarAttributes 修改汽车属性（车牌号、颜色、车型），须填写变更原因
+func (s *CarDealerService) UpdateCarAttributes(carID, plate, color, model, reason string) error {
+	return updateCarAttributes(CAR_DEALER_ORG, carID, plate, color, model, reason)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (s *CarDealerService) QueryCarByPlate(plate string) (map[string]interface{}, error) {
+	return queryCarByPlate(CAR_DEALER_ORG, plate)
+}
+
+// QueryCarChangeLog 查询汽车的属性变更记录
+func (s *CarDealerService) QueryCarChangeLog(carID string) ([]map[string]interface{}, error) {
+	return queryCarChangeLog(CAR_DEALER_ORG, carID)
+}
+
+// QueryMarketStats 查询市场统计数据
+func (s *CarDealerService) QueryMarketStats(period, startTime, endTime string) (map[string]interface{}, error) {
+	return queryMarketStats(CAR_DEALER_ORG, period, startTime, endTime)
+}
+
 // QueryBlockList 分页查询区块列表
 func (s *CarDealerService) QueryBlockList(pageSize int, pageNum int) (*fabric.BlockQueryResult, error) {
 	result, err := fabric.GetBlockListener().GetBlocksByOrg(CAR_DEALER_ORG, pageSize, pageNum)
@@ -27,19 +27,55 @@ type CertificatePayload struct {
 	FileHash     string    `json:"fileHash"`
 	FileLocation string    `json:"fileLocation"`
 	UploadTime   time.Time `json:"uploadTime"`
+	ValidFrom    time.Time `json:"validFrom"`              // 生效时间，零值表示从上传时起生效
+	ValidUntil   time.Time `json:"validUntil"`             // 失效时间，零值表示长期有效
+	Status       string    `json:"status"`                 // 证书状态：ACTIVE/REVOKED
+	RevokeReason string    `json:"revokeReason,omitempty"` // 吊销原因
+	IssuerID     string    `json:"issuerId,omitempty"`     // 签发机构ID（可选）
+	IssuerName   string    `json:"issuerName,omitempty"`   // 签发机构名称
+	Signature    string    `json:"signature,omitempty"`    // 签发机构对文件哈希的分离签名（Base64）
+
+	Expired        bool   `json:"expired,omitempty"`        // 是否已过期（由链码查询时填充）
+	SignatureValid bool   `json:"signatureValid,omitempty"` // 签名是否有效（由链码查询时填充）
+	SignatureError string `json:"signatureError,omitempty"` // 签名无效的原因（由链码查询时填充）
+}
+
+// CertificateVerifyResult 证书验证结果：哈希一致、未过期、未吊销且签名（如有）有效时证书才有效
+type CertificateVerifyResult struct {
+	CertID         string    `json:"certId"`
+	Match          bool      `json:"match"`                    // 文件哈希是否与链上记录一致
+	Expired        bool      `json:"expired"`                  // 证书是否已过期
+	Revoked        bool      `json:"revoked"`                  // 证书是否已被吊销
+	Signed         bool      `json:"signed"`                   // 是否带有签发机构签名
+	SignatureValid bool      `json:"signatureValid"`           // 签发机构签名是否有效
+	SignatureError string    `json:"signatureError,omitempty"` // 签名无效的原因
+	IssuerID       string    `json:"issuerId,omitempty"`       // 签发机构ID
+	IssuerName     string    `json:"issuerName,omitempty"`     // 签发机构名称
+	Valid          bool      `json:"valid"`                    // 证书是否有效
+	StoredHash     string    `json:"storedHash"`               // 链上记录的哈希
+	CurrentHash    string    `json:"currentHash"`              // 当前文件的哈希
+	ValidFrom      time.Time `json:"validFrom"`
+	ValidUntil     time.Time `json:"validUntil"`
 }
 
 const certificateBaseDir = "data/certificates" // 相对于服务器根目录存储证书文件的基础目录
 
 // AddCertificate 处理保存文件、计算哈希并调用链码
-func (s *CertificateService) AddCertificate(carId string, certType string, fileHeader *multipart.FileHeader) (*CertificatePayload, error) {
-	// --- 检查该车辆是否已存在证书 ---
+// validFrom/validUntil 为证书有效期，零值分别表示从上传时起生效、长期有效
+// issuerID/signature 为可选的签发机构签名（Base64 编码的 ECDSA 签名，签名对象为文件 SHA256 摘要），由链码验证
+func (s *CertificateService) AddCertificate(carId string, certType string, validFrom time.Time, validUntil time.Time, issuerID string, signature string, fileHeader *multipart.FileHeader) (*CertificatePayload, error) {
+	// --- 检查该车辆是否已存在同类型证书 ---
+	// 设有失效时间或已吊销的证书允许上传新证书续办，长期有效的证书不能重复上传
 	existingCerts, err := s.GetCertificatesByCar(carId) // 此函数中err的首次声明
 	if err != nil {
 		// 如果检查本身失败，不要阻止上传，但要记录它
 		fmt.Printf("警告: 检查车辆 %s 的现有证书时出错: %v\n", carId, err)
-	} else if len(existingCerts) > 0 {
-		return nil, fmt.Errorf("车辆 %s 已存在证书，无法重复上传", carId)
+	} else {
+		for _, existing := range existingCerts {
+			if existing.CertType == certType && existing.ValidUntil.IsZero() && existing.Status != "REVOKED" {
+				return nil, fmt.Errorf("车辆 %s 已存在长期有效的 %s 证书，无法重复上传", carId, certType)
+			}
+		}
 	}
 	// --- 检查结束 ---
 
@@ -112,6 +148,7 @@ func (s *CertificateService) AddCertificate(carId string, certType string, fileH
 	fmt.Printf("DEBUG: 文件已显式关闭，调用链码前应存在于 %s。\n", serverFilePath) // DEBUG LOG 3
 
 	// 6. 准备链码的payload
+	// 上传时间由链码以交易时间戳写入，这里的值仅作占位
 	uploadTime := time.Now()
 	certPayload := CertificatePayload{
 		CertID:       certID,
@@ -120,6 +157,10 @@ func (s *CertificateService) AddCertificate(carId string, certType string, fileH
 		FileHash:     fileHash,
 		FileLocation: chaincodeFileLocation, // 存储链码特定的相对路径
 		UploadTime:   uploadTime,
+		ValidFrom:    validFrom,
+		ValidUntil:   validUntil,
+		IssuerID:     issuerID,
+		Signature:    signature,
 	}
 	certJsonBytes, err := json.Marshal(certPayload) // 赋值给现有的err
 	if err != nil {
@@ -144,6 +185,19 @@ func (s *CertificateService) AddCertificate(carId string, certType string, fileH
 		fmt.Printf("DEBUG: 服务器认为 AddCertificate 的链码 SubmitTransaction 调用成功。文件应位于 %s。\n", serverFilePath) // DEBUG LOG 6
 	}
 
+	// 读取链上记录，以链码写入的上传时间为准
+	if resultBytes, evalErr := contract.EvaluateTransaction("GetCertificate", certID); evalErr == nil {
+		var onChainCert CertificatePayload
+		if json.Unmarshal(resultBytes, &onChainCert) == nil {
+			certPayload.UploadTime = onChainCert.UploadTime
+			certPayload.ValidFrom = onChainCert.ValidFrom
+			certPayload.Status = onChainCert.Status
+			certPayload.Expired = onChainCert.Expired
+			certPayload.IssuerName = onChainCert.IssuerName
+			certPayload.SignatureValid = onChainCert.SignatureValid
+		}
+	}
+
 	// 如果一切成功，err应该为nil
 	return &certPayload, err // 返回最终的err状态（成功时应为nil）
 }
@@ -226,89 +280,135 @@ func (s *CertificateService) GetCertificateFileLocation(certId string) (string,
 	return "", fmt.Errorf("证书 %s 未找到 (not in list)", certId)
 }
 
-// VerifyCertificate 比较区块链中存储的哈希与服务器上实际文件的哈希
-func (s *CertificateService) VerifyCertificate(certId string) (bool, string, string, error) {
+// VerifyCertificate 比较区块链中存储的哈希与服务器上实际文件的哈希，并检查证书是否已过期
+func (s *CertificateService) VerifyCertificate(certId string) (*CertificateVerifyResult, error) {
 	contract := fabric.GetContract(CAR_DEALER_ORG)
 	resultBytes, err := contract.EvaluateTransaction("GetCertificate", certId) // 此函数中err的首次声明
 	if err != nil {
 		errMsg := fabric.ExtractErrorMessage(err)
 		if strings.Contains(errMsg, "不存在") || strings.Contains(strings.ToLower(errMsg), "not found") {
-			return false, "", "", fmt.Errorf("证书 %s 在链上未找到", certId)
+			return nil, fmt.Errorf("证书 %s 在链上未找到", certId)
 		}
-		return false, "", "", fmt.Errorf("调用链码 GetCertificate(%s) 失败: %s", certId, errMsg)
+		return nil, fmt.Errorf("调用链码 GetCertificate(%s) 失败: %s", certId, errMsg)
 	}
 	if len(resultBytes) == 0 || string(resultBytes) == "null" {
-		return false, "", "", fmt.Errorf("证书 %s 在链上未找到 (empty result)", certId)
+		return nil, fmt.Errorf("证书 %s 在链上未找到 (empty result)", certId)
 	}
 
 	var certPayload CertificatePayload
 	err = json.Unmarshal(resultBytes, &certPayload) // 赋值给现有的err
 	if err != nil {
-		return false, "", "", fmt.Errorf("解析链码返回的证书数据失败: %v, Raw: %s", err, string(resultBytes))
+		return nil, fmt.Errorf("解析链码返回的证书数据失败: %v, Raw: %s", err, string(resultBytes))
 	}
 
 	storedHash := certPayload.FileHash
 	chaincodeFileLocation := certPayload.FileLocation // 这是"CAR_ID/filename.ext"
 
 	if storedHash == "" || chaincodeFileLocation == "" {
-		return false, "", "", fmt.Errorf("链上证书记录缺少哈希或文件路径信息")
+		return nil, fmt.Errorf("链上证书记录缺少哈希或文件路径信息")
 	}
 
 	// 从chaincodeFileLocation重建完整的服务器路径
 	serverFilePath := filepath.Join(certificateBaseDir, chaincodeFileLocation)
 
 	if _, err = os.Stat(serverFilePath); os.IsNotExist(err) { // 赋值给现有的err
-		return false, storedHash, "", fmt.Errorf("服务器上找不到文件: %s (reconstructed from %s)", serverFilePath, chaincodeFileLocation)
+		return nil, fmt.Errorf("服务器上找不到文件: %s (reconstructed from %s)", serverFilePath, chaincodeFileLocation)
 	}
 
 	file, err := os.Open(serverFilePath) // 赋值给现有的err，声明file
 	if err != nil {
-		return false, storedHash, "", fmt.Errorf("打开服务器文件 %s 失败: %v", serverFilePath, err)
+		return nil, fmt.Errorf("打开服务器文件 %s 失败: %v", serverFilePath, err)
 	}
 	defer file.Close()
 
 	hasher := sha256.New()
 	_, err = io.Copy(hasher, file) // 赋值给现有的err
 	if err != nil {
-		return false, storedHash, "", fmt.Errorf("计算文件 %s 哈希失败: %v", serverFilePath, err)
+		return nil, fmt.Errorf("计算文件 %s 哈希失败: %v", serverFilePath, err)
 	}
 	currentHash := hex.EncodeToString(hasher.Sum(nil))
 
-	match := storedHash == currentHash
-
-	return match, storedHash, currentHash, nil
+	return newCertificateVerifyResult(&certPayload, currentHash), nil
 }
 
 // VerifyUploadedCertificate 比较上传文件的哈希与区块链上存储的给定车辆原始证书的哈希
-func (s *CertificateService) VerifyUploadedCertificate(carId string, fileHeader *multipart.FileHeader) (bool, string, string, error) {
+// 车辆有多份证书（如续办）时，与任一证书哈希一致即视为匹配，并以该证书的有效期判断是否有效
+func (s *CertificateService) VerifyUploadedCertificate(carId string, fileHeader *multipart.FileHeader) (*CertificateVerifyResult, error) {
 	originalCerts, err := s.GetCertificatesByCar(carId) // 此函数中err的首次声明
 	if err != nil {
-		return false, "", "", fmt.Errorf("获取车辆 %s 的原始证书信息失败: %v", carId, err)
+		return nil, fmt.Errorf("获取车辆 %s 的原始证书信息失败: %v", carId, err)
 	}
 	if len(originalCerts) == 0 {
-		return false, "", "", fmt.Errorf("车辆 %s 没有已上传的原始证书记录，无法进行比对", carId)
-	}
-	originalCert := originalCerts[0]
-	storedHash := originalCert.FileHash
-
-	if storedHash == "" {
-		return false, "", "", fmt.Errorf("链上原始证书记录缺少哈希信息")
+		return nil, fmt.Errorf("车辆 %s 没有已上传的原始证书记录，无法进行比对", carId)
 	}
 
 	uploadedFile, err := fileHeader.Open() // 赋值给现有的err，声明uploadedFile
 	if err != nil {
-		return false, storedHash, "", fmt.Errorf("打开待验证文件失败: %v", err)
+		return nil, fmt.Errorf("打开待验证文件失败: %v", err)
 	}
 	defer uploadedFile.Close()
 
 	hasher := sha256.New()
 	_, err = io.Copy(hasher, uploadedFile) // 赋值给现有的err
 	if err != nil {
-		return false, storedHash, "", fmt.Errorf("计算待验证文件哈希失败: %v", err)
+		return nil, fmt.Errorf("计算待验证文件哈希失败: %v", err)
 	}
 	currentHash := hex.EncodeToString(hasher.Sum(nil))
 
-	match := storedHash == currentHash
+	originalCert := originalCerts[0]
+	for _, cert := range originalCerts {
+		if cert.FileHash == currentHash {
+			originalCert = cert
+			break
+		}
+	}
+	if originalCert.FileHash == "" {
+		return nil, fmt.Errorf("链上原始证书记录缺少哈希信息")
+	}
+
+	return newCertificateVerifyResult(originalCert, currentHash), nil
+}
+
+// newCertificateVerifyResult 根据链上证书记录和当前文件哈希生成验证结果
+func newCertificateVerifyResult(cert *CertificatePayload, currentHash string) *CertificateVerifyResult {
+	match := cert.FileHash == currentHash
+	signed := cert.Signature != ""
+	revoked := cert.Status == "REVOKED"
+	return &CertificateVerifyResult{
+		CertID:         cert.CertID,
+		Match:          match,
+		Expired:        cert.Expired,
+		Revoked:        revoked,
+		Signed:         signed,
+		SignatureValid: cert.SignatureValid,
+		SignatureError: cert.SignatureError,
+		IssuerID:       cert.IssuerID,
+		IssuerName:     cert.IssuerName,
+		Valid:          match && !cert.Expired && !revoked && (!signed || cert.SignatureValid),
+		StoredHash:     cert.FileHash,
+		CurrentHash:    currentHash,
+		ValidFrom:      cert.ValidFrom,
+		ValidUntil:     cert.ValidUntil,
+	}
+}
+
+// QueryExpiringCertificates 分页查询 days 天内到期的证书（包含已过期的证书）
+func (s *CertificateService) QueryExpiringCertificates(days int, pageSize int32, bookmark string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(CAR_DEALER_ORG)
+	result, err := contract.EvaluateTransaction("QueryExpiringCertificates", fmt.Sprintf("%d", days), fmt.Sprintf("%d", pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("查询即将到期的证书失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var queryResult map[string]interface{}
+	if err := json.Unmarshal(result, &queryResult); err != nil {
+		return nil, fmt.Errorf("解析查询结果失败：%v", err)
+	}
+
+	return queryResult, nil
+}
 
-	return match, storedHash, currentHash, nil
+// QueryTrustedIssuers 查询受信任的证件签发机构列表
+func (s *CertificateService) QueryTrustedIssuers() ([]map[string]interface{}, error) {
+	return queryTrustedIssuers(CAR_DEALER_ORG)
 }
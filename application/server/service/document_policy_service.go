@@ -0,0 +1,23 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// querySaleReadiness 以指定组织身份检查汽车的证件是否满足销售所需证件策略
+func querySaleReadiness(orgName string, carID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QuerySaleReadiness", carID)
+	if err != nil {
+		return nil, fmt.Errorf("检查销售就绪状态失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var readiness map[string]interface{}
+	if err := json.Unmarshal(result, &readiness); err != nil {
+		return nil, fmt.Errorf("解析销售就绪状态失败：%v", err)
+	}
+
+	return readiness, nil
+}
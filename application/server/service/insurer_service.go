@@ -0,0 +1,134 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+type InsurerService struct{}
+
+const INSURER_ORG = "org5" // 保险公司组织
+
+// RegisterPolicy 登记保单，validFrom/validUntil 为 RFC3339 格式，onOwnerChange 为空时使用默认过户规则
+func (s *InsurerService) RegisterPolicy(policyNo, carID, insuredParty, coverageType string, coverageAmount float64, validFrom, validUntil, onOwnerChange string) error {
+	contract := fabric.GetContract(INSURER_ORG)
+	_, err := contract.SubmitTransaction("RegisterPolicy", policyNo, carID, insuredParty, coverageType, fmt.Sprintf("%f", coverageAmount), validFrom, validUntil, onOwnerChange)
+	if err != nil {
+		return fmt.Errorf("登记保单失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryPolicy 通过保单号查询保单
+func (s *InsurerService) QueryPolicy(policyNo string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(INSURER_ORG)
+	result, err := contract.EvaluateTransaction("QueryPolicy", policyNo)
+	if err != nil {
+		return nil, fmt.Errorf("查询保单失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var policy map[string]interface{}
+	if err := json.Unmarshal(result, &policy); err != nil {
+		return nil, fmt.Errorf("解析保单数据失败：%v", err)
+	}
+
+	return policy, nil
+}
+
+// QueryCarPolicies 查询汽车的全部保单
+func (s *InsurerService) QueryCarPolicies(carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(INSURER_ORG)
+	result, err := contract.EvaluateTransaction("QueryCarPolicies", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询保单失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var policies []map[string]interface{}
+	if err := json.Unmarshal(result, &policies); err != nil {
+		return nil, fmt.Errorf("解析保单数据失败：%v", err)
+	}
+
+	return policies, nil
+}
+
+// LogClaim 登记理赔，incidentTime 为 RFC3339 格式
+func (s *InsurerService) LogClaim(claimID, policyNo, incidentTime, damageLevel, description string, claimAmount float64) error {
+	contract := fabric.GetContract(INSURER_ORG)
+	_, err := contract.SubmitTransaction("LogClaim", claimID, policyNo, incidentTime, damageLevel, description, fmt.Sprintf("%f", claimAmount))
+	if err != nil {
+		return fmt.Errorf("登记理赔失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// UpdateClaimStatus 更新理赔状态
+func (s *InsurerService) UpdateClaimStatus(carID, claimID, status string, paidAmount float64) error {
+	contract := fabric.GetContract(INSURER_ORG)
+	_, err := contract.SubmitTransaction("UpdateClaimStatus", carID, claimID, status, fmt.Sprintf("%f", paidAmount))
+	if err != nil {
+		return fmt.Errorf("更新理赔状态失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryDamageHistory 查询汽车的损伤历史
+func (s *InsurerService) QueryDamageHistory(carID string) ([]map[string]interface{}, error) {
+	return queryDamageHistory(INSURER_ORG, carID)
+}
+
+// SetPolicyTransferRule 设置车辆过户时保单的默认处理规则：LAPSE/TRANSFER
+func (s *InsurerService) SetPolicyTransferRule(rule string) error {
+	contract := fabric.GetContract(INSURER_ORG)
+	_, err := contract.SubmitTransaction("SetPolicyTransferRule", rule)
+	if err != nil {
+		return fmt.Errorf("设置保单过户规则失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryPolicyTransferRule 查询车辆过户时保单的默认处理规则
+func (s *InsurerService) QueryPolicyTransferRule() (map[string]interface{}, error) {
+	contract := fabric.GetContract(INSURER_ORG)
+	result, err := contract.EvaluateTransaction("QueryPolicyTransferRule")
+	if err != nil {
+		return nil, fmt.Errorf("查询保单过户规则失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var rule map[string]interface{}
+	if err := json.Unmarshal(result, &rule); err != nil {
+		return nil, fmt.Errorf("解析保单过户规则失败：%v", err)
+	}
+
+	return rule, nil
+}
+
+// QueryMarketStats 查询市场统计数据
+func (s *InsurerService) QueryMarketStats(period, startTime, endTime string) (map[string]interface{}, error) {
+	return queryMarketStats(INSURER_ORG, period, startTime, endTime)
+}
+
+// QueryBlockList 分页查询区块列表
+func (s *InsurerService) QueryBlockList(pageSize int, pageNum int) (*fabric.BlockQueryResult, error) {
+	result, err := fabric.GetBlockListener().GetBlocksByOrg(INSURER_ORG, pageSize, pageNum)
+	if err != nil {
+		return nil, fmt.Errorf("查询区块列表失败：%v", err)
+	}
+	return result, nil
+}
+
+// queryDamageHistory 以指定组织身份查询汽车的损伤历史
+func queryDamageHistory(orgName string, carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryDamageHistory", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询损伤历史失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var claims []map[string]interface{}
+	if err := json.Unmarshal(result, &claims); err != nil {
+		return nil, fmt.Errorf("解析损伤历史失败：%v", err)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// queryTrustedIssuers 以指定组织身份查询受信任的证件签发机构列表
+func queryTrustedIssuers(orgName string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryTrustedIssuers")
+	if err != nil {
+		return nil, fmt.Errorf("查询签发机构列表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var issuers []map[string]interface{}
+	if err := json.Unmarshal(result, &issuers); err != nil {
+		return nil, fmt.Errorf("解析签发机构列表失败：%v", err)
+	}
+
+	return issuers, nil
+}
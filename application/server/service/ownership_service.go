@@ -0,0 +1,23 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// queryOwnershipHistory 以指定组织身份查询汽车的所有权变更历史
+func queryOwnershipHistory(orgName string, carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryOwnershipHistory", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询所有权变更历史失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(result, &records); err != nil {
+		return nil, fmt.Errorf("解析所有权变更历史失败：%v", err)
+	}
+
+	return records, nil
+}
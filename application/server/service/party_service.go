@@ -0,0 +1,47 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// hashNationalID 计算证件号的 SHA256 哈希，链上只保存哈希值
+func hashNationalID(nationalID string) string {
+	normalized := strings.ToUpper(strings.TrimSpace(nationalID))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// registerParty 以指定组织身份登记参与方
+func registerParty(orgName string, partyID, partyType, name, nationalID string) error {
+	if strings.TrimSpace(nationalID) == "" {
+		return fmt.Errorf("证件号不能为空")
+	}
+
+	contract := fabric.GetContract(orgName)
+	_, err := contract.SubmitTransaction("RegisterParty", partyID, partyType, name, hashNationalID(nationalID))
+	if err != nil {
+		return fmt.Errorf("登记参与方失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// queryParty 以指定组织身份查询参与方信息
+func queryParty(orgName string, partyID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryParty", partyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询参与方信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var party map[string]interface{}
+	if err := json.Unmarshal(result, &party); err != nil {
+		return nil, fmt.Errorf("解析参与方数据失败：%v", err)
+	}
+
+	return party, nil
+}
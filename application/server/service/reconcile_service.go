@@ -0,0 +1,527 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 对账单格式
+const (
+	STATEMENT_FORMAT_CSV     = "csv"     // CSV：首行为表头，至少包含 reference 和 amount 列
+	STATEMENT_FORMAT_CAMT053 = "camt053" // ISO 20022 camt.053 银行对账单
+)
+
+// 对账结果状态
+const (
+	RECONCILE_COMPLETED = "COMPLETED" // 精确匹配并已完成交易
+	RECONCILE_UNMATCHED = "UNMATCHED" // 未找到对应的待付款交易，或金额不符
+	RECONCILE_AMBIGUOUS = "AMBIGUOUS" // 匹配到多笔交易，或多条流水匹配同一笔交易
+	RECONCILE_FAILED    = "FAILED"    // 匹配成功但完成交易失败
+)
+
+// 对账单文件的最大大小
+const maxStatementFileSize = 5 << 20
+
+// 查询待付款交易时的分页大小
+const reconcilePageSize = 100
+
+// StatementLine 对账单中的一条入账流水
+type StatementLine struct {
+	Line       int      `json:"line"`               // 行号：CSV 为文件行号，camt.053 为流水序号
+	References []string `json:"references"`         // 付款参考信息（付款附言、端到端标识等）
+	Amount     float64  `json:"amount"`             // 金额
+	Currency   string   `json:"currency,omitempty"` // 币种
+	Credit     bool     `json:"-"`                  // 是否为贷记（入账）
+}
+
+// ReconcileLine 单条流水的对账结果
+type ReconcileLine struct {
+	*StatementLine
+	Status     string   `json:"status"`               // 对账结果状态
+	TxID       string   `json:"txId,omitempty"`       // 完成的交易ID
+	Candidates []string `json:"candidates,omitempty"` // 参考信息匹配到的待付款交易ID
+	Message    string   `json:"message,omitempty"`    // 说明
+}
+
+// ReconcileReport 对账报告：精确匹配的流水自动完成交易，其余流水需人工核对
+type ReconcileReport struct {
+	Format       string           `json:"format"`       // 对账单格式
+	TotalLines   int              `json:"totalLines"`   // 流水总数
+	DebitLines   int              `json:"debitLines"`   // 忽略的借记（出账）流水数
+	PendingCount int              `json:"pendingCount"` // 对账时的待付款交易数
+	Completed    []*ReconcileLine `json:"completed"`    // 已完成交易的流水
+	Review       []*ReconcileLine `json:"review"`       // 未匹配、有歧义或完成失败的流水
+}
+
+// pendingTransaction 对账所需的待付款交易字段
+type pendingTransaction struct {
+	ID        string  `json:"id"`
+	NetAmount float64 `json:"netAmount"`
+	Fees      *struct {
+		BuyerPayable float64 `json:"buyerPayable"`
+	} `json:"fees"`
+}
+
+// payable 买家应付总额：有费用明细时为车款加买家承担的费用，否则为应付车款
+func (t *pendingTransaction) payable() float64 {
+	if t.Fees != nil {
+		return t.Fees.BuyerPayable
+	}
+	return t.NetAmount
+}
+
+// Reconcile 导入银行对账单，按参考信息和金额将入账流水与待付款交易匹配，精确匹配的自动完成交易
+// format 为空时按文件扩展名和内容识别格式
+func (s *BankService) Reconcile(fileHeader *multipart.FileHeader, format string) (*ReconcileReport, error) {
+	if fileHeader.Size > maxStatementFileSize {
+		return nil, fmt.Errorf("对账单文件不能超过 %d MB", maxStatementFileSize>>20)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开对账单文件失败：%v", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxStatementFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("读取对账单文件失败：%v", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if format == "" {
+		format = detectStatementFormat(fileHeader.Filename, data)
+	}
+
+	var lines []*StatementLine
+	switch format {
+	case STATEMENT_FORMAT_CSV:
+		lines, err = parseCSVStatement(data)
+	case STATEMENT_FORMAT_CAMT053:
+		lines, err = parseCamt053Statement(data)
+	default:
+		return nil, fmt.Errorf("不支持的对账单格式: %s，应为 csv 或 camt053", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.queryPendingTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{
+		Format:       format,
+		TotalLines:   len(lines),
+		PendingCount: len(pending),
+		Completed:    make([]*ReconcileLine, 0),
+		Review:       make([]*ReconcileLine, 0),
+	}
+
+	// 先逐条匹配，再排除多条流水匹配同一笔交易的情况，最后提交完成交易
+	matched := make(map[string][]*ReconcileLine)
+	results := make([]*ReconcileLine, 0, len(lines))
+	for _, line := range lines {
+		if !line.Credit {
+			report.DebitLines++
+			continue
+		}
+		result := matchStatementLine(line, pending)
+		if result.Status == RECONCILE_COMPLETED {
+			matched[result.TxID] = append(matched[result.TxID], result)
+		}
+		results = append(results, result)
+	}
+	for txID, group := range matched {
+		if len(group) > 1 {
+			for _, result := range group {
+				result.Status = RECONCILE_AMBIGUOUS
+				result.Candidates = []string{txID}
+				result.TxID = ""
+				result.Message = fmt.Sprintf("共有 %d 条流水匹配交易 %s，可能为重复付款", len(group), txID)
+			}
+		}
+	}
+
+	for _, result := range results {
+		if result.Status == RECONCILE_COMPLETED {
+			if err := s.CompleteTransaction(result.TxID); err != nil {
+				result.Status = RECONCILE_FAILED
+				result.Message = err.Error()
+			}
+		}
+		if result.Status == RECONCILE_COMPLETED {
+			report.Completed = append(report.Completed, result)
+		} else {
+			report.Review = append(report.Review, result)
+		}
+	}
+
+	return report, nil
+}
+
+// queryPendingTransactions 分页读取全部待付款交易
+func (s *BankService) queryPendingTransactions() (map[string]*pendingTransaction, error) {
+	contract := fabric.GetContract(BANK_ORG)
+	pending := make(map[string]*pendingTransaction)
+	bookmark := ""
+	for {
+		result, err := contract.EvaluateTransaction("QueryTransactionList", fmt.Sprintf("%d", reconcilePageSize), bookmark, "PENDING")
+		if err != nil {
+			return nil, fmt.Errorf("查询待付款交易失败：%s", fabric.ExtractErrorMessage(err))
+		}
+
+		var page struct {
+			Records  []*pendingTransaction `json:"records"`
+			Bookmark string                `json:"bookmark"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, fmt.Errorf("解析查询结果失败：%v", err)
+		}
+		for _, transaction := range page.Records {
+			pending[transaction.ID] = transaction
+		}
+		if page.Bookmark == "" || len(page.Records) == 0 {
+			return pending, nil
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+// matchStatementLine 按参考信息查找待付款交易：优先完全等于交易ID的参考信息，其次在附言中以独立词出现的交易ID
+// 只匹配到一笔交易且金额与应付金额一致时为精确匹配
+func matchStatementLine(line *StatementLine, pending map[string]*pendingTransaction) *ReconcileLine {
+	result := &ReconcileLine{StatementLine: line}
+
+	candidates := make([]string, 0)
+	for _, reference := range line.References {
+		for txID := range pending {
+			if strings.EqualFold(reference, txID) && !containsValue(candidates, txID) {
+				candidates = append(candidates, txID)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		for _, reference := range line.References {
+			for txID := range pending {
+				if containsWord(reference, txID) && !containsValue(candidates, txID) {
+					candidates = append(candidates, txID)
+				}
+			}
+		}
+	}
+
+	sort.Strings(candidates)
+
+	switch len(candidates) {
+	case 0:
+		result.Status = RECONCILE_UNMATCHED
+		result.Message = "未找到对应的待付款交易"
+	case 1:
+		transaction := pending[candidates[0]]
+		if math.Round(transaction.payable()*100) != math.Round(line.Amount*100) {
+			result.Status = RECONCILE_UNMATCHED
+			result.Candidates = candidates
+			result.Message = fmt.Sprintf("金额不符：交易 %s 应付 %.2f，实收 %.2f", transaction.ID, transaction.payable(), line.Amount)
+		} else {
+			result.Status = RECONCILE_COMPLETED
+			result.TxID = transaction.ID
+		}
+	default:
+		result.Status = RECONCILE_AMBIGUOUS
+		result.Candidates = candidates
+		result.Message = fmt.Sprintf("参考信息匹配到 %d 笔待付款交易", len(candidates))
+	}
+	return result
+}
+
+// detectStatementFormat 按文件扩展名和内容识别对账单格式
+func detectStatementFormat(filename string, data []byte) string {
+	if strings.EqualFold(filepath.Ext(filename), ".xml") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return STATEMENT_FORMAT_CAMT053
+	}
+	return STATEMENT_FORMAT_CSV
+}
+
+// CSV 对账单各列可使用的表头名称（不区分大小写）
+var (
+	csvReferenceHeaders = []string{"reference", "ref", "remittance", "参考号", "附言"}
+	csvAmountHeaders    = []string{"amount", "金额"}
+	csvCurrencyHeaders  = []string{"currency", "ccy", "币种"}
+	csvDirectionHeaders = []string{"direction", "cdtdbtind", "借贷标志"}
+)
+
+// parseCSVStatement 解析 CSV 对账单：首行为表头，必须包含参考号和金额列
+// 有借贷标志列时以其区分入账和出账（CRDT/C/CREDIT/贷 为入账），没有时金额为负数的视为出账
+func parseCSVStatement(data []byte) ([]*StatementLine, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("对账单为空")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 对账单失败：%v", err)
+	}
+	referenceCol := findColumn(header, csvReferenceHeaders)
+	amountCol := findColumn(header, csvAmountHeaders)
+	currencyCol := findColumn(header, csvCurrencyHeaders)
+	directionCol := findColumn(header, csvDirectionHeaders)
+	if referenceCol < 0 || amountCol < 0 {
+		return nil, fmt.Errorf("CSV 对账单表头必须包含参考号（reference）和金额（amount）列")
+	}
+
+	lines := make([]*StatementLine, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 CSV 对账单失败：%v", err)
+		}
+		// 空行会被跳过，行号取记录在文件中的实际位置
+		lineNo, _ := reader.FieldPos(0)
+		if isBlankRecord(record) {
+			continue
+		}
+		amountText := strings.ReplaceAll(cellAt(record, amountCol), ",", "")
+		amount, err := strconv.ParseFloat(amountText, 64)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行金额格式错误：%s", lineNo, cellAt(record, amountCol))
+		}
+
+		credit := amount > 0
+		if directionCol >= 0 {
+			switch strings.ToUpper(cellAt(record, directionCol)) {
+			case "CRDT", "C", "CR", "CREDIT", "贷":
+				credit = true
+			case "DBIT", "D", "DR", "DEBIT", "借":
+				credit = false
+			default:
+				return nil, fmt.Errorf("第 %d 行借贷标志无法识别：%s", lineNo, cellAt(record, directionCol))
+			}
+		}
+
+		lines = append(lines, &StatementLine{
+			Line:       lineNo,
+			References: nonEmpty(cellAt(record, referenceCol)),
+			Amount:     math.Abs(amount),
+			Currency:   cellAt(record, currencyCol),
+			Credit:     credit,
+		})
+	}
+	return lines, nil
+}
+
+// camt.053 对账单中用到的元素（按本地名称匹配，兼容各版本命名空间）
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtEntry struct {
+	Amount         camtAmount         `xml:"Amt"`
+	CreditDebit    string             `xml:"CdtDbtInd"`
+	AdditionalInfo string             `xml:"AddtlNtryInf"`
+	Details        []camtEntryDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtEntryDetails struct {
+	EndToEndID   string      `xml:"Refs>EndToEndId"`
+	Amount       *camtAmount `xml:"Amt"`
+	TxAmount     *camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	CreditDebit  string      `xml:"CdtDbtInd"`
+	Unstructured []string    `xml:"RmtInf>Ustrd"`
+	Structured   []string    `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+}
+
+// parseCamt053Statement 解析 camt.053 对账单：每条流水（Ntry）为一行，批量流水中的每笔明细（TxDtls）各为一行
+// 参考信息取端到端标识、结构化和非结构化付款附言以及流水附加信息
+func parseCamt053Statement(data []byte) ([]*StatementLine, error) {
+	var document camtDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("解析 camt.053 对账单失败：%v", err)
+	}
+	if len(document.Statements) == 0 {
+		return nil, fmt.Errorf("对账单中没有 BkToCstmrStmt/Stmt 元素")
+	}
+
+	lines := make([]*StatementLine, 0)
+	for _, statement := range document.Statements {
+		for _, entry := range statement.Entries {
+			if len(entry.Details) <= 1 {
+				references := nonEmpty(entry.AdditionalInfo)
+				if len(entry.Details) == 1 {
+					references = append(camtReferences(entry.Details[0]), references...)
+				}
+				line, err := newCamtLine(len(lines)+1, entry.Amount, entry.CreditDebit, references)
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, line)
+				continue
+			}
+
+			for _, details := range entry.Details {
+				amount := details.Amount
+				if amount == nil {
+					amount = details.TxAmount
+				}
+				if amount == nil {
+					return nil, fmt.Errorf("第 %d 条流水的明细缺少金额", len(lines)+1)
+				}
+				creditDebit := details.CreditDebit
+				if creditDebit == "" {
+					creditDebit = entry.CreditDebit
+				}
+				line, err := newCamtLine(len(lines)+1, *amount, creditDebit, camtReferences(details))
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines, nil
+}
+
+// newCamtLine 由 camt.053 的金额和借贷标志生成流水
+func newCamtLine(lineNo int, amount camtAmount, creditDebit string, references []string) (*StatementLine, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(amount.Value), 64)
+	if err != nil {
+		return nil, fmt.Errorf("第 %d 条流水金额格式错误：%s", lineNo, amount.Value)
+	}
+	return &StatementLine{
+		Line:       lineNo,
+		References: references,
+		Amount:     value,
+		Currency:   amount.Currency,
+		Credit:     strings.TrimSpace(creditDebit) == "CRDT",
+	}, nil
+}
+
+// camtReferences 提取明细中的参考信息，忽略未提供的端到端标识
+func camtReferences(details camtEntryDetails) []string {
+	references := make([]string, 0)
+	if !strings.EqualFold(strings.TrimSpace(details.EndToEndID), "NOTPROVIDED") {
+		references = append(references, nonEmpty(details.EndToEndID)...)
+	}
+	for _, value := range details.Structured {
+		references = append(references, nonEmpty(value)...)
+	}
+	for _, value := range details.Unstructured {
+		references = append(references, nonEmpty(value)...)
+	}
+	return references
+}
+
+// findColumn 按候选表头名称查找列下标，找不到时返回 -1
+func findColumn(header []string, names []string) int {
+	for i, column := range header {
+		for _, name := range names {
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// cellAt 读取单元格，列不存在时为空
+func cellAt(record []string, col int) string {
+	if col < 0 || col >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[col])
+}
+
+// isBlankRecord 判断是否为空行
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// nonEmpty 去除首尾空白，为空时返回空切片
+func nonEmpty(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return []string{}
+	}
+	return []string{value}
+}
+
+// containsValue 判断字符串切片中是否包含指定值
+func containsValue(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord 判断 word 是否在 text 中以独立词出现（前后不是字母或数字），不区分大小写
+func containsWord(text string, word string) bool {
+	if word == "" {
+		return false
+	}
+	text, word = strings.ToUpper(text), strings.ToUpper(word)
+	for start := 0; start <= len(text)-len(word); {
+		index := strings.Index(text[start:], word)
+		if index < 0 {
+			return false
+		}
+		begin, end := start+index, start+index+len(word)
+		if !isWordRune(lastRune(text[:begin])) && !isWordRune(firstRune(text[end:])) {
+			return true
+		}
+		start = begin + 1
+	}
+	return false
+}
+
+// isWordRune 判断是否为 ASCII 字母或数字；中文附言中交易ID常与汉字直接相连，汉字视为分隔符
+func isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return ' '
+}
+
+func lastRune(s string) rune {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return ' '
+	}
+	return runes[len(runes)-1]
+}
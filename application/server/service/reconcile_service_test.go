@@ -0,0 +1,177 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVStatement(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		lines []StatementLine
+		err   string
+	}{
+		{
+			name: "金额为负数视为出账",
+			data: "date,Reference,Amount,Currency\n2026-01-02,T1,\"1,200.50\",CNY\n\n2026-01-03,退款,-10,CNY\n",
+			lines: []StatementLine{
+				{Line: 2, References: []string{"T1"}, Amount: 1200.5, Currency: "CNY", Credit: true},
+				{Line: 4, References: []string{"退款"}, Amount: 10, Currency: "CNY", Credit: false},
+			},
+		},
+		{
+			name: "按借贷标志区分入账和出账",
+			data: "附言,金额,借贷标志\n购车款T1,100,贷\n,100,DBIT\n",
+			lines: []StatementLine{
+				{Line: 2, References: []string{"购车款T1"}, Amount: 100, Credit: true},
+				{Line: 3, References: []string{}, Amount: 100, Credit: false},
+			},
+		},
+		{name: "缺少金额列", data: "reference,value\nT1,100\n", err: "必须包含参考号（reference）和金额（amount）列"},
+		{name: "金额格式错误", data: "reference,amount\nT1,abc\n", err: "第 2 行金额格式错误：abc"},
+		{name: "借贷标志无法识别", data: "reference,amount,direction\nT1,100,X\n", err: "第 2 行借贷标志无法识别：X"},
+		{name: "对账单为空", data: "", err: "对账单为空"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := parseCSVStatement([]byte(tt.data))
+			checkStatementLines(t, lines, err, tt.lines, tt.err)
+		})
+	}
+}
+
+func TestParseCamt053Statement(t *testing.T) {
+	const document = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="CNY">100.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>NOTPROVIDED</EndToEndId></Refs>
+          <RmtInf><Ustrd>购车款 T1</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+        <AddtlNtryInf>转账</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="CNY">300.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><EndToEndId>T2</EndToEndId></Refs>
+            <Amt Ccy="CNY">200.00</Amt>
+          </TxDtls>
+          <TxDtls>
+            <AmtDtls><TxAmt><Amt Ccy="CNY">100.00</Amt></TxAmt></AmtDtls>
+            <CdtDbtInd>DBIT</CdtDbtInd>
+            <RmtInf><Strd><CdtrRefInf><Ref>T3</Ref></CdtrRefInf></Strd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+	tests := []struct {
+		name  string
+		data  string
+		lines []StatementLine
+		err   string
+	}{
+		{
+			name: "单笔和批量流水",
+			data: document,
+			lines: []StatementLine{
+				{Line: 1, References: []string{"购车款 T1", "转账"}, Amount: 100, Currency: "CNY", Credit: true},
+				{Line: 2, References: []string{"T2"}, Amount: 200, Currency: "CNY", Credit: true},
+				{Line: 3, References: []string{"T3"}, Amount: 100, Currency: "CNY", Credit: false},
+			},
+		},
+		{name: "缺少对账单元素", data: `<Document><BkToCstmrStmt></BkToCstmrStmt></Document>`, err: "没有 BkToCstmrStmt/Stmt 元素"},
+		{name: "金额格式错误", data: `<Document><BkToCstmrStmt><Stmt><Ntry><Amt>x</Amt></Ntry></Stmt></BkToCstmrStmt></Document>`, err: "第 1 条流水金额格式错误：x"},
+		{name: "不是 XML", data: "reference,amount", err: "解析 camt.053 对账单失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := parseCamt053Statement([]byte(tt.data))
+			checkStatementLines(t, lines, err, tt.lines, tt.err)
+		})
+	}
+}
+
+// checkStatementLines 比较解析结果与期望的流水或错误
+func checkStatementLines(t *testing.T, lines []*StatementLine, err error, want []StatementLine, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("期望错误包含 %q，实际为：%v", wantErr, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("意外的错误：%v", err)
+	}
+	got := make([]StatementLine, 0, len(lines))
+	for _, line := range lines {
+		got = append(got, *line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("解析结果为 %+v，应为 %+v", got, want)
+	}
+}
+
+func TestMatchStatementLine(t *testing.T) {
+	pending := map[string]*pendingTransaction{
+		"T1":  {ID: "T1", NetAmount: 100},
+		"T10": {ID: "T10", NetAmount: 80},
+		"T2": {ID: "T2", NetAmount: 100, Fees: &struct {
+			BuyerPayable float64 `json:"buyerPayable"`
+		}{BuyerPayable: 110}},
+	}
+
+	tests := []struct {
+		name       string
+		references []string
+		amount     float64
+		status     string
+		txID       string
+		candidates []string
+	}{
+		{"参考信息等于交易ID", []string{"t1"}, 100, RECONCILE_COMPLETED, "T1", nil},
+		{"附言中以独立词出现", []string{"购车款T10尾款"}, 80, RECONCILE_COMPLETED, "T10", nil},
+		{"完全相等优先于附言", []string{"T1", "T1 T10"}, 100, RECONCILE_COMPLETED, "T1", nil},
+		{"有费用明细时按买家应付总额匹配", []string{"T2"}, 110, RECONCILE_COMPLETED, "T2", nil},
+		{"金额不符", []string{"T2"}, 100, RECONCILE_UNMATCHED, "", []string{"T2"}},
+		{"附言匹配到多笔交易", []string{"T1 和 T10"}, 100, RECONCILE_AMBIGUOUS, "", []string{"T1", "T10"}},
+		{"交易ID只是附言的一部分", []string{"XT1", "T100"}, 100, RECONCILE_UNMATCHED, "", nil},
+		{"没有参考信息", []string{}, 100, RECONCILE_UNMATCHED, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchStatementLine(&StatementLine{References: tt.references, Amount: tt.amount, Credit: true}, pending)
+			if result.Status != tt.status || result.TxID != tt.txID || !reflect.DeepEqual(result.Candidates, tt.candidates) {
+				t.Fatalf("匹配结果为 %s %s %v（%s），应为 %s %s %v", result.Status, result.TxID, result.Candidates, result.Message, tt.status, tt.txID, tt.candidates)
+			}
+		})
+	}
+}
+
+func TestDetectStatementFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		data     string
+		format   string
+	}{
+		{"statement.XML", "reference,amount", STATEMENT_FORMAT_CAMT053},
+		{"statement.txt", "  <?xml version=\"1.0\"?><Document/>", STATEMENT_FORMAT_CAMT053},
+		{"statement.csv", "reference,amount", STATEMENT_FORMAT_CSV},
+	}
+	for _, tt := range tests {
+		if format := detectStatementFormat(tt.filename, []byte(tt.data)); format != tt.format {
+			t.Errorf("detectStatementFormat(%q) = %s，应为 %s", tt.filename, format, tt.format)
+		}
+	}
+}
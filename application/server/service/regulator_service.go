@@ -0,0 +1,212 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+type RegulatorService struct{}
+
+const REGULATOR_ORG = "org4" // 监管机构（公安）组织
+
+// FlagCarStolen 标记汽车为被盗车辆
+func (s *RegulatorService) FlagCarStolen(carID, caseRef, remark string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("FlagCarStolen", carID, caseRef, remark)
+	if err != nil {
+		return fmt.Errorf("标记被盗车辆失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// ClearStolenFlag 解除汽车的被盗标记
+func (s *RegulatorService) ClearStolenFlag(carID, caseRef, remark string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("ClearStolenFlag", carID, caseRef, remark)
+	if err != nil {
+		return fmt.Errorf("解除被盗标记失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// TransferOwnership 办理非交易过户（继承、赠与、法院判决），须引用链上的证明文件证书
+func (s *RegulatorService) TransferOwnership(carID, newOwner, transferType, documentCertID string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("TransferOwnership", carID, newOwner, transferType, documentCertID)
+	if err != nil {
+		return fmt.Errorf("办理过户失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// RegisterTrustedIssuer 登记受信任的证件签发机构，certPEM 为其 X.509 证书（ECDSA 公钥）
+func (s *RegulatorService) RegisterTrustedIssuer(issuerID, name, certPEM string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("RegisterTrustedIssuer", issuerID, name, certPEM)
+	if err != nil {
+		return fmt.Errorf("登记签发机构失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// RevokeTrustedIssuer 撤销对签发机构的信任
+func (s *RegulatorService) RevokeTrustedIssuer(issuerID, remark string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("RevokeTrustedIssuer", issuerID, remark)
+	if err != nil {
+		return fmt.Errorf("撤销签发机构失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryTrustedIssuers 查询受信任的签发机构列表
+func (s *RegulatorService) QueryTrustedIssuers() ([]map[string]interface{}, error) {
+	return queryTrustedIssuers(REGULATOR_ORG)
+}
+
+// RegisterAuditor 登记审计人员的客户端身份，clientID 由审计人员调用 /api/auditor/identity 获得
+func (s *RegulatorService) RegisterAuditor(mspID, clientID, name string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("RegisterAuditor", mspID, clientID, name)
+	if err != nil {
+		return fmt.Errorf("登记审计人员失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// RevokeAuditor 撤销审计人员身份
+func (s *RegulatorService) RevokeAuditor(mspID, clientID string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("RevokeAuditor", mspID, clientID)
+	if err != nil {
+		return fmt.Errorf("撤销审计人员失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryAuditors 查询全部审计人员
+func (s *RegulatorService) QueryAuditors() ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	result, err := contract.EvaluateTransaction("QueryAuditors")
+	if err != nil {
+		return nil, fmt.Errorf("查询审计人员失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var auditors []map[string]interface{}
+	if err := json.Unmarshal(result, &auditors); err != nil {
+		return nil, fmt.Errorf("解析审计人员数据失败：%v", err)
+	}
+
+	return auditors, nil
+}
+
+// RevokeCertificate 吊销证书，吊销后该证书不再满足销售所需证件要求
+func (s *RegulatorService) RevokeCertificate(certID, reason string) error {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err := contract.SubmitTransaction("RevokeCertificate", certID, reason)
+	if err != nil {
+		return fmt.Errorf("吊销证书失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// SetRequiredDocumentsPolicy 设置销售所需证件策略，certTypes 为空表示不要求任何证件
+func (s *RegulatorService) SetRequiredDocumentsPolicy(certTypes []string) error {
+	if certTypes == nil {
+		certTypes = []string{}
+	}
+	certTypesJSON, err := json.Marshal(certTypes)
+	if err != nil {
+		return fmt.Errorf("序列化证书类型列表失败：%v", err)
+	}
+
+	contract := fabric.GetContract(REGULATOR_ORG)
+	_, err = contract.SubmitTransaction("SetRequiredDocumentsPolicy", string(certTypesJSON))
+	if err != nil {
+		return fmt.Errorf("设置销售所需证件策略失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryRequiredDocumentsPolicy 查询销售所需证件策略
+func (s *RegulatorService) QueryRequiredDocumentsPolicy() (map[string]interface{}, error) {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	result, err := contract.EvaluateTransaction("QueryRequiredDocumentsPolicy")
+	if err != nil {
+		return nil, fmt.Errorf("查询销售所需证件策略失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var policy map[string]interface{}
+	if err := json.Unmarshal(result, &policy); err != nil {
+		return nil, fmt.Errorf("解析销售所需证件策略失败：%v", err)
+	}
+
+	return policy, nil
+}
+
+// QueryCar 查询汽车信息
+func (s *RegulatorService) QueryCar(id string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	result, err := contract.EvaluateTransaction("QueryCar", id)
+	if err != nil {
+		return nil, fmt.Errorf("查询汽车信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var car map[string]interface{}
+	if err := json.Unmarshal(result, &car); err != nil {
+		return nil, fmt.Errorf("解析汽车数据失败：%v", err)
+	}
+
+	return car, nil
+}
+
+// QueryStolenFlagHistory 查询汽车的被盗标记历史
+func (s *RegulatorService) QueryStolenFlagHistory(carID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(REGULATOR_ORG)
+	result, err := contract.EvaluateTransaction("QueryStolenFlagHistory", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询被盗标记历史失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(result, &records); err != nil {
+		return nil, fmt.Errorf("解析被盗标记历史失败：%v", err)
+	}
+
+	return records, nil
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史
+func (s *RegulatorService) QueryOwnershipHistory(carID string) ([]map[string]interface{}, error) {
+	return queryOwnershipHistory(REGULATOR_ORG, carID)
+}
+
+// UpdateCarAttributes 修改汽车属性（车牌号、颜色、车型），须填写变更原因
+func (s *RegulatorService) UpdateCarAttributes(carID, plate, color, model, reason string) error {
+	return updateCarAttributes(REGULATOR_ORG, carID, plate, color, model, reason)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (s *RegulatorService) QueryCarByPlate(plate string) (map[string]interface{}, error) {
+	return queryCarByPlate(REGULATOR_ORG, plate)
+}
+
+// QueryCarChangeLog 查询汽车的属性变更记录
+func (s *RegulatorService) QueryCarChangeLog(carID string) ([]map[string]interface{}, error) {
+	return queryCarChangeLog(REGULATOR_ORG, carID)
+}
+
+// QueryMarketStats 查询市场统计数据
+func (s *RegulatorService) QueryMarketStats(period, startTime, endTime string) (map[string]interface{}, error) {
+	return queryMarketStats(REGULATOR_ORG, period, startTime, endTime)
+}
+
+// QueryBlockList 分页查询区块列表
+func (s *RegulatorService) QueryBlockList(pageSize int, pageNum int) (*fabric.BlockQueryResult, error) {
+	result, err := fabric.GetBlockListener().GetBlocksByOrg(REGULATOR_ORG, pageSize, pageNum)
+	if err != nil {
+		return nil, fmt.Errorf("查询区块列表失败：%v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// queryReservation 以指定组织身份查询预订信息
+func queryReservation(orgName string, reservationID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryReservation", reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("查询预订信息失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var reservation map[string]interface{}
+	if err := json.Unmarshal(result, &reservation); err != nil {
+		return nil, fmt.Errorf("解析预订数据失败：%v", err)
+	}
+
+	return reservation, nil
+}
+
+// queryReservationList 以指定组织身份分页查询预订列表
+func queryReservationList(orgName string, pageSize int32, bookmark string, status string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryReservationList", fmt.Sprintf("%d", pageSize), bookmark, status)
+	if err != nil {
+		return nil, fmt.Errorf("查询预订列表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var queryResult map[string]interface{}
+	if err := json.Unmarshal(result, &queryResult); err != nil {
+		return nil, fmt.Errorf("解析查询结果失败：%v", err)
+	}
+
+	return queryResult, nil
+}
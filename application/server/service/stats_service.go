@@ -0,0 +1,23 @@
+package service
+
+import (
+	"application/pkg/fabric"
+	"encoding/json"
+	"fmt"
+)
+
+// queryMarketStats 以指定组织身份查询市场统计数据
+func queryMarketStats(orgName string, period, startTime, endTime string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(orgName)
+	result, err := contract.EvaluateTransaction("QueryMarketStats", period, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("查询市场统计失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(result, &stats); err != nil {
+		return nil, fmt.Errorf("解析统计数据失败：%v", err)
+	}
+
+	return stats, nil
+}
@@ -4,25 +4,229 @@ import (
 	"application/pkg/fabric"
 	"encoding/json"
 	"fmt"
-	"time"
+	"strconv"
 )
 
 type TradingPlatformService struct{}
 
 const TRADE_ORG = "org3" // 交易平台组织
 
-// CreateTransaction 生成交易
-func (s *TradingPlatformService) CreateTransaction(txID, carID, seller, buyer string, price float64) error { // 修改 realEstateID 为 carID
+// CreateTransaction 生成交易，返回成交价偏离评估价的警告（没有时为空）
+func (s *TradingPlatformService) CreateTransaction(txID, carID, seller, buyer string, price float64) (string, error) { // 修改 realEstateID 为 carID
 	contract := fabric.GetContract(TRADE_ORG)
-	now := time.Now().Format(time.RFC3339)
 	// 注意：链码函数名 CreateTransaction 的参数也需要对应修改
-	_, err := contract.SubmitTransaction("CreateTransaction", txID, carID, seller, buyer, fmt.Sprintf("%f", price), now)
+	_, err := contract.SubmitTransaction("CreateTransaction", txID, carID, seller, buyer, fmt.Sprintf("%f", price))
 	if err != nil {
-		return fmt.Errorf("生成交易失败：%s", fabric.ExtractErrorMessage(err))
+		return "", fmt.Errorf("生成交易失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	// 交易已创建，读取警告失败不影响结果
+	transaction, err := s.QueryTransaction(txID)
+	if err != nil {
+		return "", nil
+	}
+	warning, _ := transaction["priceWarning"].(string)
+	return warning, nil
+}
+
+// CreateTradeInTransaction 生成置换交易：买家以旧车 tradeInCarID 按 tradeInValue 折价购买汽车 carID，
+// 返回成交价偏离评估价的警告（没有时为空）
+func (s *TradingPlatformService) CreateTradeInTransaction(txID, carID, seller, buyer string, price float64, tradeInCarID string, tradeInValue float64) (string, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("CreateTradeInTransaction", txID, carID, seller, buyer, fmt.Sprintf("%f", price), tradeInCarID, fmt.Sprintf("%f", tradeInValue))
+	if err != nil {
+		return "", fmt.Errorf("生成置换交易失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	// 交易已创建，读取警告失败不影响结果
+	transaction, err := s.QueryTransaction(txID)
+	if err != nil {
+		return "", nil
+	}
+	warning, _ := transaction["priceWarning"].(string)
+	return warning, nil
+}
+
+// BundleItem 打包交易中的单辆汽车及其成交价
+type BundleItem struct {
+	CarID string  `json:"carId"`
+	Price float64 `json:"price"`
+}
+
+// CreateBundleTransaction 生成打包交易：多辆汽车在一笔交易中锁定并一并过户，各车成交价之和须等于总价，
+// 返回成交价偏离评估价的警告（没有时为空）
+func (s *TradingPlatformService) CreateBundleTransaction(txID, seller, buyer string, items []BundleItem, totalPrice float64) (string, error) {
+	itemsJson, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("序列化打包交易汽车列表失败：%v", err)
+	}
+
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err = contract.SubmitTransaction("CreateBundleTransaction", txID, seller, buyer, string(itemsJson), fmt.Sprintf("%f", totalPrice))
+	if err != nil {
+		return "", fmt.Errorf("生成打包交易失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	// 交易已创建，读取警告失败不影响结果
+	transaction, err := s.QueryTransaction(txID)
+	if err != nil {
+		return "", nil
+	}
+	warning, _ := transaction["priceWarning"].(string)
+	return warning, nil
+}
+
+// CreateReservation 创建预订：汽车在 holdDays 天内为买家保留，定金需由银行确认
+func (s *TradingPlatformService) CreateReservation(reservationID, carID, seller, buyer string, deposit float64, holdDays int) error {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("CreateReservation", reservationID, carID, seller, buyer, fmt.Sprintf("%f", deposit), fmt.Sprintf("%d", holdDays))
+	if err != nil {
+		return fmt.Errorf("创建预订失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// ConvertReservation 将定金已确认的预订转为待付款交易，定金抵扣车款，
+// 返回成交价偏离评估价的警告（没有时为空）
+func (s *TradingPlatformService) ConvertReservation(reservationID, txID string, price float64) (string, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("ConvertReservation", reservationID, txID, fmt.Sprintf("%f", price))
+	if err != nil {
+		return "", fmt.Errorf("预订转为交易失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	// 交易已创建，读取警告失败不影响结果
+	transaction, err := s.QueryTransaction(txID)
+	if err != nil {
+		return "", nil
+	}
+	warning, _ := transaction["priceWarning"].(string)
+	return warning, nil
+}
+
+// CancelReservation 取消预订，定金已确认时 settlement 为 REFUNDED（退还买家）或 CREDITED（归卖家所有）
+func (s *TradingPlatformService) CancelReservation(reservationID, settlement, reason string) error {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("CancelReservation", reservationID, settlement, reason)
+	if err != nil {
+		return fmt.Errorf("取消预订失败：%s", fabric.ExtractErrorMessage(err))
 	}
 	return nil
 }
 
+// ExpireReservation 使已过保留截止时间的预订失效，定金已确认时退还买家
+func (s *TradingPlatformService) ExpireReservation(reservationID string) error {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("ExpireReservation", reservationID)
+	if err != nil {
+		return fmt.Errorf("预订失效处理失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryReservation 查询预订信息
+func (s *TradingPlatformService) QueryReservation(reservationID string) (map[string]interface{}, error) {
+	return queryReservation(TRADE_ORG, reservationID)
+}
+
+// QueryReservationList 分页查询预订列表
+func (s *TradingPlatformService) QueryReservationList(pageSize int32, bookmark string, status string) (map[string]interface{}, error) {
+	return queryReservationList(TRADE_ORG, pageSize, bookmark, status)
+}
+
+// SubmitReview 代买家提交交易评价，仅限已完成的交易且每笔交易只能评价一次
+func (s *TradingPlatformService) SubmitReview(txID, reviewer string, sellerRating, dealerRating int, comment string) error {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("SubmitReview", txID, reviewer, fmt.Sprintf("%d", sellerRating), fmt.Sprintf("%d", dealerRating), comment)
+	if err != nil {
+		return fmt.Errorf("提交评价失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryReview 查询交易的评价
+func (s *TradingPlatformService) QueryReview(txID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryReview", txID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评价失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var review map[string]interface{}
+	if err := json.Unmarshal(result, &review); err != nil {
+		return nil, fmt.Errorf("解析评价数据失败：%v", err)
+	}
+
+	return review, nil
+}
+
+// QueryPartyReviews 查询参与方作为卖家或经销商收到的全部评价
+func (s *TradingPlatformService) QueryPartyReviews(partyID string) ([]map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryPartyReviews", partyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评价列表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var reviews []map[string]interface{}
+	if err := json.Unmarshal(result, &reviews); err != nil {
+		return nil, fmt.Errorf("解析评价列表失败：%v", err)
+	}
+
+	return reviews, nil
+}
+
+// QueryReputation 查询参与方作为卖家和经销商的信誉汇总
+func (s *TradingPlatformService) QueryReputation(partyID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryReputation", partyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询信誉汇总失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var reputation map[string]interface{}
+	if err := json.Unmarshal(result, &reputation); err != nil {
+		return nil, fmt.Errorf("解析信誉汇总失败：%v", err)
+	}
+
+	return reputation, nil
+}
+
+// OwnerOf 通过通证接口查询汽车的所有者
+func (s *TradingPlatformService) OwnerOf(tokenID string) (string, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("OwnerOf", tokenID)
+	if err != nil {
+		return "", fmt.Errorf("查询通证所有者失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return string(result), nil
+}
+
+// BalanceOf 通过通证接口查询参与方名下的汽车数量
+func (s *TradingPlatformService) BalanceOf(owner string) (int, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("BalanceOf", owner)
+	if err != nil {
+		return 0, fmt.Errorf("查询通证余额失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	balance, err := strconv.Atoi(string(result))
+	if err != nil {
+		return 0, fmt.Errorf("解析通证余额失败：%v", err)
+	}
+	return balance, nil
+}
+
+// GetApproved 通过通证接口查询汽车当前的被授权方
+func (s *TradingPlatformService) GetApproved(tokenID string) (string, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("GetApproved", tokenID)
+	if err != nil {
+		return "", fmt.Errorf("查询通证授权失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return string(result), nil
+}
+
 // QueryCar 查询汽车信息
 func (s *TradingPlatformService) QueryCar(id string) (map[string]interface{}, error) { // 修改函数名和返回类型注释
 	contract := fabric.GetContract(TRADE_ORG)
@@ -72,6 +276,176 @@ func (s *TradingPlatformService) QueryTransactionList(pageSize int32, bookmark s
 	return queryResult, nil
 }
 
+// RegisterParty 登记参与方（证件号在服务端哈希后上链）
+func (s *TradingPlatformService) RegisterParty(partyID, partyType, name, nationalID string) error {
+	return registerParty(TRADE_ORG, partyID, partyType, name, nationalID)
+}
+
+// QueryParty 查询参与方信息
+func (s *TradingPlatformService) QueryParty(partyID string) (map[string]interface{}, error) {
+	return queryParty(TRADE_ORG, partyID)
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史
+func (s *TradingPlatformService) QueryOwnershipHistory(carID string) ([]map[string]interface{}, error) {
+	return queryOwnershipHistory(TRADE_ORG, carID)
+}
+
+// QuerySaleReadiness 检查汽车的证件是否满足销售所需证件策略
+func (s *TradingPlatformService) QuerySaleReadiness(carID string) (map[string]interface{}, error) {
+	return querySaleReadiness(TRADE_ORG, carID)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (s *TradingPlatformService) QueryCarByPlate(plate string) (map[string]interface{}, error) {
+	return queryCarByPlate(TRADE_ORG, plate)
+}
+
+// SetAppraiserStatus 审核评估机构资质：PENDING/APPROVED/REVOKED
+func (s *TradingPlatformService) SetAppraiserStatus(appraiserID, status, remark string) error {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("SetAppraiserStatus", appraiserID, status, remark)
+	if err != nil {
+		return fmt.Errorf("审核评估机构失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryAppraiser 查询评估机构信息
+func (s *TradingPlatformService) QueryAppraiser(appraiserID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryAppraiser", appraiserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评估机构失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var appraiser map[string]interface{}
+	if err := json.Unmarshal(result, &appraiser); err != nil {
+		return nil, fmt.Errorf("解析评估机构数据失败：%v", err)
+	}
+
+	return appraiser, nil
+}
+
+// SetAppraisalPolicy 设置成交价与评估价偏离检查策略：OFF/WARN/REJECT
+func (s *TradingPlatformService) SetAppraisalPolicy(mode string, maxDeviationPercent float64) error {
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err := contract.SubmitTransaction("SetAppraisalPolicy", mode, fmt.Sprintf("%f", maxDeviationPercent))
+	if err != nil {
+		return fmt.Errorf("设置评估价检查策略失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryAppraisalPolicy 查询成交价与评估价偏离检查策略
+func (s *TradingPlatformService) QueryAppraisalPolicy() (map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryAppraisalPolicy")
+	if err != nil {
+		return nil, fmt.Errorf("查询评估价检查策略失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var policy map[string]interface{}
+	if err := json.Unmarshal(result, &policy); err != nil {
+		return nil, fmt.Errorf("解析评估价检查策略失败：%v", err)
+	}
+
+	return policy, nil
+}
+
+// FeeRule 费用规则：按单车成交价的百分比计算，不足最低金额时按最低金额收取
+type FeeRule struct {
+	Code       string  `json:"code"`                 // 费用编码
+	Name       string  `json:"name"`                 // 费用名称
+	Kind       string  `json:"kind"`                 // FEE（平台服务费）/TAX（税费）
+	Payer      string  `json:"payer"`                // BUYER/SELLER
+	ModelClass string  `json:"modelClass,omitempty"` // 适用的车型类别，为空表示默认规则
+	Percent    float64 `json:"percent"`              // 费率（百分比）
+	Minimum    float64 `json:"minimum"`              // 最低金额
+}
+
+// ModelClass 车型类别
+type ModelClass struct {
+	Class  string   `json:"class"`  // 类别名称
+	Models []string `json:"models"` // 车型列表
+}
+
+// FeeSchedule 费用与税费表
+type FeeSchedule struct {
+	Rules        []FeeRule    `json:"rules"`        // 费用规则
+	ModelClasses []ModelClass `json:"modelClasses"` // 车型类别
+	FeeAccount   string       `json:"feeAccount"`   // 平台收费账户
+	TaxAccount   string       `json:"taxAccount"`   // 代收税款账户
+}
+
+// SetFeeSchedule 设置费用与税费表，只影响之后生成的交易
+func (s *TradingPlatformService) SetFeeSchedule(schedule FeeSchedule) error {
+	if schedule.Rules == nil {
+		schedule.Rules = []FeeRule{}
+	}
+	if schedule.ModelClasses == nil {
+		schedule.ModelClasses = []ModelClass{}
+	}
+	scheduleJSON, err := json.Marshal(schedule)
+	if err != nil {
+		return fmt.Errorf("序列化费用表失败：%v", err)
+	}
+
+	contract := fabric.GetContract(TRADE_ORG)
+	_, err = contract.SubmitTransaction("SetFeeSchedule", string(scheduleJSON))
+	if err != nil {
+		return fmt.Errorf("设置费用表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+	return nil
+}
+
+// QueryFeeSchedule 查询费用与税费表
+func (s *TradingPlatformService) QueryFeeSchedule() (map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryFeeSchedule")
+	if err != nil {
+		return nil, fmt.Errorf("查询费用表失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var schedule map[string]interface{}
+	if err := json.Unmarshal(result, &schedule); err != nil {
+		return nil, fmt.Errorf("解析费用表失败：%v", err)
+	}
+
+	return schedule, nil
+}
+
+// QueryAppraisals 查询汽车的全部评估报告
+func (s *TradingPlatformService) QueryAppraisals(carID string) ([]map[string]interface{}, error) {
+	return queryAppraisals(TRADE_ORG, carID)
+}
+
+// QueryLatestAppraisal 查询汽车当前有效的最新评估报告
+func (s *TradingPlatformService) QueryLatestAppraisal(carID string) (map[string]interface{}, error) {
+	contract := fabric.GetContract(TRADE_ORG)
+	result, err := contract.EvaluateTransaction("QueryLatestAppraisal", carID)
+	if err != nil {
+		return nil, fmt.Errorf("查询最新评估报告失败：%s", fabric.ExtractErrorMessage(err))
+	}
+
+	var appraisal map[string]interface{}
+	if err := json.Unmarshal(result, &appraisal); err != nil {
+		return nil, fmt.Errorf("解析评估报告失败：%v", err)
+	}
+
+	return appraisal, nil
+}
+
+// QueryDamageHistory 查询汽车的损伤历史（保险理赔记录）
+func (s *TradingPlatformService) QueryDamageHistory(carID string) ([]map[string]interface{}, error) {
+	return queryDamageHistory(TRADE_ORG, carID)
+}
+
+// QueryMarketStats 查询市场统计数据
+func (s *TradingPlatformService) QueryMarketStats(period, startTime, endTime string) (map[string]interface{}, error) {
+	return queryMarketStats(TRADE_ORG, period, startTime, endTime)
+}
+
 // QueryBlockList 分页查询区块列表
 func (s *TradingPlatformService) QueryBlockList(pageSize int, pageNum int) (*fabric.BlockQueryResult, error) {
 	result, err := fabric.GetBlockListener().GetBlocksByOrg(TRADE_ORG, pageSize, pageNum)
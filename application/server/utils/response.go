@@ -56,6 +56,15 @@ func BadRequest(c *gin.Context, message string) {
 	Fail(c, http.StatusBadRequest, message)
 }
 
+// BadRequestWithData 带数据的400错误响应（例如逐条校验结果）
+func BadRequestWithData(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    http.StatusBadRequest,
+		Message: message,
+		Data:    data,
+	})
+}
+
 // ServerError 500错误响应
 func ServerError(c *gin.Context, message string) {
 	if message == "" {
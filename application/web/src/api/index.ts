@@ -1,12 +1,12 @@
 import request from '../utils/request';
 // 修改导入的类型
-import type { CarPageResult, TransactionPageResult, Car, Transaction, BlockQueryResult, Certificate } from '../types'; // Import Certificate
+import type { CarPageResult, TransactionPageResult, Car, Transaction, BlockQueryResult, Certificate, CertificateVerifyResult, PageResult, SaleReadiness, Reservation, Review, Reputation, Loan, ReconcileReport, FeeSchedule } from '../types'; // Import Certificate
 
 // 汽车经销商接口 (替代 realtyAgencyApi)
 export const carDealerApi = {
   // 创建汽车信息
   createCar: (data: {
-    id: string;
+    plate: string; // 车牌号，汽车ID由链码生成
     model: string; // 修改字段
     vin: string;   // 修改字段
     owner: string;
@@ -24,9 +24,18 @@ export const carDealerApi = {
     request.get<never, BlockQueryResult>('/car-dealer/block/list', { params }),
 
   // 上传证书 (新增)
-  uploadCertificate: (carId: string, certType: string, file: File) => {
+  uploadCertificate: (
+    carId: string,
+    certType: string,
+    file: File,
+    options?: { validFrom?: string; validUntil?: string; issuerId?: string; signature?: string }
+  ) => {
     const formData = new FormData();
     formData.append('certType', certType);
+    if (options?.validFrom) formData.append('validFrom', options.validFrom);
+    if (options?.validUntil) formData.append('validUntil', options.validUntil);
+    if (options?.issuerId) formData.append('issuerId', options.issuerId);
+    if (options?.signature) formData.append('signature', options.signature);
     formData.append('certificateFile', file); // Match the backend expected field name
 
     // 修改路径以匹配后端
@@ -44,13 +53,13 @@ export const carDealerApi = {
 
   // 验证证书完整性 (新增) - 验证服务器存储的文件
   verifyCertificate: (certId: string) =>
-    request.get<never, { match: boolean; storedHash: string; currentHash: string }>(`/car-dealer/certificates/verify/${certId}`), // 修改路径
+    request.get<never, CertificateVerifyResult>(`/car-dealer/certificates/verify/${certId}`), // 修改路径
 
   // 上传文件进行验证 (新增) - 对比上传文件和原始文件
   verifyUploadedCertificate: (carId: string, file: File) => {
     const formData = new FormData();
     formData.append('verificationFile', file); // Match backend expected field name
-    return request.post<never, { match: boolean; storedHash: string; currentHash: string }>(
+    return request.post<never, CertificateVerifyResult>(
       `/car-dealer/certificates/verify-upload/${carId}`,
       formData,
       {
@@ -60,6 +69,14 @@ export const carDealerApi = {
       }
     );
   },
+
+  // 检查汽车的证件是否满足销售所需证件策略
+  getSaleReadiness: (carId: string) =>
+    request.get<never, SaleReadiness>(`/car-dealer/car/readiness/${carId}`),
+
+  // 分页查询 days 天内到期的证书（包含已过期的证书）
+  getExpiringCertificates: (params: { days?: number; pageSize?: number; bookmark?: string }) =>
+    request.get<never, PageResult<Certificate>>('/car-dealer/certificates/expiring', { params }),
 };
 
 // 交易平台接口
@@ -73,6 +90,87 @@ export const tradingPlatformApi = {
     price: number;
   }) => request.post<never, void>('/trading-platform/transaction/create', data),
 
+  // 生成置换交易：买家以旧车折价购买新车
+  createTradeInTransaction: (data: {
+    txId: string;
+    carId: string;
+    seller: string;
+    buyer: string;
+    price: number;
+    tradeInCarId: string;
+    tradeInValue: number;
+  }) => request.post<never, { netAmount: number; priceWarning?: string }>('/trading-platform/transaction/trade-in', data),
+
+  // 生成打包交易：多辆汽车一并锁定、一并过户
+  createBundleTransaction: (data: {
+    txId: string;
+    seller: string;
+    buyer: string;
+    items: { carId: string; price: number }[];
+    totalPrice: number;
+  }) => request.post<never, { priceWarning?: string } | null>('/trading-platform/transaction/bundle', data),
+
+  // 设置费用与税费表，只影响之后生成的交易
+  setFeeSchedule: (data: Omit<FeeSchedule, 'updateTime'>) =>
+    request.post<never, void>('/trading-platform/fee/schedule', data),
+
+  // 查询费用与税费表
+  getFeeSchedule: () => request.get<never, FeeSchedule>('/trading-platform/fee/schedule'),
+
+  // 创建预订：汽车保留给买家，定金由银行确认
+  createReservation: (data: {
+    reservationId: string;
+    carId: string;
+    seller: string;
+    buyer: string;
+    deposit: number;
+    holdDays: number;
+  }) => request.post<never, void>('/trading-platform/reservation/create', data),
+
+  // 预订转为交易，定金抵扣车款
+  convertReservation: (id: string, data: { txId: string; price: number }) =>
+    request.post<never, { priceWarning?: string } | null>(`/trading-platform/reservation/convert/${id}`, data),
+
+  // 取消预订，定金已确认时需指定退还（REFUNDED）或归卖家（CREDITED）
+  cancelReservation: (id: string, data: { settlement?: 'REFUNDED' | 'CREDITED'; reason: string }) =>
+    request.post<never, void>(`/trading-platform/reservation/cancel/${id}`, data),
+
+  // 使已过保留截止时间的预订失效，汽车恢复为待售状态
+  expireReservation: (id: string) => request.post<never, void>(`/trading-platform/reservation/expire/${id}`),
+
+  // 查询预订信息
+  getReservation: (id: string) => request.get<never, Reservation>(`/trading-platform/reservation/${id}`),
+
+  // 分页查询预订列表
+  getReservationList: (params: { pageSize: number; bookmark: string; status?: string }) =>
+    request.get<never, PageResult<Reservation>>('/trading-platform/reservation/list', { params }),
+
+  // 代买家提交交易评价（仅限已完成的交易，每笔交易一次）
+  submitReview: (data: {
+    txId: string;
+    reviewer: string;
+    sellerRating: number;
+    dealerRating: number;
+    comment: string;
+  }) => request.post<never, void>('/trading-platform/review/submit', data),
+
+  // 查询交易的评价
+  getReview: (txId: string) => request.get<never, Review>(`/trading-platform/review/${txId}`),
+
+  // 查询参与方的信誉汇总
+  getReputation: (partyId: string) => request.get<never, Reputation>(`/trading-platform/reputation/${partyId}`),
+
+  // 查询参与方收到的全部评价
+  getPartyReviews: (partyId: string) => request.get<never, Review[]>(`/trading-platform/reputation/${partyId}/reviews`),
+
+  // 通证接口：查询汽车的所有者及被授权方
+  getTokenOwner: (tokenId: string) =>
+    request.get<never, { tokenId: string; owner: string; approved: string }>(`/trading-platform/nft/owner/${tokenId}`),
+
+  // 通证接口：查询参与方名下的汽车数量
+  getTokenBalance: (owner: string) =>
+    request.get<never, { owner: string; balance: number }>(`/trading-platform/nft/balance/${owner}`),
+
   // 查询汽车信息 (替代 getRealEstate)
   getCar: (id: string) => request.get<never, Car>(`/trading-platform/car/${id}`), // 修改路径和返回类型
 
@@ -94,6 +192,72 @@ export const bankApi = {
   completeTransaction: (txId: string) =>
     request.post<never, void>(`/bank/transaction/complete/${txId}`),
 
+  // 导入银行对账单（CSV 或 camt.053），精确匹配的入账流水自动完成交易
+  reconcile: (file: File, format?: 'csv' | 'camt053') => {
+    const formData = new FormData();
+    if (format) formData.append('format', format);
+    formData.append('statementFile', file);
+    return request.post<never, ReconcileReport>('/bank/reconcile', formData, {
+      headers: {
+        'Content-Type': 'multipart/form-data',
+      },
+    });
+  },
+
+  // 确认已收到预订定金
+  confirmReservationDeposit: (id: string, depositRef: string) =>
+    request.post<never, void>(`/bank/reservation/deposit/${id}`, { depositRef }),
+
+  // 分页查询预订列表
+  getReservationList: (params: { pageSize: number; bookmark: string; status?: string }) =>
+    request.get<never, PageResult<Reservation>>('/bank/reservation/list', { params }),
+
+  // 登记购车贷款及还款计划
+  createLoan: (data: { loanId: string; txId: string; principal: number; schedule: { dueDate: string; amount: number }[] }) =>
+    request.post<never, void>('/bank/loan/create', data),
+
+  // 记录一笔还款
+  recordLoanPayment: (id: string, paymentRef: string, amount: number) =>
+    request.post<never, void>(`/bank/loan/payment/${id}`, { paymentRef, amount }),
+
+  // 将逾期未还的贷款标记为违约
+  markLoanDefault: (id: string, reason: string) =>
+    request.post<never, void>(`/bank/loan/default/${id}`, { reason }),
+
+  // 收回违约贷款的抵押汽车
+  repossessCar: (id: string, bankParty: string) =>
+    request.post<never, void>(`/bank/loan/repossess/${id}`, { bankParty }),
+
+  // 分页查询贷款列表
+  getLoanList: (params: { pageSize: number; bookmark: string; status?: string }) =>
+    request.get<never, PageResult<Loan>>('/bank/loan/list', { params }),
+
+  // 查询贷款信息
+  getLoan: (id: string) => request.get<never, Loan>(`/bank/loan/${id}`),
+
+  // 设置链上代币结算使用的代币链码（为空时恢复链下付款）
+  setPaymentSettlement: (tokenChaincode: string) =>
+    request.post<never, void>('/bank/payment/settlement', { tokenChaincode }),
+
+  // 查询链上代币结算配置
+  getPaymentSettlement: () =>
+    request.get<never, { tokenChaincode: string; updateTime: string }>('/bank/payment/settlement'),
+
+  // 向参与方账户发行代币（金额以分计）
+  mintToken: (account: string, amount: number) =>
+    request.post<never, void>('/bank/token/mint', { account, amount }),
+
+  // 设置代币链码的结算链码（通常为二手车链码）
+  setTokenSettlementChaincode: (chaincode: string) =>
+    request.post<never, void>('/bank/token/settlement', { chaincode }),
+
+  // 查询代币链码的结算链码
+  getTokenSettlementChaincode: () => request.get<never, { chaincode: string }>('/bank/token/settlement'),
+
+  // 查询参与方账户的代币余额（分）
+  getTokenAccountBalance: (account: string) =>
+    request.get<never, { account: string; balance: number }>(`/bank/token/balance/${account}`),
+
   // 查询交易信息
   getTransaction: (txId: string) => request.get<never, Transaction>(`/bank/transaction/${txId}`),
 
@@ -34,18 +34,24 @@ export interface PageResult<T> {
 
 // 汽车信息 (替代 RealEstate)
 export interface Car {
-  id: string;
+  id: string;     // 汽车内部ID（创建后不可变更）
+  plate: string;  // 车牌号
+  color?: string; // 车身颜色
   model: string; // 车型
   vin: string;   // 车辆识别代号
   currentOwner: string;
-  status: 'AVAILABLE' | 'IN_TRANSACTION' | 'SOLD'; // 修改状态
+  status: 'AVAILABLE' | 'RESERVED' | 'IN_TRANSACTION' | 'SOLD'; // 修改状态
   createTime: string;
   updateTime: string;
+  stolen: boolean;        // 是否被标记为被盗
+  stolenCaseRef?: string; // 被盗案件编号
+  alert?: string;         // 警示信息
 }
 
 // 交易信息
 export interface Transaction {
   id: string;
+  type: 'SALE' | 'TRADE_IN' | 'BUNDLE'; // 交易类型
   carId: string; // 修改为 carId
   seller: string;
   buyer: string;
@@ -53,6 +59,57 @@ export interface Transaction {
   status: 'PENDING' | 'COMPLETED' | 'CANCELLED';
   createTime: string;
   updateTime: string;
+  carModel?: string; // 生成交易时汽车的车型
+  tradeInCarId?: string; // 置换交易中买家交回的旧车ID
+  tradeInValue?: number; // 旧车折价金额
+  netAmount: number; // 买家实际应付金额
+  items?: { carId: string; price: number; model?: string }[]; // 打包交易包含的汽车、单车成交价及车型
+  reservationId?: string; // 由预订转入的交易对应的预订ID
+  deposit?: number; // 已抵扣的预订定金
+  priceWarning?: string;
+  fees?: FeeBreakdown; // 生成交易时计算的费用明细
+  tokenPayment?: TokenPayment; // 链上代币结算的车款划转
+  tokenFeePayments?: TokenPayment[]; // 链上代币结算的费用划转
+}
+
+// 链上代币划转（金额以分计）
+export interface TokenPayment {
+  chaincode: string;
+  from: string;
+  to: string;
+  amount: number;
+}
+
+// 费用规则：按单车成交价的百分比计算，不足最低金额时按最低金额收取
+export interface FeeRule {
+  code: string;
+  name: string;
+  kind: 'FEE' | 'TAX'; // 平台服务费 / 税费
+  payer: 'BUYER' | 'SELLER';
+  modelClass?: string; // 适用的车型类别，为空表示默认规则
+  percent: number;
+  minimum: number;
+}
+
+// 费用与税费表
+export interface FeeSchedule {
+  rules: FeeRule[];
+  modelClasses: { class: string; models: string[] }[];
+  feeAccount: string; // 平台收费账户
+  taxAccount: string; // 代收税款账户
+  updateTime?: string;
+}
+
+// 交易的费用明细
+export interface FeeBreakdown {
+  items: (Omit<FeeRule, 'modelClass'> & { carId: string; modelClass?: string; base: number; amount: number })[];
+  buyerFees: number; // 买家承担的费用合计
+  sellerFees: number; // 卖家承担的费用合计
+  buyerPayable: number; // 买家应付总额
+  sellerReceivable: number; // 卖家实收金额
+  feeAccount: string;
+  taxAccount: string;
+  scheduleTime: string; // 所用费用表的更新时间
 }
 
 // 证书信息 (新增)
@@ -63,6 +120,131 @@ export interface Certificate {
   fileHash: string;
   fileLocation: string; // Relative path from server data dir
   uploadTime: string; // ISO 8601 format string
+  validFrom: string; // 生效时间
+  validUntil: string; // 失效时间（0001-01-01 表示长期有效）
+  expired?: boolean; // 是否已过期
+  status: 'ACTIVE' | 'REVOKED'; // 证书状态
+  revokeReason?: string; // 吊销原因
+  issuerId?: string; // 签发机构ID
+  issuerName?: string; // 签发机构名称
+  signature?: string; // 签发机构签名（Base64）
+  signatureValid?: boolean; // 签名是否有效
+  signatureError?: string; // 签名无效的原因
+}
+
+// 销售就绪检查结果
+export interface SaleReadiness {
+  carId: string;
+  ready: boolean;
+  requiredCertTypes: string[];
+  missing: { certType: string; issue: 'MISSING' | 'REVOKED' | 'EXPIRED' | 'NOT_YET_VALID' }[];
+  checkTime: string;
+}
+
+// 证书验证结果
+export interface CertificateVerifyResult {
+  certId: string;
+  match: boolean;
+  expired: boolean;
+  revoked: boolean;
+  signed: boolean;
+  signatureValid: boolean;
+  signatureError?: string;
+  issuerId?: string;
+  issuerName?: string;
+  valid: boolean;
+  storedHash: string;
+  currentHash: string;
+  validFrom: string;
+  validUntil: string;
+}
+
+// 预订信息
+export interface Reservation {
+  id: string;
+  carId: string;
+  seller: string;
+  buyer: string;
+  deposit: number; // 定金金额
+  status: 'PENDING_DEPOSIT' | 'ACTIVE' | 'CONVERTED' | 'CANCELLED' | 'EXPIRED';
+  expireTime: string; // 保留截止时间
+  depositRef?: string; // 银行定金收款凭证号
+  depositConfirmTime: string;
+  depositSettlement?: 'APPLIED' | 'REFUNDED' | 'CREDITED'; // 定金处理结果
+  transactionId?: string; // 转入的交易ID
+  remark?: string; // 取消或失效原因
+  createTime: string;
+  updateTime: string;
+  expired?: boolean; // 是否已超过保留截止时间
+}
+
+// 对账结果中的一条流水
+export interface ReconcileLine {
+  line: number; // 行号：CSV 为文件行号，camt.053 为流水序号
+  references: string[]; // 付款参考信息
+  amount: number;
+  currency?: string;
+  status: 'COMPLETED' | 'UNMATCHED' | 'AMBIGUOUS' | 'FAILED';
+  txId?: string; // 完成的交易ID
+  candidates?: string[]; // 参考信息匹配到的待付款交易ID
+  message?: string;
+}
+
+// 银行对账报告
+export interface ReconcileReport {
+  format: 'csv' | 'camt053';
+  totalLines: number; // 流水总数
+  debitLines: number; // 忽略的借记（出账）流水数
+  pendingCount: number; // 对账时的待付款交易数
+  completed: ReconcileLine[]; // 已完成交易的流水
+  review: ReconcileLine[]; // 需人工核对的流水
+}
+
+// 购车贷款
+export interface Loan {
+  id: string;
+  transactionId: string; // 关联的交易ID
+  carId: string; // 抵押汽车ID
+  borrower: string; // 借款人（交易买家）
+  principal: number; // 贷款本金
+  totalDue: number; // 还款计划应还总额
+  paidAmount: number; // 累计已还金额
+  outstanding: number; // 剩余应还金额
+  schedule: { seq: number; dueDate: string; amount: number; paidAmount: number; paidTime: string }[]; // 还款计划
+  payments: { paymentRef: string; amount: number; payTime: string }[]; // 还款流水
+  status: 'ACTIVE' | 'SETTLED' | 'DEFAULTED' | 'REPOSSESSED';
+  defaultReason?: string; // 违约原因
+  repossessedBy?: string; // 收回汽车的银行参与方ID
+  createTime: string;
+  updateTime: string;
+  overdueAmount?: number; // 已到期未还的金额
+}
+
+// 交易评价
+export interface Review {
+  transactionId: string;
+  reviewer: string; // 评价人（交易买家）
+  seller: string;
+  dealers: string[]; // 所购汽车首次登记时的所有者
+  sellerRating: number; // 卖家评分（1-5）
+  dealerRating: number; // 经销商评分（1-5）
+  comment: string;
+  createTime: string;
+}
+
+// 参与方在某一角色下的评价汇总
+export interface RoleReputation {
+  reviewCount: number;
+  averageRating: number;
+  ratingCounts: number[]; // 下标 0 对应 1 分
+}
+
+// 参与方信誉汇总
+export interface Reputation {
+  partyId: string;
+  asSeller: RoleReputation;
+  asDealer: RoleReputation;
+  generatedAt: string;
 }
 
 // 汽车列表查询结果 (替代 RealEstatePageResult)
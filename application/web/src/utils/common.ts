@@ -18,6 +18,8 @@ export const getStatusText = (status: string) => {
       return '正常';
     case 'IN_TRANSACTION':
       return '交易中';
+    case 'RESERVED':
+      return '已预订';
     default:
       return '未知';
   }
@@ -33,6 +35,8 @@ export const getStatusColor = (status: string) => {
       return 'green';
     case 'IN_TRANSACTION':
       return 'blue';
+    case 'RESERVED':
+      return 'purple';
     default:
       return 'default';
   }
@@ -55,3 +55,16 @@ export const generateRandomVIN = () => {
   }
   return vin;
 };
+
+// 随机生成车牌号
+export const generateRandomPlate = () => {
+  const provinces = '京沪粤浙苏川鲁豫鄂湘';
+  const letters = 'ABCDEFGHJKLMNPQRSTUVWXYZ';
+  const characters = 'ABCDEFGHJKLMNPQRSTUVWXYZ0123456789';
+  let plate = provinces.charAt(Math.floor(Math.random() * provinces.length));
+  plate += letters.charAt(Math.floor(Math.random() * letters.length));
+  for (let i = 0; i < 5; i++) {
+    plate += characters.charAt(Math.floor(Math.random() * characters.length));
+  }
+  return plate;
+};
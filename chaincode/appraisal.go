@@ -0,0 +1,447 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// AppraiserStatus 评估机构资质状态
+type AppraiserStatus string
+
+const (
+	APPRAISER_PENDING  AppraiserStatus = "PENDING"  // 待审核
+	APPRAISER_APPROVED AppraiserStatus = "APPROVED" // 已认可
+	APPRAISER_REVOKED  AppraiserStatus = "REVOKED"  // 已撤销
+)
+
+// Appraiser 第三方评估机构，与提交登记的客户端身份绑定
+type Appraiser struct {
+	ID         string          `json:"id"`         // 评估机构ID
+	Name       string          `json:"name"`       // 评估机构名称
+	LicenseNo  string          `json:"licenseNo"`  // 评估资质证书编号
+	MSPID      string          `json:"mspId"`      // 绑定的客户端身份所属组织
+	ClientID   string          `json:"clientId"`   // 绑定的客户端身份
+	Status     AppraiserStatus `json:"status"`     // 资质状态
+	Remark     string          `json:"remark"`     // 审核备注
+	CreateTime time.Time       `json:"createTime"` // 登记时间
+	UpdateTime time.Time       `json:"updateTime"` // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// ConditionGrade 单项车况等级
+type ConditionGrade struct {
+	Item  string `json:"item"`  // 评估项目，例如 外观、内饰、发动机
+	Grade string `json:"grade"` // 等级 A-E（A 最好）
+}
+
+// Appraisal 评估报告记录
+type Appraisal struct {
+	ID              string            `json:"id"`              // 评估报告ID
+	CarID           string            `json:"carId"`           // 汽车ID
+	AppraiserID     string            `json:"appraiserId"`     // 评估机构ID
+	ConditionGrades []*ConditionGrade `json:"conditionGrades"` // 各项车况等级
+	EstimatedMin    float64           `json:"estimatedMin"`    // 估值下限
+	EstimatedMax    float64           `json:"estimatedMax"`    // 估值上限
+	ReportHash      string            `json:"reportHash"`      // 评估报告文件 SHA256 哈希
+	ValidDays       int               `json:"validDays"`       // 有效天数（提交时填写）
+	ValidFrom       time.Time         `json:"validFrom"`       // 生效时间
+	ValidUntil      time.Time         `json:"validUntil"`      // 失效时间
+	CreateTime      time.Time         `json:"createTime"`      // 提交时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// PriceCheckMode 成交价与评估价偏离时的处理方式
+type PriceCheckMode string
+
+const (
+	PRICE_CHECK_OFF    PriceCheckMode = "OFF"    // 不检查
+	PRICE_CHECK_WARN   PriceCheckMode = "WARN"   // 允许交易，在交易记录中保留警告
+	PRICE_CHECK_REJECT PriceCheckMode = "REJECT" // 拒绝交易
+)
+
+// appraisalPolicyKey 评估价检查策略在 CONFIG 中的键
+const appraisalPolicyKey = "APPRAISAL_POLICY"
+
+// AppraisalPolicy 成交价与评估价偏离检查策略
+type AppraisalPolicy struct {
+	Mode                PriceCheckMode `json:"mode"`                // 处理方式
+	MaxDeviationPercent float64        `json:"maxDeviationPercent"` // 允许偏离估值区间的最大百分比
+	UpdateTime          time.Time      `json:"updateTime"`          // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// RegisterAppraiser 登记评估机构并绑定调用者的客户端身份，初始状态为待审核
+func (s *SmartContract) RegisterAppraiser(ctx contractapi.TransactionContextInterface, appraiserID string, name string, licenseNo string) error {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	clientID, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	appraiserKey, err := s.getCompositeKey(ctx, APPRAISER, []string{appraiserID})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(appraiserKey)
+	if err != nil {
+		return fmt.Errorf("查询评估机构信息失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("评估机构ID %s 已存在", appraiserID)
+	}
+
+	// 同一客户端身份只能绑定一个评估机构
+	indexKey, err := s.getCompositeKey(ctx, APPRAISER_INDEX, []string{clientMSPID, clientID})
+	if err != nil {
+		return err
+	}
+	existingID, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return fmt.Errorf("查询评估机构身份索引失败：%v", err)
+	}
+	if existingID != nil {
+		return fmt.Errorf("当前身份已登记为评估机构 %s", string(existingID))
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	appraiser := Appraiser{
+		ID:         appraiserID,
+		Name:       name,
+		LicenseNo:  licenseNo,
+		MSPID:      clientMSPID,
+		ClientID:   clientID,
+		Status:     APPRAISER_PENDING,
+		CreateTime: createTime,
+		UpdateTime: createTime,
+	}
+
+	err = s.putState(ctx, appraiserKey, appraiser)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(indexKey, []byte(appraiserID))
+	if err != nil {
+		return fmt.Errorf("保存评估机构身份索引失败：%v", err)
+	}
+	return nil
+}
+
+// SetAppraiserStatus 审核评估机构资质（仅交易平台组织可以调用）
+// 只有以第三方评估机构组织身份登记的评估机构才能获得认可，保证审核方与评估方相互独立
+func (s *SmartContract) SetAppraiserStatus(ctx contractapi.TransactionContextInterface, appraiserID string, status string, remark string) error {
+	appraiserStatus := AppraiserStatus(status)
+	if appraiserStatus != APPRAISER_PENDING && appraiserStatus != APPRAISER_APPROVED && appraiserStatus != APPRAISER_REVOKED {
+		return fmt.Errorf("无效的评估机构状态: %s", status)
+	}
+
+	appraiserKey, err := s.getCompositeKey(ctx, APPRAISER, []string{appraiserID})
+	if err != nil {
+		return err
+	}
+	var appraiser Appraiser
+	err = s.getState(ctx, appraiserKey, &appraiser)
+	if err != nil {
+		return fmt.Errorf("评估机构ID %s 不存在", appraiserID)
+	}
+	if appraiserStatus == APPRAISER_APPROVED && appraiser.MSPID != APPRAISER_ORG_MSPID {
+		return fmt.Errorf("评估机构 %s 的登记身份属于 %s，只能认可第三方评估机构组织（%s）的身份", appraiserID, appraiser.MSPID, APPRAISER_ORG_MSPID)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	appraiser.Status = appraiserStatus
+	appraiser.Remark = remark
+	appraiser.UpdateTime = updateTime
+
+	return s.putState(ctx, appraiserKey, appraiser)
+}
+
+// QueryAppraiser 查询评估机构信息
+func (s *SmartContract) QueryAppraiser(ctx contractapi.TransactionContextInterface, appraiserID string) (*Appraiser, error) {
+	appraiserKey, err := s.getCompositeKey(ctx, APPRAISER, []string{appraiserID})
+	if err != nil {
+		return nil, err
+	}
+
+	var appraiser Appraiser
+	err = s.getState(ctx, appraiserKey, &appraiser)
+	if err != nil {
+		return nil, fmt.Errorf("评估机构ID %s 不存在", appraiserID)
+	}
+	return &appraiser, nil
+}
+
+// getCallerAppraiser 查找与调用者身份绑定且已认可的评估机构
+func (s *SmartContract) getCallerAppraiser(ctx contractapi.TransactionContextInterface) (*Appraiser, error) {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	clientID, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	indexKey, err := s.getCompositeKey(ctx, APPRAISER_INDEX, []string{clientMSPID, clientID})
+	if err != nil {
+		return nil, err
+	}
+	appraiserID, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询评估机构身份索引失败：%v", err)
+	}
+	if appraiserID == nil {
+		return nil, fmt.Errorf("当前身份不是已登记的评估机构")
+	}
+
+	appraiser, err := s.QueryAppraiser(ctx, string(appraiserID))
+	if err != nil {
+		return nil, err
+	}
+	if appraiser.Status != APPRAISER_APPROVED {
+		return nil, fmt.Errorf("评估机构 %s 未获认可（当前状态：%s）", appraiser.ID, appraiser.Status)
+	}
+	return appraiser, nil
+}
+
+// SubmitAppraisal 提交评估报告（仅已认可的评估机构可以调用）
+func (s *SmartContract) SubmitAppraisal(ctx contractapi.TransactionContextInterface, appraisalJson string) error {
+	appraiser, err := s.getCallerAppraiser(ctx)
+	if err != nil {
+		return err
+	}
+
+	var appraisal Appraisal
+	err = json.Unmarshal([]byte(appraisalJson), &appraisal)
+	if err != nil {
+		return fmt.Errorf("解析评估报告 JSON 失败：%v", err)
+	}
+
+	if len(appraisal.ID) == 0 {
+		return fmt.Errorf("评估报告ID不能为空")
+	}
+	if len(appraisal.CarID) == 0 {
+		return fmt.Errorf("汽车ID不能为空")
+	}
+	if len(appraisal.ConditionGrades) == 0 {
+		return fmt.Errorf("车况等级不能为空")
+	}
+	for _, grade := range appraisal.ConditionGrades {
+		if grade == nil || len(grade.Item) == 0 {
+			return fmt.Errorf("车况评估项目不能为空")
+		}
+		switch grade.Grade {
+		case "A", "B", "C", "D", "E":
+		default:
+			return fmt.Errorf("评估项目 %s 的等级 %s 无效，应为 A-E", grade.Item, grade.Grade)
+		}
+	}
+	if appraisal.EstimatedMin <= 0 || appraisal.EstimatedMax < appraisal.EstimatedMin {
+		return fmt.Errorf("估值区间无效：下限必须大于0且不大于上限")
+	}
+	if decoded, err := hex.DecodeString(appraisal.ReportHash); err != nil || len(decoded) != 32 {
+		return fmt.Errorf("评估报告哈希必须是64位十六进制 SHA256 值")
+	}
+	if appraisal.ValidDays <= 0 {
+		return fmt.Errorf("有效天数必须大于0")
+	}
+
+	if _, _, err := s.getCar(ctx, appraisal.CarID); err != nil {
+		return err
+	}
+
+	appraisalKey, err := s.getCompositeKey(ctx, APPRAISAL, []string{appraisal.CarID, appraisal.ID})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(appraisalKey)
+	if err != nil {
+		return fmt.Errorf("查询评估报告失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("评估报告ID %s 已存在", appraisal.ID)
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	appraisal.AppraiserID = appraiser.ID
+	appraisal.ValidFrom = createTime
+	appraisal.ValidUntil = createTime.AddDate(0, 0, appraisal.ValidDays)
+	appraisal.CreateTime = createTime
+
+	return s.putState(ctx, appraisalKey, appraisal)
+}
+
+// QueryAppraisals 查询汽车的全部评估报告（按提交时间升序）
+func (s *SmartContract) QueryAppraisals(ctx contractapi.TransactionContextInterface, carID string) ([]*Appraisal, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(APPRAISAL, []string{carID})
+	if err != nil {
+		return nil, fmt.Errorf("查询评估报告失败：%v", err)
+	}
+	defer iterator.Close()
+
+	appraisals := make([]*Appraisal, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var appraisal Appraisal
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &appraisal)
+		if err != nil {
+			return nil, fmt.Errorf("解析评估报告失败：%v", err)
+		}
+		appraisals = append(appraisals, &appraisal)
+	}
+
+	sort.SliceStable(appraisals, func(i, j int) bool {
+		return appraisals[i].CreateTime.Before(appraisals[j].CreateTime)
+	})
+
+	return appraisals, nil
+}
+
+// QueryLatestAppraisal 查询汽车当前有效的最新评估报告
+func (s *SmartContract) QueryLatestAppraisal(ctx contractapi.TransactionContextInterface, carID string) (*Appraisal, error) {
+	appraisal, err := s.getLatestValidAppraisal(ctx, carID)
+	if err != nil {
+		return nil, err
+	}
+	if appraisal == nil {
+		return nil, fmt.Errorf("汽车 %s 没有有效的评估报告", carID)
+	}
+	return appraisal, nil
+}
+
+// getLatestValidAppraisal 获取当前有效的最新评估报告，没有时返回 nil
+func (s *SmartContract) getLatestValidAppraisal(ctx contractapi.TransactionContextInterface, carID string) (*Appraisal, error) {
+	appraisals, err := s.QueryAppraisals(ctx, carID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(appraisals) - 1; i >= 0; i-- {
+		if now.Before(appraisals[i].ValidUntil) {
+			return appraisals[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SetAppraisalPolicy 设置成交价与评估价偏离检查策略（仅交易平台组织可以调用）
+func (s *SmartContract) SetAppraisalPolicy(ctx contractapi.TransactionContextInterface, mode string, maxDeviationPercent float64) error {
+	checkMode := PriceCheckMode(mode)
+	if checkMode != PRICE_CHECK_OFF && checkMode != PRICE_CHECK_WARN && checkMode != PRICE_CHECK_REJECT {
+		return fmt.Errorf("无效的检查方式: %s，应为 OFF、WARN 或 REJECT", mode)
+	}
+	if maxDeviationPercent < 0 {
+		return fmt.Errorf("最大偏离百分比不能小于0")
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	policyKey, err := s.getCompositeKey(ctx, CONFIG, []string{appraisalPolicyKey})
+	if err != nil {
+		return err
+	}
+	policy := AppraisalPolicy{
+		Mode:                checkMode,
+		MaxDeviationPercent: maxDeviationPercent,
+		UpdateTime:          updateTime,
+	}
+	return s.putState(ctx, policyKey, policy)
+}
+
+// QueryAppraisalPolicy 查询成交价与评估价偏离检查策略，未设置时默认不检查
+func (s *SmartContract) QueryAppraisalPolicy(ctx contractapi.TransactionContextInterface) (*AppraisalPolicy, error) {
+	policyKey, err := s.getCompositeKey(ctx, CONFIG, []string{appraisalPolicyKey})
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(policyKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询评估价检查策略失败：%v", err)
+	}
+	if bytes == nil {
+		return &AppraisalPolicy{Mode: PRICE_CHECK_OFF}, nil
+	}
+
+	var policy AppraisalPolicy
+	err = s.unmarshalState(ctx, policyKey, bytes, &policy)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// checkPriceAgainstAppraisal 按策略检查成交价是否偏离最新有效评估的估值区间
+// 拒绝模式下返回错误；警告模式下返回警告信息；没有有效评估时不做检查
+func (s *SmartContract) checkPriceAgainstAppraisal(ctx contractapi.TransactionContextInterface, carID string, price float64) (string, error) {
+	policy, err := s.QueryAppraisalPolicy(ctx)
+	if err != nil {
+		return "", err
+	}
+	if policy.Mode == PRICE_CHECK_OFF {
+		return "", nil
+	}
+
+	appraisal, err := s.getLatestValidAppraisal(ctx, carID)
+	if err != nil {
+		return "", err
+	}
+	if appraisal == nil {
+		return "", nil
+	}
+
+	var deviation float64
+	if price < appraisal.EstimatedMin {
+		deviation = (appraisal.EstimatedMin - price) / appraisal.EstimatedMin * 100
+	} else if price > appraisal.EstimatedMax {
+		deviation = (price - appraisal.EstimatedMax) / appraisal.EstimatedMax * 100
+	}
+	if deviation <= policy.MaxDeviationPercent {
+		return "", nil
+	}
+
+	message := fmt.Sprintf("成交价 %.2f 偏离评估报告 %s 的估值区间 [%.2f, %.2f] %.2f%%，超过允许的 %.2f%%",
+		price, appraisal.ID, appraisal.EstimatedMin, appraisal.EstimatedMax, deviation, policy.MaxDeviationPercent)
+	if policy.Mode == PRICE_CHECK_REJECT {
+		return "", fmt.Errorf("%s", message)
+	}
+	return message, nil
+}
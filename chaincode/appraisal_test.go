@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// appraisalJSON 生成评估报告 JSON，modify 用于构造各种无效输入
+func appraisalJSON(t *testing.T, id string, carID string, modify func(a *Appraisal)) string {
+	t.Helper()
+	appraisal := &Appraisal{
+		ID:              id,
+		CarID:           carID,
+		ConditionGrades: []*ConditionGrade{{Item: "外观", Grade: "A"}, {Item: "发动机", Grade: "B"}},
+		EstimatedMin:    90,
+		EstimatedMax:    110,
+		ReportHash:      strings.Repeat("ab", 32),
+		ValidDays:       30,
+	}
+	if modify != nil {
+		modify(appraisal)
+	}
+	data, err := json.Marshal(appraisal)
+	requireNoError(t, err)
+	return string(data)
+}
+
+// registerTestAppraiser 以第三方评估机构组织身份登记评估机构并由交易平台认可
+func registerTestAppraiser(t *testing.T, l *mockLedger, appraiserID string) {
+	t.Helper()
+	requireNoError(t, l.callAs(APPRAISER_ORG_MSPID, appraiserID, false, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterAppraiser(ctx, appraiserID, "评估公司", "LIC-"+appraiserID)
+	}))
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetAppraiserStatus(ctx, appraiserID, string(APPRAISER_APPROVED), "资质齐全")
+	}))
+}
+
+func TestAppraiserIndependence(t *testing.T) {
+	l := newMockLedger(t)
+
+	// 调用规则只允许评估机构组织登记；此前由交易平台组织身份登记的评估机构也不能被认可
+	requireNoError(t, l.callAs(TRADE_ORG_MSPID, "user2", false, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterAppraiser(ctx, "self", "自营评估", "LIC-self")
+	}))
+	tests := []struct {
+		name   string
+		id     string
+		status AppraiserStatus
+		err    string
+	}{
+		{"同组织身份不能认可", "self", APPRAISER_APPROVED, "只能认可第三方评估机构组织（Org6MSP）的身份"},
+		{"无效状态", "self", "UNKNOWN", "无效的评估机构状态"},
+		{"评估机构不存在", "none", APPRAISER_APPROVED, "评估机构ID none 不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.SetAppraiserStatus(ctx, tt.id, string(tt.status), "")
+			}), tt.err)
+		})
+	}
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetAppraiserStatus(ctx, "self", string(APPRAISER_REVOKED), "非独立机构")
+	}))
+
+	registerTestAppraiser(t, l, "appraiser1")
+	requireError(t, l.callAs(APPRAISER_ORG_MSPID, "appraiser1", false, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterAppraiser(ctx, "appraiser2", "评估公司", "LIC-2")
+	}), "当前身份已登记为评估机构 appraiser1")
+
+	// 评估报告只接受第三方评估机构组织成员提交
+	cc, err := newChaincode()
+	requireNoError(t, err)
+	response := l.invoke(cc, TRADE_ORG_MSPID, "user2", false, "SubmitAppraisal", appraisalJSON(t, "A1", "car", nil))
+	if response.Status == shim.OK || !strings.Contains(response.Message, "只有第三方评估机构组织成员才能提交评估报告") {
+		t.Fatalf("交易平台组织不应能提交评估报告：%s", response.Message)
+	}
+}
+
+func TestSubmitAppraisal(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	registerTestAppraiser(t, l, "appraiser1")
+
+	submit := func(appraiserID string, data string) error {
+		return l.callAs(APPRAISER_ORG_MSPID, appraiserID, false, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SubmitAppraisal(ctx, data)
+		})
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a *Appraisal)
+		err    string
+	}{
+		{"缺少车况等级", func(a *Appraisal) { a.ConditionGrades = nil }, "车况等级不能为空"},
+		{"车况等级无效", func(a *Appraisal) { a.ConditionGrades[0].Grade = "F" }, "等级 F 无效"},
+		{"估值下限为0", func(a *Appraisal) { a.EstimatedMin = 0 }, "估值区间无效"},
+		{"估值上限小于下限", func(a *Appraisal) { a.EstimatedMax = 80 }, "估值区间无效"},
+		{"报告哈希无效", func(a *Appraisal) { a.ReportHash = "abcd" }, "64位十六进制"},
+		{"有效天数无效", func(a *Appraisal) { a.ValidDays = 0 }, "有效天数必须大于0"},
+		{"汽车不存在", func(a *Appraisal) { a.CarID = "none" }, "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, submit("appraiser1", appraisalJSON(t, "A1", carID, tt.modify)), tt.err)
+		})
+	}
+
+	requireError(t, submit("stranger", appraisalJSON(t, "A1", carID, nil)), "当前身份不是已登记的评估机构")
+
+	submitTime := l.now
+	requireNoError(t, submit("appraiser1", appraisalJSON(t, "A1", carID, nil)))
+	requireError(t, submit("appraiser1", appraisalJSON(t, "A1", carID, nil)), "评估报告ID A1 已存在")
+
+	var appraisal *Appraisal
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		appraisal, err = testContract.QueryLatestAppraisal(ctx, carID)
+		return err
+	}))
+	if appraisal.AppraiserID != "appraiser1" || !appraisal.ValidFrom.Equal(submitTime) || !appraisal.ValidUntil.Equal(submitTime.AddDate(0, 0, 30)) {
+		t.Fatalf("评估报告不正确：%+v", appraisal)
+	}
+
+	// 资质被撤销后不能再提交评估报告
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetAppraiserStatus(ctx, "appraiser1", string(APPRAISER_REVOKED), "")
+	}))
+	requireError(t, submit("appraiser1", appraisalJSON(t, "A2", carID, nil)), "未获认可（当前状态：REVOKED）")
+}
+
+func TestAppraisalPriceCheck(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "seller")
+	registerTestParty(t, l, "buyer")
+	registerTestAppraiser(t, l, "appraiser1")
+
+	setPolicy := func(mspID string, mode string, maxDeviation float64) error {
+		return l.call(mspID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SetAppraisalPolicy(ctx, mode, maxDeviation)
+		})
+	}
+	requireError(t, setPolicy(TRADE_ORG_MSPID, "BLOCK", 10), "无效的检查方式")
+	requireError(t, setPolicy(TRADE_ORG_MSPID, "WARN", -1), "最大偏离百分比不能小于0")
+
+	// sell 为一辆新车（估值区间 [90, 110]，appraised 为 false 时不提交评估报告）生成交易
+	sell := func(plate string, appraised bool, price float64) (*Transaction, error) {
+		carID := createTestCar(t, l, plate, "seller")
+		if appraised {
+			requireNoError(t, l.callAs(APPRAISER_ORG_MSPID, "appraiser1", false, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.SubmitAppraisal(ctx, appraisalJSON(t, "A-"+plate, carID, nil))
+			}))
+		}
+		err := l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateTransaction(ctx, "T-"+plate, carID, "seller", "buyer", price)
+		})
+		if err != nil {
+			return nil, err
+		}
+		var tx *Transaction
+		requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+			tx, err = testContract.QueryTransaction(ctx, "T-"+plate)
+			return err
+		}))
+		return tx, nil
+	}
+
+	// 未设置策略时不检查
+	_, err := sell("京A00001", true, 500)
+	requireNoError(t, err)
+
+	requireNoError(t, setPolicy(TRADE_ORG_MSPID, "REJECT", 10))
+	_, err = sell("京A00002", false, 1)
+	requireNoError(t, err)
+	_, err = sell("京A00003", true, 122)
+	requireError(t, err, "超过允许的 10.00%")
+	tx, err := sell("京A00004", true, 81)
+	requireNoError(t, err)
+	if tx.PriceWarning != "" {
+		t.Fatalf("允许范围内的成交价不应产生警告：%s", tx.PriceWarning)
+	}
+
+	requireNoError(t, setPolicy(TRADE_ORG_MSPID, "WARN", 10))
+	tx, err = sell("京A00005", true, 80)
+	requireNoError(t, err)
+	if !strings.Contains(tx.PriceWarning, "评估报告 A-京A00005") {
+		t.Fatalf("超出允许范围的成交价应记录警告：%q", tx.PriceWarning)
+	}
+}
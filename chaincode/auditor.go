@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Auditor 审计人员：登记的客户端身份可以调用全部查询函数，但不能调用任何写入账本的函数
+type Auditor struct {
+	MSPID      string    `json:"mspId"`      // 客户端身份所属组织
+	ClientID   string    `json:"clientId"`   // 客户端身份标识（由该客户端调用 ClientIdentityID 获得）
+	Name       string    `json:"name"`       // 审计人员或审计机构名称
+	Active     bool      `json:"active"`     // 是否有效，撤销后为 false
+	CreateTime time.Time `json:"createTime"` // 登记时间
+	UpdateTime time.Time `json:"updateTime"` // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// StateHistoryRecord 账本键的一次历史修改
+type StateHistoryRecord struct {
+	Key       string    `json:"key"`       // 账本键（复合键中的状态部分会随状态变化）
+	TxID      string    `json:"txId"`      // 修改该键的交易ID
+	Timestamp time.Time `json:"timestamp"` // 交易时间
+	IsDelete  bool      `json:"isDelete"`  // 是否为删除操作
+	Value     string    `json:"value"`     // 修改后的记录（JSON，删除时为空）
+}
+
+// readOnlyFunctions 不以 Query/Get 开头的只读函数
+var readOnlyFunctions = map[string]bool{
+	"Hello":             true,
+	"ValidateCarsBatch": true,
+	"ClientIdentityID":  true,
+	"ClientAccountID":   true,
+	"OwnerOf":           true,
+	"BalanceOf":         true,
+	"IsApprovedForAll":  true,
+}
+
+// isReadOnlyFunction 判断链码函数是否只读：以 Query/Get 开头或在 readOnlyFunctions 中
+func isReadOnlyFunction(function string) bool {
+	name := transactionName(function)
+	return strings.HasPrefix(name, "Query") || strings.HasPrefix(name, "Get") || readOnlyFunctions[name]
+}
+
+// denyAuditorWrites 由合约前置钩子调用：审计人员只能调用只读函数，其余函数一律拒绝
+func (s *SmartContract) denyAuditorWrites(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	if isReadOnlyFunction(function) {
+		return nil
+	}
+	auditor, err := s.getCallerAuditor(ctx)
+	if err != nil {
+		return err
+	}
+	if auditor != nil {
+		return fmt.Errorf("审计人员 %s 只能调用查询函数，不能调用 %s", auditor.Name, function)
+	}
+	return nil
+}
+
+// RegisterAuditor 登记审计人员的客户端身份（仅监管机构组织可以调用）
+func (s *SmartContract) RegisterAuditor(ctx contractapi.TransactionContextInterface, mspID string, clientID string, name string) error {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	callerID, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+	if mspID == clientMSPID && clientID == callerID {
+		return fmt.Errorf("不能将自己登记为审计人员")
+	}
+
+	auditorKey, err := s.getCompositeKey(ctx, AUDITOR, []string{mspID, clientID})
+	if err != nil {
+		return err
+	}
+	existing, err := s.getAuditor(ctx, auditorKey)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Active {
+		return fmt.Errorf("该客户端身份已登记为审计人员 %s", existing.Name)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	auditor := Auditor{
+		MSPID:      mspID,
+		ClientID:   clientID,
+		Name:       name,
+		Active:     true,
+		CreateTime: updateTime,
+		UpdateTime: updateTime,
+	}
+	if existing != nil {
+		auditor.CreateTime = existing.CreateTime
+	}
+	return s.putState(ctx, auditorKey, auditor)
+}
+
+// RevokeAuditor 撤销审计人员身份（仅监管机构组织可以调用），撤销后该身份恢复所属组织的普通权限
+func (s *SmartContract) RevokeAuditor(ctx contractapi.TransactionContextInterface, mspID string, clientID string) error {
+	auditorKey, err := s.getCompositeKey(ctx, AUDITOR, []string{mspID, clientID})
+	if err != nil {
+		return err
+	}
+	auditor, err := s.getAuditor(ctx, auditorKey)
+	if err != nil {
+		return err
+	}
+	if auditor == nil || !auditor.Active {
+		return fmt.Errorf("该客户端身份未登记为审计人员")
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	auditor.Active = false
+	auditor.UpdateTime = updateTime
+	return s.putState(ctx, auditorKey, auditor)
+}
+
+// QueryAuditors 查询全部审计人员（含已撤销的）
+func (s *SmartContract) QueryAuditors(ctx contractapi.TransactionContextInterface) ([]*Auditor, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(AUDITOR, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("查询审计人员失败：%v", err)
+	}
+	defer iterator.Close()
+
+	auditors := make([]*Auditor, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var auditor Auditor
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &auditor)
+		if err != nil {
+			return nil, fmt.Errorf("解析审计人员记录失败：%v", err)
+		}
+		auditors = append(auditors, &auditor)
+	}
+	return auditors, nil
+}
+
+// QueryCarHistory 查询汽车记录的全部历史版本（仅审计人员和监管机构组织可以调用）
+// 汽车的复合键包含状态，状态变化时会删除旧键、写入新键，这里合并各状态键的历史并按时间排序
+func (s *SmartContract) QueryCarHistory(ctx contractapi.TransactionContextInterface, carID string) ([]*StateHistoryRecord, error) {
+	keys := make([]string, 0)
+	for _, status := range []CarStatus{AVAILABLE, RESERVED, IN_TRANSACTION, SOLD} {
+		key, err := s.getCompositeKey(ctx, CAR, []string{string(status), carID})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return s.queryStateHistory(ctx, keys)
+}
+
+// QueryTransactionHistory 查询交易记录的全部历史版本（仅审计人员和监管机构组织可以调用）
+func (s *SmartContract) QueryTransactionHistory(ctx contractapi.TransactionContextInterface, txID string) ([]*StateHistoryRecord, error) {
+	keys := make([]string, 0)
+	for _, status := range []TransactionStatus{PENDING, COMPLETED} {
+		key, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(status), txID})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return s.queryStateHistory(ctx, keys)
+}
+
+// QueryCertificateHistory 查询证书记录的全部历史版本（仅审计人员和监管机构组织可以调用）
+func (s *SmartContract) QueryCertificateHistory(ctx contractapi.TransactionContextInterface, certID string) ([]*StateHistoryRecord, error) {
+	key, err := s.getCompositeKey(ctx, CERTIFICATE, []string{certID})
+	if err != nil {
+		return nil, err
+	}
+	return s.queryStateHistory(ctx, []string{key})
+}
+
+// QueryPrivateDataHash 查询私有数据集合中记录的哈希（十六进制，仅审计人员和监管机构组织可以调用）
+// 不是集合成员的组织也能读取哈希，用于核对链下提供的私有数据是否与账本一致
+func (s *SmartContract) QueryPrivateDataHash(ctx contractapi.TransactionContextInterface, collection string, key string) (string, error) {
+	hash, err := ctx.GetStub().GetPrivateDataHash(collection, key)
+	if err != nil {
+		return "", fmt.Errorf("查询私有数据哈希失败：%v", err)
+	}
+	if hash == nil {
+		return "", fmt.Errorf("集合 %s 中不存在键 %s", collection, key)
+	}
+	return hex.EncodeToString(hash), nil
+}
+
+// queryStateHistory 读取各键的修改历史，合并后按时间排序
+func (s *SmartContract) queryStateHistory(ctx contractapi.TransactionContextInterface, keys []string) ([]*StateHistoryRecord, error) {
+	records := make([]*StateHistoryRecord, 0)
+	for _, key := range keys {
+		iterator, err := ctx.GetStub().GetHistoryForKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("查询历史记录失败：%v", err)
+		}
+		for iterator.HasNext() {
+			modification, err := iterator.Next()
+			if err != nil {
+				iterator.Close()
+				return nil, fmt.Errorf("获取下一条历史记录失败：%v", err)
+			}
+			record := &StateHistoryRecord{
+				Key:      key,
+				TxID:     modification.TxId,
+				IsDelete: modification.IsDelete,
+				Value:    string(modification.Value),
+			}
+			if modification.Timestamp != nil {
+				record.Timestamp = modification.Timestamp.AsTime().UTC()
+			}
+			records = append(records, record)
+		}
+		iterator.Close()
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// checkAuditAccess 校验调用者是否为审计人员或监管机构组织成员
+func (s *SmartContract) checkAuditAccess(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	if clientMSPID == REGULATOR_ORG_MSPID {
+		return nil
+	}
+	auditor, err := s.getCallerAuditor(ctx)
+	if err != nil {
+		return err
+	}
+	if auditor == nil {
+		return fmt.Errorf("只有审计人员和监管机构组织成员才能查询账本历史和私有数据哈希")
+	}
+	return nil
+}
+
+// getCallerAuditor 返回调用者登记的有效审计人员记录，不是审计人员时返回 nil
+func (s *SmartContract) getCallerAuditor(ctx contractapi.TransactionContextInterface) (*Auditor, error) {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	clientID, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auditorKey, err := s.getCompositeKey(ctx, AUDITOR, []string{clientMSPID, clientID})
+	if err != nil {
+		return nil, err
+	}
+	auditor, err := s.getAuditor(ctx, auditorKey)
+	if err != nil || auditor == nil || !auditor.Active {
+		return nil, err
+	}
+	return auditor, nil
+}
+
+// getAuditor 读取审计人员记录，不存在时返回 nil
+func (s *SmartContract) getAuditor(ctx contractapi.TransactionContextInterface, auditorKey string) (*Auditor, error) {
+	bytes, err := ctx.GetStub().GetState(auditorKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询审计人员失败：%v", err)
+	}
+	if bytes == nil {
+		return nil, nil
+	}
+	var auditor Auditor
+	if err := s.unmarshalState(ctx, auditorKey, bytes, &auditor); err != nil {
+		return nil, err
+	}
+	return &auditor, nil
+}
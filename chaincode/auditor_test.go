@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestAuditorIsReadOnly(t *testing.T) {
+	l := newMockLedger(t)
+	cc, err := newChaincode()
+	requireNoError(t, err)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+
+	// 银行组织的 auditor1 登记为审计人员
+	var clientID string
+	requireNoError(t, l.callAs(BANK_ORG_MSPID, "auditor1", false, func(ctx contractapi.TransactionContextInterface) (err error) {
+		clientID, err = testContract.ClientIdentityID(ctx)
+		return err
+	}))
+	requireError(t, l.callAs(REGULATOR_ORG_MSPID, "auditor1", false, func(ctx contractapi.TransactionContextInterface) error {
+		ownID, err := testContract.ClientIdentityID(ctx)
+		if err != nil {
+			return err
+		}
+		return testContract.RegisterAuditor(ctx, REGULATOR_ORG_MSPID, ownID, "审计员甲")
+	}), "不能将自己登记为审计人员")
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterAuditor(ctx, BANK_ORG_MSPID, clientID, "审计员甲")
+	}))
+
+	tests := []struct {
+		name       string
+		mspID      string
+		commonName string
+		args       []string
+		err        string
+	}{
+		{"审计人员查询汽车", BANK_ORG_MSPID, "auditor1", []string{"QueryCar", carID}, ""},
+		{"审计人员查询证书", BANK_ORG_MSPID, "auditor1", []string{"GetAllCertificates"}, ""},
+		{"审计人员查询交易历史", BANK_ORG_MSPID, "auditor1", []string{"QueryTransactionHistory", "T1"}, ""},
+		{"审计人员不能完成交易", BANK_ORG_MSPID, "auditor1", []string{"CompleteTransaction", "T1"}, "审计人员 审计员甲 只能调用查询函数"},
+		{"带合约名调用同样被拒绝", BANK_ORG_MSPID, "auditor1", []string{"trade:SetPartyKYCStatus", "alice", "VERIFIED"}, "审计人员 审计员甲 只能调用查询函数"},
+		{"不限组织的写函数同样被拒绝", BANK_ORG_MSPID, "auditor1", []string{"ExpireReservation", "R1"}, "审计人员 审计员甲 只能调用查询函数"},
+		{"同组织的普通成员不能查询历史", BANK_ORG_MSPID, "user1", []string{"QueryCarHistory", carID}, "只有审计人员和监管机构组织成员才能查询账本历史"},
+		{"监管机构组织成员可以查询历史", REGULATOR_ORG_MSPID, "user1", []string{"QueryCarHistory", carID}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := l.invoke(cc, tt.mspID, tt.commonName, false, tt.args...)
+			if tt.err == "" {
+				if response.Status != shim.OK {
+					t.Fatalf("意外的错误：%s", response.Message)
+				}
+				return
+			}
+			if response.Status == shim.OK || !strings.Contains(response.Message, tt.err) {
+				t.Fatalf("期望错误包含 %q，实际为：%d %s", tt.err, response.Status, response.Message)
+			}
+		})
+	}
+
+	// 汽车在交易中变更状态会换用新的复合键，历史须合并各状态键的修改记录
+	response := l.invoke(cc, BANK_ORG_MSPID, "auditor1", false, "QueryCarHistory", carID)
+	var history []*StateHistoryRecord
+	if err := json.Unmarshal(response.Payload, &history); err != nil {
+		t.Fatalf("解析汽车历史失败：%v", err)
+	}
+	statuses := make([]string, 0, len(history))
+	for _, record := range history {
+		if record.IsDelete {
+			continue
+		}
+		var car Car
+		if err := json.Unmarshal([]byte(record.Value), &car); err != nil {
+			t.Fatalf("解析汽车历史版本失败：%v", err)
+		}
+		statuses = append(statuses, string(car.Status))
+	}
+	if strings.Join(statuses, ",") != "AVAILABLE,IN_TRANSACTION,SOLD" {
+		t.Fatalf("汽车历史的状态依次为 %v", statuses)
+	}
+
+	// 撤销后恢复所属组织的普通权限
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RevokeAuditor(ctx, BANK_ORG_MSPID, clientID)
+	}))
+	if response := l.invoke(cc, BANK_ORG_MSPID, "auditor1", false, "QueryCarHistory", carID); response.Status == shim.OK {
+		t.Fatalf("撤销后不应再能查询账本历史")
+	}
+	if response := l.invoke(cc, BANK_ORG_MSPID, "auditor1", false, "SetPartyKYCStatus", "alice", "VERIFIED"); strings.Contains(response.Message, "只能调用查询函数") {
+		t.Fatalf("撤销后不应再受审计人员只读限制：%s", response.Message)
+	}
+}
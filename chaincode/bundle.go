@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// 打包交易的最大汽车数量
+const MAX_BUNDLE_SIZE = 100
+
+// CreateBundleTransaction 生成打包交易（仅交易平台组织可以调用）
+// itemsJson 为 BundleItem 数组，各车成交价之和必须等于 totalPrice；
+// 所有汽车同时锁定为交易中状态，任一汽车不满足条件则整笔交易失败
+func (s *SmartContract) CreateBundleTransaction(ctx contractapi.TransactionContextInterface, txID string, seller string, buyer string, itemsJson string, totalPrice float64) error {
+	if seller == buyer {
+		return fmt.Errorf("买家和卖家不能是同一人")
+	}
+
+	var items []*BundleItem
+	if err := json.Unmarshal([]byte(itemsJson), &items); err != nil {
+		return fmt.Errorf("解析打包交易汽车列表失败：%v", err)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("打包交易至少包含一辆汽车")
+	}
+	if len(items) > MAX_BUNDLE_SIZE {
+		return fmt.Errorf("单笔打包交易最多 %d 辆汽车，当前 %d 辆", MAX_BUNDLE_SIZE, len(items))
+	}
+
+	seen := make(map[string]bool)
+	sum := 0.0
+	for i, item := range items {
+		if item == nil || len(item.CarID) == 0 {
+			return fmt.Errorf("第 %d 项的汽车ID不能为空", i+1)
+		}
+		if seen[item.CarID] {
+			return fmt.Errorf("汽车 %s 在打包交易中重复出现", item.CarID)
+		}
+		seen[item.CarID] = true
+		if item.Price <= 0 {
+			return fmt.Errorf("汽车 %s 的成交价必须大于0", item.CarID)
+		}
+		sum += item.Price
+	}
+	// 按分比较，避免浮点累加误差
+	if math.Round(sum*100) != math.Round(totalPrice*100) {
+		return fmt.Errorf("各车成交价之和 %.2f 与总价 %.2f 不一致", sum, totalPrice)
+	}
+
+	// 买卖双方必须是已通过 KYC 认证的参与方
+	if _, err := s.requireVerifiedParty(ctx, seller, "卖家"); err != nil {
+		return err
+	}
+	if _, err := s.requireVerifiedParty(ctx, buyer, "买家"); err != nil {
+		return err
+	}
+
+	if err := s.checkTransactionIDAvailable(ctx, txID); err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	fees, err := s.computeFeeBreakdown(ctx, items, totalPrice)
+	if err != nil {
+		return err
+	}
+
+	warnings := make([]string, 0)
+	for _, item := range items {
+		car, warning, err := s.lockCarForSale(ctx, item.CarID, seller, item.Price, []CarStatus{AVAILABLE}, createTime)
+		if err != nil {
+			return err
+		}
+		item.Model = car.Model
+		if warning != "" {
+			warnings = append(warnings, fmt.Sprintf("汽车 %s：%s", item.CarID, warning))
+		}
+	}
+
+	transaction := Transaction{
+		ID:         txID,
+		Type:       TX_TYPE_BUNDLE,
+		Seller:     seller,
+		Buyer:      buyer,
+		Price:      totalPrice,
+		NetAmount:  totalPrice,
+		Status:     PENDING,
+		CreateTime: createTime,
+		UpdateTime: createTime,
+
+		Items: items,
+		Fees:  fees,
+
+		PriceWarning: strings.Join(warnings, "；"),
+	}
+
+	txKey, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(PENDING), txID})
+	if err != nil {
+		return err
+	}
+	return s.putState(ctx, txKey, transaction)
+}
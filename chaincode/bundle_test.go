@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestBundleTransaction(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "fleet")
+	carIDs := make([]string, 3)
+	for i := range carIDs {
+		carIDs[i] = createTestCar(t, l, fmt.Sprintf("京A0000%d", i+1), "dealer")
+	}
+	fleetCarID := createTestCar(t, l, "京B00001", "fleet")
+
+	createBundle := func(buyer string, items []*BundleItem, totalPrice float64) error {
+		data, err := json.Marshal(items)
+		requireNoError(t, err)
+		return l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateBundleTransaction(ctx, "T1", "dealer", buyer, string(data), totalPrice)
+		})
+	}
+
+	tooMany := make([]*BundleItem, MAX_BUNDLE_SIZE+1)
+	for i := range tooMany {
+		tooMany[i] = &BundleItem{CarID: fmt.Sprintf("car%d", i), Price: 1}
+	}
+	tests := []struct {
+		name  string
+		buyer string
+		items []*BundleItem
+		total float64
+		err   string
+	}{
+		{"买卖双方相同", "dealer", []*BundleItem{{CarID: carIDs[0], Price: 1}}, 1, "买家和卖家不能是同一人"},
+		{"汽车列表为空", "fleet", []*BundleItem{}, 0, "打包交易至少包含一辆汽车"},
+		{"超过数量上限", "fleet", tooMany, float64(len(tooMany)), fmt.Sprintf("最多 %d 辆汽车", MAX_BUNDLE_SIZE)},
+		{"汽车ID为空", "fleet", []*BundleItem{{CarID: carIDs[0], Price: 1}, {Price: 1}}, 2, "第 2 项的汽车ID不能为空"},
+		{"汽车重复", "fleet", []*BundleItem{{CarID: carIDs[0], Price: 1}, {CarID: carIDs[0], Price: 1}}, 2, "在打包交易中重复出现"},
+		{"单车价格为0", "fleet", []*BundleItem{{CarID: carIDs[0], Price: 0}}, 0, "成交价必须大于0"},
+		{"总价不一致", "fleet", []*BundleItem{{CarID: carIDs[0], Price: 1}, {CarID: carIDs[1], Price: 2}}, 3.01, "与总价 3.01 不一致"},
+		{"其中一辆不属于卖家", "fleet", []*BundleItem{{CarID: carIDs[0], Price: 1}, {CarID: carIDs[1], Price: 1}, {CarID: fleetCarID, Price: 1}}, 3, "不是汽车"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, createBundle(tt.buyer, tt.items, tt.total), tt.err)
+			for _, carID := range carIDs {
+				if car := queryTestCar(t, l, carID); car.Status != AVAILABLE {
+					t.Fatalf("打包交易失败时汽车 %s 不应被锁定", carID)
+				}
+			}
+		})
+	}
+
+	// 总价按分比较，0.1 + 0.2 与 0.3 视为一致
+	items := []*BundleItem{{CarID: carIDs[0], Price: 0.1}, {CarID: carIDs[1], Price: 0.2}, {CarID: carIDs[2], Price: 100}}
+	requireNoError(t, createBundle("fleet", items, 100.3))
+	for _, carID := range carIDs {
+		if car := queryTestCar(t, l, carID); car.Status != IN_TRANSACTION {
+			t.Fatalf("汽车 %s 应被锁定为交易中", carID)
+		}
+	}
+
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CompleteTransaction(ctx, "T1")
+	}))
+	for i, carID := range carIDs {
+		if car := queryTestCar(t, l, carID); car.Status != SOLD || car.CurrentOwner != "fleet" {
+			t.Fatalf("汽车 %s 未过户给买家：%+v", carID, car)
+		}
+		records := queryTestOwnershipHistory(t, l, carID)
+		last := records[len(records)-1]
+		if last.ChangeType != OWNERSHIP_SALE || last.Price != items[i].Price || last.ReferenceID != "T1" {
+			t.Fatalf("汽车 %s 的所有权变更记录不正确：%+v", carID, last)
+		}
+	}
+	var transaction *Transaction
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transaction, err = testContract.QueryTransaction(ctx, "T1")
+		return err
+	}))
+	for _, item := range transaction.Items {
+		if item.Model != "Model S" {
+			t.Fatalf("打包交易应记录每辆车的车型：%+v", item)
+		}
+	}
+
+	// 各车按单车成交价和生成交易时的车型计入车型统计
+	var stats *MarketStats
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		stats, err = testContract.QueryMarketStats(ctx, string(PERIOD_DAY), "", "")
+		return err
+	}))
+	if len(stats.ModelPrices) != 1 || stats.ModelPrices[0].Model != "Model S" || stats.ModelPrices[0].Count != 3 || stats.UnknownModelCount != 0 {
+		t.Fatalf("打包交易的车型统计不正确：%+v", stats.ModelPrices)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// FieldChange 单个字段的变更
+type FieldChange struct {
+	Field    string `json:"field"`    // 字段名
+	OldValue string `json:"oldValue"` // 变更前的值
+	NewValue string `json:"newValue"` // 变更后的值
+}
+
+// CarChangeRecord 汽车属性变更记录（只追加，不修改、不删除）
+type CarChangeRecord struct {
+	RecordID      string         `json:"recordId"`      // 记录ID（Fabric 交易ID）
+	CarID         string         `json:"carId"`         // 汽车ID
+	Changes       []*FieldChange `json:"changes"`       // 字段级变更明细
+	Reason        string         `json:"reason"`        // 变更原因
+	OperatorMSPID string         `json:"operatorMspId"` // 操作者所属组织
+	OperatorID    string         `json:"operatorId"`    // 操作者身份
+	ChangeTime    time.Time      `json:"changeTime"`    // 变更时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// newCarID 根据交易ID和车牌号生成确定性的汽车内部ID（UUID 格式），保证各背书节点结果一致
+func (s *SmartContract) newCarID(ctx contractapi.TransactionContextInterface, plate string) string {
+	sum := sha256.Sum256([]byte(ctx.GetStub().GetTxID() + "\x00" + plate))
+	sum[6] = (sum[6] & 0x0f) | 0x50 // 版本 5（基于名称的哈希）
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// getCarIDByPlate 通过车牌号索引查找汽车ID
+func (s *SmartContract) getCarIDByPlate(ctx contractapi.TransactionContextInterface, plate string) (string, bool, error) {
+	key, err := s.getCompositeKey(ctx, PLATE_INDEX, []string{plate})
+	if err != nil {
+		return "", false, err
+	}
+	carID, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", false, fmt.Errorf("查询车牌号索引失败：%v", err)
+	}
+	if carID == nil {
+		return "", false, nil
+	}
+	return string(carID), true, nil
+}
+
+// getLegacyCarIDByPlate 查找尚未迁移的旧汽车：版本 2 之前汽车ID即车牌号，且没有车牌号索引
+// 旧汽车换牌后汽车ID不再是其车牌号，因此还要求记录中的车牌号与之一致
+func (s *SmartContract) getLegacyCarIDByPlate(ctx contractapi.TransactionContextInterface, plate string) (string, bool) {
+	car, _, err := s.getCar(ctx, plate)
+	if err != nil || car.Plate != plate {
+		return "", false
+	}
+	return car.ID, true
+}
+
+// checkPlateAvailable 检查车牌号未被占用
+// 旧数据在 MigrateState 补齐车牌号索引之前只能按汽车ID查找，因此同时检查与车牌号相同的旧汽车ID
+func (s *SmartContract) checkPlateAvailable(ctx contractapi.TransactionContextInterface, plate string) error {
+	carID, found, err := s.getCarIDByPlate(ctx, plate)
+	if err != nil {
+		return err
+	}
+	if !found {
+		carID, found = s.getLegacyCarIDByPlate(ctx, plate)
+	}
+	if found {
+		return fmt.Errorf("车牌号 %s 已登记在汽车 %s 上", plate, carID)
+	}
+	return nil
+}
+
+// putPlateIndex 写入车牌号到汽车ID的索引
+func (s *SmartContract) putPlateIndex(ctx contractapi.TransactionContextInterface, plate string, carID string) error {
+	key, err := s.getCompositeKey(ctx, PLATE_INDEX, []string{plate})
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, []byte(carID))
+	if err != nil {
+		return fmt.Errorf("保存车牌号索引失败：%v", err)
+	}
+	return nil
+}
+
+// backfillPlateIndex 为迁移后的旧汽车补写车牌号索引，车牌号已被其他汽车占用时报错
+func (s *SmartContract) backfillPlateIndex(ctx contractapi.TransactionContextInterface, data []byte) error {
+	var car Car
+	if err := json.Unmarshal(data, &car); err != nil {
+		return fmt.Errorf("解析汽车记录失败：%v", err)
+	}
+	carID, found, err := s.getCarIDByPlate(ctx, car.Plate)
+	if err != nil {
+		return err
+	}
+	if found {
+		if carID != car.ID {
+			return fmt.Errorf("车牌号 %s 已登记在汽车 %s 上，与旧汽车 %s 冲突", car.Plate, carID, car.ID)
+		}
+		return nil
+	}
+	return s.putPlateIndex(ctx, car.Plate, car.ID)
+}
+
+// UpdateCarAttributes 更正汽车属性或变更车牌号（汽车经销商、监管机构组织可以调用）
+// plate、color、model 为空表示不修改，至少需要修改一项，且必须填写变更原因
+func (s *SmartContract) UpdateCarAttributes(ctx contractapi.TransactionContextInterface, carID string, plate string, color string, model string, reason string) error {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	operatorID, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	car, carKey, err := s.getCar(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if car.Status == IN_TRANSACTION {
+		return fmt.Errorf("汽车 %s 正在交易中，无法修改属性", carID)
+	}
+	if car.Stolen {
+		return fmt.Errorf("汽车 %s 已被标记为被盗车辆（案件编号：%s），无法修改属性", carID, car.StolenCaseRef)
+	}
+
+	changes := make([]*FieldChange, 0)
+	oldPlate := car.Plate
+	if plate != "" && plate != car.Plate {
+		if err := s.checkPlateAvailable(ctx, plate); err != nil {
+			return err
+		}
+		changes = append(changes, &FieldChange{Field: "plate", OldValue: car.Plate, NewValue: plate})
+		car.Plate = plate
+	}
+	if color != "" && color != car.Color {
+		changes = append(changes, &FieldChange{Field: "color", OldValue: car.Color, NewValue: color})
+		car.Color = color
+	}
+	if model != "" && model != car.Model {
+		changes = append(changes, &FieldChange{Field: "model", OldValue: car.Model, NewValue: model})
+		car.Model = model
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("没有需要修改的属性")
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	car.UpdateTime = updateTime
+
+	err = s.putState(ctx, carKey, car)
+	if err != nil {
+		return err
+	}
+
+	// 车牌号变更时同步更新索引
+	if car.Plate != oldPlate {
+		oldKey, err := s.getCompositeKey(ctx, PLATE_INDEX, []string{oldPlate})
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().DelState(oldKey)
+		if err != nil {
+			return fmt.Errorf("删除旧车牌号索引失败：%v", err)
+		}
+		err = s.putPlateIndex(ctx, car.Plate, car.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	recordID := ctx.GetStub().GetTxID()
+	recordKey, err := s.getCompositeKey(ctx, CAR_CHANGE, []string{car.ID, recordID})
+	if err != nil {
+		return err
+	}
+	record := CarChangeRecord{
+		RecordID:      recordID,
+		CarID:         car.ID,
+		Changes:       changes,
+		Reason:        reason,
+		OperatorMSPID: clientMSPID,
+		OperatorID:    operatorID,
+		ChangeTime:    updateTime,
+	}
+	return s.putState(ctx, recordKey, record)
+}
+
+// QueryCarByPlate 通过车牌号查询汽车信息
+func (s *SmartContract) QueryCarByPlate(ctx contractapi.TransactionContextInterface, plate string) (*Car, error) {
+	if len(plate) == 0 {
+		return nil, fmt.Errorf("车牌号不能为空")
+	}
+
+	carID, found, err := s.getCarIDByPlate(ctx, plate)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		// 旧数据迁移前没有车牌号索引
+		carID, found = s.getLegacyCarIDByPlate(ctx, plate)
+	}
+	if !found {
+		return nil, fmt.Errorf("车牌号 %s 未登记", plate)
+	}
+
+	car, err := s.QueryCar(ctx, carID)
+	if err != nil || car.Plate != plate {
+		return nil, fmt.Errorf("车牌号 %s 未登记", plate)
+	}
+	return car, nil
+}
+
+// QueryCarChangeLog 查询汽车的属性变更记录（按时间升序）
+func (s *SmartContract) QueryCarChangeLog(ctx contractapi.TransactionContextInterface, carID string) ([]*CarChangeRecord, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(CAR_CHANGE, []string{carID})
+	if err != nil {
+		return nil, fmt.Errorf("查询汽车属性变更记录失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]*CarChangeRecord, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var record CarChangeRecord
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &record)
+		if err != nil {
+			return nil, fmt.Errorf("解析汽车属性变更记录失败：%v", err)
+		}
+		records = append(records, &record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ChangeTime.Before(records[j].ChangeTime)
+	})
+
+	return records, nil
+}
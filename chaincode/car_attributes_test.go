@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// putLegacyCar 写入一条版本 1 的旧汽车记录（汽车ID即车牌号，没有车牌号索引）
+func putLegacyCar(t *testing.T, l *mockLedger, plate string) {
+	t.Helper()
+	key, err := shim.CreateCompositeKey(CAR, []string{string(AVAILABLE), plate})
+	requireNoError(t, err)
+	l.state[key] = []byte(`{"id":"` + plate + `","model":"Model S","vin":"LSVAB000000000999","currentOwner":"dealer","status":"AVAILABLE","schemaVersion":1}`)
+}
+
+// migrateLegacyCar 以版本 1 为起始版本迁移旧汽车记录
+func migrateLegacyCar(l *mockLedger, plate string) error {
+	return l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.MigrateState(ctx, 1, `[{"objectType":"CAR","attributes":["AVAILABLE","`+plate+`"]}]`)
+		return err
+	})
+}
+
+func queryCarByPlate(l *mockLedger, plate string) (car *Car, err error) {
+	err = l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		car, err = testContract.QueryCarByPlate(ctx, plate)
+		return err
+	})
+	return car, err
+}
+
+func TestLegacyCarPlateIndex(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	putLegacyCar(t, l, "京A00001")
+
+	// 迁移前旧汽车没有车牌号索引，按与车牌号相同的汽车ID查找
+	car, err := queryCarByPlate(l, "京A00001")
+	requireNoError(t, err)
+	if car.ID != "京A00001" {
+		t.Fatalf("迁移前应按汽车ID找到旧汽车：%+v", car)
+	}
+
+	requireNoError(t, migrateLegacyCar(l, "京A00001"))
+	car, err = queryCarByPlate(l, "京A00001")
+	requireNoError(t, err)
+	if car.ID != "京A00001" || car.SchemaVersion != CURRENT_SCHEMA_VERSION {
+		t.Fatalf("旧汽车迁移结果不正确：%+v", car)
+	}
+	requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.CreateCar(ctx, "京A00001", "Model 3", "LSVAB000000000101", "dealer")
+		return err
+	}), "已登记在汽车 京A00001 上")
+
+	// 旧汽车换牌后原车牌号（即其汽车ID）可以重新登记
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.UpdateCarAttributes(ctx, "京A00001", "京A00009", "", "", "换牌")
+	}))
+	newCarID := createTestCar(t, l, "京A00001", "dealer")
+	if car, err := queryCarByPlate(l, "京A00001"); err != nil || car.ID != newCarID {
+		t.Fatalf("车牌号应指向新汽车 %s：%+v %v", newCarID, car, err)
+	}
+	if car, err := queryCarByPlate(l, "京A00009"); err != nil || car.ID != "京A00001" {
+		t.Fatalf("新车牌号应指向旧汽车：%+v %v", car, err)
+	}
+}
+
+func TestLegacyCarPlateConflict(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	putLegacyCar(t, l, "京A00001")
+	carID := createTestCar(t, l, "京A00002", "dealer")
+
+	// 迁移前新车和换牌都不能占用旧汽车的车牌号，迁移因此不会遇到冲突
+	requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.CreateCar(ctx, "京A00001", "Model 3", "LSVAB000000000101", "dealer")
+		return err
+	}), "车牌号 京A00001 已登记在汽车 京A00001 上")
+	requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.UpdateCarAttributes(ctx, carID, "京A00001", "", "", "换牌")
+	}), "车牌号 京A00001 已登记在汽车 京A00001 上")
+	requireNoError(t, migrateLegacyCar(l, "京A00001"))
+
+	// 加入该检查之前登记的数据可能已占用旧汽车的车牌号，迁移时报错且不修改索引
+	putLegacyCar(t, l, "京A00002")
+	requireError(t, migrateLegacyCar(l, "京A00002"), "车牌号 京A00002 已登记在汽车 "+carID+" 上，与旧汽车 京A00002 冲突")
+	if car, err := queryCarByPlate(l, "京A00002"); err != nil || car.ID != carID {
+		t.Fatalf("迁移失败时不应修改车牌号索引：%+v %v", car, err)
+	}
+}
+
+func TestUpdateCarAttributes(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	createTestCar(t, l, "京A00002", "dealer")
+
+	tests := []struct {
+		name  string
+		plate string
+		color string
+		err   string
+	}{
+		{"没有修改", "京A00001", "", "没有需要修改的属性"},
+		{"车牌号已被占用", "京A00002", "", "车牌号 京A00002 已登记"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.UpdateCarAttributes(ctx, carID, tt.plate, tt.color, "", "更正")
+			}), tt.err)
+		})
+	}
+
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.UpdateCarAttributes(ctx, carID, "京A00003", "红色", "", "换牌")
+	}))
+	if _, err := queryCarByPlate(l, "京A00001"); err == nil {
+		t.Fatal("旧车牌号索引应被删除")
+	}
+	var records []*CarChangeRecord
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		records, err = testContract.QueryCarChangeLog(ctx, carID)
+		return err
+	}))
+	if len(records) != 1 || len(records[0].Changes) != 2 || records[0].OperatorMSPID != REGULATOR_ORG_MSPID {
+		t.Fatalf("属性变更记录不正确：%+v", records)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+)
+
+// 外部链码服务（Chaincode as a Service）模式的环境变量
+// 设置了 CHAINCODE_SERVER_ADDRESS 时链码不再由节点启动，而是作为 gRPC 服务监听该地址，由节点主动连接
+const (
+	envServerAddress = "CHAINCODE_SERVER_ADDRESS" // 监听地址，例如 0.0.0.0:9999
+	envChaincodeID   = "CHAINCODE_ID"             // 链码包ID（peer lifecycle chaincode calculatepackageid 的输出）
+	envTLSCert       = "CHAINCODE_TLS_CERT"       // 服务端 TLS 证书文件（PEM），与私钥同时设置时启用 TLS
+	envTLSKey        = "CHAINCODE_TLS_KEY"        // 服务端 TLS 私钥文件（PEM）
+	envClientCACert  = "CHAINCODE_CLIENT_CA_CERT" // 可选，校验节点客户端证书的 CA 证书文件（PEM），设置后要求双向 TLS
+)
+
+// newChaincodeServerFromEnv 按环境变量创建外部链码服务，未设置 CHAINCODE_SERVER_ADDRESS 时返回 nil（由节点启动链码）
+func newChaincodeServerFromEnv(cc shim.Chaincode) (*shim.ChaincodeServer, error) {
+	address := os.Getenv(envServerAddress)
+	if address == "" {
+		return nil, nil
+	}
+	ccid := os.Getenv(envChaincodeID)
+	if ccid == "" {
+		return nil, fmt.Errorf("设置了 %s 时必须同时设置 %s", envServerAddress, envChaincodeID)
+	}
+
+	tlsProps, err := chaincodeServerTLSFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &shim.ChaincodeServer{
+		CCID:     ccid,
+		Address:  address,
+		CC:       cc,
+		TLSProps: tlsProps,
+	}, nil
+}
+
+// chaincodeServerTLSFromEnv 读取外部链码服务的 TLS 配置，未设置证书和私钥时不启用 TLS
+func chaincodeServerTLSFromEnv() (shim.TLSProperties, error) {
+	certFile := os.Getenv(envTLSCert)
+	keyFile := os.Getenv(envTLSKey)
+	clientCAFile := os.Getenv(envClientCACert)
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return shim.TLSProperties{}, fmt.Errorf("设置 %s 时必须同时设置 %s 和 %s", envClientCACert, envTLSCert, envTLSKey)
+		}
+		return shim.TLSProperties{Disabled: true}, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return shim.TLSProperties{}, fmt.Errorf("%s 和 %s 必须同时设置", envTLSCert, envTLSKey)
+	}
+
+	cert, err := os.ReadFile(certFile)
+	if err != nil {
+		return shim.TLSProperties{}, fmt.Errorf("读取 TLS 证书失败：%v", err)
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return shim.TLSProperties{}, fmt.Errorf("读取 TLS 私钥失败：%v", err)
+	}
+	tlsProps := shim.TLSProperties{Cert: cert, Key: key}
+	if clientCAFile != "" {
+		tlsProps.ClientCACerts, err = os.ReadFile(clientCAFile)
+		if err != nil {
+			return shim.TLSProperties{}, fmt.Errorf("读取客户端 CA 证书失败：%v", err)
+		}
+	}
+	return tlsProps, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewChaincodeServerFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		requireNoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	certFile := writeFile("server.crt", "CERT")
+	keyFile := writeFile("server.key", "KEY")
+	caFile := writeFile("ca.crt", "CA")
+	missingFile := filepath.Join(dir, "missing.crt")
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		server   bool   // 是否以外部链码服务模式运行
+		tls      bool   // 是否启用 TLS
+		clientCA string // 校验节点客户端证书的 CA 证书内容
+		err      string
+	}{
+		{name: "未设置监听地址时由节点启动", env: map[string]string{envChaincodeID: "mycc:1"}},
+		{name: "缺少链码包ID", env: map[string]string{envServerAddress: "0.0.0.0:9999"}, err: "设置了 CHAINCODE_SERVER_ADDRESS 时必须同时设置 CHAINCODE_ID"},
+		{name: "不启用 TLS", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1"}, server: true},
+		{name: "单向 TLS", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1", envTLSCert: certFile, envTLSKey: keyFile}, server: true, tls: true},
+		{name: "双向 TLS", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1", envTLSCert: certFile, envTLSKey: keyFile, envClientCACert: caFile}, server: true, tls: true, clientCA: "CA"},
+		{name: "只设置证书", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1", envTLSCert: certFile}, err: "CHAINCODE_TLS_CERT 和 CHAINCODE_TLS_KEY 必须同时设置"},
+		{name: "只设置客户端 CA", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1", envClientCACert: caFile}, err: "设置 CHAINCODE_CLIENT_CA_CERT 时必须同时设置"},
+		{name: "证书文件不存在", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1", envTLSCert: missingFile, envTLSKey: keyFile}, err: "读取 TLS 证书失败"},
+		{name: "客户端 CA 文件不存在", env: map[string]string{envServerAddress: "0.0.0.0:9999", envChaincodeID: "mycc:1", envTLSCert: certFile, envTLSKey: keyFile, envClientCACert: missingFile}, err: "读取客户端 CA 证书失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{envServerAddress, envChaincodeID, envTLSCert, envTLSKey, envClientCACert} {
+				t.Setenv(name, tt.env[name])
+			}
+			server, err := newChaincodeServerFromEnv(&routedChaincode{})
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			requireNoError(t, err)
+			if !tt.server {
+				if server != nil {
+					t.Fatalf("未设置监听地址时不应创建外部链码服务")
+				}
+				return
+			}
+			if server == nil || server.CCID != "mycc:1" || server.Address != "0.0.0.0:9999" {
+				t.Fatalf("外部链码服务配置不正确：%+v", server)
+			}
+			if server.TLSProps.Disabled == tt.tls {
+				t.Fatalf("TLS 启用状态为 %v，应为 %v", !server.TLSProps.Disabled, tt.tls)
+			}
+			if tt.tls && (string(server.TLSProps.Cert) != "CERT" || string(server.TLSProps.Key) != "KEY") {
+				t.Fatalf("TLS 证书和私钥读取不正确")
+			}
+			if string(server.TLSProps.ClientCACerts) != tt.clientCA {
+				t.Fatalf("客户端 CA 证书为 %q，应为 %q", server.TLSProps.ClientCACerts, tt.clientCA)
+			}
+		})
+	}
+}
@@ -20,17 +20,41 @@ type SmartContract struct {
 
 // 文档类型常量（用于创建复合键）
 const (
-	CAR         = "CAR"  // 汽车信息 (修改常量)
-	TRANSACTION = "TX"   // 交易信息
-	CERTIFICATE = "CERT" // 证书信息 (新增)
+	CAR             = "CAR"                // 汽车信息 (修改常量)
+	TRANSACTION     = "TX"                 // 交易信息
+	CERTIFICATE     = "CERT"               // 证书信息 (新增)
+	STOLEN_FLAG     = "STOLEN"             // 被盗标记记录 (只追加，不删除)
+	PARTY           = "PARTY"              // 参与方信息
+	PARTY_NID_INDEX = "PARTY_NID"          // 证件号哈希到参与方ID的索引
+	OWNERSHIP       = "OWNER"              // 所有权变更记录 (只追加，不删除)
+	CAR_CHANGE      = "CAR_CHANGE"         // 汽车属性变更记录 (只追加，不删除)
+	PLATE_INDEX     = "PLATE"              // 车牌号到汽车ID的索引
+	APPRAISER       = "APPRAISER"          // 评估机构信息
+	APPRAISER_INDEX = "APPRAISER_IDENTITY" // 客户端身份到评估机构ID的索引
+	APPRAISAL       = "APPRAISAL"          // 评估报告记录
+	CONFIG          = "CONFIG"             // 链上业务配置
+	POLICY          = "POLICY"             // 保单信息
+	POLICY_NO_INDEX = "POLICY_NO"          // 保单号到汽车ID的索引
+	CLAIM           = "CLAIM"              // 理赔记录
+	ISSUER          = "ISSUER"             // 受信任的证件签发机构
+	RESERVATION     = "RESERVATION"        // 预订记录
+	REVIEW          = "REVIEW"             // 交易评价
+	PARTY_IDENTITY  = "PARTY_IDENTITY"     // 客户端身份到参与方ID的索引
+	NFT_APPROVAL    = "NFT_APPROVAL"       // 单车转移授权
+	NFT_OPERATOR    = "NFT_OPERATOR"       // 操作员授权（可转移所有者名下全部汽车）
+	NFT_DOCUMENT    = "NFT_TRANSFER_DOC"   // 通证转移的过户类型和证明文件登记
+	LOAN            = "LOAN"               // 购车贷款
+	LOAN_CAR_INDEX  = "LOAN_CAR"           // 汽车到未结清贷款的索引
+	AUDITOR         = "AUDITOR"            // 审计人员身份（只读）
 )
 
-// CertificateStatus 证书状态 (新增, MVP 暂未使用)
-// type CertificateStatus string
-// const (
-// 	CERT_ACTIVE  CertificateStatus = "ACTIVE"
-// 	CERT_REVOKED CertificateStatus = "REVOKED"
-// )
+// CertificateStatus 证书状态
+type CertificateStatus string
+
+const (
+	CERT_ACTIVE  CertificateStatus = "ACTIVE"  // 有效
+	CERT_REVOKED CertificateStatus = "REVOKED" // 已吊销
+)
 
 // CarStatus 汽车状态 (修改类型名)
 type CarStatus string
@@ -38,6 +62,7 @@ type CarStatus string
 const (
 	AVAILABLE      CarStatus = "AVAILABLE"      // 待售 (修改状态)
 	IN_TRANSACTION CarStatus = "IN_TRANSACTION" // 交易中
+	RESERVED       CarStatus = "RESERVED"       // 已预订（买家支付定金后保留）
 	SOLD           CarStatus = "SOLD"           // 已售 (新增状态)
 )
 
@@ -50,27 +75,72 @@ const (
 	// 可以考虑添加 CANCELLED 状态，但当前逻辑未包含
 )
 
+// TransactionType 交易类型
+type TransactionType string
+
+const (
+	TX_TYPE_SALE     TransactionType = "SALE"     // 普通买卖
+	TX_TYPE_TRADE_IN TransactionType = "TRADE_IN" // 置换：买家以旧车抵扣部分车款
+	TX_TYPE_BUNDLE   TransactionType = "BUNDLE"   // 打包：一次购买多辆汽车
+)
+
+// BundleItem 打包交易中的单辆汽车及其成交价
+type BundleItem struct {
+	CarID string  `json:"carId"`                                // 汽车ID
+	Price float64 `json:"price"`                                // 单车成交价
+	Model string  `json:"model,omitempty" metadata:",optional"` // 生成交易时汽车的车型（由链码填写）
+}
+
 // Car 汽车信息 (修改结构体名和字段)
 type Car struct {
-	ID           string    `json:"id"`           // 汽车ID (例如车牌号)
+	ID           string    `json:"id"`           // 汽车内部ID（链码生成的 UUID，创建后不可变更；旧数据为创建时的车牌号）
+	Plate        string    `json:"plate"`        // 车牌号（可通过属性变更修改）
 	Model        string    `json:"model"`        // 车型
+	Color        string    `json:"color"`        // 车身颜色
 	VIN          string    `json:"vin"`          // 车辆识别代号
-	CurrentOwner string    `json:"currentOwner"` // 当前所有者
+	CurrentOwner string    `json:"currentOwner"` // 当前所有者（参与方ID）
 	Status       CarStatus `json:"status"`       // 状态
 	CreateTime   time.Time `json:"createTime"`   // 创建时间
 	UpdateTime   time.Time `json:"updateTime"`   // 更新时间
+
+	Stolen        bool   `json:"stolen"`                                       // 是否被监管机构标记为被盗
+	StolenCaseRef string `json:"stolenCaseRef,omitempty" metadata:",optional"` // 被盗案件编号
+	Alert         string `json:"alert,omitempty" metadata:",optional"`         // 警示信息（仅查询时填充，不落账本）
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
 }
 
 // Transaction 交易信息 (修改字段)
 type Transaction struct {
 	ID         string            `json:"id"`         // 交易ID
+	Type       TransactionType   `json:"type"`       // 交易类型
 	CarID      string            `json:"carId"`      // 汽车ID (修改字段名)
-	Seller     string            `json:"seller"`     // 卖家
-	Buyer      string            `json:"buyer"`      // 买家
+	Seller     string            `json:"seller"`     // 卖家（参与方ID）
+	Buyer      string            `json:"buyer"`      // 买家（参与方ID）
 	Price      float64           `json:"price"`      // 成交价格
 	Status     TransactionStatus `json:"status"`     // 状态
 	CreateTime time.Time         `json:"createTime"` // 创建时间
 	UpdateTime time.Time         `json:"updateTime"` // 更新时间
+
+	CarModel string `json:"carModel,omitempty" metadata:",optional"` // 生成交易时汽车的车型，用于成交统计（打包交易见 items）
+
+	TradeInCarID string  `json:"tradeInCarId,omitempty" metadata:",optional"` // 置换交易中买家交回的旧车ID
+	TradeInValue float64 `json:"tradeInValue,omitempty" metadata:",optional"` // 旧车折价金额
+	NetAmount    float64 `json:"netAmount"`                                   // 买家实际应付金额（成交价减旧车折价，为负数时由卖家向买家补差价）
+
+	Items []*BundleItem `json:"items,omitempty" metadata:",optional"` // 打包交易包含的汽车及单车成交价（打包交易的 carId 为空，price 为总价）
+
+	ReservationID string  `json:"reservationId,omitempty" metadata:",optional"` // 由预订转入的交易对应的预订ID
+	Deposit       float64 `json:"deposit,omitempty" metadata:",optional"`       // 预订定金，已从应付金额中抵扣
+
+	PriceWarning string `json:"priceWarning,omitempty" metadata:",optional"` // 成交价偏离评估价的警告（仅警告模式下记录）
+
+	Fees *FeeBreakdown `json:"fees,omitempty" metadata:",optional"` // 生成交易时按费用表计算的费用明细（之后不再变更）
+
+	TokenPayment     *TokenPayment   `json:"tokenPayment,omitempty" metadata:",optional"`     // 链上代币结算的车款划转（链下付款时为空）
+	TokenFeePayments []*TokenPayment `json:"tokenFeePayments,omitempty" metadata:",optional"` // 链上代币结算的费用划转（链下付款时为空）
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
 }
 
 // Certificate 证书信息 (新增 MVP 结构)
@@ -81,6 +151,21 @@ type Certificate struct {
 	FileHash     string    `json:"fileHash"`     // 文件SHA256哈希
 	FileLocation string    `json:"fileLocation"` // 本地文件路径
 	UploadTime   time.Time `json:"uploadTime"`   // 上传时间
+	ValidFrom    time.Time `json:"validFrom"`    // 生效时间（未填写时为上传时间）
+	ValidUntil   time.Time `json:"validUntil"`   // 失效时间（零值表示长期有效）
+
+	Status       CertificateStatus `json:"status"`                                      // 证书状态
+	RevokeReason string            `json:"revokeReason,omitempty" metadata:",optional"` // 吊销原因
+
+	IssuerID   string `json:"issuerId,omitempty" metadata:",optional"`   // 签发机构ID（可选，提供签名时必填）
+	IssuerName string `json:"issuerName,omitempty" metadata:",optional"` // 签发机构名称
+	Signature  string `json:"signature,omitempty" metadata:",optional"`  // 签发机构对文件哈希的分离签名（Base64 编码的 ECDSA 签名）
+
+	Expired        bool   `json:"expired,omitempty" metadata:",optional"`        // 是否已过期（仅查询时填充，不落账本）
+	SignatureValid bool   `json:"signatureValid,omitempty" metadata:",optional"` // 签名是否有效（仅查询时填充，不落账本）
+	SignatureError string `json:"signatureError,omitempty" metadata:",optional"` // 签名无效的原因（仅查询时填充，不落账本）
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
 }
 
 // QueryResult 分页查询结果
@@ -96,6 +181,9 @@ const (
 	CAR_DEALER_ORG_MSPID = "Org1MSP" // 汽车经销商组织 MSP ID
 	BANK_ORG_MSPID       = "Org2MSP" // 银行组织 MSP ID
 	TRADE_ORG_MSPID      = "Org3MSP" // 交易平台组织 MSP ID
+	REGULATOR_ORG_MSPID  = "Org4MSP" // 监管机构（公安）组织 MSP ID
+	INSURER_ORG_MSPID    = "Org5MSP" // 保险公司组织 MSP ID
+	APPRAISER_ORG_MSPID  = "Org6MSP" // 第三方评估机构组织 MSP ID
 )
 
 // 通用方法: 获取客户端身份信息
@@ -107,6 +195,42 @@ func (s *SmartContract) getClientIdentityMSPID(ctx contractapi.TransactionContex
 	return clientID.GetMSPID()
 }
 
+// 通用方法: 获取客户端身份唯一标识
+func (s *SmartContract) getClientIdentityID(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientID, err := cid.New(ctx.GetStub())
+	if err != nil {
+		return "", fmt.Errorf("获取客户端身份信息失败：%v", err)
+	}
+	return clientID.GetID()
+}
+
+// 通用方法: 判断客户端是否为组织管理员（依据证书 OU，需启用 NodeOUs）
+func (s *SmartContract) isClientAdmin(ctx contractapi.TransactionContextInterface) (bool, error) {
+	clientID, err := cid.New(ctx.GetStub())
+	if err != nil {
+		return false, fmt.Errorf("获取客户端身份信息失败：%v", err)
+	}
+	cert, err := clientID.GetX509Certificate()
+	if err != nil {
+		return false, fmt.Errorf("获取客户端证书失败：%v", err)
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == "admin" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 通用方法：获取交易时间戳（由客户端签名提案时确定，各背书节点一致），所有记录时间均以此为准
+func (s *SmartContract) getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("获取交易时间戳失败：%v", err)
+	}
+	return timestamp.AsTime().UTC(), nil
+}
+
 // 通用方法：创建和获取复合键
 func (s *SmartContract) getCompositeKey(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) (string, error) {
 	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
@@ -126,16 +250,12 @@ func (s *SmartContract) getState(ctx contractapi.TransactionContextInterface, ke
 		return fmt.Errorf("键 %s 不存在", key)
 	}
 
-	err = json.Unmarshal(bytes, value)
-	if err != nil {
-		return fmt.Errorf("解析数据失败：%v", err)
-	}
-	return nil
+	return s.unmarshalState(ctx, key, bytes, value)
 }
 
-// 通用方法：保存状态
+// 通用方法：保存状态（写入时统一标记为当前结构版本）
 func (s *SmartContract) putState(ctx contractapi.TransactionContextInterface, key string, value interface{}) error {
-	bytes, err := json.Marshal(value)
+	bytes, err := json.Marshal(withSchemaVersion(value))
 	if err != nil {
 		return fmt.Errorf("序列化数据失败：%v", err)
 	}
@@ -147,22 +267,36 @@ func (s *SmartContract) putState(ctx contractapi.TransactionContextInterface, ke
 	return nil
 }
 
-// CreateCar 创建汽车信息（仅汽车经销商组织可以调用）(修改函数名和逻辑)
-func (s *SmartContract) CreateCar(ctx contractapi.TransactionContextInterface, id string, model string, vin string, owner string, createTime time.Time) error {
-	// 检查调用者身份
-	clientMSPID, err := s.getClientIdentityMSPID(ctx)
-	if err != nil {
-		return fmt.Errorf("获取调用者身份失败：%v", err)
-	}
+// 通用方法：遍历所有状态查找汽车，返回汽车信息及其当前的复合键
+func (s *SmartContract) getCar(ctx contractapi.TransactionContextInterface, id string) (*Car, string, error) {
+	for _, status := range []CarStatus{AVAILABLE, RESERVED, IN_TRANSACTION, SOLD} {
+		key, err := s.getCompositeKey(ctx, CAR, []string{string(status), id})
+		if err != nil {
+			return nil, "", err
+		}
 
-	// 验证是否是汽车经销商组织的成员 (修改常量)
-	if clientMSPID != CAR_DEALER_ORG_MSPID {
-		return fmt.Errorf("只有汽车经销商组织成员才能创建汽车信息") // 修改错误信息
+		bytes, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, "", fmt.Errorf("查询汽车信息失败：%v", err)
+		}
+		if bytes != nil {
+			var car Car
+			err = s.unmarshalState(ctx, key, bytes, &car)
+			if err != nil {
+				return nil, "", fmt.Errorf("解析汽车信息失败：%v", err)
+			}
+			return &car, key, nil
+		}
 	}
 
+	return nil, "", fmt.Errorf("汽车ID %s 不存在", id)
+}
+
+// validateNewCar 校验新建汽车的参数，并检查车牌号是否已被占用
+func (s *SmartContract) validateNewCar(ctx contractapi.TransactionContextInterface, plate string, model string, vin string, owner string) error {
 	// 参数验证 (修改验证字段)
-	if len(id) == 0 {
-		return fmt.Errorf("汽车ID不能为空")
+	if len(plate) == 0 {
+		return fmt.Errorf("车牌号不能为空")
 	}
 	if len(model) == 0 {
 		return fmt.Errorf("车型不能为空")
@@ -173,26 +307,31 @@ func (s *SmartContract) CreateCar(ctx contractapi.TransactionContextInterface, i
 	if len(owner) == 0 {
 		return fmt.Errorf("所有者不能为空")
 	}
+	if _, err := s.requireParty(ctx, owner); err != nil {
+		return fmt.Errorf("所有者%v", err)
+	}
 
-	// 检查汽车是否已存在（检查所有可能的状态）(修改常量和状态)
-	for _, status := range []CarStatus{AVAILABLE, IN_TRANSACTION, SOLD} {
-		key, err := s.getCompositeKey(ctx, CAR, []string{string(status), id}) // 修改常量
-		if err != nil {
-			return fmt.Errorf("创建复合键失败：%v", err)
-		}
+	return s.checkPlateAvailable(ctx, plate)
+}
 
-		exists, err := ctx.GetStub().GetState(key)
-		if err != nil {
-			return fmt.Errorf("查询汽车信息失败：%v", err) // 修改错误信息
-		}
-		if exists != nil {
-			return fmt.Errorf("汽车ID %s 已存在", id) // 修改错误信息
-		}
+// CreateCar 创建汽车信息（仅汽车经销商组织可以调用），返回链码生成的汽车ID (修改函数名和逻辑)
+func (s *SmartContract) CreateCar(ctx contractapi.TransactionContextInterface, plate string, model string, vin string, owner string) (string, error) {
+	err := s.validateNewCar(ctx, plate, model, vin, owner)
+	if err != nil {
+		return "", err
 	}
 
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	id := s.newCarID(ctx, plate)
+
 	// 创建汽车信息 (修改结构体和字段)
 	car := Car{
 		ID:           id,
+		Plate:        plate,
 		Model:        model,
 		VIN:          vin,
 		CurrentOwner: owner,
@@ -204,43 +343,184 @@ func (s *SmartContract) CreateCar(ctx contractapi.TransactionContextInterface, i
 	// 保存汽车信息（复合键：类型_状态_ID）(修改常量和状态)
 	key, err := s.getCompositeKey(ctx, CAR, []string{string(AVAILABLE), id})
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	err = s.putState(ctx, key, car)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	err = s.putPlateIndex(ctx, plate, id)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.appendOwnershipRecord(ctx, id, OWNERSHIP_REGISTRATION, "", owner, 0, "", createTime)
+	if err != nil {
+		return "", err
+	}
+
+	// 创建汽车即铸造通证
+	err = s.emitTransferEvent(ctx, &TransferEvent{From: "", To: owner, TokenID: id})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
 }
 
-// CreateTransaction 生成交易（仅交易平台组织可以调用）(修改逻辑)
-func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInterface, txID string, carID string, seller string, buyer string, price float64, createTime time.Time) error {
-	// 检查调用者身份
-	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+// 批量创建汽车的最大条数
+const MAX_CAR_BATCH_SIZE = 100
+
+// CarBatchItem 批量创建汽车的单条输入
+type CarBatchItem struct {
+	Plate string `json:"plate"` // 车牌号
+	Model string `json:"model"` // 车型
+	VIN   string `json:"vin"`   // 车辆识别代号
+	Owner string `json:"owner"` // 所有者
+	Color string `json:"color"` // 车身颜色（可选）
+}
+
+// CarBatchItemResult 批量创建汽车的单条结果
+type CarBatchItemResult struct {
+	Index   int    `json:"index"`                             // 在批次中的序号（从0开始）
+	Plate   string `json:"plate"`                             // 车牌号
+	ID      string `json:"id,omitempty" metadata:",optional"` // 链码生成的汽车ID（创建成功后返回）
+	Success bool   `json:"success"`                           // 是否校验/创建成功
+	Message string `json:"message"`                           // 结果说明
+}
+
+// validateCarsBatch 校验整个批次，包括批次内部的重复ID/VIN，返回每一条的校验结果
+func (s *SmartContract) validateCarsBatch(ctx contractapi.TransactionContextInterface, carsJson string) ([]CarBatchItem, []*CarBatchItemResult, bool, error) {
+	var items []CarBatchItem
+	err := json.Unmarshal([]byte(carsJson), &items)
 	if err != nil {
-		return fmt.Errorf("获取调用者身份失败：%v", err)
+		return nil, nil, false, fmt.Errorf("解析汽车批次 JSON 失败：%v", err)
+	}
+	if len(items) == 0 {
+		return nil, nil, false, fmt.Errorf("汽车批次不能为空")
+	}
+	if len(items) > MAX_CAR_BATCH_SIZE {
+		return nil, nil, false, fmt.Errorf("单个批次最多 %d 辆汽车，当前 %d 辆", MAX_CAR_BATCH_SIZE, len(items))
+	}
+
+	allValid := true
+	results := make([]*CarBatchItemResult, 0, len(items))
+	seenPlates := make(map[string]int)
+	seenVINs := make(map[string]int)
+	for i, item := range items {
+		result := &CarBatchItemResult{Index: i, Plate: item.Plate, Success: true, Message: "校验通过"}
+
+		if first, ok := seenPlates[item.Plate]; ok && len(item.Plate) > 0 {
+			result.Success = false
+			result.Message = fmt.Sprintf("车牌号 %s 与批次中第 %d 条重复", item.Plate, first)
+		} else if first, ok := seenVINs[item.VIN]; ok && len(item.VIN) > 0 {
+			result.Success = false
+			result.Message = fmt.Sprintf("VIN %s 与批次中第 %d 条重复", item.VIN, first)
+		} else if err := s.validateNewCar(ctx, item.Plate, item.Model, item.VIN, item.Owner); err != nil {
+			result.Success = false
+			result.Message = err.Error()
+		}
+
+		if _, ok := seenPlates[item.Plate]; !ok {
+			seenPlates[item.Plate] = i
+		}
+		if _, ok := seenVINs[item.VIN]; !ok {
+			seenVINs[item.VIN] = i
+		}
+		if !result.Success {
+			allValid = false
+		}
+		results = append(results, result)
 	}
 
-	// 验证是否是交易平台组织的成员
-	if clientMSPID != TRADE_ORG_MSPID {
-		return fmt.Errorf("只有交易平台组织成员才能生成交易")
+	return items, results, allValid, nil
+}
+
+// ValidateCarsBatch 预校验汽车批次，返回每一条的校验结果，不写入账本
+func (s *SmartContract) ValidateCarsBatch(ctx contractapi.TransactionContextInterface, carsJson string) ([]*CarBatchItemResult, error) {
+	_, results, _, err := s.validateCarsBatch(ctx, carsJson)
+	if err != nil {
+		return nil, err
 	}
+	return results, nil
+}
 
-	// 参数验证 (修改字段名)
-	if len(txID) == 0 {
-		return fmt.Errorf("交易ID不能为空")
+// CreateCarsBatch 批量创建汽车信息（仅汽车经销商组织可以调用），全部成功或全部失败
+func (s *SmartContract) CreateCarsBatch(ctx contractapi.TransactionContextInterface, carsJson string) ([]*CarBatchItemResult, error) {
+	items, results, allValid, err := s.validateCarsBatch(ctx, carsJson)
+	if err != nil {
+		return nil, err
 	}
-	if len(carID) == 0 {
-		return fmt.Errorf("汽车ID不能为空") // 修改错误信息
+
+	// 任意一条校验失败则整个批次不写入
+	if !allValid {
+		var failures []string
+		for _, result := range results {
+			if !result.Success {
+				failures = append(failures, fmt.Sprintf("第 %d 条(%s)：%s", result.Index, result.Plate, result.Message))
+			}
+		}
+		return nil, fmt.Errorf("汽车批次校验失败，未写入任何记录：%s", strings.Join(failures, "；"))
 	}
-	if len(seller) == 0 {
-		return fmt.Errorf("卖家不能为空")
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mints := make([]*TransferEvent, 0, len(items))
+	for i, item := range items {
+		id := s.newCarID(ctx, item.Plate)
+		car := Car{
+			ID:           id,
+			Plate:        item.Plate,
+			Model:        item.Model,
+			Color:        item.Color,
+			VIN:          item.VIN,
+			CurrentOwner: item.Owner,
+			Status:       AVAILABLE,
+			CreateTime:   createTime,
+			UpdateTime:   createTime,
+		}
+
+		key, err := s.getCompositeKey(ctx, CAR, []string{string(AVAILABLE), id})
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.putState(ctx, key, car)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.putPlateIndex(ctx, item.Plate, id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.appendOwnershipRecord(ctx, id, OWNERSHIP_REGISTRATION, "", item.Owner, 0, "", createTime)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].ID = id
+		results[i].Message = "创建成功"
+		mints = append(mints, &TransferEvent{From: "", To: item.Owner, TokenID: id})
 	}
-	if len(buyer) == 0 {
-		return fmt.Errorf("买家不能为空")
+
+	err = s.emitTransferEvent(ctx, mints...)
+	if err != nil {
+		return nil, err
 	}
+
+	return results, nil
+}
+
+// CreateTransaction 生成交易（仅交易平台组织可以调用）(修改逻辑)
+func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInterface, txID string, carID string, seller string, buyer string, price float64) error {
+	// 参数验证 (修改字段名)
 	if seller == buyer {
 		return fmt.Errorf("买家和卖家不能是同一人")
 	}
@@ -248,99 +528,203 @@ func (s *SmartContract) CreateTransaction(ctx contractapi.TransactionContextInte
 		return fmt.Errorf("价格必须大于0")
 	}
 
-	// 查询汽车信息 (修改常量、状态和变量)
-	carKey, err := s.getCompositeKey(ctx, CAR, []string{string(AVAILABLE), carID})
+	// 买卖双方必须是已通过 KYC 认证的参与方
+	if _, err := s.requireVerifiedParty(ctx, seller, "卖家"); err != nil {
+		return err
+	}
+	if _, err := s.requireVerifiedParty(ctx, buyer, "买家"); err != nil {
+		return err
+	}
+
+	if err := s.checkTransactionIDAvailable(ctx, txID); err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
 	if err != nil {
 		return err
 	}
 
-	var car Car
-	err = s.getState(ctx, carKey, &car)
+	// 按当前费用表计算费用明细，须在锁定汽车之前读取汽车信息
+	fees, err := s.computeFeeBreakdown(ctx, []*BundleItem{{CarID: carID, Price: price}}, price)
 	if err != nil {
-		// 如果在 AVAILABLE 状态找不到，尝试在 IN_TRANSACTION 状态找 (防止重复创建交易)
-		inTransactionKey, keyErr := s.getCompositeKey(ctx, CAR, []string{string(IN_TRANSACTION), carID})
-		if keyErr == nil {
-			existsBytes, getErr := ctx.GetStub().GetState(inTransactionKey)
-			if getErr == nil && existsBytes != nil {
-				return fmt.Errorf("汽车 %s 正在交易中，无法创建新交易", carID)
-			}
-		}
-		// 如果在 SOLD 状态找到
-		soldKey, keyErr := s.getCompositeKey(ctx, CAR, []string{string(SOLD), carID})
-		if keyErr == nil {
-			existsBytes, getErr := ctx.GetStub().GetState(soldKey)
-			if getErr == nil && existsBytes != nil {
-				return fmt.Errorf("汽车 %s 已售出，无法创建新交易", carID)
-			}
-		}
-		return fmt.Errorf("查询汽车信息失败或汽车非待售状态：%v", err) // 修改错误信息
+		return err
 	}
 
-	// 检查卖家是否是汽车所有者 (修改变量)
-	if car.CurrentOwner != seller {
-		return fmt.Errorf("卖家不是汽车所有者") // 修改错误信息
+	// 校验汽车可以出售并锁定为交易中状态，同时按评估价检查策略校验成交价
+	car, priceWarning, err := s.lockCarForSale(ctx, carID, seller, price, []CarStatus{AVAILABLE}, createTime)
+	if err != nil {
+		return err
 	}
 
 	// 生成交易信息 (修改字段名)
 	transaction := Transaction{
 		ID:         txID,
+		Type:       TX_TYPE_SALE,
 		CarID:      carID,
+		CarModel:   car.Model,
 		Seller:     seller,
 		Buyer:      buyer,
 		Price:      price,
+		NetAmount:  price,
 		Status:     PENDING,
 		CreateTime: createTime,
 		UpdateTime: createTime,
-	}
 
-	// 更新汽车状态 (修改变量和状态)
-	car.Status = IN_TRANSACTION
-	car.UpdateTime = createTime
+		Fees: fees,
+
+		PriceWarning: priceWarning,
+	}
 
-	// 保存状态 (修改常量和变量)
 	txKey, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(PENDING), txID})
 	if err != nil {
 		return err
 	}
+	return s.putState(ctx, txKey, transaction)
+}
+
+// soldItems 返回交易中卖给买家的汽车及各自的成交价（不含置换交回的旧车）
+func (t *Transaction) soldItems() []*BundleItem {
+	if t.Type == TX_TYPE_BUNDLE {
+		return t.Items
+	}
+	return []*BundleItem{{CarID: t.CarID, Price: t.Price, Model: t.CarModel}}
+}
+
+// checkTransactionIDAvailable 检查交易ID未被使用
+func (s *SmartContract) checkTransactionIDAvailable(ctx contractapi.TransactionContextInterface, txID string) error {
+	if _, err := s.QueryTransaction(ctx, txID); err == nil {
+		return fmt.Errorf("交易ID %s 已存在", txID)
+	}
+	return nil
+}
+
+// lockCarForSale 校验汽车可以由 seller 出售，并将其锁定为交易中状态，返回锁定后的汽车信息和成交价偏离评估价的警告
+// allowedStatuses 为允许参与交易的汽车状态：普通交易仅允许待售汽车，置换中买家交回的旧车还允许已售出（由个人持有），
+// 由预订转入的交易仅允许已预订的汽车
+func (s *SmartContract) lockCarForSale(ctx contractapi.TransactionContextInterface, carID string, seller string, price float64, allowedStatuses []CarStatus, updateTime time.Time) (*Car, string, error) {
+	car, carKey, err := s.getCar(ctx, carID)
+	if err != nil {
+		return nil, "", fmt.Errorf("查询汽车信息失败或汽车非待售状态：%v", err)
+	}
+	allowed := false
+	for _, status := range allowedStatuses {
+		if car.Status == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		switch car.Status {
+		case IN_TRANSACTION:
+			return nil, "", fmt.Errorf("汽车 %s 正在交易中，无法创建新交易", carID)
+		case RESERVED:
+			return nil, "", fmt.Errorf("汽车 %s 已被预订，只能由该预订转为交易", carID)
+		case SOLD:
+			return nil, "", fmt.Errorf("汽车 %s 已售出，无法创建新交易", carID)
+		default:
+			return nil, "", fmt.Errorf("汽车 %s 当前状态为 %s，无法创建交易", carID, car.Status)
+		}
+	}
+
+	// 被盗车辆禁止交易
+	if car.Stolen {
+		return nil, "", fmt.Errorf("汽车 %s 已被标记为被盗车辆（案件编号：%s），无法创建交易", carID, car.StolenCaseRef)
+	}
+
+	// 出售方必须是汽车所有者
+	if car.CurrentOwner != seller {
+		return nil, "", fmt.Errorf("%s 不是汽车 %s 的所有者", seller, carID)
+	}
+
+	// 贷款未结清的汽车不能出售
+	if err := s.checkNoActiveLoan(ctx, carID); err != nil {
+		return nil, "", err
+	}
+
+	// 销售所需证件必须齐全、有效且未过期
+	readiness, err := s.checkSaleReadiness(ctx, carID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !readiness.Ready {
+		return nil, "", fmt.Errorf("汽车 %s 的证件不满足销售要求：%s", carID, formatMissingDocuments(readiness.Missing))
+	}
+
+	// 按评估价检查策略校验成交价
+	priceWarning, err := s.checkPriceAgainstAppraisal(ctx, carID, price)
+	if err != nil {
+		return nil, "", err
+	}
 
-	// 删除旧的汽车记录 (修改变量)
+	// 状态是复合键的一部分，需要删除旧记录后以新状态写入
 	err = ctx.GetStub().DelState(carKey)
 	if err != nil {
-		return fmt.Errorf("删除旧的汽车记录失败：%v", err) // 修改错误信息
+		return nil, "", fmt.Errorf("删除旧的汽车记录失败：%v", err)
 	}
+	car.Status = IN_TRANSACTION
+	car.UpdateTime = updateTime
 
-	// 创建新的汽车记录（使用新状态）(修改常量、状态和变量)
 	newCarKey, err := s.getCompositeKey(ctx, CAR, []string{string(IN_TRANSACTION), carID})
 	if err != nil {
-		return err
+		return nil, "", err
+	}
+	err = s.putState(ctx, newCarKey, car)
+	if err != nil {
+		return nil, "", err
 	}
 
-	err = s.putState(ctx, txKey, transaction)
+	return car, priceWarning, nil
+}
+
+// settleCarSale 完成交易中汽车的过户：变更所有者和状态，追加所有权变更记录并按规则处理保单
+func (s *SmartContract) settleCarSale(ctx contractapi.TransactionContextInterface, carID string, newOwner string, newStatus CarStatus, changeType OwnershipChangeType, price float64, txID string, updateTime time.Time) error {
+	carKey, err := s.getCompositeKey(ctx, CAR, []string{string(IN_TRANSACTION), carID})
 	if err != nil {
 		return err
 	}
 
-	err = s.putState(ctx, newCarKey, car)
+	var car Car
+	err = s.getState(ctx, carKey, &car)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	// 被盗车辆禁止完成交易
+	if car.Stolen {
+		return fmt.Errorf("汽车 %s 已被标记为被盗车辆（案件编号：%s），无法完成交易", car.ID, car.StolenCaseRef)
+	}
 
-// CompleteTransaction 完成交易（仅银行组织可以调用）(修改逻辑)
-func (s *SmartContract) CompleteTransaction(ctx contractapi.TransactionContextInterface, txID string, updateTime time.Time) error {
-	// 检查调用者身份
-	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	previousOwner := car.CurrentOwner
+	car.CurrentOwner = newOwner
+	car.Status = newStatus
+	car.UpdateTime = updateTime
+
+	err = ctx.GetStub().DelState(carKey)
 	if err != nil {
-		return fmt.Errorf("获取调用者身份失败：%v", err)
+		return fmt.Errorf("删除旧的汽车记录失败：%v", err)
 	}
 
-	// 验证是否是银行组织的成员
-	if clientMSPID != BANK_ORG_MSPID {
-		return fmt.Errorf("只有银行组织成员才能完成交易")
+	newCarKey, err := s.getCompositeKey(ctx, CAR, []string{string(newStatus), carID})
+	if err != nil {
+		return err
+	}
+	err = s.putState(ctx, newCarKey, car)
+	if err != nil {
+		return err
 	}
 
+	err = s.appendOwnershipRecord(ctx, carID, changeType, previousOwner, newOwner, price, txID, updateTime)
+	if err != nil {
+		return err
+	}
+
+	// 按规则处理该车的有效保单
+	return s.applyPolicyOwnerChange(ctx, carID, newOwner, updateTime)
+}
+
+// CompleteTransaction 完成交易（仅银行组织可以调用）(修改逻辑)
+func (s *SmartContract) CompleteTransaction(ctx contractapi.TransactionContextInterface, txID string) error {
 	// 查询交易信息
 	txKey, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(PENDING), txID})
 	if err != nil {
@@ -353,23 +737,43 @@ func (s *SmartContract) CompleteTransaction(ctx contractapi.TransactionContextIn
 		return err
 	}
 
-	// 查询汽车信息 (修改常量、状态和变量)
-	carKey, err := s.getCompositeKey(ctx, CAR, []string{string(IN_TRANSACTION), transaction.CarID}) // 使用 CarID
+	updateTime, err := s.getTxTime(ctx)
 	if err != nil {
 		return err
 	}
 
-	var car Car
-	err = s.getState(ctx, carKey, &car)
+	// 买家取得所购汽车，交易完成后状态变为 SOLD；打包交易中的汽车一并过户，任一失败则整笔交易失败
+	transfers := make([]*TransferEvent, 0)
+	for _, item := range transaction.soldItems() {
+		err = s.settleCarSale(ctx, item.CarID, transaction.Buyer, SOLD, OWNERSHIP_SALE, item.Price, txID, updateTime)
+		if err != nil {
+			return err
+		}
+		transfers = append(transfers, &TransferEvent{From: transaction.Seller, To: transaction.Buyer, TokenID: item.CarID})
+	}
+
+	// 置换交易：卖家同时取得买家交回的旧车，旧车进入卖家的待售库存
+	if transaction.Type == TX_TYPE_TRADE_IN {
+		err = s.settleCarSale(ctx, transaction.TradeInCarID, transaction.Seller, AVAILABLE, OWNERSHIP_TRADE_IN, transaction.TradeInValue, txID, updateTime)
+		if err != nil {
+			return err
+		}
+		transfers = append(transfers, &TransferEvent{From: transaction.Buyer, To: transaction.Seller, TokenID: transaction.TradeInCarID})
+	}
+
+	// 配置了代币链码时车款和费用在同一笔交易中划转，余额不足等失败会使过户一并回滚
+	tokenPayment, tokenFeePayments, err := s.settleTokenPayment(ctx, &transaction)
 	if err != nil {
 		return err
 	}
 
-	// 更新状态 (修改变量和状态)
-	car.CurrentOwner = transaction.Buyer
-	car.Status = SOLD // 交易完成后状态变为 SOLD
-	car.UpdateTime = updateTime
+	err = s.emitTransferEvent(ctx, transfers...)
+	if err != nil {
+		return err
+	}
 
+	transaction.TokenPayment = tokenPayment
+	transaction.TokenFeePayments = tokenFeePayments
 	transaction.Status = COMPLETED
 	transaction.UpdateTime = updateTime
 
@@ -379,59 +783,38 @@ func (s *SmartContract) CompleteTransaction(ctx contractapi.TransactionContextIn
 		return fmt.Errorf("删除旧的交易记录失败：%v", err)
 	}
 
-	err = ctx.GetStub().DelState(carKey)
-	if err != nil {
-		return fmt.Errorf("删除旧的汽车记录失败：%v", err) // 修改错误信息
-	}
-
 	// 创建新记录 (修改常量、状态和变量)
 	newTxKey, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(COMPLETED), txID})
 	if err != nil {
 		return err
 	}
 
-	newCarKey, err := s.getCompositeKey(ctx, CAR, []string{string(SOLD), transaction.CarID}) // 新状态为 SOLD
-	if err != nil {
-		return err
-	}
-
-	err = s.putState(ctx, newTxKey, transaction)
-	if err != nil {
-		return err
-	}
-
-	err = s.putState(ctx, newCarKey, car)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return s.putState(ctx, newTxKey, transaction)
 }
 
-// QueryCar 查询汽车信息 (修改函数名和逻辑)
+// QueryCar 查询汽车信息，按汽车ID找不到时再按车牌号查找 (修改函数名和逻辑)
 func (s *SmartContract) QueryCar(ctx contractapi.TransactionContextInterface, id string) (*Car, error) {
-	// 遍历所有可能的状态查询汽车 (修改常量、状态和变量)
-	for _, status := range []CarStatus{AVAILABLE, IN_TRANSACTION, SOLD} {
-		key, err := s.getCompositeKey(ctx, CAR, []string{string(status), id})
-		if err != nil {
-			return nil, fmt.Errorf("创建复合键失败：%v", err)
+	car, _, err := s.getCar(ctx, id)
+	if err != nil {
+		carID, found, plateErr := s.getCarIDByPlate(ctx, id)
+		if plateErr != nil {
+			return nil, plateErr
 		}
-
-		bytes, err := ctx.GetStub().GetState(key)
-		if err != nil {
-			return nil, fmt.Errorf("查询汽车信息失败：%v", err) // 修改错误信息
+		if !found {
+			return nil, err
 		}
-		if bytes != nil {
-			var car Car
-			err = json.Unmarshal(bytes, &car)
-			if err != nil {
-				return nil, fmt.Errorf("解析汽车信息失败：%v", err) // 修改错误信息
-			}
-			return &car, nil
+		car, _, err = s.getCar(ctx, carID)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return nil, fmt.Errorf("汽车ID %s 不存在", id) // 修改错误信息
+	// 被盗车辆附加醒目警示
+	if car.Stolen {
+		car.Alert = fmt.Sprintf("警告：该车辆已被监管机构标记为被盗车辆（案件编号：%s），禁止交易！", car.StolenCaseRef)
+	}
+
+	return car, nil
 }
 
 // QueryTransaction 查询交易信息
@@ -449,7 +832,7 @@ func (s *SmartContract) QueryTransaction(ctx contractapi.TransactionContextInter
 		}
 		if bytes != nil {
 			var transaction Transaction
-			err = json.Unmarshal(bytes, &transaction)
+			err = s.unmarshalState(ctx, key, bytes, &transaction)
 			if err != nil {
 				return nil, fmt.Errorf("解析交易信息失败：%v", err)
 			}
@@ -469,7 +852,7 @@ func (s *SmartContract) QueryCarList(ctx contractapi.TransactionContextInterface
 	// 验证 status 是否是有效的 CarStatus
 	isValidStatus := false
 	if status != "" {
-		for _, validStatus := range []CarStatus{AVAILABLE, IN_TRANSACTION, SOLD} {
+		for _, validStatus := range []CarStatus{AVAILABLE, RESERVED, IN_TRANSACTION, SOLD} {
 			if CarStatus(status) == validStatus {
 				isValidStatus = true
 				break
@@ -511,7 +894,7 @@ func (s *SmartContract) QueryCarList(ctx contractapi.TransactionContextInterface
 		}
 
 		var car Car // 修改变量类型
-		err = json.Unmarshal(queryResponse.Value, &car)
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &car)
 		if err != nil {
 			return nil, fmt.Errorf("解析汽车信息失败：%v", err) // 修改错误信息
 		}
@@ -576,7 +959,7 @@ func (s *SmartContract) QueryTransactionList(ctx contractapi.TransactionContextI
 		}
 
 		var transaction Transaction
-		err = json.Unmarshal(queryResponse.Value, &transaction)
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &transaction)
 		if err != nil {
 			return nil, fmt.Errorf("解析交易信息失败：%v", err)
 		}
@@ -638,7 +1021,6 @@ func (s *SmartContract) AddCertificate(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("文件位置 (FileLocation) '%s' 在车辆ID '%s/' 之后不应包含额外的子目录路径，应直接是文件名", cert.FileLocation, cert.CarID)
 	}
 
-
 	// 检查证书是否已存在
 	certKey, err := s.getCompositeKey(ctx, CERTIFICATE, []string{cert.CertID})
 	if err != nil {
@@ -652,6 +1034,38 @@ func (s *SmartContract) AddCertificate(ctx contractapi.TransactionContextInterfa
 		return fmt.Errorf("证书ID %s 已存在", cert.CertID)
 	}
 
+	// 上传时间以交易时间戳为准，忽略客户端传入的值
+	cert.UploadTime, err = s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 有效期：生效时间默认为上传时间，失效时间为空表示长期有效
+	if cert.ValidFrom.IsZero() {
+		cert.ValidFrom = cert.UploadTime
+	}
+	if !cert.ValidUntil.IsZero() && !cert.ValidUntil.After(cert.ValidFrom) {
+		return fmt.Errorf("证书失效时间必须晚于生效时间")
+	}
+	cert.Expired = false
+	cert.Status = CERT_ACTIVE
+	cert.RevokeReason = ""
+
+	// 可选的签发机构签名：提供时必须通过受信任签发机构证书的验证
+	cert.IssuerName = ""
+	cert.SignatureValid = false
+	cert.SignatureError = ""
+	if cert.Signature != "" || cert.IssuerID != "" {
+		if cert.Signature == "" || cert.IssuerID == "" {
+			return fmt.Errorf("签发机构ID与签名必须同时提供")
+		}
+		issuer, err := s.verifyIssuerSignature(ctx, &cert, cert.UploadTime)
+		if err != nil {
+			return fmt.Errorf("签发机构签名验证失败：%v", err)
+		}
+		cert.IssuerName = issuer.Name
+	}
+
 	// 保存证书
 	err = s.putState(ctx, certKey, cert)
 	if err != nil {
@@ -678,7 +1092,7 @@ func (s *SmartContract) GetAllCertificates(ctx contractapi.TransactionContextInt
 		}
 
 		var cert Certificate
-		err = json.Unmarshal(queryResponse.Value, &cert)
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &cert)
 		if err != nil {
 			// 记录错误但继续处理其他记录
 			log.Printf("解析证书失败 (Key: %s): %v", queryResponse.Key, err)
@@ -687,6 +1101,15 @@ func (s *SmartContract) GetAllCertificates(ctx contractapi.TransactionContextInt
 		certificates = append(certificates, &cert)
 	}
 
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certificates {
+		cert.Expired = certExpired(cert, now)
+		s.fillSignatureStatus(ctx, cert)
+	}
+
 	return certificates, nil
 }
 
@@ -709,14 +1132,73 @@ func (s *SmartContract) GetCertificate(ctx contractapi.TransactionContextInterfa
 	}
 
 	var cert Certificate
-	err = json.Unmarshal(certJSON, &cert)
+	err = s.unmarshalState(ctx, certKey, certJSON, &cert)
 	if err != nil {
 		return nil, fmt.Errorf("解析证书JSON失败: %v", err)
 	}
 
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cert.Expired = certExpired(&cert, now)
+	s.fillSignatureStatus(ctx, &cert)
+
 	return &cert, nil
 }
 
+// QueryExpiringCertificates 分页查询 days 天内到期的证书（包含已过期的证书），便于经销商在挂牌前续办
+func (s *SmartContract) QueryExpiringCertificates(ctx contractapi.TransactionContextInterface, days int, pageSize int32, bookmark string) (*QueryResult, error) {
+	if days < 0 {
+		return nil, fmt.Errorf("天数不能为负数")
+	}
+
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := now.AddDate(0, 0, days)
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(CERTIFICATE, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("查询证书列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]interface{}, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var cert Certificate
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &cert)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败：%v", err)
+		}
+		if cert.ValidUntil.IsZero() || cert.ValidUntil.After(deadline) {
+			continue
+		}
+
+		cert.Expired = certExpired(&cert, now)
+		s.fillSignatureStatus(ctx, &cert)
+		records = append(records, cert)
+	}
+
+	return &QueryResult{
+		Records:             records,
+		RecordsCount:        int32(len(records)),
+		Bookmark:            metadata.Bookmark,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+	}, nil
+}
+
+// certExpired 判断证书在给定时间是否已过期（长期有效的证书永不过期）
+func certExpired(cert *Certificate, now time.Time) bool {
+	return !cert.ValidUntil.IsZero() && !now.Before(cert.ValidUntil)
+}
+
 // Hello 用于验证
 func (s *SmartContract) Hello(ctx contractapi.TransactionContextInterface) (string, error) {
 	return "hello", nil
@@ -745,12 +1227,27 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 }
 
 func main() {
-	chaincode, err := contractapi.NewChaincode(&SmartContract{})
+	// 按业务拆分为多个具名合约，权限、参数校验和调用日志由合约钩子统一处理
+	chaincode, err := newChaincode()
 	if err != nil {
 		log.Panicf("创建智能合约失败：%v", err)
 	}
 
-	if err := chaincode.Start(); err != nil {
+	// 设置了 CHAINCODE_SERVER_ADDRESS 时作为外部链码服务运行，否则由节点启动
+	// 记录时间统一取自交易时间戳，旧版客户端在末尾多传的时间参数由 contractapi 忽略
+	server, err := newChaincodeServerFromEnv(chaincode)
+	if err != nil {
+		log.Panicf("创建外部链码服务失败：%v", err)
+	}
+	if server != nil {
+		log.Printf("以外部链码服务模式启动，监听 %s（TLS：%v）", server.Address, !server.TLSProps.Disabled)
+		if err := server.Start(); err != nil {
+			log.Panicf("启动外部链码服务失败：%v", err)
+		}
+		return
+	}
+
+	if err := shim.Start(chaincode); err != nil {
 		log.Panicf("启动智能合约失败：%v", err)
 	}
 }
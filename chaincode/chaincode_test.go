@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// carsBatchJSON 生成批量创建汽车的 JSON 输入
+func carsBatchJSON(t *testing.T, items ...CarBatchItem) string {
+	t.Helper()
+	data, err := json.Marshal(items)
+	requireNoError(t, err)
+	return string(data)
+}
+
+func TestCreateCarsBatchAllOrNothing(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	createTestCar(t, l, "京A00001", "dealer")
+
+	valid := CarBatchItem{Plate: "京B00001", Model: "Model 3", VIN: "LSVAB000000000101", Owner: "dealer"}
+	tooMany := make([]CarBatchItem, MAX_CAR_BATCH_SIZE+1)
+
+	tests := []struct {
+		name  string
+		items []CarBatchItem
+		err   string
+	}{
+		{"空批次", []CarBatchItem{}, "汽车批次不能为空"},
+		{"超过批次上限", tooMany, fmt.Sprintf("最多 %d 辆", MAX_CAR_BATCH_SIZE)},
+		{"批次内车牌重复", []CarBatchItem{valid, {Plate: valid.Plate, Model: "X", VIN: "LSVAB000000000102", Owner: "dealer"}}, "与批次中第 0 条重复"},
+		{"批次内VIN重复", []CarBatchItem{valid, {Plate: "京B00002", Model: "X", VIN: valid.VIN, Owner: "dealer"}}, "与批次中第 0 条重复"},
+		{"车牌已被占用", []CarBatchItem{valid, {Plate: "京A00001", Model: "X", VIN: "LSVAB000000000103", Owner: "dealer"}}, "第 1 条(京A00001)"},
+		{"所有者未登记", []CarBatchItem{valid, {Plate: "京B00003", Model: "X", VIN: "LSVAB000000000104", Owner: "nobody"}}, "第 1 条(京B00003)"},
+		{"VIN长度错误", []CarBatchItem{{Plate: "京B00004", Model: "X", VIN: "SHORT", Owner: "dealer"}}, "VIN必须是17位"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateSize := len(l.state)
+			requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				_, err := testContract.CreateCarsBatch(ctx, carsBatchJSON(t, tt.items...))
+				return err
+			}), tt.err)
+			if len(l.state) != stateSize {
+				t.Fatal("批次校验失败时不应写入任何记录")
+			}
+		})
+	}
+
+	// 预校验只返回每一条的结果，不写入账本
+	var results []*CarBatchItemResult
+	stateSize := len(l.state)
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		results, err = testContract.ValidateCarsBatch(ctx, carsBatchJSON(t, valid, CarBatchItem{Plate: "京A00001", Model: "X", VIN: "LSVAB000000000105", Owner: "dealer"}))
+		return err
+	}))
+	if len(results) != 2 || !results[0].Success || results[1].Success || len(l.state) != stateSize {
+		t.Fatalf("预校验结果不正确：%+v", results)
+	}
+
+	second := CarBatchItem{Plate: "京B00002", Model: "Model Y", VIN: "LSVAB000000000106", Owner: "dealer", Color: "白色"}
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		results, err = testContract.CreateCarsBatch(ctx, carsBatchJSON(t, valid, second))
+		return err
+	}))
+	if len(results) != 2 {
+		t.Fatalf("批量创建结果数量不正确：%d", len(results))
+	}
+	for i, result := range results {
+		if !result.Success || len(result.ID) == 0 {
+			t.Fatalf("第 %d 条创建失败：%+v", i, result)
+		}
+		car := queryTestCar(t, l, result.ID)
+		if car.Plate != result.Plate || car.Status != AVAILABLE || car.CurrentOwner != "dealer" {
+			t.Fatalf("第 %d 条汽车信息不正确：%+v", i, car)
+		}
+	}
+}
+
+func TestCertExpired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		validUntil time.Time
+		want       bool
+	}{
+		{"长期有效", time.Time{}, false},
+		{"尚未到期", now.Add(time.Second), false},
+		{"恰好到期", now, true},
+		{"已经过期", now.Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certExpired(&Certificate{ValidUntil: tt.validUntil}, now); got != tt.want {
+				t.Fatalf("过期判断为 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateValidity(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.AddCertificate(ctx, fmt.Sprintf(`{"certId":"C0","carId":"%s","certType":"INSPECTION","fileHash":"h","fileLocation":"%s/c0.pdf","validUntil":"2025-01-01T00:00:00Z"}`, carID, carID))
+	}), "证书失效时间必须晚于生效时间")
+
+	uploadTime := l.now
+	addTestCertificate(t, l, "SOON", carID, "INSPECTION", time.Time{}, uploadTime.Add(2*time.Minute))
+	addTestCertificate(t, l, "EXPIRED", carID, "INSURANCE", uploadTime.AddDate(0, 0, -30), uploadTime.AddDate(0, 0, -1))
+	addTestCertificate(t, l, "LATER", carID, "INSPECTION", time.Time{}, uploadTime.AddDate(0, 0, 10))
+	addTestCertificate(t, l, "FOREVER", carID, "REGISTRATION", time.Time{}, time.Time{})
+
+	getCert := func(certID string) *Certificate {
+		var cert *Certificate
+		requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+			cert, err = testContract.GetCertificate(ctx, certID)
+			return err
+		}))
+		return cert
+	}
+	if cert := getCert("FOREVER"); cert.Expired || cert.Status != CERT_ACTIVE || !cert.ValidFrom.Equal(cert.UploadTime) {
+		t.Fatalf("长期有效证书不正确：%+v", cert)
+	}
+	if cert := getCert("SOON"); !cert.Expired {
+		t.Fatalf("证书应在失效时间后标记为过期：%+v", cert)
+	}
+
+	expiring := func(days int, pageSize int32, bookmark string) *QueryResult {
+		var result *QueryResult
+		requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+			result, err = testContract.QueryExpiringCertificates(ctx, days, pageSize, bookmark)
+			return err
+		}))
+		return result
+	}
+	tests := []struct {
+		name string
+		days int
+		want []string
+	}{
+		{"只返回已过期和即将到期的证书", 3, []string{"EXPIRED", "SOON"}},
+		{"扩大天数范围", 30, []string{"EXPIRED", "LATER", "SOON"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := expiring(tt.days, 10, "")
+			got := make([]string, 0)
+			for _, record := range result.Records {
+				got = append(got, record.(Certificate).CertID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("到期证书为 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	// 分页按扫描的证书数计算，过滤后的记录数可能少于页大小
+	first := expiring(3, 2, "")
+	second := expiring(3, 2, first.Bookmark)
+	if first.RecordsCount+second.RecordsCount != 2 || len(second.Bookmark) != 0 {
+		t.Fatalf("分页结果不正确：%+v %+v", first, second)
+	}
+	requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.QueryExpiringCertificates(ctx, -1, 10, "")
+		return err
+	}), "天数不能为负数")
+}
+
+func TestCreateCarIgnoresClientTime(t *testing.T) {
+	cc, err := newChaincode()
+	requireNoError(t, err)
+
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+
+	// 旧版客户端在末尾多传的时间参数由 contractapi 忽略，记录时间取自交易时间戳
+	createTime := l.now
+	response := l.invoke(cc, CAR_DEALER_ORG_MSPID, "user1", false, "CreateCar", "京A00001", "Model S", "LSVAB000000000001", "dealer", "2020-01-01T00:00:00Z")
+	if response.Status != shim.OK {
+		t.Fatalf("创建汽车失败：%s", response.Message)
+	}
+	carID := string(response.Payload)
+	car := queryTestCar(t, l, carID)
+	if !car.CreateTime.Equal(createTime) || !car.UpdateTime.Equal(createTime) {
+		t.Fatalf("创建时间为 %v，期望交易时间 %v", car.CreateTime, createTime)
+	}
+
+	txTime := l.now
+	response = l.invoke(cc, TRADE_ORG_MSPID, "user1", false, "CreateTransaction", "T1", carID, "dealer", "alice", "100", "2020-01-01T00:00:00Z")
+	if response.Status != shim.OK {
+		t.Fatalf("生成交易失败：%s", response.Message)
+	}
+	var transaction *Transaction
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transaction, err = testContract.QueryTransaction(ctx, "T1")
+		return err
+	}))
+	if !transaction.CreateTime.Equal(txTime) || !transaction.UpdateTime.Equal(txTime) {
+		t.Fatalf("交易时间为 %v，期望交易时间戳 %v", transaction.CreateTime, txTime)
+	}
+	if car := queryTestCar(t, l, carID); !car.UpdateTime.Equal(txTime) {
+		t.Fatalf("汽车更新时间为 %v，期望交易时间戳 %v", car.UpdateTime, txTime)
+	}
+}
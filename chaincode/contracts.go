@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-contract-api-go/v2/metadata"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// CONTRACT_VERSION 合约元数据中的版本号
+const CONTRACT_VERSION = "1.0.0"
+
+// 各组织的调用权限（用于调用规则）
+var (
+	dealerOrg    = []string{CAR_DEALER_ORG_MSPID}
+	bankOrg      = []string{BANK_ORG_MSPID}
+	tradeOrg     = []string{TRADE_ORG_MSPID}
+	regulatorOrg = []string{REGULATOR_ORG_MSPID}
+	insurerOrg   = []string{INSURER_ORG_MSPID}
+	appraiserOrg = []string{APPRAISER_ORG_MSPID}
+)
+
+// carTransactions 汽车合约：汽车登记与属性变更、非交易过户、被盗标记、保险和汽车 NFT
+var carTransactions = map[string]transactionRule{
+	"CreateCar":               {orgs: dealerOrg, action: "创建汽车信息"},
+	"ValidateCarsBatch":       {},
+	"CreateCarsBatch":         {orgs: dealerOrg, action: "创建汽车信息"},
+	"QueryCar":                {},
+	"QueryCarList":            {},
+	"UpdateCarAttributes":     {orgs: []string{CAR_DEALER_ORG_MSPID, REGULATOR_ORG_MSPID}, action: "修改汽车属性", required: []string{"汽车ID", "", "", "", "变更原因"}},
+	"QueryCarByPlate":         {},
+	"QueryCarChangeLog":       {},
+	"TransferOwnership":       {orgs: regulatorOrg, action: "办理非交易过户", required: []string{"汽车ID", "新所有者", "", "证明文件证书ID"}},
+	"QueryOwnershipHistory":   {},
+	"FlagCarStolen":           {orgs: regulatorOrg, action: "管理被盗标记", required: []string{"汽车ID", "案件编号"}},
+	"ClearStolenFlag":         {orgs: regulatorOrg, action: "管理被盗标记", required: []string{"汽车ID", "案件编号"}},
+	"QueryStolenFlagHistory":  {},
+	"RegisterPolicy":          {orgs: insurerOrg, action: "管理保单和理赔", required: []string{"保单号", "汽车ID", "", "险种"}},
+	"QueryPolicy":             {},
+	"QueryCarPolicies":        {},
+	"LogClaim":                {orgs: insurerOrg, action: "管理保单和理赔", required: []string{"理赔编号"}},
+	"UpdateClaimStatus":       {orgs: insurerOrg, action: "管理保单和理赔"},
+	"QueryDamageHistory":      {},
+	"SetPolicyTransferRule":   {orgs: insurerOrg, action: "管理保单和理赔"},
+	"QueryPolicyTransferRule": {},
+	"BindPartyIdentity":       {orgs: bankOrg, action: "绑定参与方身份", required: []string{"", "客户端 MSP ID", "客户端身份标识"}},
+	"OwnerOf":                 {},
+	"BalanceOf":               {},
+	"Approve":                 {},
+	"SetApprovalForAll":       {required: []string{"操作员"}},
+	"GetApproved":             {},
+	"IsApprovedForAll":        {},
+	"SetTransferDocument":     {required: []string{"", "", "证明文件证书ID"}},
+	"GetTransferDocument":     {},
+	"TransferFrom":            {required: []string{"", "接收方"}},
+}
+
+// tradeTransactions 交易合约：买卖、打包与置换交易、预订、评价、费用、结算、贷款、参与方和评估
+var tradeTransactions = map[string]transactionRule{
+	"CreateTransaction":         {orgs: tradeOrg, action: "生成交易", required: []string{"交易ID", "汽车ID", "卖家", "买家"}},
+	"CompleteTransaction":       {orgs: bankOrg, action: "完成交易"},
+	"QueryTransaction":          {},
+	"QueryTransactionList":      {},
+	"CreateBundleTransaction":   {orgs: tradeOrg, action: "生成交易", required: []string{"交易ID", "卖家", "买家"}},
+	"CreateTradeInTransaction":  {orgs: tradeOrg, action: "生成交易", required: []string{"交易ID", "汽车ID", "卖家", "买家", "", "置换旧车ID"}},
+	"CreateReservation":         {orgs: tradeOrg, action: "创建预订", required: []string{"预订ID", "汽车ID", "卖家", "买家"}},
+	"ConfirmReservationDeposit": {orgs: bankOrg, action: "确认定金", required: []string{"", "定金收款凭证号"}},
+	"ConvertReservation":        {orgs: tradeOrg, action: "生成交易", required: []string{"", "交易ID"}},
+	"CancelReservation":         {orgs: tradeOrg, action: "取消预订", required: []string{"", "", "取消原因"}},
+	"ExpireReservation":         {},
+	"QueryReservation":          {},
+	"QueryReservationList":      {},
+	"SubmitReview":              {orgs: tradeOrg, action: "提交评价", required: []string{"交易ID", "评价人"}},
+	"QueryReview":               {},
+	"QueryPartyReviews":         {},
+	"QueryReputation":           {},
+	"SetFeeSchedule":            {orgs: tradeOrg, action: "设置费用表"},
+	"QueryFeeSchedule":          {},
+	"SetPaymentSettlement":      {orgs: bankOrg, action: "设置结算方式"},
+	"QueryPaymentSettlement":    {},
+	"CreateLoan":                {orgs: bankOrg, action: "办理贷款业务", required: []string{"贷款ID", "交易ID"}},
+	"RecordLoanPayment":         {orgs: bankOrg, action: "办理贷款业务", required: []string{"", "还款凭证号"}},
+	"MarkLoanDefault":           {orgs: bankOrg, action: "办理贷款业务", required: []string{"", "违约原因"}},
+	"RepossessCar":              {orgs: bankOrg, action: "办理贷款业务", required: []string{"", "收回方"}},
+	"QueryLoan":                 {},
+	"QueryLoanList":             {},
+	"RegisterParty":             {orgs: []string{CAR_DEALER_ORG_MSPID, TRADE_ORG_MSPID}, action: "登记参与方", required: []string{"参与方ID", "", "参与方名称"}},
+	"SetPartyKYCStatus":         {orgs: bankOrg, action: "设置 KYC 状态"},
+	"QueryParty":                {},
+	"QueryPartyList":            {},
+	"RegisterAppraiser":         {orgs: appraiserOrg, action: "登记评估机构", required: []string{"评估机构ID", "评估机构名称", "评估资质证书编号"}},
+	"SetAppraiserStatus":        {orgs: tradeOrg, action: "审核评估机构"},
+	"QueryAppraiser":            {},
+	"SubmitAppraisal":           {orgs: appraiserOrg, action: "提交评估报告"},
+	"QueryAppraisals":           {},
+	"QueryLatestAppraisal":      {},
+	"SetAppraisalPolicy":        {orgs: tradeOrg, action: "设置评估价检查策略"},
+	"QueryAppraisalPolicy":      {},
+	"QueryMarketStats":          {},
+}
+
+// certificateTransactions 证书合约：证书登记与吊销、销售所需证件策略和受信任的签发机构
+var certificateTransactions = map[string]transactionRule{
+	"AddCertificate":               {orgs: []string{CAR_DEALER_ORG_MSPID, TRADE_ORG_MSPID}, action: "登记证书"},
+	"GetAllCertificates":           {},
+	"GetCertificate":               {},
+	"QueryExpiringCertificates":    {},
+	"RevokeCertificate":            {orgs: regulatorOrg, action: "吊销证书", required: []string{"", "吊销原因"}},
+	"SetRequiredDocumentsPolicy":   {orgs: regulatorOrg, action: "设置销售所需证件策略"},
+	"QueryRequiredDocumentsPolicy": {},
+	"QuerySaleReadiness":           {},
+	"RegisterTrustedIssuer":        {orgs: regulatorOrg, action: "登记签发机构", required: []string{"签发机构ID", "签发机构名称"}},
+	"RevokeTrustedIssuer":          {orgs: regulatorOrg, action: "撤销签发机构"},
+	"QueryTrustedIssuer":           {},
+	"QueryTrustedIssuers":          {},
+}
+
+// adminTransactions 管理合约：连通性检查、数据迁移、客户端身份、审计人员和账本审计查询
+var adminTransactions = map[string]transactionRule{
+	"Hello":                   {},
+	"InitLedger":              {},
+	"QueryMigrationPage":      {adminOnly: true, action: "查询待迁移记录"},
+	"QueryMigrationStatus":    {adminOnly: true, action: "查询待迁移记录"},
+	"MigrateState":            {adminOnly: true, action: "执行数据迁移", required: []string{"", "待迁移记录"}},
+	"ClientIdentityID":        {},
+	"ClientAccountID":         {},
+	"RegisterAuditor":         {orgs: regulatorOrg, action: "登记审计人员", required: []string{"客户端 MSP ID", "客户端身份标识", "审计人员名称"}},
+	"RevokeAuditor":           {orgs: regulatorOrg, action: "撤销审计人员"},
+	"QueryAuditors":           {},
+	"QueryCarHistory":         {auditOnly: true, required: []string{"汽车ID"}},
+	"QueryTransactionHistory": {auditOnly: true, required: []string{"交易ID"}},
+	"QueryCertificateHistory": {auditOnly: true, required: []string{"证书ID"}},
+	"QueryPrivateDataHash":    {auditOnly: true, required: []string{"集合名称", "键"}},
+}
+
+// namedContract 按业务拆分的具名合约，共用 SmartContract 的实现，只对外公开 transactions 中列出的函数
+type namedContract struct {
+	SmartContract
+	transactions map[string]transactionRule
+}
+
+// newNamedContract 创建具名合约并挂载统一的前置、后置和未知函数钩子
+func newNamedContract(name string, description string, transactions map[string]transactionRule) *namedContract {
+	contract := &namedContract{transactions: transactions}
+	contract.Name = name
+	contract.Info = metadata.InfoMetadata{
+		Title:       name,
+		Description: description,
+		Version:     CONTRACT_VERSION,
+		License:     &metadata.LicenseMetadata{Name: "MIT"},
+	}
+	contract.BeforeTransaction = contract.beforeTransaction
+	contract.AfterTransaction = contract.afterTransaction
+	contract.UnknownTransaction = contract.unknownTransaction
+	return contract
+}
+
+// GetIgnoredFunctions 不属于本合约的函数不对外公开
+func (c *namedContract) GetIgnoredFunctions() []string {
+	ignored := make([]string, 0)
+	contractType := reflect.TypeOf(c)
+	for i := 0; i < contractType.NumMethod(); i++ {
+		name := contractType.Method(i).Name
+		if _, ok := c.transactions[name]; !ok {
+			ignored = append(ignored, name)
+		}
+	}
+	return ignored
+}
+
+// GetEvaluateTransactions 只读函数在元数据中标记为 evaluate，提示客户端以查询方式调用
+func (c *namedContract) GetEvaluateTransactions() []string {
+	evaluate := make([]string, 0)
+	for name := range c.transactions {
+		if isReadOnlyFunction(name) {
+			evaluate = append(evaluate, name)
+		}
+	}
+	sort.Strings(evaluate)
+	return evaluate
+}
+
+// newChaincode 创建包含汽车、交易、证书和管理四个合约的链码，汽车合约为默认合约
+// 未指定合约名的调用按函数名转发到所属合约，拆分合约前的函数名保持可用
+func newChaincode() (shim.Chaincode, error) {
+	contracts := []*namedContract{
+		newNamedContract("car", "汽车登记与属性变更、非交易过户、被盗标记、保险和汽车 NFT", carTransactions),
+		newNamedContract("trade", "买卖、打包与置换交易、预订、评价、费用、结算、贷款、参与方和评估", tradeTransactions),
+		newNamedContract("certificate", "证书登记与吊销、销售所需证件策略和受信任的签发机构", certificateTransactions),
+		newNamedContract("admin", "连通性检查、数据迁移、客户端身份、审计人员和账本审计查询", adminTransactions),
+	}
+
+	functionContracts, err := checkContractTransactions(contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := make([]contractapi.ContractInterface, 0, len(contracts))
+	for _, contract := range contracts {
+		interfaces = append(interfaces, contract)
+	}
+	chaincode, err := contractapi.NewChaincode(interfaces...)
+	if err != nil {
+		return nil, err
+	}
+	return &routedChaincode{chaincode: chaincode, functionContracts: functionContracts}, nil
+}
+
+// checkContractTransactions 校验每个公开函数恰好归入一个合约、必填参数均为字符串，返回函数名到合约名的映射
+func checkContractTransactions(contracts []*namedContract) (map[string]string, error) {
+	baseMethods := make(map[string]bool)
+	baseType := reflect.TypeOf(&contractapi.Contract{})
+	for i := 0; i < baseType.NumMethod(); i++ {
+		baseMethods[baseType.Method(i).Name] = true
+	}
+
+	functionContracts := make(map[string]string)
+	contractType := reflect.TypeOf(&SmartContract{})
+	for _, contract := range contracts {
+		for name, rule := range contract.transactions {
+			method, ok := contractType.MethodByName(name)
+			if !ok {
+				return nil, fmt.Errorf("合约 %s 中的函数 %s 不存在", contract.Name, name)
+			}
+			if owner, ok := functionContracts[name]; ok {
+				return nil, fmt.Errorf("函数 %s 同时归入了合约 %s 和 %s", name, owner, contract.Name)
+			}
+			// 方法参数依次为接收者、交易上下文和函数参数
+			if len(rule.required) > method.Type.NumIn()-2 {
+				return nil, fmt.Errorf("函数 %s 的必填参数个数超过了参数个数", name)
+			}
+			for i, label := range rule.required {
+				if len(label) > 0 && method.Type.In(i+2).Kind() != reflect.String {
+					return nil, fmt.Errorf("函数 %s 的第 %d 个参数不是字符串，不能校验非空", name, i+1)
+				}
+			}
+			if len(rule.orgs) > 0 || rule.adminOnly {
+				if len(rule.action) == 0 {
+					return nil, fmt.Errorf("函数 %s 缺少权限提示", name)
+				}
+			}
+			functionContracts[name] = contract.Name
+		}
+	}
+
+	for i := 0; i < contractType.NumMethod(); i++ {
+		name := contractType.Method(i).Name
+		if _, ok := functionContracts[name]; !ok && !baseMethods[name] {
+			return nil, fmt.Errorf("函数 %s 未归入任何合约", name)
+		}
+	}
+	return functionContracts, nil
+}
+
+// routedChaincode 包装合约链码：未指定合约名的调用按函数名补上所属合约名，并记录失败的调用
+type routedChaincode struct {
+	chaincode         shim.Chaincode
+	functionContracts map[string]string // 函数名 -> 合约名
+}
+
+// Init 初始化链码
+func (c *routedChaincode) Init(stub shim.ChaincodeStubInterface) *peer.Response {
+	return c.chaincode.Init(c.route(stub))
+}
+
+// Invoke 调用链码
+func (c *routedChaincode) Invoke(stub shim.ChaincodeStubInterface) *peer.Response {
+	stub = c.route(stub)
+	response := c.chaincode.Invoke(stub)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		function, _ := stub.GetFunctionAndParameters()
+		log.Printf("失败 %s（TxID：%s）：%s", function, stub.GetTxID(), response.Message)
+	}
+	return response
+}
+
+// route 函数名不含合约名时补上所属合约名，未知函数保持原样由默认合约处理
+func (c *routedChaincode) route(stub shim.ChaincodeStubInterface) shim.ChaincodeStubInterface {
+	args := stub.GetArgs()
+	if len(args) == 0 || strings.Contains(string(args[0]), ":") {
+		return stub
+	}
+
+	contractName, ok := c.functionContracts[transactionName(string(args[0]))]
+	if !ok {
+		return stub
+	}
+	routedArgs := make([][]byte, len(args))
+	copy(routedArgs, args)
+	routedArgs[0] = []byte(contractName + ":" + string(args[0]))
+	return &argsStub{ChaincodeStubInterface: stub, args: routedArgs}
+}
+
+// argsStub 替换了调用参数的 stub，其余方法透传给原 stub
+type argsStub struct {
+	shim.ChaincodeStubInterface
+	args [][]byte
+}
+
+func (s *argsStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *argsStub) GetStringArgs() []string {
+	strArgs := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		strArgs = append(strArgs, string(arg))
+	}
+	return strArgs
+}
+
+func (s *argsStub) GetFunctionAndParameters() (string, []string) {
+	allArgs := s.GetStringArgs()
+	function := ""
+	params := []string{}
+	if len(allArgs) >= 1 {
+		function = allArgs[0]
+		params = allArgs[1:]
+	}
+	return function, params
+}
+
+func (s *argsStub) GetArgsSlice() ([]byte, error) {
+	res := []byte{}
+	for _, arg := range s.args {
+		res = append(res, arg...)
+	}
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+)
+
+func TestCheckContractTransactions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(contracts map[string]map[string]transactionRule)
+		err    string
+	}{
+		{"当前的合约函数表", func(map[string]map[string]transactionRule) {}, ""},
+		{"函数不存在", func(contracts map[string]map[string]transactionRule) {
+			contracts["admin"]["NoSuchFunction"] = transactionRule{}
+		}, "合约 admin 中的函数 NoSuchFunction 不存在"},
+		{"函数归入两个合约", func(contracts map[string]map[string]transactionRule) {
+			contracts["admin"]["CreateCar"] = transactionRule{orgs: dealerOrg, action: "创建汽车信息"}
+		}, "函数 CreateCar 同时归入了合约"},
+		{"函数未归入任何合约", func(contracts map[string]map[string]transactionRule) {
+			delete(contracts["car"], "QueryCar")
+		}, "函数 QueryCar 未归入任何合约"},
+		{"必填参数个数超过参数个数", func(contracts map[string]map[string]transactionRule) {
+			contracts["admin"]["Hello"] = transactionRule{required: []string{"名称"}}
+		}, "函数 Hello 的必填参数个数超过了参数个数"},
+		{"必填参数不是字符串", func(contracts map[string]map[string]transactionRule) {
+			contracts["trade"]["CreateTransaction"] = transactionRule{orgs: tradeOrg, action: "生成交易", required: []string{"", "", "", "", "成交价"}}
+		}, "函数 CreateTransaction 的第 5 个参数不是字符串"},
+		{"限定组织但缺少权限提示", func(contracts map[string]map[string]transactionRule) {
+			contracts["trade"]["SetFeeSchedule"] = transactionRule{orgs: tradeOrg}
+		}, "函数 SetFeeSchedule 缺少权限提示"},
+		{"仅限管理员但缺少权限提示", func(contracts map[string]map[string]transactionRule) {
+			contracts["admin"]["MigrateState"] = transactionRule{adminOnly: true}
+		}, "函数 MigrateState 缺少权限提示"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 复制各合约的函数表，修改副本不影响链码使用的函数表
+			contracts := map[string]map[string]transactionRule{}
+			for name, transactions := range map[string]map[string]transactionRule{
+				"car": carTransactions, "trade": tradeTransactions, "certificate": certificateTransactions, "admin": adminTransactions,
+			} {
+				contracts[name] = map[string]transactionRule{}
+				for function, rule := range transactions {
+					contracts[name][function] = rule
+				}
+			}
+			tt.modify(contracts)
+
+			named := make([]*namedContract, 0, len(contracts))
+			for _, name := range []string{"car", "trade", "certificate", "admin"} {
+				named = append(named, newNamedContract(name, name, contracts[name]))
+			}
+			functionContracts, err := checkContractTransactions(named)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			requireNoError(t, err)
+			if functionContracts["CreateCar"] != "car" || functionContracts["CreateTransaction"] != "trade" ||
+				functionContracts["AddCertificate"] != "certificate" || functionContracts["MigrateState"] != "admin" {
+				t.Fatalf("函数所属合约不正确：%v", functionContracts)
+			}
+		})
+	}
+}
+
+func TestContractHooks(t *testing.T) {
+	l := newMockLedger(t)
+	cc, err := newChaincode()
+	requireNoError(t, err)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	certJSON := func(certID string) string {
+		return fmt.Sprintf(`{"certId":"%s","carId":"%s","certType":"INSPECTION","fileHash":"h","fileLocation":"%s/%s.pdf"}`, certID, carID, carID, certID)
+	}
+
+	tests := []struct {
+		name  string
+		mspID string
+		admin bool
+		args  []string
+		err   string
+	}{
+		{"不带合约名按函数名转发", CAR_DEALER_ORG_MSPID, false, []string{"QueryCar", carID}, ""},
+		{"带合约名调用", CAR_DEALER_ORG_MSPID, false, []string{"car:QueryCar", carID}, ""},
+		{"函数名首字母小写", CAR_DEALER_ORG_MSPID, false, []string{"queryCar", carID}, ""},
+		{"函数不属于指定的合约", CAR_DEALER_ORG_MSPID, false, []string{"trade:QueryCar", carID}, "合约 trade 中不存在函数 QueryCar"},
+		{"不存在的函数由默认合约处理", CAR_DEALER_ORG_MSPID, false, []string{"NoSuchFunction"}, "合约 car 中不存在函数 NoSuchFunction"},
+		{"组织不在允许范围内", BANK_ORG_MSPID, false, []string{"CreateCar", "京A00002", "Model S", "LSVAB900000000002", "dealer"}, "只有汽车经销商组织成员才能创建汽车信息"},
+		{"允许多个组织时列出全部组织", BANK_ORG_MSPID, false, []string{"AddCertificate", certJSON("C1")}, "只有汽车经销商或交易平台组织成员才能登记证书"},
+		{"交易平台可以登记证书", TRADE_ORG_MSPID, false, []string{"AddCertificate", certJSON("C2")}, ""},
+		{"只有评估机构组织可以登记评估机构", TRADE_ORG_MSPID, false, []string{"RegisterAppraiser", "appraiser1", "评估公司", "LIC-1"}, "只有第三方评估机构组织成员才能登记评估机构"},
+		{"评估机构组织登记评估机构", APPRAISER_ORG_MSPID, false, []string{"RegisterAppraiser", "appraiser1", "评估公司", "LIC-1"}, ""},
+		{"非管理员不能执行数据迁移", CAR_DEALER_ORG_MSPID, false, []string{"QueryMigrationPage", "0", "10", ""}, "只有组织管理员才能查询待迁移记录"},
+		{"组织管理员查询待迁移记录", CAR_DEALER_ORG_MSPID, true, []string{"QueryMigrationPage", "0", "10", ""}, ""},
+		{"必填参数为空", TRADE_ORG_MSPID, false, []string{"CreateTransaction", "T1", carID, "dealer", "", "100"}, "买家不能为空"},
+		{"空参数只在必填时报错", REGULATOR_ORG_MSPID, false, []string{"UpdateCarAttributes", carID, "", "", "", ""}, "变更原因不能为空"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := l.invoke(cc, tt.mspID, "user1", tt.admin, tt.args...)
+			if tt.err == "" {
+				if response.Status != shim.OK {
+					t.Fatalf("意外的错误：%s", response.Message)
+				}
+				return
+			}
+			if response.Status == shim.OK || !strings.Contains(response.Message, tt.err) {
+				t.Fatalf("期望错误包含 %q，实际为：%d %s", tt.err, response.Status, response.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// requiredDocumentsPolicyKey 销售所需证件策略在 CONFIG 中的键
+const requiredDocumentsPolicyKey = "REQUIRED_DOCUMENTS_POLICY"
+
+// RequiredDocumentsPolicy 销售所需证件策略：列出的证书类型必须存在、处于有效状态且未过期
+type RequiredDocumentsPolicy struct {
+	CertTypes  []string  `json:"certTypes"`  // 必需的证书类型，例如 REGISTRATION、INSPECTION
+	UpdateTime time.Time `json:"updateTime"` // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// DocumentIssue 证件不满足要求的原因
+type DocumentIssue string
+
+const (
+	DOCUMENT_MISSING       DocumentIssue = "MISSING"       // 未上传
+	DOCUMENT_REVOKED       DocumentIssue = "REVOKED"       // 已吊销
+	DOCUMENT_EXPIRED       DocumentIssue = "EXPIRED"       // 已过期
+	DOCUMENT_NOT_YET_VALID DocumentIssue = "NOT_YET_VALID" // 尚未生效
+)
+
+// documentIssueLabels 证件问题的中文说明
+var documentIssueLabels = map[DocumentIssue]string{
+	DOCUMENT_MISSING:       "缺失",
+	DOCUMENT_REVOKED:       "已吊销",
+	DOCUMENT_EXPIRED:       "已过期",
+	DOCUMENT_NOT_YET_VALID: "尚未生效",
+}
+
+// MissingDocument 不满足要求的证件
+type MissingDocument struct {
+	CertType string        `json:"certType"` // 证书类型
+	Issue    DocumentIssue `json:"issue"`    // 不满足要求的原因
+}
+
+// SaleReadiness 汽车的销售就绪检查结果
+type SaleReadiness struct {
+	CarID             string             `json:"carId"`             // 汽车ID
+	Ready             bool               `json:"ready"`             // 证件是否齐全
+	RequiredCertTypes []string           `json:"requiredCertTypes"` // 策略要求的证书类型
+	Missing           []*MissingDocument `json:"missing"`           // 不满足要求的证件
+	CheckTime         time.Time          `json:"checkTime"`         // 检查时间
+}
+
+// RevokeCertificate 吊销证书（仅监管机构组织可以调用），吊销后该证书不再满足销售所需证件要求
+func (s *SmartContract) RevokeCertificate(ctx contractapi.TransactionContextInterface, certID string, reason string) error {
+	cert, err := s.GetCertificate(ctx, certID)
+	if err != nil {
+		return err
+	}
+	if cert.Status == CERT_REVOKED {
+		return fmt.Errorf("证书 %s 已被吊销", certID)
+	}
+
+	certKey, err := s.getCompositeKey(ctx, CERTIFICATE, []string{certID})
+	if err != nil {
+		return err
+	}
+
+	// 清除仅查询时填充的字段，避免写入账本
+	cert.Expired = false
+	cert.SignatureValid = false
+	cert.SignatureError = ""
+	cert.Status = CERT_REVOKED
+	cert.RevokeReason = reason
+	return s.putState(ctx, certKey, cert)
+}
+
+// SetRequiredDocumentsPolicy 设置销售所需证件策略（仅监管机构组织可以调用）
+// certTypesJSON 为证书类型的 JSON 数组，空数组表示不要求任何证件
+func (s *SmartContract) SetRequiredDocumentsPolicy(ctx contractapi.TransactionContextInterface, certTypesJSON string) error {
+	var certTypes []string
+	if err := json.Unmarshal([]byte(certTypesJSON), &certTypes); err != nil {
+		return fmt.Errorf("解析证书类型列表失败：%v", err)
+	}
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(certTypes))
+	for _, certType := range certTypes {
+		certType = strings.TrimSpace(certType)
+		if certType == "" {
+			return fmt.Errorf("证书类型不能为空")
+		}
+		if seen[certType] {
+			return fmt.Errorf("证书类型 %s 重复", certType)
+		}
+		seen[certType] = true
+		normalized = append(normalized, certType)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	policyKey, err := s.getCompositeKey(ctx, CONFIG, []string{requiredDocumentsPolicyKey})
+	if err != nil {
+		return err
+	}
+	policy := RequiredDocumentsPolicy{
+		CertTypes:  normalized,
+		UpdateTime: updateTime,
+	}
+	return s.putState(ctx, policyKey, policy)
+}
+
+// QueryRequiredDocumentsPolicy 查询销售所需证件策略，未设置时不要求任何证件
+func (s *SmartContract) QueryRequiredDocumentsPolicy(ctx contractapi.TransactionContextInterface) (*RequiredDocumentsPolicy, error) {
+	policyKey, err := s.getCompositeKey(ctx, CONFIG, []string{requiredDocumentsPolicyKey})
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(policyKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询销售所需证件策略失败：%v", err)
+	}
+	if bytes == nil {
+		return &RequiredDocumentsPolicy{CertTypes: []string{}}, nil
+	}
+
+	var policy RequiredDocumentsPolicy
+	err = s.unmarshalState(ctx, policyKey, bytes, &policy)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// QuerySaleReadiness 检查汽车的证件是否满足销售所需证件策略
+func (s *SmartContract) QuerySaleReadiness(ctx contractapi.TransactionContextInterface, carID string) (*SaleReadiness, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+	if _, _, err := s.getCar(ctx, carID); err != nil {
+		return nil, err
+	}
+	return s.checkSaleReadiness(ctx, carID)
+}
+
+// checkSaleReadiness 按策略逐项检查汽车的证件，同一类型只要有一份有效证件即视为满足
+func (s *SmartContract) checkSaleReadiness(ctx contractapi.TransactionContextInterface, carID string) (*SaleReadiness, error) {
+	policy, err := s.QueryRequiredDocumentsPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	readiness := &SaleReadiness{
+		CarID:             carID,
+		Ready:             true,
+		RequiredCertTypes: policy.CertTypes,
+		Missing:           make([]*MissingDocument, 0),
+		CheckTime:         now,
+	}
+	if len(policy.CertTypes) == 0 {
+		return readiness, nil
+	}
+
+	certificates, err := s.GetAllCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, certType := range policy.CertTypes {
+		// 记录该类型证件中最接近有效的问题：尚未生效 优先于 已过期 优先于 已吊销
+		issue := DOCUMENT_MISSING
+		for _, cert := range certificates {
+			if cert.CarID != carID || cert.CertType != certType {
+				continue
+			}
+			var certIssue DocumentIssue
+			switch {
+			case cert.Status != CERT_ACTIVE:
+				certIssue = DOCUMENT_REVOKED
+			case cert.Expired:
+				certIssue = DOCUMENT_EXPIRED
+			case now.Before(cert.ValidFrom):
+				certIssue = DOCUMENT_NOT_YET_VALID
+			default:
+				certIssue = ""
+			}
+			if certIssue == "" {
+				issue = ""
+				break
+			}
+			if documentIssueRank(certIssue) > documentIssueRank(issue) {
+				issue = certIssue
+			}
+		}
+		if issue != "" {
+			readiness.Ready = false
+			readiness.Missing = append(readiness.Missing, &MissingDocument{CertType: certType, Issue: issue})
+		}
+	}
+
+	return readiness, nil
+}
+
+// documentIssueRank 证件问题的接近有效程度，数值越大越接近有效
+func documentIssueRank(issue DocumentIssue) int {
+	switch issue {
+	case DOCUMENT_REVOKED:
+		return 1
+	case DOCUMENT_EXPIRED:
+		return 2
+	case DOCUMENT_NOT_YET_VALID:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// formatMissingDocuments 将不满足要求的证件格式化为错误信息，例如 "REGISTRATION（缺失）、INSPECTION（已过期）"
+func formatMissingDocuments(missing []*MissingDocument) string {
+	items := make([]string, 0, len(missing))
+	for _, doc := range missing {
+		items = append(items, fmt.Sprintf("%s（%s）", doc.CertType, documentIssueLabels[doc.Issue]))
+	}
+	return strings.Join(items, "、")
+}
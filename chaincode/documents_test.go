@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func setTestRequiredDocuments(l *mockLedger, certTypesJSON string) error {
+	return l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetRequiredDocumentsPolicy(ctx, certTypesJSON)
+	})
+}
+
+func TestSetRequiredDocumentsPolicy(t *testing.T) {
+	l := newMockLedger(t)
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{"JSON 格式错误", `REGISTRATION`, "解析证书类型列表失败"},
+		{"证书类型为空", `["REGISTRATION", " "]`, "证书类型不能为空"},
+		{"证书类型重复", `["REGISTRATION", " REGISTRATION"]`, "证书类型 REGISTRATION 重复"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, setTestRequiredDocuments(l, tt.json), tt.err)
+		})
+	}
+
+	requireNoError(t, setTestRequiredDocuments(l, `[" REGISTRATION ", "INSPECTION"]`))
+	var policy *RequiredDocumentsPolicy
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		policy, err = testContract.QueryRequiredDocumentsPolicy(ctx)
+		return err
+	}))
+	if len(policy.CertTypes) != 2 || policy.CertTypes[0] != "REGISTRATION" || policy.CertTypes[1] != "INSPECTION" {
+		t.Fatalf("证件策略不正确：%+v", policy)
+	}
+}
+
+func TestSaleReadiness(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	otherCarID := createTestCar(t, l, "京A00002", "dealer")
+	requireNoError(t, setTestRequiredDocuments(l, `["REGISTRATION", "INSPECTION", "INSURANCE", "EMISSION"]`))
+
+	now := l.now
+	addTestCertificate(t, l, "REG", carID, "REGISTRATION", time.Time{}, time.Time{})
+	addTestCertificate(t, l, "INSP-REVOKED", carID, "INSPECTION", time.Time{}, time.Time{})
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RevokeCertificate(ctx, "INSP-REVOKED", "伪造")
+	}))
+	addTestCertificate(t, l, "INSP-EXPIRED", carID, "INSPECTION", now.AddDate(-1, 0, 0), now.AddDate(0, 0, -1))
+	addTestCertificate(t, l, "INS-FUTURE", carID, "INSURANCE", now.AddDate(0, 1, 0), time.Time{})
+	addTestCertificate(t, l, "EMISSION-OTHER", otherCarID, "EMISSION", time.Time{}, time.Time{})
+
+	var readiness *SaleReadiness
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		readiness, err = testContract.QuerySaleReadiness(ctx, carID)
+		return err
+	}))
+	want := []*MissingDocument{
+		{CertType: "INSPECTION", Issue: DOCUMENT_EXPIRED},
+		{CertType: "INSURANCE", Issue: DOCUMENT_NOT_YET_VALID},
+		{CertType: "EMISSION", Issue: DOCUMENT_MISSING},
+	}
+	if readiness.Ready || len(readiness.Missing) != len(want) {
+		t.Fatalf("销售就绪检查结果不正确：%+v", readiness)
+	}
+	for i, doc := range want {
+		if *readiness.Missing[i] != *doc {
+			t.Fatalf("第 %d 项为 %+v，期望 %+v", i, readiness.Missing[i], doc)
+		}
+	}
+
+	requireError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, "T1", carID, "dealer", "alice", 100)
+	}), "证件不满足销售要求：INSPECTION（已过期）、INSURANCE（尚未生效）、EMISSION（缺失）")
+
+	addTestCertificate(t, l, "INSP", carID, "INSPECTION", time.Time{}, now.AddDate(1, 0, 0))
+	addTestCertificate(t, l, "INS", carID, "INSURANCE", time.Time{}, now.AddDate(1, 0, 0))
+	addTestCertificate(t, l, "EMISSION", carID, "EMISSION", time.Time{}, time.Time{})
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// feeScheduleKey 费用与税费表在 CONFIG 中的键
+const feeScheduleKey = "FEE_SCHEDULE"
+
+// FeeKind 费用类别
+type FeeKind string
+
+const (
+	FEE_KIND_FEE FeeKind = "FEE" // 平台服务费，划入平台收费账户
+	FEE_KIND_TAX FeeKind = "TAX" // 税费（如车辆购置税），划入代收税款账户
+)
+
+// FeePayer 费用承担方
+type FeePayer string
+
+const (
+	FEE_PAYER_BUYER  FeePayer = "BUYER"  // 买家承担，在应付车款之外另行支付
+	FEE_PAYER_SELLER FeePayer = "SELLER" // 卖家承担，从卖家所得车款中扣除
+)
+
+// FeeRule 费用规则：按单车成交价的百分比计算，不足最低金额时按最低金额收取
+// modelClass 为空的规则为默认规则；指定车型类别的规则对该类别的汽车覆盖同一编码的默认规则
+type FeeRule struct {
+	Code       string   `json:"code"`                                      // 费用编码，例如 PLATFORM_FEE、PURCHASE_TAX
+	Name       string   `json:"name"`                                      // 费用名称
+	Kind       FeeKind  `json:"kind"`                                      // 费用类别
+	Payer      FeePayer `json:"payer"`                                     // 承担方
+	ModelClass string   `json:"modelClass,omitempty" metadata:",optional"` // 适用的车型类别，为空表示默认规则
+	Percent    float64  `json:"percent"`                                   // 费率（百分比，0~100）
+	Minimum    float64  `json:"minimum"`                                   // 最低金额
+}
+
+// ModelClass 车型类别：列出的车型（与汽车的 model 字段比较，不区分大小写）归入该类别
+type ModelClass struct {
+	Class  string   `json:"class"`  // 类别名称，例如 NEV、LUXURY
+	Models []string `json:"models"` // 车型列表
+}
+
+// FeeSchedule 费用与税费表
+type FeeSchedule struct {
+	Rules        []*FeeRule    `json:"rules"`        // 费用规则
+	ModelClasses []*ModelClass `json:"modelClasses"` // 车型类别
+	FeeAccount   string        `json:"feeAccount"`   // 平台收费账户（参与方ID），链上代币结算时平台服务费划入该账户
+	TaxAccount   string        `json:"taxAccount"`   // 代收税款账户（参与方ID），链上代币结算时税费划入该账户
+	UpdateTime   time.Time     `json:"updateTime"`   // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// FeeItem 交易的单项费用（按汽车逐项计算）
+type FeeItem struct {
+	Code       string   `json:"code"`                                      // 费用编码
+	Name       string   `json:"name"`                                      // 费用名称
+	Kind       FeeKind  `json:"kind"`                                      // 费用类别
+	Payer      FeePayer `json:"payer"`                                     // 承担方
+	CarID      string   `json:"carId"`                                     // 计费的汽车ID
+	ModelClass string   `json:"modelClass,omitempty" metadata:",optional"` // 汽车所属车型类别，采用默认规则时为空
+	Base       float64  `json:"base"`                                      // 计费基数（单车成交价）
+	Percent    float64  `json:"percent"`                                   // 费率（百分比）
+	Minimum    float64  `json:"minimum"`                                   // 最低金额
+	Amount     float64  `json:"amount"`                                    // 费用金额
+}
+
+// FeeBreakdown 交易的费用明细，生成交易时按当时的费用表计算并随交易保存，之后不再变更
+type FeeBreakdown struct {
+	Items            []*FeeItem `json:"items"`            // 费用明细
+	BuyerFees        float64    `json:"buyerFees"`        // 买家承担的费用合计
+	SellerFees       float64    `json:"sellerFees"`       // 卖家承担的费用合计
+	BuyerPayable     float64    `json:"buyerPayable"`     // 买家应付总额（应付车款加买家承担的费用）
+	SellerReceivable float64    `json:"sellerReceivable"` // 卖家实收金额（应付车款减卖家承担的费用）
+	FeeAccount       string     `json:"feeAccount"`       // 平台收费账户
+	TaxAccount       string     `json:"taxAccount"`       // 代收税款账户
+	ScheduleTime     time.Time  `json:"scheduleTime"`     // 所用费用表的更新时间
+}
+
+// SetFeeSchedule 设置费用与税费表（仅交易平台组织可以调用），只影响之后生成的交易
+// scheduleJSON 为 FeeSchedule 的 JSON，规则为空表示不收取任何费用
+func (s *SmartContract) SetFeeSchedule(ctx contractapi.TransactionContextInterface, scheduleJSON string) error {
+	var schedule FeeSchedule
+	if err := json.Unmarshal([]byte(scheduleJSON), &schedule); err != nil {
+		return fmt.Errorf("解析费用表失败：%v", err)
+	}
+	if err := validateFeeSchedule(&schedule); err != nil {
+		return err
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	schedule.UpdateTime = updateTime
+
+	scheduleKey, err := s.getCompositeKey(ctx, CONFIG, []string{feeScheduleKey})
+	if err != nil {
+		return err
+	}
+	return s.putState(ctx, scheduleKey, schedule)
+}
+
+// QueryFeeSchedule 查询费用与税费表，未设置时不收取任何费用
+func (s *SmartContract) QueryFeeSchedule(ctx contractapi.TransactionContextInterface) (*FeeSchedule, error) {
+	scheduleKey, err := s.getCompositeKey(ctx, CONFIG, []string{feeScheduleKey})
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(scheduleKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询费用表失败：%v", err)
+	}
+	if bytes == nil {
+		return &FeeSchedule{Rules: []*FeeRule{}, ModelClasses: []*ModelClass{}}, nil
+	}
+
+	var schedule FeeSchedule
+	err = s.unmarshalState(ctx, scheduleKey, bytes, &schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// validateFeeSchedule 校验并规范化费用表：编码、类别和承担方必须有效，同一编码在同一车型类别下只能有一条规则，
+// 一个车型只能属于一个类别；存在平台服务费或税费规则时必须指定对应的收款账户
+func validateFeeSchedule(schedule *FeeSchedule) error {
+	if schedule.Rules == nil {
+		schedule.Rules = []*FeeRule{}
+	}
+	if schedule.ModelClasses == nil {
+		schedule.ModelClasses = []*ModelClass{}
+	}
+
+	classes := make(map[string]bool)
+	modelClasses := make(map[string]string)
+	for _, modelClass := range schedule.ModelClasses {
+		if modelClass == nil {
+			return fmt.Errorf("车型类别不能为空")
+		}
+		modelClass.Class = strings.TrimSpace(modelClass.Class)
+		if modelClass.Class == "" {
+			return fmt.Errorf("车型类别名称不能为空")
+		}
+		if classes[modelClass.Class] {
+			return fmt.Errorf("车型类别 %s 重复", modelClass.Class)
+		}
+		classes[modelClass.Class] = true
+		for i, model := range modelClass.Models {
+			model = strings.TrimSpace(model)
+			if model == "" {
+				return fmt.Errorf("车型类别 %s 中的车型不能为空", modelClass.Class)
+			}
+			if other, ok := modelClasses[strings.ToLower(model)]; ok {
+				return fmt.Errorf("车型 %s 同时属于类别 %s 和 %s", model, other, modelClass.Class)
+			}
+			modelClasses[strings.ToLower(model)] = modelClass.Class
+			modelClass.Models[i] = model
+		}
+	}
+
+	seen := make(map[string]bool)
+	hasFee, hasTax := false, false
+	for _, rule := range schedule.Rules {
+		if rule == nil {
+			return fmt.Errorf("费用规则不能为空")
+		}
+		rule.Code = strings.TrimSpace(rule.Code)
+		rule.ModelClass = strings.TrimSpace(rule.ModelClass)
+		if rule.Code == "" {
+			return fmt.Errorf("费用编码不能为空")
+		}
+		switch rule.Kind {
+		case FEE_KIND_FEE:
+			hasFee = true
+		case FEE_KIND_TAX:
+			hasTax = true
+		default:
+			return fmt.Errorf("费用 %s 的类别 %s 无效，应为 FEE 或 TAX", rule.Code, rule.Kind)
+		}
+		if rule.Payer != FEE_PAYER_BUYER && rule.Payer != FEE_PAYER_SELLER {
+			return fmt.Errorf("费用 %s 的承担方 %s 无效，应为 BUYER 或 SELLER", rule.Code, rule.Payer)
+		}
+		if rule.Percent < 0 || rule.Percent > 100 {
+			return fmt.Errorf("费用 %s 的费率必须在0到100之间", rule.Code)
+		}
+		if rule.Minimum < 0 {
+			return fmt.Errorf("费用 %s 的最低金额不能为负数", rule.Code)
+		}
+		if rule.ModelClass != "" && !classes[rule.ModelClass] {
+			return fmt.Errorf("费用 %s 引用的车型类别 %s 不存在", rule.Code, rule.ModelClass)
+		}
+		ruleKey := rule.Code + "\x00" + rule.ModelClass
+		if seen[ruleKey] {
+			if rule.ModelClass == "" {
+				return fmt.Errorf("费用 %s 的默认规则重复", rule.Code)
+			}
+			return fmt.Errorf("费用 %s 在车型类别 %s 下的规则重复", rule.Code, rule.ModelClass)
+		}
+		seen[ruleKey] = true
+	}
+
+	schedule.FeeAccount = strings.TrimSpace(schedule.FeeAccount)
+	schedule.TaxAccount = strings.TrimSpace(schedule.TaxAccount)
+	if hasFee && schedule.FeeAccount == "" {
+		return fmt.Errorf("存在平台服务费规则时必须指定平台收费账户")
+	}
+	if hasTax && schedule.TaxAccount == "" {
+		return fmt.Errorf("存在税费规则时必须指定代收税款账户")
+	}
+	return nil
+}
+
+// classOf 返回车型所属的类别，不属于任何类别时为空
+func (schedule *FeeSchedule) classOf(model string) string {
+	for _, modelClass := range schedule.ModelClasses {
+		for _, classModel := range modelClass.Models {
+			if strings.EqualFold(classModel, strings.TrimSpace(model)) {
+				return modelClass.Class
+			}
+		}
+	}
+	return ""
+}
+
+// rulesFor 返回适用于指定车型类别的规则：按编码取该类别的规则，没有时取默认规则，保持规则在费用表中的顺序
+func (schedule *FeeSchedule) rulesFor(class string) []*FeeRule {
+	overridden := make(map[string]bool)
+	if class != "" {
+		for _, rule := range schedule.Rules {
+			if rule.ModelClass == class {
+				overridden[rule.Code] = true
+			}
+		}
+	}
+	rules := make([]*FeeRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		if (rule.ModelClass == "" && !overridden[rule.Code]) || (class != "" && rule.ModelClass == class) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// computeFeeBreakdown 按当前费用表计算交易的费用明细，每辆售出的汽车按其成交价和车型类别逐项计费
+// 须在锁定汽车之前调用，读取的是汽车的已提交状态；netAmount 为买家应付车款
+func (s *SmartContract) computeFeeBreakdown(ctx contractapi.TransactionContextInterface, items []*BundleItem, netAmount float64) (*FeeBreakdown, error) {
+	schedule, err := s.QueryFeeSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &FeeBreakdown{
+		Items:        []*FeeItem{},
+		FeeAccount:   schedule.FeeAccount,
+		TaxAccount:   schedule.TaxAccount,
+		ScheduleTime: schedule.UpdateTime,
+	}
+	if len(schedule.Rules) > 0 {
+		for _, item := range items {
+			car, _, err := s.getCar(ctx, item.CarID)
+			if err != nil {
+				return nil, fmt.Errorf("查询汽车信息失败或汽车非待售状态：%v", err)
+			}
+			class := schedule.classOf(car.Model)
+			for _, rule := range schedule.rulesFor(class) {
+				amount := roundFeeCents(math.Max(item.Price*rule.Percent/100, rule.Minimum))
+				if amount == 0 {
+					continue
+				}
+				breakdown.Items = append(breakdown.Items, &FeeItem{
+					Code:       rule.Code,
+					Name:       rule.Name,
+					Kind:       rule.Kind,
+					Payer:      rule.Payer,
+					CarID:      item.CarID,
+					ModelClass: rule.ModelClass,
+					Base:       item.Price,
+					Percent:    rule.Percent,
+					Minimum:    rule.Minimum,
+					Amount:     amount,
+				})
+				if rule.Payer == FEE_PAYER_BUYER {
+					breakdown.BuyerFees += amount
+				} else {
+					breakdown.SellerFees += amount
+				}
+			}
+		}
+	}
+
+	breakdown.BuyerFees = roundFeeCents(breakdown.BuyerFees)
+	breakdown.SellerFees = roundFeeCents(breakdown.SellerFees)
+	breakdown.BuyerPayable = roundFeeCents(netAmount + breakdown.BuyerFees)
+	breakdown.SellerReceivable = roundFeeCents(netAmount - breakdown.SellerFees)
+	return breakdown, nil
+}
+
+// roundFeeCents 费用和税费金额按分四舍五入（半分进位）
+// 先舍去百万分之一以下的浮点表示误差（如 1.005 实际存储为 1.00499…），再按分四舍五入
+func roundFeeCents(amount float64) float64 {
+	return math.Round(math.Round(amount*1e6)/1e4) / 100
+}
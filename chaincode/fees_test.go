@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestValidateFeeSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		err      string
+	}{
+		{"空费用表", `{}`, ""},
+		{"类别无效", `{"rules":[{"code":"X","kind":"OTHER","payer":"BUYER"}]}`, "费用 X 的类别 OTHER 无效"},
+		{"承担方无效", `{"rules":[{"code":"X","kind":"FEE","payer":"BANK"}],"feeAccount":"platform"}`, "费用 X 的承担方 BANK 无效"},
+		{"费率超过100", `{"rules":[{"code":"X","kind":"FEE","payer":"BUYER","percent":101}],"feeAccount":"platform"}`, "费率必须在0到100之间"},
+		{"最低金额为负数", `{"rules":[{"code":"X","kind":"FEE","payer":"BUYER","minimum":-1}],"feeAccount":"platform"}`, "最低金额不能为负数"},
+		{"默认规则重复", `{"rules":[{"code":"X","kind":"FEE","payer":"BUYER"},{"code":" X ","kind":"FEE","payer":"SELLER"}],"feeAccount":"platform"}`, "费用 X 的默认规则重复"},
+		{"车型类别不存在", `{"rules":[{"code":"X","kind":"FEE","payer":"BUYER","modelClass":"NEV"}],"feeAccount":"platform"}`, "引用的车型类别 NEV 不存在"},
+		{"车型属于两个类别", `{"modelClasses":[{"class":"NEV","models":["Model 3"]},{"class":"LUXURY","models":["model 3"]}]}`, "同时属于类别 NEV 和 LUXURY"},
+		{"缺少平台收费账户", `{"rules":[{"code":"X","kind":"FEE","payer":"BUYER"}]}`, "必须指定平台收费账户"},
+		{"缺少代收税款账户", `{"rules":[{"code":"X","kind":"TAX","payer":"BUYER"}],"feeAccount":"platform"}`, "必须指定代收税款账户"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newMockLedger(t)
+			err := l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.SetFeeSchedule(ctx, tt.schedule)
+			})
+			if tt.err == "" {
+				requireNoError(t, err)
+				return
+			}
+			requireError(t, err, tt.err)
+		})
+	}
+}
+
+func TestComputeFeeBreakdown(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	// createTestCar 登记的车型为 Model S，不属于任何类别
+	defaultCarID := createTestCar(t, l, "京A00001", "dealer")
+	var nevCarID string
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		nevCarID, err = testContract.CreateCar(ctx, "京A00002", "Model 3", "LSVAB900000000002", "dealer")
+		return err
+	}))
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetFeeSchedule(ctx, `{"rules":[`+
+			`{"code":"PLATFORM_FEE","kind":"FEE","payer":"SELLER","percent":1,"minimum":0},`+
+			`{"code":"PURCHASE_TAX","kind":"TAX","payer":"BUYER","percent":10,"minimum":0},`+
+			`{"code":"PLATFORM_FEE","kind":"FEE","payer":"SELLER","modelClass":"NEV","percent":1,"minimum":5},`+
+			`{"code":"PURCHASE_TAX","kind":"TAX","payer":"BUYER","modelClass":"NEV","percent":0,"minimum":0}],`+
+			`"modelClasses":[{"class":"NEV","models":["model 3"]}],"feeAccount":"platform","taxAccount":"tax"}`)
+	}))
+
+	type feeAmount struct {
+		code   string
+		carID  string
+		amount float64
+	}
+	tests := []struct {
+		name             string
+		items            []*BundleItem
+		netAmount        float64
+		fees             []feeAmount
+		buyerPayable     float64
+		sellerReceivable float64
+	}{
+		{
+			name:             "半分按分四舍五入",
+			items:            []*BundleItem{{CarID: defaultCarID, Price: 100.5}},
+			netAmount:        100.5,
+			fees:             []feeAmount{{"PLATFORM_FEE", defaultCarID, 1.01}, {"PURCHASE_TAX", defaultCarID, 10.05}},
+			buyerPayable:     110.55,
+			sellerReceivable: 99.49,
+		},
+		{
+			name:             "车型类别规则覆盖默认规则，不足最低金额按最低金额收取，金额为0的不计入",
+			items:            []*BundleItem{{CarID: nevCarID, Price: 100}},
+			netAmount:        100,
+			fees:             []feeAmount{{"PLATFORM_FEE", nevCarID, 5}},
+			buyerPayable:     100,
+			sellerReceivable: 95,
+		},
+		{
+			name:             "打包交易逐车计费",
+			items:            []*BundleItem{{CarID: defaultCarID, Price: 1000}, {CarID: nevCarID, Price: 2000}},
+			netAmount:        2700,
+			fees:             []feeAmount{{"PLATFORM_FEE", defaultCarID, 10}, {"PURCHASE_TAX", defaultCarID, 100}, {"PLATFORM_FEE", nevCarID, 20}},
+			buyerPayable:     2800,
+			sellerReceivable: 2670,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var breakdown *FeeBreakdown
+			requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+				breakdown, err = testContract.computeFeeBreakdown(ctx, tt.items, tt.netAmount)
+				return err
+			}))
+			got := make([]feeAmount, 0, len(breakdown.Items))
+			for _, item := range breakdown.Items {
+				got = append(got, feeAmount{item.Code, item.CarID, item.Amount})
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.fees) {
+				t.Fatalf("费用明细为 %v，应为 %v", got, tt.fees)
+			}
+			if breakdown.BuyerPayable != tt.buyerPayable || breakdown.SellerReceivable != tt.sellerReceivable {
+				t.Fatalf("买家应付 %v、卖家实收 %v，应为 %v、%v", breakdown.BuyerPayable, breakdown.SellerReceivable, tt.buyerPayable, tt.sellerReceivable)
+			}
+		})
+	}
+
+	// 费用明细随交易保存，之后修改费用表不影响已生成的交易
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, "T1", defaultCarID, "dealer", "alice", 100.5)
+	}))
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetFeeSchedule(ctx, `{}`)
+	}))
+	var transaction *Transaction
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transaction, err = testContract.QueryTransaction(ctx, "T1")
+		return err
+	}))
+	if transaction.Fees == nil || transaction.Fees.BuyerPayable != 110.55 || len(transaction.Fees.Items) != 2 {
+		t.Fatalf("交易的费用明细不应随费用表变更：%+v", transaction.Fees)
+	}
+}
+
+func TestRoundFeeCents(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   float64
+	}{
+		{1.005, 1.01},
+		{0.1 + 0.2, 0.3},
+		{2.675, 2.68},
+		{1.004, 1},
+		{-1.005, -1.01},
+	}
+	for _, tt := range tests {
+		if got := roundFeeCents(tt.amount); got != tt.want {
+			t.Errorf("roundFeeCents(%v) = %v，应为 %v", tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// transactionRule 链码函数的调用规则，由合约的前置钩子在执行函数前统一校验
+type transactionRule struct {
+	orgs      []string // 允许调用的组织 MSP ID，为空表示不限组织
+	action    string   // 权限不足时提示的操作，如"创建汽车信息"
+	adminOnly bool     // 仅组织管理员可以调用
+	auditOnly bool     // 仅审计人员和监管机构组织成员可以调用
+	required  []string // 按参数顺序给出必填字符串参数的名称，空字符串表示该参数不做非空校验
+}
+
+// orgNames 组织 MSP ID 对应的组织名称，用于权限错误提示
+var orgNames = map[string]string{
+	CAR_DEALER_ORG_MSPID: "汽车经销商",
+	BANK_ORG_MSPID:       "银行",
+	TRADE_ORG_MSPID:      "交易平台",
+	REGULATOR_ORG_MSPID:  "监管机构",
+	INSURER_ORG_MSPID:    "保险公司",
+	APPRAISER_ORG_MSPID:  "第三方评估机构",
+}
+
+// transactionName 从调用的函数名中去掉合约名前缀，并与 contractapi 一致地将首字母转为大写
+func transactionName(function string) string {
+	name := []rune(function[strings.LastIndex(function, ":")+1:])
+	if len(name) > 0 {
+		name[0] = unicode.ToUpper(name[0])
+	}
+	return string(name)
+}
+
+// beforeTransaction 前置钩子：记录调用日志，拒绝审计人员的写操作，再按函数的调用规则校验权限和参数
+func (c *namedContract) beforeTransaction(ctx contractapi.TransactionContextInterface) error {
+	function, params := ctx.GetStub().GetFunctionAndParameters()
+	name := transactionName(function)
+	log.Printf("调用 %s:%s（调用者：%s，TxID：%s）", c.Name, name, callerDescription(ctx), ctx.GetStub().GetTxID())
+
+	rule, ok := c.transactions[name]
+	if !ok {
+		// 未知函数交由 unknownTransaction 处理
+		return nil
+	}
+	if err := c.denyAuditorWrites(ctx); err != nil {
+		return err
+	}
+	if err := c.authorize(ctx, rule); err != nil {
+		return err
+	}
+	for i, label := range rule.required {
+		if len(label) > 0 && i < len(params) && len(params[i]) == 0 {
+			return fmt.Errorf("%s不能为空", label)
+		}
+	}
+	return nil
+}
+
+// afterTransaction 后置钩子：函数执行成功后记录日志（失败的调用不会进入后置钩子，由 routedChaincode 记录）
+func (c *namedContract) afterTransaction(ctx contractapi.TransactionContextInterface, _ interface{}) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	log.Printf("完成 %s:%s（TxID：%s）", c.Name, transactionName(function), ctx.GetStub().GetTxID())
+	return nil
+}
+
+// unknownTransaction 调用了合约中不存在的函数
+func (c *namedContract) unknownTransaction(ctx contractapi.TransactionContextInterface) error {
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	return fmt.Errorf("合约 %s 中不存在函数 %s", c.Name, transactionName(function))
+}
+
+// authorize 按调用规则校验调用者的组织、管理员身份和审计权限
+func (c *namedContract) authorize(ctx contractapi.TransactionContextInterface, rule transactionRule) error {
+	if len(rule.orgs) > 0 {
+		clientMSPID, err := c.getClientIdentityMSPID(ctx)
+		if err != nil {
+			return fmt.Errorf("获取调用者身份失败：%v", err)
+		}
+		allowed := false
+		names := make([]string, 0, len(rule.orgs))
+		for _, org := range rule.orgs {
+			allowed = allowed || clientMSPID == org
+			names = append(names, orgNames[org])
+		}
+		if !allowed {
+			return fmt.Errorf("只有%s组织成员才能%s", strings.Join(names, "或"), rule.action)
+		}
+	}
+	if rule.adminOnly {
+		isAdmin, err := c.isClientAdmin(ctx)
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			return fmt.Errorf("只有组织管理员才能%s", rule.action)
+		}
+	}
+	if rule.auditOnly {
+		return c.checkAuditAccess(ctx)
+	}
+	return nil
+}
+
+// callerDescription 返回调用者的组织和证书通用名，用于调用日志
+func callerDescription(ctx contractapi.TransactionContextInterface) string {
+	clientID, err := cid.New(ctx.GetStub())
+	if err != nil {
+		return "未知身份"
+	}
+	mspID, err := clientID.GetMSPID()
+	if err != nil {
+		return "未知身份"
+	}
+	cert, err := clientID.GetX509Certificate()
+	if err != nil || cert == nil {
+		return mspID
+	}
+	return mspID + "/" + cert.Subject.CommonName
+}
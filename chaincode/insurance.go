@@ -0,0 +1,473 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// PolicyStatus 保单状态
+type PolicyStatus string
+
+const (
+	POLICY_ACTIVE PolicyStatus = "ACTIVE" // 有效
+	POLICY_LAPSED PolicyStatus = "LAPSED" // 已失效（车辆过户后终止）
+)
+
+// PolicyOwnerChangeRule 车辆所有权变更时保单的处理规则
+type PolicyOwnerChangeRule string
+
+const (
+	POLICY_RULE_LAPSE    PolicyOwnerChangeRule = "LAPSE"    // 保单随原车主终止
+	POLICY_RULE_TRANSFER PolicyOwnerChangeRule = "TRANSFER" // 保单随车转给新车主
+)
+
+// policyTransferRuleKey 默认保单过户规则在 CONFIG 中的键
+const policyTransferRuleKey = "POLICY_TRANSFER_RULE"
+
+// ClaimStatus 理赔状态
+type ClaimStatus string
+
+const (
+	CLAIM_FILED    ClaimStatus = "FILED"    // 已报案
+	CLAIM_APPROVED ClaimStatus = "APPROVED" // 已核赔
+	CLAIM_REJECTED ClaimStatus = "REJECTED" // 已拒赔
+	CLAIM_PAID     ClaimStatus = "PAID"     // 已赔付
+)
+
+// DamageLevel 损伤程度
+type DamageLevel string
+
+const (
+	DAMAGE_MINOR      DamageLevel = "MINOR"      // 轻微
+	DAMAGE_MODERATE   DamageLevel = "MODERATE"   // 中度
+	DAMAGE_SEVERE     DamageLevel = "SEVERE"     // 严重
+	DAMAGE_TOTAL_LOSS DamageLevel = "TOTAL_LOSS" // 全损
+)
+
+// Policy 保单信息
+type Policy struct {
+	PolicyNo       string                `json:"policyNo"`       // 保单号
+	CarID          string                `json:"carId"`          // 汽车ID
+	InsurerMSPID   string                `json:"insurerMspId"`   // 承保保险公司组织
+	InsuredParty   string                `json:"insuredParty"`   // 被保险人（参与方ID）
+	CoverageType   string                `json:"coverageType"`   // 险种，例如 交强险、商业险
+	CoverageAmount float64               `json:"coverageAmount"` // 保险金额
+	ValidFrom      time.Time             `json:"validFrom"`      // 保险起期
+	ValidUntil     time.Time             `json:"validUntil"`     // 保险止期
+	OnOwnerChange  PolicyOwnerChangeRule `json:"onOwnerChange"`  // 过户处理规则（为空时使用默认规则）
+	Status         PolicyStatus          `json:"status"`         // 保单状态
+	Remark         string                `json:"remark"`         // 状态变更说明
+	CreateTime     time.Time             `json:"createTime"`     // 登记时间
+	UpdateTime     time.Time             `json:"updateTime"`     // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// Claim 理赔记录，同时构成车辆的损伤历史
+type Claim struct {
+	ClaimID      string      `json:"claimId"`      // 理赔编号
+	PolicyNo     string      `json:"policyNo"`     // 保单号
+	CarID        string      `json:"carId"`        // 汽车ID
+	IncidentTime time.Time   `json:"incidentTime"` // 出险时间
+	DamageLevel  DamageLevel `json:"damageLevel"`  // 损伤程度
+	Description  string      `json:"description"`  // 事故及损伤描述
+	ClaimAmount  float64     `json:"claimAmount"`  // 报损金额
+	PaidAmount   float64     `json:"paidAmount"`   // 实际赔付金额
+	Status       ClaimStatus `json:"status"`       // 理赔状态
+	CreateTime   time.Time   `json:"createTime"`   // 报案登记时间
+	UpdateTime   time.Time   `json:"updateTime"`   // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// PolicyTransferRuleConfig 默认保单过户规则
+type PolicyTransferRuleConfig struct {
+	Rule       PolicyOwnerChangeRule `json:"rule"`       // 过户处理规则
+	UpdateTime time.Time             `json:"updateTime"` // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// RegisterPolicy 登记保单（仅保险公司组织可以调用）
+// validFrom/validUntil 为 RFC3339 格式；onOwnerChange 为 LAPSE/TRANSFER，为空时使用默认规则
+func (s *SmartContract) RegisterPolicy(ctx contractapi.TransactionContextInterface, policyNo string, carID string, insuredParty string, coverageType string, coverageAmount float64, validFrom string, validUntil string, onOwnerChange string) error {
+	insurerMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+
+	if coverageAmount <= 0 {
+		return fmt.Errorf("保险金额必须大于0")
+	}
+	rule := PolicyOwnerChangeRule(onOwnerChange)
+	if rule != "" && rule != POLICY_RULE_LAPSE && rule != POLICY_RULE_TRANSFER {
+		return fmt.Errorf("无效的过户处理规则: %s，应为 LAPSE 或 TRANSFER", onOwnerChange)
+	}
+	from, err := time.Parse(time.RFC3339, validFrom)
+	if err != nil {
+		return fmt.Errorf("保险起期格式错误，应为 RFC3339 格式：%v", err)
+	}
+	until, err := time.Parse(time.RFC3339, validUntil)
+	if err != nil {
+		return fmt.Errorf("保险止期格式错误，应为 RFC3339 格式：%v", err)
+	}
+	if !until.After(from) {
+		return fmt.Errorf("保险止期必须晚于保险起期")
+	}
+
+	if _, _, err := s.getCar(ctx, carID); err != nil {
+		return err
+	}
+	if _, err := s.requireParty(ctx, insuredParty); err != nil {
+		return fmt.Errorf("被保险人%v", err)
+	}
+
+	indexKey, err := s.getCompositeKey(ctx, POLICY_NO_INDEX, []string{policyNo})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return fmt.Errorf("查询保单号索引失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("保单号 %s 已存在", policyNo)
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	policy := Policy{
+		PolicyNo:       policyNo,
+		CarID:          carID,
+		InsurerMSPID:   insurerMSPID,
+		InsuredParty:   insuredParty,
+		CoverageType:   coverageType,
+		CoverageAmount: coverageAmount,
+		ValidFrom:      from.UTC(),
+		ValidUntil:     until.UTC(),
+		OnOwnerChange:  rule,
+		Status:         POLICY_ACTIVE,
+		CreateTime:     createTime,
+		UpdateTime:     createTime,
+	}
+
+	policyKey, err := s.getCompositeKey(ctx, POLICY, []string{carID, policyNo})
+	if err != nil {
+		return err
+	}
+	err = s.putState(ctx, policyKey, policy)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(indexKey, []byte(carID))
+	if err != nil {
+		return fmt.Errorf("保存保单号索引失败：%v", err)
+	}
+	return nil
+}
+
+// getPolicy 通过保单号查询保单及其复合键
+func (s *SmartContract) getPolicy(ctx contractapi.TransactionContextInterface, policyNo string) (*Policy, string, error) {
+	indexKey, err := s.getCompositeKey(ctx, POLICY_NO_INDEX, []string{policyNo})
+	if err != nil {
+		return nil, "", err
+	}
+	carID, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("查询保单号索引失败：%v", err)
+	}
+	if carID == nil {
+		return nil, "", fmt.Errorf("保单号 %s 不存在", policyNo)
+	}
+
+	policyKey, err := s.getCompositeKey(ctx, POLICY, []string{string(carID), policyNo})
+	if err != nil {
+		return nil, "", err
+	}
+	var policy Policy
+	err = s.getState(ctx, policyKey, &policy)
+	if err != nil {
+		return nil, "", err
+	}
+	return &policy, policyKey, nil
+}
+
+// QueryPolicy 通过保单号查询保单
+func (s *SmartContract) QueryPolicy(ctx contractapi.TransactionContextInterface, policyNo string) (*Policy, error) {
+	policy, _, err := s.getPolicy(ctx, policyNo)
+	return policy, err
+}
+
+// QueryCarPolicies 查询汽车的全部保单（按保险起期升序）
+func (s *SmartContract) QueryCarPolicies(ctx contractapi.TransactionContextInterface, carID string) ([]*Policy, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(POLICY, []string{carID})
+	if err != nil {
+		return nil, fmt.Errorf("查询保单失败：%v", err)
+	}
+	defer iterator.Close()
+
+	policies := make([]*Policy, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var policy Policy
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &policy)
+		if err != nil {
+			return nil, fmt.Errorf("解析保单失败：%v", err)
+		}
+		policies = append(policies, &policy)
+	}
+
+	sort.SliceStable(policies, func(i, j int) bool {
+		return policies[i].ValidFrom.Before(policies[j].ValidFrom)
+	})
+
+	return policies, nil
+}
+
+// LogClaim 登记理赔（仅承保该保单的保险公司可以调用），incidentTime 为 RFC3339 格式
+func (s *SmartContract) LogClaim(ctx contractapi.TransactionContextInterface, claimID string, policyNo string, incidentTime string, damageLevel string, description string, claimAmount float64) error {
+	insurerMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+
+	switch DamageLevel(damageLevel) {
+	case DAMAGE_MINOR, DAMAGE_MODERATE, DAMAGE_SEVERE, DAMAGE_TOTAL_LOSS:
+	default:
+		return fmt.Errorf("无效的损伤程度: %s", damageLevel)
+	}
+	if claimAmount < 0 {
+		return fmt.Errorf("报损金额不能小于0")
+	}
+	incident, err := time.Parse(time.RFC3339, incidentTime)
+	if err != nil {
+		return fmt.Errorf("出险时间格式错误，应为 RFC3339 格式：%v", err)
+	}
+
+	policy, _, err := s.getPolicy(ctx, policyNo)
+	if err != nil {
+		return err
+	}
+	if policy.InsurerMSPID != insurerMSPID {
+		return fmt.Errorf("保单 %s 不是当前保险公司承保", policyNo)
+	}
+	if incident.Before(policy.ValidFrom) || !incident.Before(policy.ValidUntil) {
+		return fmt.Errorf("出险时间不在保单 %s 的保险期间内", policyNo)
+	}
+
+	claimKey, err := s.getCompositeKey(ctx, CLAIM, []string{policy.CarID, claimID})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(claimKey)
+	if err != nil {
+		return fmt.Errorf("查询理赔记录失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("理赔编号 %s 已存在", claimID)
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	claim := Claim{
+		ClaimID:      claimID,
+		PolicyNo:     policyNo,
+		CarID:        policy.CarID,
+		IncidentTime: incident.UTC(),
+		DamageLevel:  DamageLevel(damageLevel),
+		Description:  description,
+		ClaimAmount:  claimAmount,
+		Status:       CLAIM_FILED,
+		CreateTime:   createTime,
+		UpdateTime:   createTime,
+	}
+	return s.putState(ctx, claimKey, claim)
+}
+
+// UpdateClaimStatus 更新理赔状态（仅承保该保单的保险公司可以调用），赔付时记录实际赔付金额
+func (s *SmartContract) UpdateClaimStatus(ctx contractapi.TransactionContextInterface, carID string, claimID string, status string, paidAmount float64) error {
+	insurerMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+
+	claimStatus := ClaimStatus(status)
+	switch claimStatus {
+	case CLAIM_FILED, CLAIM_APPROVED, CLAIM_REJECTED, CLAIM_PAID:
+	default:
+		return fmt.Errorf("无效的理赔状态: %s", status)
+	}
+	if paidAmount < 0 {
+		return fmt.Errorf("赔付金额不能小于0")
+	}
+
+	claimKey, err := s.getCompositeKey(ctx, CLAIM, []string{carID, claimID})
+	if err != nil {
+		return err
+	}
+	var claim Claim
+	err = s.getState(ctx, claimKey, &claim)
+	if err != nil {
+		return fmt.Errorf("理赔编号 %s 不存在", claimID)
+	}
+
+	policy, _, err := s.getPolicy(ctx, claim.PolicyNo)
+	if err != nil {
+		return err
+	}
+	if policy.InsurerMSPID != insurerMSPID {
+		return fmt.Errorf("保单 %s 不是当前保险公司承保", claim.PolicyNo)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	claim.Status = claimStatus
+	if claimStatus == CLAIM_PAID {
+		claim.PaidAmount = paidAmount
+	}
+	claim.UpdateTime = updateTime
+
+	return s.putState(ctx, claimKey, claim)
+}
+
+// QueryDamageHistory 查询汽车的损伤历史（全部理赔记录，按出险时间升序）
+func (s *SmartContract) QueryDamageHistory(ctx contractapi.TransactionContextInterface, carID string) ([]*Claim, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(CLAIM, []string{carID})
+	if err != nil {
+		return nil, fmt.Errorf("查询理赔记录失败：%v", err)
+	}
+	defer iterator.Close()
+
+	claims := make([]*Claim, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var claim Claim
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &claim)
+		if err != nil {
+			return nil, fmt.Errorf("解析理赔记录失败：%v", err)
+		}
+		claims = append(claims, &claim)
+	}
+
+	sort.SliceStable(claims, func(i, j int) bool {
+		return claims[i].IncidentTime.Before(claims[j].IncidentTime)
+	})
+
+	return claims, nil
+}
+
+// SetPolicyTransferRule 设置车辆过户时保单的默认处理规则（仅保险公司组织可以调用）
+func (s *SmartContract) SetPolicyTransferRule(ctx contractapi.TransactionContextInterface, rule string) error {
+	transferRule := PolicyOwnerChangeRule(rule)
+	if transferRule != POLICY_RULE_LAPSE && transferRule != POLICY_RULE_TRANSFER {
+		return fmt.Errorf("无效的过户处理规则: %s，应为 LAPSE 或 TRANSFER", rule)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	configKey, err := s.getCompositeKey(ctx, CONFIG, []string{policyTransferRuleKey})
+	if err != nil {
+		return err
+	}
+	return s.putState(ctx, configKey, PolicyTransferRuleConfig{Rule: transferRule, UpdateTime: updateTime})
+}
+
+// QueryPolicyTransferRule 查询车辆过户时保单的默认处理规则，未设置时为 LAPSE
+func (s *SmartContract) QueryPolicyTransferRule(ctx contractapi.TransactionContextInterface) (*PolicyTransferRuleConfig, error) {
+	configKey, err := s.getCompositeKey(ctx, CONFIG, []string{policyTransferRuleKey})
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(configKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询保单过户规则失败：%v", err)
+	}
+	if bytes == nil {
+		return &PolicyTransferRuleConfig{Rule: POLICY_RULE_LAPSE}, nil
+	}
+
+	var config PolicyTransferRuleConfig
+	err = s.unmarshalState(ctx, configKey, bytes, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// applyPolicyOwnerChange 车辆所有权变更后按规则处理仍在保险期间内的有效保单：转给新车主或终止
+func (s *SmartContract) applyPolicyOwnerChange(ctx contractapi.TransactionContextInterface, carID string, newOwner string, changeTime time.Time) error {
+	policies, err := s.QueryCarPolicies(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	defaultRule, err := s.QueryPolicyTransferRule(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if policy.Status != POLICY_ACTIVE || !changeTime.Before(policy.ValidUntil) {
+			continue
+		}
+
+		rule := policy.OnOwnerChange
+		if rule == "" {
+			rule = defaultRule.Rule
+		}
+		if rule == POLICY_RULE_TRANSFER {
+			policy.Remark = fmt.Sprintf("车辆过户，被保险人由 %s 变更为 %s", policy.InsuredParty, newOwner)
+			policy.InsuredParty = newOwner
+		} else {
+			policy.Status = POLICY_LAPSED
+			policy.Remark = fmt.Sprintf("车辆过户给 %s，保单终止", newOwner)
+		}
+		policy.UpdateTime = changeTime
+
+		policyKey, err := s.getCompositeKey(ctx, POLICY, []string{carID, policy.PolicyNo})
+		if err != nil {
+			return err
+		}
+		err = s.putState(ctx, policyKey, policy)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const (
+	testPolicyFrom  = "2025-06-01T00:00:00Z"
+	testPolicyUntil = "2026-06-01T00:00:00Z"
+)
+
+// registerTestPolicy 由保险公司组织为汽车登记保单
+func registerTestPolicy(t *testing.T, l *mockLedger, policyNo string, carID string, insured string, validUntil string, rule PolicyOwnerChangeRule) {
+	t.Helper()
+	requireNoError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterPolicy(ctx, policyNo, carID, insured, "商业险", 100000, testPolicyFrom, validUntil, string(rule))
+	}))
+}
+
+func queryTestPolicy(t *testing.T, l *mockLedger, policyNo string) *Policy {
+	t.Helper()
+	var policy *Policy
+	requireNoError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		policy, err = testContract.QueryPolicy(ctx, policyNo)
+		return err
+	}))
+	return policy
+}
+
+func TestRegisterPolicy(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	registerTestPolicy(t, l, "P1", carID, "dealer", testPolicyUntil, "")
+
+	tests := []struct {
+		name       string
+		policyNo   string
+		carID      string
+		insured    string
+		amount     float64
+		validFrom  string
+		validUntil string
+		rule       string
+		err        string
+	}{
+		{"保险金额为0", "P2", carID, "dealer", 0, testPolicyFrom, testPolicyUntil, "", "保险金额必须大于0"},
+		{"过户规则无效", "P2", carID, "dealer", 1, testPolicyFrom, testPolicyUntil, "KEEP", "无效的过户处理规则"},
+		{"起期格式错误", "P2", carID, "dealer", 1, "2025-06-01", testPolicyUntil, "", "保险起期格式错误"},
+		{"止期早于起期", "P2", carID, "dealer", 1, testPolicyUntil, testPolicyFrom, "", "保险止期必须晚于保险起期"},
+		{"汽车不存在", "P2", "none", "dealer", 1, testPolicyFrom, testPolicyUntil, "", "none"},
+		{"被保险人未登记", "P2", carID, "nobody", 1, testPolicyFrom, testPolicyUntil, "", "被保险人参与方 nobody 未登记"},
+		{"保单号重复", "P1", carID, "dealer", 1, testPolicyFrom, testPolicyUntil, "", "保单号 P1 已存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.RegisterPolicy(ctx, tt.policyNo, tt.carID, tt.insured, "商业险", tt.amount, tt.validFrom, tt.validUntil, tt.rule)
+			}), tt.err)
+		})
+	}
+
+	if policy := queryTestPolicy(t, l, "P1"); policy.Status != POLICY_ACTIVE || policy.InsurerMSPID != INSURER_ORG_MSPID || policy.CarID != carID {
+		t.Fatalf("保单信息不正确：%+v", policy)
+	}
+}
+
+func TestPolicyOwnerChange(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	requireNoError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetPolicyTransferRule(ctx, string(POLICY_RULE_TRANSFER))
+	}))
+	registerTestPolicy(t, l, "LAPSE", carID, "dealer", testPolicyUntil, POLICY_RULE_LAPSE)
+	registerTestPolicy(t, l, "TRANSFER", carID, "dealer", testPolicyUntil, POLICY_RULE_TRANSFER)
+	registerTestPolicy(t, l, "DEFAULT", carID, "dealer", testPolicyUntil, "")
+	registerTestPolicy(t, l, "ENDED", carID, "dealer", "2025-12-01T00:00:00Z", POLICY_RULE_LAPSE)
+
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+
+	tests := []struct {
+		policyNo string
+		status   PolicyStatus
+		insured  string
+	}{
+		{"LAPSE", POLICY_LAPSED, "dealer"},
+		{"TRANSFER", POLICY_ACTIVE, "alice"},
+		{"DEFAULT", POLICY_ACTIVE, "alice"},
+		{"ENDED", POLICY_ACTIVE, "dealer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policyNo, func(t *testing.T) {
+			policy := queryTestPolicy(t, l, tt.policyNo)
+			if policy.Status != tt.status || policy.InsuredParty != tt.insured {
+				t.Fatalf("保单状态为 %s，被保险人为 %s，期望 %s %s", policy.Status, policy.InsuredParty, tt.status, tt.insured)
+			}
+		})
+	}
+}
+
+func TestClaims(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	registerTestPolicy(t, l, "P1", carID, "dealer", testPolicyUntil, "")
+
+	logClaim := func(mspID string, claimID string, incidentTime string, level DamageLevel, amount float64) error {
+		return l.call(mspID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.LogClaim(ctx, claimID, "P1", incidentTime, string(level), "追尾", amount)
+		})
+	}
+	requireNoError(t, logClaim(INSURER_ORG_MSPID, "C2", "2025-09-01T00:00:00Z", DAMAGE_SEVERE, 5000))
+	requireNoError(t, logClaim(INSURER_ORG_MSPID, "C1", "2025-07-01T08:00:00+08:00", DAMAGE_MINOR, 800))
+
+	tests := []struct {
+		name     string
+		mspID    string
+		claimID  string
+		incident string
+		level    DamageLevel
+		amount   float64
+		err      string
+	}{
+		{"损伤程度无效", INSURER_ORG_MSPID, "C3", "2025-07-01T00:00:00Z", "BROKEN", 1, "无效的损伤程度"},
+		{"报损金额为负", INSURER_ORG_MSPID, "C3", "2025-07-01T00:00:00Z", DAMAGE_MINOR, -1, "报损金额不能小于0"},
+		{"出险时间早于保险期间", INSURER_ORG_MSPID, "C3", "2025-05-31T23:59:59Z", DAMAGE_MINOR, 1, "不在保单 P1 的保险期间内"},
+		{"出险时间等于保险止期", INSURER_ORG_MSPID, "C3", testPolicyUntil, DAMAGE_MINOR, 1, "不在保单 P1 的保险期间内"},
+		{"非承保保险公司", BANK_ORG_MSPID, "C3", "2025-07-01T00:00:00Z", DAMAGE_MINOR, 1, "不是当前保险公司承保"},
+		{"理赔编号重复", INSURER_ORG_MSPID, "C1", "2025-07-01T00:00:00Z", DAMAGE_MINOR, 1, "理赔编号 C1 已存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, logClaim(tt.mspID, tt.claimID, tt.incident, tt.level, tt.amount), tt.err)
+		})
+	}
+
+	requireError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.UpdateClaimStatus(ctx, carID, "C2", "CLOSED", 0)
+	}), "无效的理赔状态")
+	requireNoError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.UpdateClaimStatus(ctx, carID, "C2", string(CLAIM_PAID), 4500)
+	}))
+
+	var claims []*Claim
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		claims, err = testContract.QueryDamageHistory(ctx, carID)
+		return err
+	}))
+	if len(claims) != 2 || claims[0].ClaimID != "C1" || claims[1].ClaimID != "C2" {
+		t.Fatalf("损伤历史应按出险时间排序：%+v", claims)
+	}
+	if claims[1].Status != CLAIM_PAID || claims[1].PaidAmount != 4500 || claims[0].IncidentTime.Location().String() != "UTC" {
+		t.Fatalf("理赔记录不正确：%+v %+v", claims[0], claims[1])
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// IssuerStatus 签发机构信任状态
+type IssuerStatus string
+
+const (
+	ISSUER_ACTIVE  IssuerStatus = "ACTIVE"  // 受信任
+	ISSUER_REVOKED IssuerStatus = "REVOKED" // 已撤销信任
+)
+
+// TrustedIssuer 受信任的证件签发机构（如车管所），以其 X.509 证书中的 ECDSA 公钥验证签名
+type TrustedIssuer struct {
+	ID          string       `json:"id"`          // 签发机构ID
+	Name        string       `json:"name"`        // 签发机构名称
+	CertPEM     string       `json:"certPem"`     // 签发机构 X.509 证书（PEM 格式）
+	Fingerprint string       `json:"fingerprint"` // 证书 SHA256 指纹
+	Subject     string       `json:"subject"`     // 证书主题
+	NotBefore   time.Time    `json:"notBefore"`   // 证书生效时间
+	NotAfter    time.Time    `json:"notAfter"`    // 证书失效时间
+	Status      IssuerStatus `json:"status"`      // 信任状态
+	Remark      string       `json:"remark"`      // 备注（撤销原因等）
+	CreateTime  time.Time    `json:"createTime"`  // 登记时间
+	UpdateTime  time.Time    `json:"updateTime"`  // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// RegisterTrustedIssuer 登记受信任的签发机构证书（仅监管机构组织可以调用）
+func (s *SmartContract) RegisterTrustedIssuer(ctx contractapi.TransactionContextInterface, issuerID string, name string, certPEM string) error {
+	issuerKey, err := s.getCompositeKey(ctx, ISSUER, []string{issuerID})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(issuerKey)
+	if err != nil {
+		return fmt.Errorf("查询签发机构信息失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("签发机构ID %s 已存在", issuerID)
+	}
+
+	x509Cert, err := parseIssuerCertificate(certPEM)
+	if err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if createTime.After(x509Cert.NotAfter) {
+		return fmt.Errorf("签发机构证书已于 %s 过期", x509Cert.NotAfter.Format(time.RFC3339))
+	}
+
+	fingerprint := sha256.Sum256(x509Cert.Raw)
+	issuer := TrustedIssuer{
+		ID:          issuerID,
+		Name:        name,
+		CertPEM:     certPEM,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		Subject:     x509Cert.Subject.String(),
+		NotBefore:   x509Cert.NotBefore,
+		NotAfter:    x509Cert.NotAfter,
+		Status:      ISSUER_ACTIVE,
+		CreateTime:  createTime,
+		UpdateTime:  createTime,
+	}
+
+	return s.putState(ctx, issuerKey, issuer)
+}
+
+// RevokeTrustedIssuer 撤销对签发机构的信任（仅监管机构组织可以调用）
+// 撤销后该机构签名的证书在查询和验证时均视为签名无效
+func (s *SmartContract) RevokeTrustedIssuer(ctx contractapi.TransactionContextInterface, issuerID string, remark string) error {
+	issuer, issuerKey, err := s.getTrustedIssuer(ctx, issuerID)
+	if err != nil {
+		return err
+	}
+	if issuer.Status == ISSUER_REVOKED {
+		return fmt.Errorf("签发机构 %s 已被撤销", issuerID)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	issuer.Status = ISSUER_REVOKED
+	issuer.Remark = remark
+	issuer.UpdateTime = updateTime
+
+	return s.putState(ctx, issuerKey, issuer)
+}
+
+// QueryTrustedIssuer 查询签发机构信息
+func (s *SmartContract) QueryTrustedIssuer(ctx contractapi.TransactionContextInterface, issuerID string) (*TrustedIssuer, error) {
+	issuer, _, err := s.getTrustedIssuer(ctx, issuerID)
+	return issuer, err
+}
+
+// QueryTrustedIssuers 查询全部签发机构
+func (s *SmartContract) QueryTrustedIssuers(ctx contractapi.TransactionContextInterface) ([]*TrustedIssuer, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(ISSUER, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("查询签发机构列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	issuers := make([]*TrustedIssuer, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var issuer TrustedIssuer
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &issuer)
+		if err != nil {
+			return nil, fmt.Errorf("解析签发机构信息失败：%v", err)
+		}
+		issuers = append(issuers, &issuer)
+	}
+
+	return issuers, nil
+}
+
+// getTrustedIssuer 读取签发机构信息
+func (s *SmartContract) getTrustedIssuer(ctx contractapi.TransactionContextInterface, issuerID string) (*TrustedIssuer, string, error) {
+	if len(issuerID) == 0 {
+		return nil, "", fmt.Errorf("签发机构ID不能为空")
+	}
+	issuerKey, err := s.getCompositeKey(ctx, ISSUER, []string{issuerID})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var issuer TrustedIssuer
+	err = s.getState(ctx, issuerKey, &issuer)
+	if err != nil {
+		return nil, "", fmt.Errorf("签发机构 %s 不存在", issuerID)
+	}
+	return &issuer, issuerKey, nil
+}
+
+// parseIssuerCertificate 解析 PEM 格式的签发机构证书，要求公钥为 ECDSA
+func parseIssuerCertificate(certPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("签发机构证书不是有效的 PEM 格式")
+	}
+	x509Cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析签发机构证书失败：%v", err)
+	}
+	if _, ok := x509Cert.PublicKey.(*ecdsa.PublicKey); !ok {
+		return nil, fmt.Errorf("签发机构证书的公钥必须为 ECDSA")
+	}
+	return x509Cert, nil
+}
+
+// verifyIssuerSignature 使用受信任签发机构的公钥验证证书文件哈希上的分离签名
+// 签名为对文件 SHA256 摘要的 ASN.1 DER 编码 ECDSA 签名（Base64），
+// 与 `openssl dgst -sha256 -sign key.pem file` 对原始文件的签名等价；
+// at 为判断签发机构证书有效期所用的时间
+func (s *SmartContract) verifyIssuerSignature(ctx contractapi.TransactionContextInterface, cert *Certificate, at time.Time) (*TrustedIssuer, error) {
+	issuer, _, err := s.getTrustedIssuer(ctx, cert.IssuerID)
+	if err != nil {
+		return nil, err
+	}
+	if issuer.Status != ISSUER_ACTIVE {
+		return issuer, fmt.Errorf("签发机构 %s 已被撤销信任", issuer.ID)
+	}
+
+	x509Cert, err := parseIssuerCertificate(issuer.CertPEM)
+	if err != nil {
+		return issuer, err
+	}
+	if at.Before(x509Cert.NotBefore) || at.After(x509Cert.NotAfter) {
+		return issuer, fmt.Errorf("签发机构 %s 的证书在 %s 不在有效期内", issuer.ID, at.Format(time.RFC3339))
+	}
+
+	digest, err := hex.DecodeString(cert.FileHash)
+	if err != nil || len(digest) != sha256.Size {
+		return issuer, fmt.Errorf("文件哈希必须为 64 位十六进制 SHA256 值")
+	}
+	signature, err := base64.StdEncoding.DecodeString(cert.Signature)
+	if err != nil {
+		return issuer, fmt.Errorf("签名不是有效的 Base64 编码：%v", err)
+	}
+
+	if !ecdsa.VerifyASN1(x509Cert.PublicKey.(*ecdsa.PublicKey), digest, signature) {
+		return issuer, fmt.Errorf("签名与签发机构 %s 的证书不匹配", issuer.ID)
+	}
+	return issuer, nil
+}
+
+// fillSignatureStatus 查询时填充证书的签名状态：签发机构撤销或签名失效时 SignatureValid 为 false
+// 签名时点按证书上传时间判断签发机构证书的有效期
+func (s *SmartContract) fillSignatureStatus(ctx contractapi.TransactionContextInterface, cert *Certificate) {
+	if cert.Signature == "" {
+		return
+	}
+	issuer, err := s.verifyIssuerSignature(ctx, cert, cert.UploadTime)
+	if issuer != nil {
+		cert.IssuerName = issuer.Name
+	}
+	cert.SignatureValid = err == nil
+	if err != nil {
+		cert.SignatureError = err.Error()
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// newTestIssuerCert 生成签发机构的自签名证书（PEM）
+func newTestIssuerCert(t *testing.T, publicKey interface{}, signer interface{}, notBefore time.Time, notAfter time.Time) string {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "车管所"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, publicKey, signer)
+	requireNoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// signedCertificateJSON 生成由签发机构私钥签名的证书 JSON
+func signedCertificateJSON(t *testing.T, certID string, carID string, issuerID string, key *ecdsa.PrivateKey, content string) string {
+	t.Helper()
+	digest := sha256.Sum256([]byte(content))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	requireNoError(t, err)
+	data, err := json.Marshal(Certificate{
+		CertID:       certID,
+		CarID:        carID,
+		CertType:     "REGISTRATION",
+		FileHash:     hex.EncodeToString(digest[:]),
+		FileLocation: carID + "/" + certID + ".pdf",
+		IssuerID:     issuerID,
+		Signature:    base64.StdEncoding.EncodeToString(signature),
+	})
+	requireNoError(t, err)
+	return string(data)
+}
+
+func TestRegisterTrustedIssuer(t *testing.T) {
+	l := newMockLedger(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	requireNoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	requireNoError(t, err)
+
+	validPEM := newTestIssuerCert(t, &key.PublicKey, key, l.now.AddDate(-1, 0, 0), l.now.AddDate(1, 0, 0))
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterTrustedIssuer(ctx, "DMV", "车管所", validPEM)
+	}))
+
+	tests := []struct {
+		name    string
+		id      string
+		certPEM string
+		err     string
+	}{
+		{"签发机构ID重复", "DMV", validPEM, "签发机构ID DMV 已存在"},
+		{"不是 PEM 格式", "DMV2", "not a certificate", "不是有效的 PEM 格式"},
+		{"公钥不是 ECDSA", "DMV2", newTestIssuerCert(t, &rsaKey.PublicKey, rsaKey, l.now.AddDate(-1, 0, 0), l.now.AddDate(1, 0, 0)), "公钥必须为 ECDSA"},
+		{"证书已过期", "DMV2", newTestIssuerCert(t, &key.PublicKey, key, l.now.AddDate(-2, 0, 0), l.now.AddDate(-1, 0, 0)), "签发机构证书已于"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.RegisterTrustedIssuer(ctx, tt.id, "车管所", tt.certPEM)
+			}), tt.err)
+		})
+	}
+
+	var issuer *TrustedIssuer
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		issuer, err = testContract.QueryTrustedIssuer(ctx, "DMV")
+		return err
+	}))
+	if issuer.Status != ISSUER_ACTIVE || len(issuer.Fingerprint) != 64 || issuer.Subject != "CN=车管所" {
+		t.Fatalf("签发机构信息不正确：%+v", issuer)
+	}
+}
+
+func TestCertificateIssuerSignature(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	requireNoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	requireNoError(t, err)
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterTrustedIssuer(ctx, "DMV", "车管所", newTestIssuerCert(t, &key.PublicKey, key, l.now.AddDate(-1, 0, 0), l.now.AddDate(1, 0, 0)))
+	}))
+
+	addCertificate := func(data string) error {
+		return l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.AddCertificate(ctx, data)
+		})
+	}
+	withoutIssuer := signedCertificateJSON(t, "C1", carID, "", key, "行驶证")
+	badBase64 := signedCertificateJSON(t, "C1", carID, "DMV", key, "行驶证")
+	var cert map[string]interface{}
+	requireNoError(t, json.Unmarshal([]byte(badBase64), &cert))
+	cert["signature"] = "!!!"
+	data, err := json.Marshal(cert)
+	requireNoError(t, err)
+	badBase64 = string(data)
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"只有签名没有签发机构", withoutIssuer, "签发机构ID与签名必须同时提供"},
+		{"签发机构未登记", signedCertificateJSON(t, "C1", carID, "NONE", key, "行驶证"), "签发机构 NONE 不存在"},
+		{"签名私钥不匹配", signedCertificateJSON(t, "C1", carID, "DMV", otherKey, "行驶证"), "签名与签发机构 DMV 的证书不匹配"},
+		{"签名不是 Base64", badBase64, "签名不是有效的 Base64 编码"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, addCertificate(tt.data), tt.err)
+		})
+	}
+
+	requireNoError(t, addCertificate(signedCertificateJSON(t, "C1", carID, "DMV", key, "行驶证")))
+	getCert := func() *Certificate {
+		var cert *Certificate
+		requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+			cert, err = testContract.GetCertificate(ctx, "C1")
+			return err
+		}))
+		return cert
+	}
+	if cert := getCert(); !cert.SignatureValid || cert.IssuerName != "车管所" {
+		t.Fatalf("签名应验证通过：%+v", cert)
+	}
+
+	// 撤销信任后，已上链证书的签名在查询时视为无效
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RevokeTrustedIssuer(ctx, "DMV", "私钥泄露")
+	}))
+	if cert := getCert(); cert.SignatureValid || cert.SignatureError != "签发机构 DMV 已被撤销信任" {
+		t.Fatalf("撤销信任后签名应无效：%+v", cert)
+	}
+	requireError(t, addCertificate(signedCertificateJSON(t, "C2", carID, "DMV", key, "检验合格证")), "已被撤销信任")
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// 单笔贷款的最大还款期数
+const MAX_LOAN_INSTALLMENTS = 120
+
+// LoanStatus 贷款状态
+type LoanStatus string
+
+const (
+	LOAN_ACTIVE      LoanStatus = "ACTIVE"      // 还款中
+	LOAN_SETTLED     LoanStatus = "SETTLED"     // 已结清
+	LOAN_DEFAULTED   LoanStatus = "DEFAULTED"   // 已违约
+	LOAN_REPOSSESSED LoanStatus = "REPOSSESSED" // 违约后汽车已收回
+)
+
+// LoanInstallment 还款计划中的一期
+type LoanInstallment struct {
+	Seq        int       `json:"seq"`        // 期数（从1开始）
+	DueDate    time.Time `json:"dueDate"`    // 应还日期
+	Amount     float64   `json:"amount"`     // 应还金额（本息合计）
+	PaidAmount float64   `json:"paidAmount"` // 已还金额
+	PaidTime   time.Time `json:"paidTime"`   // 还清时间（未还清时为零值）
+}
+
+// LoanPayment 还款记录
+type LoanPayment struct {
+	PaymentRef string    `json:"paymentRef"` // 银行还款凭证号
+	Amount     float64   `json:"amount"`     // 还款金额
+	PayTime    time.Time `json:"payTime"`    // 记账时间
+}
+
+// Loan 购车贷款：银行为交易买家提供融资，记录还款计划和还款流水
+type Loan struct {
+	ID            string             `json:"id"`                                           // 贷款ID
+	TransactionID string             `json:"transactionId"`                                // 关联的交易ID
+	CarID         string             `json:"carId"`                                        // 抵押汽车ID
+	Borrower      string             `json:"borrower"`                                     // 借款人（交易买家的参与方ID）
+	Principal     float64            `json:"principal"`                                    // 贷款本金
+	TotalDue      float64            `json:"totalDue"`                                     // 还款计划应还总额
+	PaidAmount    float64            `json:"paidAmount"`                                   // 累计已还金额
+	Outstanding   float64            `json:"outstanding"`                                  // 剩余应还金额
+	Schedule      []*LoanInstallment `json:"schedule"`                                     // 还款计划
+	Payments      []*LoanPayment     `json:"payments"`                                     // 还款流水
+	Status        LoanStatus         `json:"status"`                                       // 贷款状态
+	DefaultReason string             `json:"defaultReason,omitempty" metadata:",optional"` // 违约原因
+	RepossessedBy string             `json:"repossessedBy,omitempty" metadata:",optional"` // 收回汽车的银行参与方ID
+	CreateTime    time.Time          `json:"createTime"`                                   // 创建时间
+	UpdateTime    time.Time          `json:"updateTime"`                                   // 更新时间
+
+	OverdueAmount float64 `json:"overdueAmount,omitempty" metadata:",optional"` // 已到期未还的金额（仅查询时填充，不落账本）
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// CreateLoan 登记购车贷款及还款计划（仅银行组织可以调用）
+// scheduleJson 为还款计划：[{"dueDate": "2026-02-01T00:00:00Z", "amount": 1000}]，应还日期须逐期递增，应还总额不得低于本金
+func (s *SmartContract) CreateLoan(ctx contractapi.TransactionContextInterface, loanID string, txID string, principal float64, scheduleJson string) error {
+	if principal <= 0 {
+		return fmt.Errorf("贷款本金必须大于0")
+	}
+
+	var schedule []*LoanInstallment
+	if err := json.Unmarshal([]byte(scheduleJson), &schedule); err != nil {
+		return fmt.Errorf("解析还款计划失败：%v", err)
+	}
+	if len(schedule) == 0 {
+		return fmt.Errorf("还款计划至少包含一期")
+	}
+	if len(schedule) > MAX_LOAN_INSTALLMENTS {
+		return fmt.Errorf("还款计划最多 %d 期，当前 %d 期", MAX_LOAN_INSTALLMENTS, len(schedule))
+	}
+	totalDue := 0.0
+	for i, installment := range schedule {
+		if installment == nil || installment.DueDate.IsZero() {
+			return fmt.Errorf("第 %d 期的应还日期不能为空", i+1)
+		}
+		if i > 0 && !installment.DueDate.After(schedule[i-1].DueDate) {
+			return fmt.Errorf("第 %d 期的应还日期必须晚于上一期", i+1)
+		}
+		if installment.Amount <= 0 {
+			return fmt.Errorf("第 %d 期的应还金额必须大于0", i+1)
+		}
+		installment.Seq = i + 1
+		installment.Amount = roundCents(installment.Amount)
+		installment.PaidAmount = 0
+		installment.PaidTime = time.Time{}
+		totalDue += installment.Amount
+	}
+	totalDue = roundCents(totalDue)
+	if totalDue < roundCents(principal) {
+		return fmt.Errorf("还款计划应还总额 %.2f 低于贷款本金 %.2f", totalDue, principal)
+	}
+
+	if _, _, err := s.getLoan(ctx, loanID); err == nil {
+		return fmt.Errorf("贷款ID %s 已存在", loanID)
+	}
+
+	transaction, err := s.QueryTransaction(ctx, txID)
+	if err != nil {
+		return err
+	}
+	// 贷款以汽车作抵押，交易完成、汽车过户给买家后才能登记
+	if transaction.Status != COMPLETED {
+		return fmt.Errorf("交易 %s 当前状态为 %s，只能为已完成的交易登记贷款", txID, transaction.Status)
+	}
+	items := transaction.soldItems()
+	if len(items) != 1 {
+		return fmt.Errorf("贷款只能关联购买单辆汽车的交易")
+	}
+	if principal > transaction.NetAmount {
+		return fmt.Errorf("贷款本金 %.2f 不能超过交易应付金额 %.2f", principal, transaction.NetAmount)
+	}
+	carID := items[0].CarID
+	car, _, err := s.getCar(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if car.CurrentOwner != transaction.Buyer {
+		return fmt.Errorf("汽车 %s 已不在买家 %s 名下，不能登记贷款", carID, transaction.Buyer)
+	}
+
+	// 同一辆汽车只能有一笔未结清的贷款
+	if err := s.checkNoActiveLoan(ctx, carID); err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	loan := &Loan{
+		ID:            loanID,
+		TransactionID: txID,
+		CarID:         carID,
+		Borrower:      transaction.Buyer,
+		Principal:     roundCents(principal),
+		TotalDue:      totalDue,
+		Outstanding:   totalDue,
+		Schedule:      schedule,
+		Payments:      make([]*LoanPayment, 0),
+		Status:        LOAN_ACTIVE,
+		CreateTime:    createTime,
+		UpdateTime:    createTime,
+	}
+	return s.putLoan(ctx, loan, "")
+}
+
+// RecordLoanPayment 记录一笔还款（仅银行组织可以调用）
+// 还款按期数顺序冲抵未还金额，不能超过剩余应还金额；还清后贷款自动结清（违约后还清同样视为结清）
+func (s *SmartContract) RecordLoanPayment(ctx contractapi.TransactionContextInterface, loanID string, paymentRef string, amount float64) error {
+	amount = roundCents(amount)
+	if amount <= 0 {
+		return fmt.Errorf("还款金额必须大于0")
+	}
+
+	loan, loanKey, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return err
+	}
+	if loan.Status != LOAN_ACTIVE && loan.Status != LOAN_DEFAULTED {
+		return fmt.Errorf("贷款 %s 当前状态为 %s，不能记录还款", loanID, loan.Status)
+	}
+	for _, payment := range loan.Payments {
+		if payment.PaymentRef == paymentRef {
+			return fmt.Errorf("还款凭证号 %s 已记录", paymentRef)
+		}
+	}
+	if amount > loan.Outstanding {
+		return fmt.Errorf("还款金额 %.2f 超过剩余应还金额 %.2f", amount, loan.Outstanding)
+	}
+
+	payTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	remaining := amount
+	for _, installment := range loan.Schedule {
+		if remaining <= 0 {
+			break
+		}
+		unpaid := roundCents(installment.Amount - installment.PaidAmount)
+		if unpaid <= 0 {
+			continue
+		}
+		applied := math.Min(unpaid, remaining)
+		installment.PaidAmount = roundCents(installment.PaidAmount + applied)
+		remaining = roundCents(remaining - applied)
+		if installment.PaidAmount >= installment.Amount {
+			installment.PaidTime = payTime
+		}
+	}
+
+	loan.Payments = append(loan.Payments, &LoanPayment{PaymentRef: paymentRef, Amount: amount, PayTime: payTime})
+	loan.PaidAmount = roundCents(loan.PaidAmount + amount)
+	loan.Outstanding = roundCents(loan.TotalDue - loan.PaidAmount)
+	if loan.Outstanding <= 0 {
+		loan.Status = LOAN_SETTLED
+	}
+	loan.UpdateTime = payTime
+	return s.putLoan(ctx, loan, loanKey)
+}
+
+// MarkLoanDefault 将逾期未还的贷款标记为违约（仅银行组织可以调用），违约后银行可以收回抵押汽车
+func (s *SmartContract) MarkLoanDefault(ctx contractapi.TransactionContextInterface, loanID string, reason string) error {
+	loan, loanKey, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return err
+	}
+	if loan.Status != LOAN_ACTIVE {
+		return fmt.Errorf("只有还款中的贷款才能标记违约，当前状态为 %s", loan.Status)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if loanOverdueAmount(loan, updateTime) <= 0 {
+		return fmt.Errorf("贷款 %s 没有逾期未还的款项，不能标记违约", loanID)
+	}
+
+	loan.Status = LOAN_DEFAULTED
+	loan.DefaultReason = reason
+	loan.UpdateTime = updateTime
+	return s.putLoan(ctx, loan, loanKey)
+}
+
+// RepossessCar 收回违约贷款的抵押汽车，过户给银行指定的参与方（仅银行组织可以调用）
+// 汽车须仍登记在借款人名下且未处于交易中或预订中，收回后汽车转为待售状态
+func (s *SmartContract) RepossessCar(ctx contractapi.TransactionContextInterface, loanID string, bankParty string) error {
+	loan, loanKey, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return err
+	}
+	if loan.Status != LOAN_DEFAULTED {
+		return fmt.Errorf("只有已违约的贷款才能收回汽车，当前状态为 %s", loan.Status)
+	}
+	if _, err := s.requireVerifiedParty(ctx, bankParty, "收回方"); err != nil {
+		return err
+	}
+
+	car, carKey, err := s.getCar(ctx, loan.CarID)
+	if err != nil {
+		return err
+	}
+	if car.CurrentOwner != loan.Borrower {
+		return fmt.Errorf("汽车 %s 已不在借款人 %s 名下，无法收回", car.ID, loan.Borrower)
+	}
+	if car.Status == IN_TRANSACTION {
+		return fmt.Errorf("汽车 %s 正在交易中，无法收回", car.ID)
+	}
+	if car.Status == RESERVED {
+		return fmt.Errorf("汽车 %s 已被预订，请先取消预订再收回", car.ID)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	car.CurrentOwner = bankParty
+	err = s.moveCarStatus(ctx, car, carKey, AVAILABLE, updateTime)
+	if err != nil {
+		return err
+	}
+
+	err = s.appendOwnershipRecord(ctx, car.ID, OWNERSHIP_REPOSSESSION, loan.Borrower, bankParty, 0, loanID, updateTime)
+	if err != nil {
+		return err
+	}
+
+	err = s.applyPolicyOwnerChange(ctx, car.ID, bankParty, updateTime)
+	if err != nil {
+		return err
+	}
+
+	err = s.emitTransferEvent(ctx, &TransferEvent{From: loan.Borrower, To: bankParty, TokenID: car.ID})
+	if err != nil {
+		return err
+	}
+
+	loan.Status = LOAN_REPOSSESSED
+	loan.RepossessedBy = bankParty
+	loan.UpdateTime = updateTime
+	return s.putLoan(ctx, loan, loanKey)
+}
+
+// QueryLoan 查询贷款信息
+func (s *SmartContract) QueryLoan(ctx contractapi.TransactionContextInterface, loanID string) (*Loan, error) {
+	loan, _, err := s.getLoan(ctx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loan.OverdueAmount = loanOverdueAmount(loan, now)
+	return loan, nil
+}
+
+// QueryLoanList 分页查询贷款列表，status 为空时查询全部状态
+func (s *SmartContract) QueryLoanList(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string, status string) (*QueryResult, error) {
+	attributes := []string{}
+	if status != "" {
+		isValidStatus := false
+		for _, validStatus := range []LoanStatus{LOAN_ACTIVE, LOAN_SETTLED, LOAN_DEFAULTED, LOAN_REPOSSESSED} {
+			if LoanStatus(status) == validStatus {
+				isValidStatus = true
+				break
+			}
+		}
+		if !isValidStatus {
+			return nil, fmt.Errorf("无效的贷款状态: %s", status)
+		}
+		attributes = append(attributes, status)
+	}
+
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(LOAN, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("查询列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]interface{}, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var loan Loan
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &loan)
+		if err != nil {
+			return nil, fmt.Errorf("解析贷款信息失败：%v", err)
+		}
+		loan.OverdueAmount = loanOverdueAmount(&loan, now)
+
+		records = append(records, loan)
+	}
+
+	return &QueryResult{
+		Records:             records,
+		RecordsCount:        int32(len(records)),
+		Bookmark:            metadata.Bookmark,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+	}, nil
+}
+
+// getLoan 遍历所有状态查找贷款，返回贷款信息及其当前的复合键（复合键：类型_状态_贷款ID）
+func (s *SmartContract) getLoan(ctx contractapi.TransactionContextInterface, loanID string) (*Loan, string, error) {
+	if len(loanID) == 0 {
+		return nil, "", fmt.Errorf("贷款ID不能为空")
+	}
+	for _, status := range []LoanStatus{LOAN_ACTIVE, LOAN_SETTLED, LOAN_DEFAULTED, LOAN_REPOSSESSED} {
+		key, err := s.getCompositeKey(ctx, LOAN, []string{string(status), loanID})
+		if err != nil {
+			return nil, "", err
+		}
+
+		bytes, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, "", fmt.Errorf("查询贷款信息失败：%v", err)
+		}
+		if bytes != nil {
+			var loan Loan
+			err = s.unmarshalState(ctx, key, bytes, &loan)
+			if err != nil {
+				return nil, "", fmt.Errorf("解析贷款信息失败：%v", err)
+			}
+			return &loan, key, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("贷款ID %s 不存在", loanID)
+}
+
+// checkNoActiveLoan 校验汽车没有未结清（还款中或已违约）的贷款
+// 汽车在贷款结清前抵押给银行，不能出售、预订或过户；违约后只能由银行通过 RepossessCar 收回
+func (s *SmartContract) checkNoActiveLoan(ctx contractapi.TransactionContextInterface, carID string) error {
+	// 未结清贷款索引（复合键：LOAN_CAR_汽车ID_贷款ID），只查询该车的记录
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(LOAN_CAR_INDEX, []string{carID})
+	if err != nil {
+		return fmt.Errorf("查询汽车贷款索引失败：%v", err)
+	}
+	defer iterator.Close()
+
+	if !iterator.HasNext() {
+		return nil
+	}
+	queryResponse, err := iterator.Next()
+	if err != nil {
+		return fmt.Errorf("获取下一条记录失败：%v", err)
+	}
+	loan, _, err := s.getLoan(ctx, string(queryResponse.Value))
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("汽车 %s 已抵押于未结清的贷款 %s（状态：%s）", carID, loan.ID, loan.Status)
+}
+
+// putLoan 以贷款的当前状态写入记录并维护汽车贷款索引，oldKey 非空且与新键不同时删除旧记录
+func (s *SmartContract) putLoan(ctx contractapi.TransactionContextInterface, loan *Loan, oldKey string) error {
+	newKey, err := s.getCompositeKey(ctx, LOAN, []string{string(loan.Status), loan.ID})
+	if err != nil {
+		return err
+	}
+	if oldKey != "" && oldKey != newKey {
+		if err := ctx.GetStub().DelState(oldKey); err != nil {
+			return fmt.Errorf("删除旧的贷款记录失败：%v", err)
+		}
+	}
+
+	// 还款中或已违约的贷款写入汽车贷款索引，结清或收回汽车后清除
+	indexKey, err := s.getCompositeKey(ctx, LOAN_CAR_INDEX, []string{loan.CarID, loan.ID})
+	if err != nil {
+		return err
+	}
+	if loan.Status == LOAN_ACTIVE || loan.Status == LOAN_DEFAULTED {
+		err = ctx.GetStub().PutState(indexKey, []byte(loan.ID))
+	} else {
+		err = ctx.GetStub().DelState(indexKey)
+	}
+	if err != nil {
+		return fmt.Errorf("更新汽车贷款索引失败：%v", err)
+	}
+	return s.putState(ctx, newKey, loan)
+}
+
+// loanOverdueAmount 计算还款中或已违约的贷款在 now 时已到期未还的金额
+func loanOverdueAmount(loan *Loan, now time.Time) float64 {
+	if loan.Status != LOAN_ACTIVE && loan.Status != LOAN_DEFAULTED {
+		return 0
+	}
+	overdue := 0.0
+	for _, installment := range loan.Schedule {
+		if installment.DueDate.Before(now) {
+			overdue += installment.Amount - installment.PaidAmount
+		}
+	}
+	return roundCents(overdue)
+}
+
+// roundCents 金额保留两位小数，避免浮点累加误差
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
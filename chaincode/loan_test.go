@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// loanScheduleJSON 生成从 first 开始每月一期、每期金额相同的还款计划
+func loanScheduleJSON(first time.Time, installments int, amount float64) string {
+	schedule := "["
+	for i := 0; i < installments; i++ {
+		if i > 0 {
+			schedule += ","
+		}
+		schedule += fmt.Sprintf(`{"dueDate":%q,"amount":%v}`, first.AddDate(0, i, 0).Format(time.RFC3339), amount)
+	}
+	return schedule + "]"
+}
+
+// createTestLoan 由银行为交易登记贷款
+func createTestLoan(l *mockLedger, loanID string, txID string, principal float64, schedule string) error {
+	return l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateLoan(ctx, loanID, txID, principal, schedule)
+	})
+}
+
+// queryTestLoan 查询贷款信息
+func queryTestLoan(t *testing.T, l *mockLedger, loanID string) *Loan {
+	t.Helper()
+	var loan *Loan
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		loan, err = testContract.QueryLoan(ctx, loanID)
+		return err
+	}))
+	return loan
+}
+
+// hasTestLoanIndex 检查已提交的状态中是否有汽车到贷款的索引
+func hasTestLoanIndex(t *testing.T, l *mockLedger, carID string, loanID string) bool {
+	t.Helper()
+	key, err := shim.CreateCompositeKey(LOAN_CAR_INDEX, []string{carID, loanID})
+	requireNoError(t, err)
+	_, ok := l.state[key]
+	return ok
+}
+
+func TestCreateLoan(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+	pendingCarID := createTestCar(t, l, "京A00002", "dealer")
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, "T2", pendingCarID, "dealer", "alice", 100)
+	}))
+	first := l.now.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name      string
+		txID      string
+		principal float64
+		schedule  string
+		err       string
+	}{
+		{"本金为0", "T1", 0, loanScheduleJSON(first, 2, 50), "贷款本金必须大于0"},
+		{"还款计划为空", "T1", 80, "[]", "还款计划至少包含一期"},
+		{"应还日期未递增", "T1", 80, fmt.Sprintf(`[{"dueDate":%q,"amount":50},{"dueDate":%q,"amount":50}]`, first.Format(time.RFC3339), first.Format(time.RFC3339)), "第 2 期的应还日期必须晚于上一期"},
+		{"应还总额低于本金", "T1", 80, loanScheduleJSON(first, 2, 39.99), "还款计划应还总额 79.98 低于贷款本金 80.00"},
+		{"本金超过应付金额", "T1", 100.01, loanScheduleJSON(first, 2, 60), "不能超过交易应付金额"},
+		{"交易尚未完成", "T2", 80, loanScheduleJSON(first, 2, 50), "交易 T2 当前状态为 PENDING，只能为已完成的交易登记贷款"},
+		{"交易不存在", "T9", 80, loanScheduleJSON(first, 2, 50), "T9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, createTestLoan(l, "L1", tt.txID, tt.principal, tt.schedule), tt.err)
+		})
+	}
+
+	requireNoError(t, createTestLoan(l, "L1", "T1", 80, loanScheduleJSON(first, 2, 45)))
+	loan := queryTestLoan(t, l, "L1")
+	if loan.Status != LOAN_ACTIVE || loan.Borrower != "alice" || loan.CarID != carID || loan.TotalDue != 90 || loan.Outstanding != 90 {
+		t.Fatalf("贷款信息不正确：%+v", loan)
+	}
+	requireError(t, createTestLoan(l, "L2", "T1", 80, loanScheduleJSON(first, 2, 45)), "已抵押于未结清的贷款 L1")
+	requireError(t, createTestLoan(l, "L1", "T1", 80, loanScheduleJSON(first, 2, 45)), "贷款ID L1 已存在")
+}
+
+func TestLoanTransitions(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	registerTestParty(t, l, "bank")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+	requireNoError(t, createTestLoan(l, "L1", "T1", 80, loanScheduleJSON(l.now.AddDate(0, 1, 0), 2, 45)))
+
+	payment := func(ref string, amount float64) error {
+		return l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.RecordLoanPayment(ctx, "L1", ref, amount)
+		})
+	}
+	markDefault := func() error {
+		return l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.MarkLoanDefault(ctx, "L1", "逾期未还")
+		})
+	}
+	repossess := func() error {
+		return l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.RepossessCar(ctx, "L1", "bank")
+		})
+	}
+
+	steps := []struct {
+		name   string
+		action func() error
+		err    string
+		status LoanStatus
+	}{
+		{"未违约不能收回汽车", repossess, "只有已违约的贷款才能收回汽车", LOAN_ACTIVE},
+		{"未逾期不能标记违约", markDefault, "没有逾期未还的款项", LOAN_ACTIVE},
+		{"还款超过剩余应还金额", func() error { return payment("P1", 90.01) }, "超过剩余应还金额 90.00", LOAN_ACTIVE},
+		{"部分还款", func() error { return payment("P1", 30) }, "", LOAN_ACTIVE},
+		{"还款凭证号重复", func() error { return payment("P1", 10) }, "还款凭证号 P1 已记录", LOAN_ACTIVE},
+		{"第一期逾期后标记违约", func() error {
+			l.now = l.now.AddDate(0, 1, 1)
+			return markDefault()
+		}, "", LOAN_DEFAULTED},
+		{"已违约不能重复标记", markDefault, "只有还款中的贷款才能标记违约", LOAN_DEFAULTED},
+		{"违约后收回汽车", repossess, "", LOAN_REPOSSESSED},
+		{"收回后不能再还款", func() error { return payment("P2", 10) }, "当前状态为 REPOSSESSED，不能记录还款", LOAN_REPOSSESSED},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if err := step.action(); step.err == "" {
+				requireNoError(t, err)
+			} else {
+				requireError(t, err, step.err)
+			}
+			if loan := queryTestLoan(t, l, "L1"); loan.Status != step.status {
+				t.Fatalf("贷款状态为 %s，应为 %s", loan.Status, step.status)
+			}
+			// 只有还款中和已违约的贷款保留汽车贷款索引
+			indexed := step.status == LOAN_ACTIVE || step.status == LOAN_DEFAULTED
+			if got := hasTestLoanIndex(t, l, carID, "L1"); got != indexed {
+				t.Fatalf("贷款状态为 %s 时汽车贷款索引存在为 %v，应为 %v", step.status, got, indexed)
+			}
+		})
+	}
+
+	loan := queryTestLoan(t, l, "L1")
+	if loan.PaidAmount != 30 || loan.Outstanding != 60 || loan.Schedule[0].PaidAmount != 30 || loan.RepossessedBy != "bank" {
+		t.Fatalf("贷款还款信息不正确：%+v", loan)
+	}
+	car := queryTestCar(t, l, carID)
+	if car.CurrentOwner != "bank" || car.Status != AVAILABLE {
+		t.Fatalf("收回后汽车应登记在银行名下并待售：%+v", car)
+	}
+}
+
+func TestLoanSettlement(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+	requireNoError(t, createTestLoan(l, "L1", "T1", 80, loanScheduleJSON(l.now.AddDate(0, 1, 0), 3, 30)))
+	requireError(t, createTestLoan(l, "L2", "T1", 80, loanScheduleJSON(l.now.AddDate(0, 1, 0), 3, 30)), "已抵押于未结清的贷款 L1（状态：ACTIVE）")
+
+	// 还款按期数顺序冲抵，跨期的还款拆分到多期
+	for _, p := range []struct {
+		ref    string
+		amount float64
+	}{{"P1", 45}, {"P2", 44.99}, {"P3", 0.01}} {
+		requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.RecordLoanPayment(ctx, "L1", p.ref, p.amount)
+		}))
+	}
+	loan := queryTestLoan(t, l, "L1")
+	if loan.Status != LOAN_SETTLED || loan.Outstanding != 0 {
+		t.Fatalf("还清后贷款应结清：%+v", loan)
+	}
+	if hasTestLoanIndex(t, l, carID, "L1") {
+		t.Fatalf("结清后应清除汽车贷款索引")
+	}
+	for _, installment := range loan.Schedule {
+		if installment.PaidAmount != 30 || installment.PaidTime.IsZero() {
+			t.Fatalf("第 %d 期还款不正确：%+v", installment.Seq, installment)
+		}
+	}
+
+	// 结清后汽车不再抵押，可以再次登记贷款
+	requireNoError(t, createTestLoan(l, "L2", "T1", 80, loanScheduleJSON(l.now.AddDate(0, 1, 0), 3, 30)))
+	if !hasTestLoanIndex(t, l, carID, "L2") {
+		t.Fatalf("新贷款应写入汽车贷款索引")
+	}
+}
+
+// setTestLoanStatus 直接变更贷款状态
+func setTestLoanStatus(t *testing.T, l *mockLedger, loanID string, status LoanStatus) {
+	t.Helper()
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		loan, loanKey, err := testContract.getLoan(ctx, loanID)
+		if err != nil {
+			return err
+		}
+		loan.Status = status
+		return testContract.putLoan(ctx, loan, loanKey)
+	}))
+}
+
+func TestActiveLoanBlocksCarTransfers(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	registerTestParty(t, l, "bob")
+	bindTestIdentity(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+	addTestCertificate(t, l, "DEED", carID, "GIFT_DEED", time.Time{}, time.Time{})
+	requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "alice", false, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetTransferDocument(ctx, carID, string(OWNERSHIP_GIFT), "DEED")
+	}))
+	requireNoError(t, createTestLoan(l, "L1", "T1", 80, loanScheduleJSON(l.now.AddDate(0, 1, 0), 2, 45)))
+
+	// 贷款只能为已完成的交易登记，这里直接写入一笔待售汽车的贷款，覆盖出售和预订的校验
+	dealerCarID := createTestCar(t, l, "京A00002", "dealer")
+	cleanCarID := createTestCar(t, l, "京A00003", "dealer")
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.putLoan(ctx, &Loan{ID: "L2", CarID: dealerCarID, Borrower: "dealer", Status: LOAN_ACTIVE}, "")
+	}))
+
+	operations := []struct {
+		name   string
+		loanID string
+		fn     func(ctx contractapi.TransactionContextInterface) error
+	}{
+		{"出售", "L2", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateTransaction(ctx, "T2", dealerCarID, "dealer", "bob", 100)
+		}},
+		{"打包出售", "L2", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateBundleTransaction(ctx, "T2", "dealer", "bob", fmt.Sprintf(`[{"carId":%q,"price":100}]`, dealerCarID), 100)
+		}},
+		{"预订", "L2", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateReservation(ctx, "R1", dealerCarID, "dealer", "bob", 10, 3)
+		}},
+		{"作为置换旧车交回", "L1", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateTradeInTransaction(ctx, "T2", cleanCarID, "dealer", "alice", 100, carID, 30)
+		}},
+		{"非交易过户", "L1", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.TransferOwnership(ctx, carID, "bob", string(OWNERSHIP_GIFT), "DEED")
+		}},
+		{"通证转移", "L1", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.TransferFrom(ctx, "alice", "bob", carID)
+		}},
+	}
+	// check 以 alice 的身份逐项调用但不提交，status 为空表示都应通过
+	check := func(t *testing.T, status LoanStatus) {
+		for _, op := range operations {
+			t.Run(op.name, func(t *testing.T) {
+				ctx := &contractapi.TransactionContext{}
+				ctx.SetStub(l.newStub(CAR_DEALER_ORG_MSPID, "alice", false))
+				if status == "" {
+					requireNoError(t, op.fn(ctx))
+				} else {
+					requireError(t, op.fn(ctx), fmt.Sprintf("已抵押于未结清的贷款 %s（状态：%s）", op.loanID, status))
+				}
+			})
+		}
+	}
+
+	t.Run("还款中", func(t *testing.T) { check(t, LOAN_ACTIVE) })
+
+	l.now = l.now.AddDate(0, 1, 1)
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.MarkLoanDefault(ctx, "L1", "逾期未还")
+	}))
+	setTestLoanStatus(t, l, "L2", LOAN_DEFAULTED)
+	t.Run("已违约", func(t *testing.T) { check(t, LOAN_DEFAULTED) })
+
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RecordLoanPayment(ctx, "L1", "P1", 90)
+	}))
+	setTestLoanStatus(t, l, "L2", LOAN_SETTLED)
+	t.Run("已结清", func(t *testing.T) { check(t, "") })
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// 以 ERC-721 风格将每辆汽车视为一枚通证：通证ID即汽车ID，账户即参与方ID。
+// 参与方需由银行将其 Fabric 客户端身份绑定后，才能以自己的身份调用 Approve、SetApprovalForAll、SetTransferDocument 和 TransferFrom；
+// 创建汽车对应铸造，交易过户、置换、非交易过户和 TransferFrom 均触发 Transfer 事件。
+
+// 通证事件名称
+const (
+	NFT_EVENT_TRANSFER         = "Transfer"
+	NFT_EVENT_APPROVAL         = "Approval"
+	NFT_EVENT_APPROVAL_FOR_ALL = "ApprovalForAll"
+)
+
+// TransferEvent 通证转移事件，铸造时 from 为空
+// Fabric 每笔交易只能设置一个事件，因此 Transfer 事件的负载为本交易内全部转移组成的数组
+type TransferEvent struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	TokenID string `json:"tokenId"`
+}
+
+// ApprovalEvent 单车授权事件，approved 为空表示撤销授权
+type ApprovalEvent struct {
+	Owner    string `json:"owner"`
+	Approved string `json:"approved"`
+	TokenID  string `json:"tokenId"`
+}
+
+// ApprovalForAllEvent 操作员授权事件
+type ApprovalForAllEvent struct {
+	Owner    string `json:"owner"`
+	Operator string `json:"operator"`
+	Approved bool   `json:"approved"`
+}
+
+// TokenApproval 单车授权记录：被授权方可以转移该车
+// 记录授权时的所有者，所有者变更（无论以何种方式过户）后授权自动失效
+type TokenApproval struct {
+	TokenID    string    `json:"tokenId"`    // 通证ID（汽车ID）
+	Owner      string    `json:"owner"`      // 授权时的所有者
+	Approved   string    `json:"approved"`   // 被授权的参与方ID
+	UpdateTime time.Time `json:"updateTime"` // 授权时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// OperatorApproval 操作员授权记录：操作员可以转移所有者名下的全部汽车
+type OperatorApproval struct {
+	Owner      string    `json:"owner"`      // 所有者
+	Operator   string    `json:"operator"`   // 操作员（参与方ID）
+	Approved   bool      `json:"approved"`   // 是否授权
+	UpdateTime time.Time `json:"updateTime"` // 更新时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// TransferDocument 通证转移的过户类型和证明文件登记，TransferFrom 按此办理非交易过户
+// 记录登记时的所有者，所有者变更后登记自动失效
+type TransferDocument struct {
+	TokenID        string              `json:"tokenId"`        // 通证ID（汽车ID）
+	Owner          string              `json:"owner"`          // 登记时的所有者
+	TransferType   OwnershipChangeType `json:"transferType"`   // 过户类型
+	DocumentCertID string              `json:"documentCertId"` // 证明文件证书ID
+	UpdateTime     time.Time           `json:"updateTime"`     // 登记时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// BindPartyIdentity 将 Fabric 客户端身份绑定到已通过 KYC 认证的参与方（仅银行组织可以调用）
+// clientID 可由该客户端调用 ClientIdentityID 获得；一个身份只能绑定一个参与方，一个参与方也只能绑定一个身份
+func (s *SmartContract) BindPartyIdentity(ctx contractapi.TransactionContextInterface, partyID string, mspID string, clientID string) error {
+	party, err := s.requireVerifiedParty(ctx, partyID, "参与方")
+	if err != nil {
+		return err
+	}
+	if party.ClientID != "" {
+		return fmt.Errorf("参与方 %s 已绑定客户端身份", partyID)
+	}
+
+	indexKey, err := s.getCompositeKey(ctx, PARTY_IDENTITY, []string{mspID, clientID})
+	if err != nil {
+		return err
+	}
+	existingID, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return fmt.Errorf("查询参与方身份索引失败：%v", err)
+	}
+	if existingID != nil {
+		return fmt.Errorf("该客户端身份已绑定参与方 %s", string(existingID))
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	partyKey, err := s.getCompositeKey(ctx, PARTY, []string{partyID})
+	if err != nil {
+		return err
+	}
+	party.ClientMSPID = mspID
+	party.ClientID = clientID
+	party.UpdateTime = updateTime
+	err = s.putState(ctx, partyKey, party)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(indexKey, []byte(partyID))
+	if err != nil {
+		return fmt.Errorf("保存参与方身份索引失败：%v", err)
+	}
+	return nil
+}
+
+// ClientIdentityID 返回调用者的客户端身份标识，用于申请绑定参与方
+func (s *SmartContract) ClientIdentityID(ctx contractapi.TransactionContextInterface) (string, error) {
+	return s.getClientIdentityID(ctx)
+}
+
+// ClientAccountID 返回与调用者身份绑定的参与方ID（即通证账户）
+func (s *SmartContract) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
+	return s.getCallerPartyID(ctx)
+}
+
+// OwnerOf 查询通证（汽车）的所有者
+func (s *SmartContract) OwnerOf(ctx contractapi.TransactionContextInterface, tokenID string) (string, error) {
+	car, _, err := s.getCar(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+	return car.CurrentOwner, nil
+}
+
+// BalanceOf 查询参与方名下的汽车数量
+func (s *SmartContract) BalanceOf(ctx contractapi.TransactionContextInterface, owner string) (int, error) {
+	if len(owner) == 0 {
+		return 0, fmt.Errorf("所有者不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(CAR, []string{})
+	if err != nil {
+		return 0, fmt.Errorf("查询汽车列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	balance := 0
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var car Car
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &car)
+		if err != nil {
+			return 0, fmt.Errorf("解析汽车信息失败：%v", err)
+		}
+		if car.CurrentOwner == owner {
+			balance++
+		}
+	}
+	return balance, nil
+}
+
+// Approve 授权参与方转移指定汽车，approved 为空时撤销授权（调用者须为所有者或其操作员）
+func (s *SmartContract) Approve(ctx contractapi.TransactionContextInterface, approved string, tokenID string) error {
+	sender, err := s.getCallerPartyID(ctx)
+	if err != nil {
+		return err
+	}
+
+	car, _, err := s.getCar(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	owner := car.CurrentOwner
+	if sender != owner {
+		isOperator, err := s.IsApprovedForAll(ctx, owner, sender)
+		if err != nil {
+			return err
+		}
+		if !isOperator {
+			return fmt.Errorf("%s 不是汽车 %s 的所有者或其操作员，无权授权", sender, tokenID)
+		}
+	}
+	if approved == owner {
+		return fmt.Errorf("不能授权给当前所有者")
+	}
+	if approved != "" {
+		if _, err := s.requireParty(ctx, approved); err != nil {
+			return fmt.Errorf("被授权方%v", err)
+		}
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	approvalKey, err := s.getCompositeKey(ctx, NFT_APPROVAL, []string{tokenID})
+	if err != nil {
+		return err
+	}
+	if approved == "" {
+		err = ctx.GetStub().DelState(approvalKey)
+		if err != nil {
+			return fmt.Errorf("撤销授权失败：%v", err)
+		}
+	} else {
+		approval := TokenApproval{
+			TokenID:    tokenID,
+			Owner:      owner,
+			Approved:   approved,
+			UpdateTime: updateTime,
+		}
+		err = s.putState(ctx, approvalKey, approval)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.setEvent(ctx, NFT_EVENT_APPROVAL, ApprovalEvent{Owner: owner, Approved: approved, TokenID: tokenID})
+}
+
+// SetApprovalForAll 授权或撤销操作员转移调用者名下的全部汽车
+func (s *SmartContract) SetApprovalForAll(ctx contractapi.TransactionContextInterface, operator string, approved bool) error {
+	sender, err := s.getCallerPartyID(ctx)
+	if err != nil {
+		return err
+	}
+	if operator == sender {
+		return fmt.Errorf("不能将自己设为操作员")
+	}
+	if _, err := s.requireParty(ctx, operator); err != nil {
+		return fmt.Errorf("操作员%v", err)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	operatorKey, err := s.getCompositeKey(ctx, NFT_OPERATOR, []string{sender, operator})
+	if err != nil {
+		return err
+	}
+	approval := OperatorApproval{
+		Owner:      sender,
+		Operator:   operator,
+		Approved:   approved,
+		UpdateTime: updateTime,
+	}
+	err = s.putState(ctx, operatorKey, approval)
+	if err != nil {
+		return err
+	}
+
+	return s.setEvent(ctx, NFT_EVENT_APPROVAL_FOR_ALL, ApprovalForAllEvent{Owner: sender, Operator: operator, Approved: approved})
+}
+
+// GetApproved 查询汽车当前的被授权方，没有有效授权时返回空
+func (s *SmartContract) GetApproved(ctx contractapi.TransactionContextInterface, tokenID string) (string, error) {
+	car, _, err := s.getCar(ctx, tokenID)
+	if err != nil {
+		return "", err
+	}
+
+	approvalKey, err := s.getCompositeKey(ctx, NFT_APPROVAL, []string{tokenID})
+	if err != nil {
+		return "", err
+	}
+	bytes, err := ctx.GetStub().GetState(approvalKey)
+	if err != nil {
+		return "", fmt.Errorf("查询授权信息失败：%v", err)
+	}
+	if bytes == nil {
+		return "", nil
+	}
+
+	var approval TokenApproval
+	err = s.unmarshalState(ctx, approvalKey, bytes, &approval)
+	if err != nil {
+		return "", err
+	}
+	// 所有者已变更，原授权失效
+	if approval.Owner != car.CurrentOwner {
+		return "", nil
+	}
+	return approval.Approved, nil
+}
+
+// IsApprovedForAll 查询 operator 是否为 owner 的操作员
+func (s *SmartContract) IsApprovedForAll(ctx contractapi.TransactionContextInterface, owner string, operator string) (bool, error) {
+	operatorKey, err := s.getCompositeKey(ctx, NFT_OPERATOR, []string{owner, operator})
+	if err != nil {
+		return false, err
+	}
+	bytes, err := ctx.GetStub().GetState(operatorKey)
+	if err != nil {
+		return false, fmt.Errorf("查询操作员授权失败：%v", err)
+	}
+	if bytes == nil {
+		return false, nil
+	}
+
+	var approval OperatorApproval
+	err = s.unmarshalState(ctx, operatorKey, bytes, &approval)
+	if err != nil {
+		return false, err
+	}
+	return approval.Approved, nil
+}
+
+// SetTransferDocument 登记汽车下一次通证转移的过户类型和证明文件（调用者须为所有者、被授权方或操作员）
+// transferType 为 INHERITANCE、GIFT 或 COURT_ORDER，须引用该车有效的证明文件证书；
+// 登记记录所有者，所有者变更后失效，转移完成后清除
+func (s *SmartContract) SetTransferDocument(ctx contractapi.TransactionContextInterface, tokenID string, transferType string, documentCertID string) error {
+	sender, err := s.getCallerPartyID(ctx)
+	if err != nil {
+		return err
+	}
+
+	car, _, err := s.getCar(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	err = s.checkTransferAuthorized(ctx, sender, car.CurrentOwner, tokenID)
+	if err != nil {
+		return err
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	// 接收方和汽车状态在转移时校验，此处只校验过户类型和证明文件
+	_, err = s.checkTransferDocument(ctx, car, transferType, documentCertID, updateTime)
+	if err != nil {
+		return err
+	}
+
+	documentKey, err := s.getCompositeKey(ctx, NFT_DOCUMENT, []string{tokenID})
+	if err != nil {
+		return err
+	}
+	document := TransferDocument{
+		TokenID:        tokenID,
+		Owner:          car.CurrentOwner,
+		TransferType:   OwnershipChangeType(transferType),
+		DocumentCertID: documentCertID,
+		UpdateTime:     updateTime,
+	}
+	return s.putState(ctx, documentKey, document)
+}
+
+// GetTransferDocument 查询汽车已登记的过户类型和证明文件，没有有效登记时返回空
+func (s *SmartContract) GetTransferDocument(ctx contractapi.TransactionContextInterface, tokenID string) (*TransferDocument, error) {
+	car, _, err := s.getCar(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	documentKey, err := s.getCompositeKey(ctx, NFT_DOCUMENT, []string{tokenID})
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := ctx.GetStub().GetState(documentKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询过户证明文件失败：%v", err)
+	}
+	if bytes == nil {
+		return nil, nil
+	}
+
+	var document TransferDocument
+	err = s.unmarshalState(ctx, documentKey, bytes, &document)
+	if err != nil {
+		return nil, err
+	}
+	// 所有者已变更，原登记失效
+	if document.Owner != car.CurrentOwner {
+		return nil, nil
+	}
+	return &document, nil
+}
+
+// TransferFrom 将汽车从 from 转移给 to（调用者须为所有者、被授权方或操作员）
+// 与监管机构办理的非交易过户适用相同规则，过户类型和证明文件须先通过 SetTransferDocument 登记；
+// 买卖必须通过交易流程，不能以通证转移代替
+func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface, from string, to string, tokenID string) error {
+	sender, err := s.getCallerPartyID(ctx)
+	if err != nil {
+		return err
+	}
+
+	car, carKey, err := s.getCar(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if car.CurrentOwner != from {
+		return fmt.Errorf("%s 不是汽车 %s 的所有者", from, tokenID)
+	}
+	err = s.checkTransferAuthorized(ctx, sender, from, tokenID)
+	if err != nil {
+		return err
+	}
+
+	document, err := s.GetTransferDocument(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	if document == nil {
+		return fmt.Errorf("汽车 %s 尚未登记过户类型和证明文件，请先调用 SetTransferDocument（买卖须通过交易流程）", tokenID)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	changeType, err := s.checkNonSaleTransfer(ctx, car, to, string(document.TransferType), document.DocumentCertID, updateTime)
+	if err != nil {
+		return err
+	}
+
+	// 转移后清除单车授权和过户证明文件登记
+	for _, objectType := range []string{NFT_APPROVAL, NFT_DOCUMENT} {
+		key, err := s.getCompositeKey(ctx, objectType, []string{tokenID})
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().DelState(key)
+		if err != nil {
+			return fmt.Errorf("清除授权失败：%v", err)
+		}
+	}
+
+	return s.changeCarOwner(ctx, car, carKey, to, changeType, document.DocumentCertID, updateTime)
+}
+
+// checkTransferAuthorized 校验调用者可以转移所有者的汽车：本人、被授权方或操作员
+func (s *SmartContract) checkTransferAuthorized(ctx contractapi.TransactionContextInterface, sender string, owner string, tokenID string) error {
+	if sender == owner {
+		return nil
+	}
+	approved, err := s.GetApproved(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+	isOperator, err := s.IsApprovedForAll(ctx, owner, sender)
+	if err != nil {
+		return err
+	}
+	if approved != sender && !isOperator {
+		return fmt.Errorf("%s 未获授权转移汽车 %s", sender, tokenID)
+	}
+	return nil
+}
+
+// getCallerPartyID 查找与调用者身份绑定的参与方ID
+func (s *SmartContract) getCallerPartyID(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientMSPID, err := s.getClientIdentityMSPID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	clientID, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	indexKey, err := s.getCompositeKey(ctx, PARTY_IDENTITY, []string{clientMSPID, clientID})
+	if err != nil {
+		return "", err
+	}
+	partyID, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return "", fmt.Errorf("查询参与方身份索引失败：%v", err)
+	}
+	if partyID == nil {
+		return "", fmt.Errorf("当前身份未绑定参与方")
+	}
+	return string(partyID), nil
+}
+
+// emitTransferEvent 设置本交易的 Transfer 事件
+func (s *SmartContract) emitTransferEvent(ctx contractapi.TransactionContextInterface, transfers ...*TransferEvent) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	return s.setEvent(ctx, NFT_EVENT_TRANSFER, transfers)
+}
+
+// setEvent 序列化并设置链码事件
+func (s *SmartContract) setEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败：%v", err)
+	}
+	err = ctx.GetStub().SetEvent(name, bytes)
+	if err != nil {
+		return fmt.Errorf("设置事件失败：%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// bindTestIdentity 将汽车经销商组织中通用名为 partyID 的客户端身份绑定到同名参与方
+func bindTestIdentity(t *testing.T, l *mockLedger, partyID string) {
+	t.Helper()
+	var clientID string
+	requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, partyID, false, func(ctx contractapi.TransactionContextInterface) (err error) {
+		clientID, err = testContract.ClientIdentityID(ctx)
+		return err
+	}))
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.BindPartyIdentity(ctx, partyID, CAR_DEALER_ORG_MSPID, clientID)
+	}))
+}
+
+func TestTransferFrom(t *testing.T) {
+	l := newMockLedger(t)
+	for _, partyID := range []string{"alice", "bob", "carol", "mallory"} {
+		registerTestParty(t, l, partyID)
+		bindTestIdentity(t, l, partyID)
+	}
+	carID := createTestCar(t, l, "京A00001", "alice")
+	reservedCarID := createTestCar(t, l, "京A00002", "alice")
+	addTestCertificate(t, l, "DEED", carID, "GIFT_DEED", time.Time{}, time.Time{})
+	addTestCertificate(t, l, "RESERVED_DEED", reservedCarID, "GIFT_DEED", time.Time{}, time.Time{})
+	addTestCertificate(t, l, "REVOKED", carID, "GIFT_DEED", time.Time{}, time.Time{})
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RevokeCertificate(ctx, "REVOKED", "伪造")
+	}))
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateReservation(ctx, "R1", reservedCarID, "alice", "bob", 10, 3)
+	}))
+	// alice 授权 carol 转移 carID
+	requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "alice", false, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Approve(ctx, "carol", carID)
+	}))
+
+	setTransferDocument := func(sender string, tokenID string, transferType string, certID string) error {
+		return l.callAs(CAR_DEALER_ORG_MSPID, sender, false, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SetTransferDocument(ctx, tokenID, transferType, certID)
+		})
+	}
+	transferFrom := func(sender string, tokenID string) error {
+		return l.callAs(CAR_DEALER_ORG_MSPID, sender, false, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.TransferFrom(ctx, "alice", "bob", tokenID)
+		})
+	}
+
+	tests := []struct {
+		name         string
+		sender       string
+		tokenID      string
+		transferType string
+		certID       string
+		err          string
+	}{
+		{"未获授权", "mallory", carID, string(OWNERSHIP_GIFT), "DEED", "mallory 未获授权转移汽车"},
+		{"买卖不能以通证转移代替", "alice", carID, string(OWNERSHIP_SALE), "DEED", "无效的过户类型: SALE"},
+		{"缺少证明文件", "alice", carID, string(OWNERSHIP_GIFT), "", "证明文件证书无效"},
+		{"证明文件属于其他汽车", "alice", carID, string(OWNERSHIP_GIFT), "RESERVED_DEED", "不属于汽车"},
+		{"证明文件已吊销", "alice", carID, string(OWNERSHIP_GIFT), "REVOKED", "证书 REVOKED 已被吊销"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, setTransferDocument(tt.sender, tt.tokenID, tt.transferType, tt.certID), tt.err)
+		})
+	}
+
+	// 未登记过户类型和证明文件时不能转移，标准的 TransferFrom 不能绕过交易流程
+	requireError(t, transferFrom("alice", carID), "尚未登记过户类型和证明文件")
+	// 已预订的汽车可以登记证明文件，但转移时仍按非交易过户规则拒绝
+	requireNoError(t, setTransferDocument("alice", reservedCarID, string(OWNERSHIP_GIFT), "RESERVED_DEED"))
+	requireError(t, transferFrom("alice", reservedCarID), "已被预订")
+
+	// 被授权方登记赠与证明后完成转移，记录与监管机构办理的过户一致
+	requireNoError(t, setTransferDocument("carol", carID, string(OWNERSHIP_GIFT), "DEED"))
+	requireError(t, transferFrom("mallory", carID), "mallory 未获授权转移汽车")
+	requireNoError(t, transferFrom("carol", carID))
+	if car := queryTestCar(t, l, carID); car.CurrentOwner != "bob" {
+		t.Fatalf("转移后所有者为 %s", car.CurrentOwner)
+	}
+	history := queryTestOwnershipHistory(t, l, carID)
+	last := history[len(history)-1]
+	if last.ChangeType != OWNERSHIP_GIFT || last.FromOwner != "alice" || last.ToOwner != "bob" || last.ReferenceID != "DEED" {
+		t.Fatalf("所有权变更记录不正确：%+v", last)
+	}
+	var approved string
+	var document *TransferDocument
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		approved, err = testContract.GetApproved(ctx, carID)
+		if err != nil {
+			return err
+		}
+		document, err = testContract.GetTransferDocument(ctx, carID)
+		return err
+	}))
+	if approved != "" || document != nil {
+		t.Fatalf("转移后单车授权和过户证明文件登记应失效，当前为 %s、%+v", approved, document)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// OwnershipChangeType 所有权变更类型
+type OwnershipChangeType string
+
+const (
+	OWNERSHIP_REGISTRATION   OwnershipChangeType = "REGISTRATION"   // 首次登记
+	OWNERSHIP_SALE           OwnershipChangeType = "SALE"           // 交易过户
+	OWNERSHIP_INHERITANCE    OwnershipChangeType = "INHERITANCE"    // 继承
+	OWNERSHIP_GIFT           OwnershipChangeType = "GIFT"           // 赠与
+	OWNERSHIP_COURT_ORDER    OwnershipChangeType = "COURT_ORDER"    // 法院判决
+	OWNERSHIP_TRADE_IN       OwnershipChangeType = "TRADE_IN"       // 置换交回
+	OWNERSHIP_TOKEN_TRANSFER OwnershipChangeType = "TOKEN_TRANSFER" // 通证接口转移（旧版本 TransferFrom 的记录，现按过户类型记录）
+	OWNERSHIP_REPOSSESSION   OwnershipChangeType = "REPOSSESSION"   // 贷款违约后银行收回
+)
+
+// OwnershipRecord 所有权变更记录（只追加，不修改、不删除）
+type OwnershipRecord struct {
+	RecordID    string              `json:"recordId"`    // 记录ID（Fabric 交易ID）
+	CarID       string              `json:"carId"`       // 汽车ID
+	ChangeType  OwnershipChangeType `json:"changeType"`  // 变更类型
+	FromOwner   string              `json:"fromOwner"`   // 原所有者（首次登记时为空）
+	ToOwner     string              `json:"toOwner"`     // 新所有者
+	Price       float64             `json:"price"`       // 成交价格（非交易过户为0）
+	ReferenceID string              `json:"referenceId"` // 关联凭据：交易ID或证明文件的证书ID
+	CreateTime  time.Time           `json:"createTime"`  // 变更时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// TransferOwnership 非交易方式的所有权变更：继承、赠与、法院判决（仅监管机构组织可以调用）
+// 必须引用链上已存在的该车辆的证明文件证书，变更不涉及价格
+func (s *SmartContract) TransferOwnership(ctx contractapi.TransactionContextInterface, carID string, newOwner string, transferType string, documentCertID string) error {
+	car, carKey, err := s.getCar(ctx, carID)
+	if err != nil {
+		return err
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	changeType, err := s.checkNonSaleTransfer(ctx, car, newOwner, transferType, documentCertID, updateTime)
+	if err != nil {
+		return err
+	}
+	return s.changeCarOwner(ctx, car, carKey, newOwner, changeType, documentCertID, updateTime)
+}
+
+// QueryOwnershipHistory 查询汽车的所有权变更历史（按时间升序）
+func (s *SmartContract) QueryOwnershipHistory(ctx contractapi.TransactionContextInterface, carID string) ([]*OwnershipRecord, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(OWNERSHIP, []string{carID})
+	if err != nil {
+		return nil, fmt.Errorf("查询所有权变更历史失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]*OwnershipRecord, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var record OwnershipRecord
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &record)
+		if err != nil {
+			return nil, fmt.Errorf("解析所有权变更记录失败：%v", err)
+		}
+		records = append(records, &record)
+	}
+
+	// 复合键按交易ID排序，这里按变更时间重新排序
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreateTime.Before(records[j].CreateTime)
+	})
+
+	return records, nil
+}
+
+// appendOwnershipRecord 追加一条所有权变更记录（复合键：类型_汽车ID_交易ID）
+func (s *SmartContract) appendOwnershipRecord(ctx contractapi.TransactionContextInterface, carID string, changeType OwnershipChangeType, fromOwner string, toOwner string, price float64, referenceID string, createTime time.Time) error {
+	recordID := ctx.GetStub().GetTxID()
+	key, err := s.getCompositeKey(ctx, OWNERSHIP, []string{carID, recordID})
+	if err != nil {
+		return err
+	}
+
+	existsBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("查询所有权变更记录失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("所有权变更记录 %s 已存在，不允许覆盖", recordID)
+	}
+
+	record := OwnershipRecord{
+		RecordID:    recordID,
+		CarID:       carID,
+		ChangeType:  changeType,
+		FromOwner:   fromOwner,
+		ToOwner:     toOwner,
+		Price:       price,
+		ReferenceID: referenceID,
+		CreateTime:  createTime,
+	}
+
+	return s.putState(ctx, key, record)
+}
+
+// checkNonSaleTransfer 校验非交易过户：过户类型、汽车状态、新所有者和证明文件，返回过户类型
+// 监管机构过户和通证接口转移（TransferFrom）共用此校验，买卖必须通过交易流程以计算费用和税费
+func (s *SmartContract) checkNonSaleTransfer(ctx contractapi.TransactionContextInterface, car *Car, newOwner string, transferType string, documentCertID string, updateTime time.Time) (OwnershipChangeType, error) {
+	if car.Status == IN_TRANSACTION {
+		return "", fmt.Errorf("汽车 %s 正在交易中，无法办理过户", car.ID)
+	}
+	if car.Status == RESERVED {
+		return "", fmt.Errorf("汽车 %s 已被预订，请先取消预订再办理过户", car.ID)
+	}
+	if car.Stolen {
+		return "", fmt.Errorf("汽车 %s 已被标记为被盗车辆（案件编号：%s），无法办理过户", car.ID, car.StolenCaseRef)
+	}
+	if car.CurrentOwner == newOwner {
+		return "", fmt.Errorf("新所有者与当前所有者相同")
+	}
+
+	if _, err := s.requireVerifiedParty(ctx, newOwner, "新所有者"); err != nil {
+		return "", err
+	}
+
+	// 贷款未结清的汽车不能过户
+	if err := s.checkNoActiveLoan(ctx, car.ID); err != nil {
+		return "", err
+	}
+
+	return s.checkTransferDocument(ctx, car, transferType, documentCertID, updateTime)
+}
+
+// checkTransferDocument 校验非交易过户的过户类型和证明文件，返回过户类型
+func (s *SmartContract) checkTransferDocument(ctx contractapi.TransactionContextInterface, car *Car, transferType string, documentCertID string, updateTime time.Time) (OwnershipChangeType, error) {
+	changeType := OwnershipChangeType(transferType)
+	switch changeType {
+	case OWNERSHIP_INHERITANCE, OWNERSHIP_GIFT, OWNERSHIP_COURT_ORDER:
+	default:
+		return "", fmt.Errorf("无效的过户类型: %s，应为 INHERITANCE、GIFT 或 COURT_ORDER", transferType)
+	}
+
+	// 证明文件必须已上链且属于该车辆
+	cert, err := s.GetCertificate(ctx, documentCertID)
+	if err != nil {
+		return "", fmt.Errorf("证明文件证书无效：%v", err)
+	}
+	if cert.CarID != car.ID {
+		return "", fmt.Errorf("证书 %s 不属于汽车 %s", documentCertID, car.ID)
+	}
+
+	// 已吊销、已过期或尚未生效的证明文件不能作为过户依据
+	if cert.Status != CERT_ACTIVE {
+		return "", fmt.Errorf("证书 %s 已被吊销，不能作为过户证明文件", documentCertID)
+	}
+	if certExpired(cert, updateTime) {
+		return "", fmt.Errorf("证书 %s 已过期，不能作为过户证明文件", documentCertID)
+	}
+	if updateTime.Before(cert.ValidFrom) {
+		return "", fmt.Errorf("证书 %s 尚未生效，不能作为过户证明文件", documentCertID)
+	}
+	return changeType, nil
+}
+
+// changeCarOwner 完成非交易过户：变更所有者、追加所有权记录、按保单过户规则处理保单并触发 Transfer 事件
+func (s *SmartContract) changeCarOwner(ctx contractapi.TransactionContextInterface, car *Car, carKey string, newOwner string, changeType OwnershipChangeType, documentCertID string, updateTime time.Time) error {
+	previousOwner := car.CurrentOwner
+	car.CurrentOwner = newOwner
+	car.UpdateTime = updateTime
+
+	err := s.putState(ctx, carKey, car)
+	if err != nil {
+		return err
+	}
+
+	err = s.appendOwnershipRecord(ctx, car.ID, changeType, previousOwner, newOwner, 0, documentCertID, updateTime)
+	if err != nil {
+		return err
+	}
+
+	err = s.applyPolicyOwnerChange(ctx, car.ID, newOwner, updateTime)
+	if err != nil {
+		return err
+	}
+
+	return s.emitTransferEvent(ctx, &TransferEvent{From: previousOwner, To: newOwner, TokenID: car.ID})
+}
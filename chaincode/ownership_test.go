@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// addTestCertificate 由汽车经销商组织为汽车上传一份证书
+func addTestCertificate(t *testing.T, l *mockLedger, certID string, carID string, certType string, validFrom time.Time, validUntil time.Time) {
+	t.Helper()
+	data, err := json.Marshal(Certificate{
+		CertID:       certID,
+		CarID:        carID,
+		CertType:     certType,
+		FileHash:     "hash-" + certID,
+		FileLocation: carID + "/" + certID + ".pdf",
+		ValidFrom:    validFrom,
+		ValidUntil:   validUntil,
+	})
+	requireNoError(t, err)
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.AddCertificate(ctx, string(data))
+	}))
+}
+
+func TestTransferOwnershipDocument(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	otherCarID := createTestCar(t, l, "京A00002", "dealer")
+
+	addTestCertificate(t, l, "OTHER", otherCarID, "GIFT_DEED", time.Time{}, time.Time{})
+	addTestCertificate(t, l, "REVOKED", carID, "GIFT_DEED", time.Time{}, time.Time{})
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RevokeCertificate(ctx, "REVOKED", "伪造")
+	}))
+	addTestCertificate(t, l, "EXPIRED", carID, "GIFT_DEED", time.Time{}, l.now.Add(time.Minute))
+	addTestCertificate(t, l, "FUTURE", carID, "GIFT_DEED", l.now.Add(time.Hour), time.Time{})
+	addTestCertificate(t, l, "VALID", carID, "GIFT_DEED", time.Time{}, l.now.Add(time.Hour))
+
+	tests := []struct {
+		name         string
+		transferType string
+		certID       string
+		err          string
+	}{
+		{"交易过户不走此接口", string(OWNERSHIP_SALE), "VALID", "无效的过户类型"},
+		{"证书不存在", string(OWNERSHIP_GIFT), "NONE", "证明文件证书无效"},
+		{"证书属于其他汽车", string(OWNERSHIP_GIFT), "OTHER", "不属于汽车"},
+		{"证书已吊销", string(OWNERSHIP_GIFT), "REVOKED", "证书 REVOKED 已被吊销"},
+		{"证书已过期", string(OWNERSHIP_GIFT), "EXPIRED", "证书 EXPIRED 已过期"},
+		{"证书尚未生效", string(OWNERSHIP_GIFT), "FUTURE", "证书 FUTURE 尚未生效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.TransferOwnership(ctx, carID, "alice", tt.transferType, tt.certID)
+			}), tt.err)
+		})
+	}
+
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.TransferOwnership(ctx, carID, "alice", string(OWNERSHIP_GIFT), "VALID")
+	}))
+	if car := queryTestCar(t, l, carID); car.CurrentOwner != "alice" {
+		t.Fatalf("过户后所有者为 %s", car.CurrentOwner)
+	}
+
+	var records []*OwnershipRecord
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		records, err = testContract.QueryOwnershipHistory(ctx, carID)
+		return err
+	}))
+	last := records[len(records)-1]
+	if last.ChangeType != OWNERSHIP_GIFT || last.FromOwner != "dealer" || last.ToOwner != "alice" || last.ReferenceID != "VALID" {
+		t.Fatalf("所有权变更记录不正确：%+v", last)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// PartyType 参与方类型
+type PartyType string
+
+const (
+	INDIVIDUAL PartyType = "INDIVIDUAL" // 个人
+	COMPANY    PartyType = "COMPANY"    // 企业
+)
+
+// KYCStatus 参与方实名认证（KYC）状态
+type KYCStatus string
+
+const (
+	KYC_PENDING  KYCStatus = "PENDING"  // 待认证
+	KYC_VERIFIED KYCStatus = "VERIFIED" // 已认证
+	KYC_REJECTED KYCStatus = "REJECTED" // 认证未通过
+)
+
+// Party 参与方信息（车主、买家、卖家均通过参与方ID引用）
+type Party struct {
+	ID             string    `json:"id"`             // 参与方ID
+	Type           PartyType `json:"type"`           // 参与方类型
+	Name           string    `json:"name"`           // 名称（个人姓名或企业名称）
+	NationalIDHash string    `json:"nationalIdHash"` // 身份证号/统一社会信用代码的 SHA256 哈希
+	KYCStatus      KYCStatus `json:"kycStatus"`      // KYC 状态
+	KYCRemark      string    `json:"kycRemark"`      // KYC 审核备注
+	KYCUpdateTime  time.Time `json:"kycUpdateTime"`  // KYC 状态更新时间
+	CreateTime     time.Time `json:"createTime"`     // 创建时间
+	UpdateTime     time.Time `json:"updateTime"`     // 更新时间
+
+	ClientMSPID string `json:"clientMspId,omitempty" metadata:",optional"` // 绑定的客户端身份所属组织
+	ClientID    string `json:"clientId,omitempty" metadata:",optional"`    // 绑定的客户端身份标识（通证接口据此识别调用者）
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// RegisterParty 登记参与方（汽车经销商、交易平台组织可以调用），初始 KYC 状态为待认证
+func (s *SmartContract) RegisterParty(ctx contractapi.TransactionContextInterface, partyID string, partyType string, name string, nationalIDHash string) error {
+	if PartyType(partyType) != INDIVIDUAL && PartyType(partyType) != COMPANY {
+		return fmt.Errorf("无效的参与方类型: %s", partyType)
+	}
+	if decoded, err := hex.DecodeString(nationalIDHash); err != nil || len(decoded) != 32 {
+		return fmt.Errorf("证件号哈希必须是64位十六进制 SHA256 值")
+	}
+
+	partyKey, err := s.getCompositeKey(ctx, PARTY, []string{partyID})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(partyKey)
+	if err != nil {
+		return fmt.Errorf("查询参与方信息失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("参与方ID %s 已存在", partyID)
+	}
+
+	// 同一证件只能登记一次
+	nidKey, err := s.getCompositeKey(ctx, PARTY_NID_INDEX, []string{nationalIDHash})
+	if err != nil {
+		return err
+	}
+	existingID, err := ctx.GetStub().GetState(nidKey)
+	if err != nil {
+		return fmt.Errorf("查询证件索引失败：%v", err)
+	}
+	if existingID != nil {
+		return fmt.Errorf("该证件已登记为参与方 %s", string(existingID))
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	party := Party{
+		ID:             partyID,
+		Type:           PartyType(partyType),
+		Name:           name,
+		NationalIDHash: nationalIDHash,
+		KYCStatus:      KYC_PENDING,
+		KYCUpdateTime:  createTime,
+		CreateTime:     createTime,
+		UpdateTime:     createTime,
+	}
+
+	err = s.putState(ctx, partyKey, party)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(nidKey, []byte(partyID))
+	if err != nil {
+		return fmt.Errorf("保存证件索引失败：%v", err)
+	}
+	return nil
+}
+
+// SetPartyKYCStatus 设置参与方的 KYC 状态（仅银行组织可以调用）
+func (s *SmartContract) SetPartyKYCStatus(ctx contractapi.TransactionContextInterface, partyID string, status string, remark string) error {
+	kycStatus := KYCStatus(status)
+	if kycStatus != KYC_PENDING && kycStatus != KYC_VERIFIED && kycStatus != KYC_REJECTED {
+		return fmt.Errorf("无效的 KYC 状态: %s", status)
+	}
+
+	partyKey, err := s.getCompositeKey(ctx, PARTY, []string{partyID})
+	if err != nil {
+		return err
+	}
+	var party Party
+	err = s.getState(ctx, partyKey, &party)
+	if err != nil {
+		return err
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	party.KYCStatus = kycStatus
+	party.KYCRemark = remark
+	party.KYCUpdateTime = updateTime
+	party.UpdateTime = updateTime
+
+	return s.putState(ctx, partyKey, party)
+}
+
+// QueryParty 查询参与方信息
+func (s *SmartContract) QueryParty(ctx contractapi.TransactionContextInterface, partyID string) (*Party, error) {
+	if len(partyID) == 0 {
+		return nil, fmt.Errorf("参与方ID不能为空")
+	}
+
+	partyKey, err := s.getCompositeKey(ctx, PARTY, []string{partyID})
+	if err != nil {
+		return nil, err
+	}
+
+	var party Party
+	err = s.getState(ctx, partyKey, &party)
+	if err != nil {
+		return nil, fmt.Errorf("参与方ID %s 不存在", partyID)
+	}
+	return &party, nil
+}
+
+// QueryPartyList 分页查询参与方列表，kycStatus 为空时返回全部
+func (s *SmartContract) QueryPartyList(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string, kycStatus string) (*QueryResult, error) {
+	var iterator shim.StateQueryIteratorInterface
+	var metadata *peer.QueryResponseMetadata
+	var err error
+
+	iterator, metadata, err = ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(PARTY, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("查询列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]interface{}, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var party Party
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &party)
+		if err != nil {
+			return nil, fmt.Errorf("解析参与方信息失败：%v", err)
+		}
+		if kycStatus != "" && string(party.KYCStatus) != kycStatus {
+			continue
+		}
+
+		records = append(records, party)
+	}
+
+	return &QueryResult{
+		Records:             records,
+		RecordsCount:        int32(len(records)),
+		Bookmark:            metadata.Bookmark,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+	}, nil
+}
+
+// requireParty 校验参与方已登记
+func (s *SmartContract) requireParty(ctx contractapi.TransactionContextInterface, partyID string) (*Party, error) {
+	partyKey, err := s.getCompositeKey(ctx, PARTY, []string{partyID})
+	if err != nil {
+		return nil, err
+	}
+
+	var party Party
+	err = s.getState(ctx, partyKey, &party)
+	if err != nil {
+		return nil, fmt.Errorf("参与方 %s 未登记", partyID)
+	}
+	return &party, nil
+}
+
+// requireVerifiedParty 校验参与方已登记且已通过 KYC 认证
+func (s *SmartContract) requireVerifiedParty(ctx contractapi.TransactionContextInterface, partyID string, role string) (*Party, error) {
+	party, err := s.requireParty(ctx, partyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s%v", role, err)
+	}
+	if party.KYCStatus != KYC_VERIFIED {
+		return nil, fmt.Errorf("%s %s 未通过 KYC 认证（当前状态：%s）", role, partyID, party.KYCStatus)
+	}
+	return party, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestRegisterParty(t *testing.T) {
+	l := newMockLedger(t)
+	hash := strings.Repeat("ab", 32)
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterParty(ctx, "alice", string(INDIVIDUAL), "Alice", hash)
+	}))
+
+	tests := []struct {
+		name           string
+		partyID        string
+		partyType      string
+		nationalIDHash string
+		err            string
+	}{
+		{"无效的参与方类型", "bob", "GOVERNMENT", strings.Repeat("cd", 32), "无效的参与方类型"},
+		{"证件号哈希长度错误", "bob", string(INDIVIDUAL), "abcd", "64位十六进制"},
+		{"证件号哈希不是十六进制", "bob", string(INDIVIDUAL), strings.Repeat("zz", 32), "64位十六进制"},
+		{"参与方ID重复", "alice", string(COMPANY), strings.Repeat("cd", 32), "参与方ID alice 已存在"},
+		{"同一证件重复登记", "bob", string(INDIVIDUAL), hash, "该证件已登记为参与方 alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.RegisterParty(ctx, tt.partyID, tt.partyType, tt.partyID, tt.nationalIDHash)
+			}), tt.err)
+		})
+	}
+
+	var party *Party
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		party, err = testContract.QueryParty(ctx, "alice")
+		return err
+	}))
+	if party.KYCStatus != KYC_PENDING || party.NationalIDHash != hash {
+		t.Fatalf("参与方信息不正确：%+v", party)
+	}
+}
+
+func TestTransactionRequiresVerifiedParties(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	setKYC := func(status KYCStatus) {
+		requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SetPartyKYCStatus(ctx, "alice", string(status), "复核")
+		}))
+	}
+	createTransaction := func(buyer string) error {
+		return l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateTransaction(ctx, "T1", carID, "dealer", buyer, 100)
+		})
+	}
+
+	tests := []struct {
+		name   string
+		status KYCStatus
+		buyer  string
+		err    string
+	}{
+		{"买家未登记", KYC_VERIFIED, "nobody", "买家参与方 nobody 未登记"},
+		{"买家认证未通过", KYC_REJECTED, "alice", "未通过 KYC 认证（当前状态：REJECTED）"},
+		{"买家待认证", KYC_PENDING, "alice", "未通过 KYC 认证（当前状态：PENDING）"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKYC(tt.status)
+			requireError(t, createTransaction(tt.buyer), tt.err)
+		})
+	}
+	requireError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetPartyKYCStatus(ctx, "alice", "UNKNOWN", "")
+	}), "无效的 KYC 状态")
+
+	setKYC(KYC_VERIFIED)
+	requireNoError(t, createTransaction("alice"))
+
+	var result *QueryResult
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		result, err = testContract.QueryPartyList(ctx, 10, "", string(KYC_VERIFIED))
+		return err
+	}))
+	if result.RecordsCount != 2 {
+		t.Fatalf("已认证参与方数量为 %d", result.RecordsCount)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// paymentSettlementKey 链上代币结算配置在 CONFIG 中的键
+const paymentSettlementKey = "PAYMENT_SETTLEMENT"
+
+// PaymentSettlementConfig 链上代币结算配置：设置代币链码名称后，完成交易时车款通过该链码划转；为空时沿用链下付款
+type PaymentSettlementConfig struct {
+	TokenChaincode string    `json:"tokenChaincode"` // 代币链码名称（同一通道）
+	UpdateTime     time.Time `json:"updateTime"`     // 更新时间
+}
+
+// TokenPayment 交易完成时通过代币链码完成的车款划转
+type TokenPayment struct {
+	Chaincode string `json:"chaincode"` // 代币链码名称
+	From      string `json:"from"`      // 付款方（参与方ID）
+	To        string `json:"to"`        // 收款方（参与方ID）
+	Amount    int64  `json:"amount"`    // 划转金额（分）
+}
+
+// SetPaymentSettlement 设置链上代币结算使用的代币链码（仅银行组织可以调用），传空字符串恢复链下付款
+func (s *SmartContract) SetPaymentSettlement(ctx contractapi.TransactionContextInterface, tokenChaincode string) error {
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	configKey, err := s.getCompositeKey(ctx, CONFIG, []string{paymentSettlementKey})
+	if err != nil {
+		return err
+	}
+	return s.putState(ctx, configKey, PaymentSettlementConfig{TokenChaincode: tokenChaincode, UpdateTime: updateTime})
+}
+
+// QueryPaymentSettlement 查询链上代币结算配置，未设置时代币链码为空（链下付款）
+func (s *SmartContract) QueryPaymentSettlement(ctx contractapi.TransactionContextInterface) (*PaymentSettlementConfig, error) {
+	configKey, err := s.getCompositeKey(ctx, CONFIG, []string{paymentSettlementKey})
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := ctx.GetStub().GetState(configKey)
+	if err != nil {
+		return nil, fmt.Errorf("查询结算配置失败：%v", err)
+	}
+	if bytes == nil {
+		return &PaymentSettlementConfig{}, nil
+	}
+
+	var config PaymentSettlementConfig
+	err = s.unmarshalState(ctx, configKey, bytes, &config)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// settleTokenPayment 按交易的应付金额和费用明细调用代币链码划转车款和各项费用，代币链码返回失败时整笔交易失败
+// 应付金额为负数（置换旧车折价高于新车价格）时由卖家向买家补差价；费用由承担方划入费用表指定的收费或代收税款账户
+// 车款和费用通过 TransferBatch 一次划转，未配置代币链码或没有需要划转的金额时不调用代币链码；
+// 付款方须事先在代币链码中以自己的身份通过 Approve 授权本链码划转的额度，代币链码只在额度内扣款
+func (s *SmartContract) settleTokenPayment(ctx contractapi.TransactionContextInterface, transaction *Transaction) (*TokenPayment, []*TokenPayment, error) {
+	config, err := s.QueryPaymentSettlement(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(config.TokenChaincode) == 0 {
+		return nil, nil, nil
+	}
+
+	var payment *TokenPayment
+	if amount := toTokenAmount(transaction.NetAmount); amount > 0 {
+		payment = &TokenPayment{Chaincode: config.TokenChaincode, From: transaction.Buyer, To: transaction.Seller, Amount: amount}
+	} else if amount < 0 {
+		payment = &TokenPayment{Chaincode: config.TokenChaincode, From: transaction.Seller, To: transaction.Buyer, Amount: -amount}
+	}
+
+	feePayments := make([]*TokenPayment, 0)
+	if transaction.Fees != nil {
+		for _, item := range transaction.Fees.Items {
+			feePayment := &TokenPayment{Chaincode: config.TokenChaincode, From: transaction.Buyer, To: transaction.Fees.FeeAccount, Amount: toTokenAmount(item.Amount)}
+			if item.Payer == FEE_PAYER_SELLER {
+				feePayment.From = transaction.Seller
+			}
+			if item.Kind == FEE_KIND_TAX {
+				feePayment.To = transaction.Fees.TaxAccount
+			}
+			if feePayment.Amount <= 0 || feePayment.From == feePayment.To {
+				continue
+			}
+			feePayments = append(feePayments, feePayment)
+		}
+	}
+
+	transfers := make([]map[string]interface{}, 0, len(feePayments)+1)
+	for _, p := range append([]*TokenPayment{payment}, feePayments...) {
+		if p != nil {
+			transfers = append(transfers, map[string]interface{}{"from": p.From, "to": p.To, "value": p.Amount})
+		}
+	}
+	if len(transfers) == 0 {
+		return nil, nil, nil
+	}
+	transfersJSON, err := json.Marshal(transfers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("序列化转账列表失败：%v", err)
+	}
+
+	args := [][]byte{[]byte("TransferBatch"), transfersJSON}
+	response := ctx.GetStub().InvokeChaincode(config.TokenChaincode, args, "")
+	if response.Status != shim.OK {
+		return nil, nil, fmt.Errorf("代币支付失败：%s", response.Message)
+	}
+	if len(feePayments) == 0 {
+		feePayments = nil
+	}
+	return payment, feePayments, nil
+}
+
+// toTokenAmount 将金额（元）换算为代币的最小单位（分）
+func toTokenAmount(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+)
+
+// mockTokenChaincode 注册模拟的代币链码，记录每次 TransferBatch 收到的转账列表，message 非空时返回失败
+func mockTokenChaincode(l *mockLedger, name string, message string) *[][]map[string]interface{} {
+	calls := make([][]map[string]interface{}, 0)
+	l.chaincodes[name] = func(args [][]byte) *peer.Response {
+		if string(args[0]) != "TransferBatch" {
+			return &peer.Response{Status: shim.ERROR, Message: "未知方法 " + string(args[0])}
+		}
+		if message != "" {
+			return &peer.Response{Status: shim.ERROR, Message: message}
+		}
+		var transfers []map[string]interface{}
+		if err := json.Unmarshal(args[1], &transfers); err != nil {
+			return &peer.Response{Status: shim.ERROR, Message: err.Error()}
+		}
+		calls = append(calls, transfers)
+		return &peer.Response{Status: shim.OK}
+	}
+	return &calls
+}
+
+func TestSettleTokenPayment(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	calls := mockTokenChaincode(l, "tokenchaincode", "")
+	mockTokenChaincode(l, "brokentoken", "账户 alice 余额不足")
+
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetFeeSchedule(ctx, `{"rules":[`+
+			`{"code":"PLATFORM_FEE","kind":"FEE","payer":"SELLER","percent":1,"minimum":0},`+
+			`{"code":"PURCHASE_TAX","kind":"TAX","payer":"BUYER","percent":10,"minimum":0}],`+
+			`"feeAccount":"platform","taxAccount":"tax"}`)
+	}))
+	setSettlement := func(tokenChaincode string) {
+		requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SetPaymentSettlement(ctx, tokenChaincode)
+		}))
+	}
+
+	tests := []struct {
+		name           string
+		tokenChaincode string
+		price          float64
+		tradeInValue   float64
+		transfers      []map[string]interface{}
+		err            string
+	}{
+		{"链下付款不调用代币链码", "", 100, 0, nil, ""},
+		{"车款和费用一次划转", "tokenchaincode", 120, 0, []map[string]interface{}{
+			{"from": "alice", "to": "dealer", "value": float64(12000)},
+			{"from": "dealer", "to": "platform", "value": float64(120)},
+			{"from": "alice", "to": "tax", "value": float64(1200)},
+		}, ""},
+		{"置换折价高于车价时卖家补差价，旧车不计费", "tokenchaincode", 100, 130.5, []map[string]interface{}{
+			{"from": "dealer", "to": "alice", "value": float64(3050)},
+			{"from": "dealer", "to": "platform", "value": float64(100)},
+			{"from": "alice", "to": "tax", "value": float64(1000)},
+		}, ""},
+		{"代币链码失败时整笔交易失败", "brokentoken", 100, 0, nil, "代币支付失败：账户 alice 余额不足"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSettlement(tt.tokenChaincode)
+			carID := createTestCar(t, l, "京A0000"+string(rune('1'+i)), "dealer")
+			txID := "T" + string(rune('1'+i))
+			tradeInCarID := ""
+			if tt.tradeInValue > 0 {
+				tradeInCarID = createTestCar(t, l, "京B0000"+string(rune('1'+i)), "alice")
+			}
+			requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				if tt.tradeInValue > 0 {
+					return testContract.CreateTradeInTransaction(ctx, txID, carID, "dealer", "alice", tt.price, tradeInCarID, tt.tradeInValue)
+				}
+				return testContract.CreateTransaction(ctx, txID, carID, "dealer", "alice", tt.price)
+			}))
+			callCount := len(*calls)
+			err := l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.CompleteTransaction(ctx, txID)
+			})
+
+			var transaction *Transaction
+			requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+				transaction, err = testContract.QueryTransaction(ctx, txID)
+				return err
+			}))
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				if transaction.Status != PENDING || queryTestCar(t, l, carID).Status != IN_TRANSACTION {
+					t.Fatalf("代币支付失败后交易和汽车状态应保持不变：%s", transaction.Status)
+				}
+				return
+			}
+			requireNoError(t, err)
+			if tt.transfers == nil {
+				if len(*calls) != callCount || transaction.TokenPayment != nil {
+					t.Fatalf("链下付款不应调用代币链码")
+				}
+				return
+			}
+			if got := (*calls)[len(*calls)-1]; !reflect.DeepEqual(got, tt.transfers) {
+				t.Fatalf("转账列表为 %v，应为 %v", got, tt.transfers)
+			}
+			payment := transaction.TokenPayment
+			if payment == nil || payment.From != tt.transfers[0]["from"] || float64(payment.Amount) != tt.transfers[0]["value"] || len(transaction.TokenFeePayments) != len(tt.transfers)-1 {
+				t.Fatalf("交易记录的代币划转不正确：%+v %+v", payment, transaction.TokenFeePayments)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// StolenFlagAction 被盗标记操作类型
+type StolenFlagAction string
+
+const (
+	FLAG_STOLEN  StolenFlagAction = "FLAG"  // 标记被盗
+	CLEAR_STOLEN StolenFlagAction = "CLEAR" // 解除标记
+)
+
+// StolenFlagRecord 被盗标记操作记录（只追加，不修改、不删除）
+type StolenFlagRecord struct {
+	RecordID   string           `json:"recordId"`   // 记录ID（Fabric 交易ID）
+	CarID      string           `json:"carId"`      // 汽车ID
+	Action     StolenFlagAction `json:"action"`     // 操作类型
+	CaseRef    string           `json:"caseRef"`    // 案件编号
+	Remark     string           `json:"remark"`     // 备注
+	Operator   string           `json:"operator"`   // 操作人身份标识
+	CreateTime time.Time        `json:"createTime"` // 操作时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// FlagCarStolen 标记汽车为被盗车辆（仅监管机构组织可以调用）
+func (s *SmartContract) FlagCarStolen(ctx contractapi.TransactionContextInterface, carID string, caseRef string, remark string) error {
+	car, carKey, err := s.getCar(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if car.Stolen {
+		return fmt.Errorf("汽车 %s 已被标记为被盗车辆（案件编号：%s）", carID, car.StolenCaseRef)
+	}
+
+	flagTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	car.Stolen = true
+	car.StolenCaseRef = caseRef
+	car.UpdateTime = flagTime
+
+	err = s.putState(ctx, carKey, car)
+	if err != nil {
+		return err
+	}
+
+	return s.appendStolenFlagRecord(ctx, carID, FLAG_STOLEN, caseRef, remark, flagTime)
+}
+
+// ClearStolenFlag 解除汽车的被盗标记（仅监管机构组织可以调用）
+func (s *SmartContract) ClearStolenFlag(ctx contractapi.TransactionContextInterface, carID string, caseRef string, remark string) error {
+	car, carKey, err := s.getCar(ctx, carID)
+	if err != nil {
+		return err
+	}
+	if !car.Stolen {
+		return fmt.Errorf("汽车 %s 未被标记为被盗车辆", carID)
+	}
+	if car.StolenCaseRef != caseRef {
+		return fmt.Errorf("案件编号不匹配，汽车 %s 当前关联的案件编号为 %s", carID, car.StolenCaseRef)
+	}
+
+	clearTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	car.Stolen = false
+	car.StolenCaseRef = ""
+	car.UpdateTime = clearTime
+
+	err = s.putState(ctx, carKey, car)
+	if err != nil {
+		return err
+	}
+
+	return s.appendStolenFlagRecord(ctx, carID, CLEAR_STOLEN, caseRef, remark, clearTime)
+}
+
+// QueryStolenFlagHistory 查询汽车的被盗标记历史
+func (s *SmartContract) QueryStolenFlagHistory(ctx contractapi.TransactionContextInterface, carID string) ([]*StolenFlagRecord, error) {
+	if len(carID) == 0 {
+		return nil, fmt.Errorf("汽车ID不能为空")
+	}
+
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(STOLEN_FLAG, []string{carID})
+	if err != nil {
+		return nil, fmt.Errorf("查询被盗标记历史失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]*StolenFlagRecord, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var record StolenFlagRecord
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &record)
+		if err != nil {
+			return nil, fmt.Errorf("解析被盗标记记录失败：%v", err)
+		}
+		records = append(records, &record)
+	}
+
+	// 复合键按交易ID排序，这里按操作时间重新排序
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreateTime.Before(records[j].CreateTime)
+	})
+
+	return records, nil
+}
+
+// appendStolenFlagRecord 追加一条被盗标记操作记录（复合键：类型_汽车ID_交易ID）
+func (s *SmartContract) appendStolenFlagRecord(ctx contractapi.TransactionContextInterface, carID string, action StolenFlagAction, caseRef string, remark string, createTime time.Time) error {
+	operator, err := s.getClientIdentityID(ctx)
+	if err != nil {
+		return err
+	}
+
+	recordID := ctx.GetStub().GetTxID()
+	key, err := s.getCompositeKey(ctx, STOLEN_FLAG, []string{carID, recordID})
+	if err != nil {
+		return err
+	}
+
+	existsBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("查询被盗标记记录失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("被盗标记记录 %s 已存在，不允许覆盖", recordID)
+	}
+
+	record := StolenFlagRecord{
+		RecordID:   recordID,
+		CarID:      carID,
+		Action:     action,
+		CaseRef:    caseRef,
+		Remark:     remark,
+		Operator:   operator,
+		CreateTime: createTime,
+	}
+
+	return s.putState(ctx, key, record)
+}
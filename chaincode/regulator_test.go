@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestStolenFlag(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	flagTime := l.now
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.FlagCarStolen(ctx, carID, "CASE-1", "报案")
+	}))
+	car := queryTestCar(t, l, carID)
+	if !car.Stolen || car.StolenCaseRef != "CASE-1" || !car.UpdateTime.Equal(flagTime) || len(car.Alert) == 0 {
+		t.Fatalf("被盗标记未生效：%+v", car)
+	}
+
+	tests := []struct {
+		name string
+		fn   func(ctx contractapi.TransactionContextInterface) error
+		err  string
+	}{
+		{"重复标记", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.FlagCarStolen(ctx, carID, "CASE-2", "")
+		}, "已被标记为被盗车辆"},
+		{"被盗车辆禁止交易", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateTransaction(ctx, "T1", carID, "dealer", "alice", 100)
+		}, "无法创建交易"},
+		{"案件编号不匹配", func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.ClearStolenFlag(ctx, carID, "CASE-2", "")
+		}, "案件编号不匹配"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(REGULATOR_ORG_MSPID, tt.fn), tt.err)
+		})
+	}
+
+	clearTime := l.now
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ClearStolenFlag(ctx, carID, "CASE-1", "已追回")
+	}))
+	car = queryTestCar(t, l, carID)
+	if car.Stolen || len(car.StolenCaseRef) != 0 || len(car.Alert) != 0 || !car.UpdateTime.Equal(clearTime) {
+		t.Fatalf("被盗标记未解除：%+v", car)
+	}
+
+	var records []*StolenFlagRecord
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		records, err = testContract.QueryStolenFlagHistory(ctx, carID)
+		return err
+	}))
+	if len(records) != 2 || records[0].Action != FLAG_STOLEN || records[1].Action != CLEAR_STOLEN ||
+		!records[0].CreateTime.Equal(flagTime) || !records[1].CreateTime.Equal(clearTime) {
+		t.Fatalf("被盗标记历史不正确：%+v", records)
+	}
+}
+
+func TestStolenFlagIgnoresClientTime(t *testing.T) {
+	cc, err := newChaincode()
+	requireNoError(t, err)
+
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	// 被盗标记时间始终取自交易时间戳，客户端多传的时间参数不起作用
+	flagTime := l.now
+	response := l.invoke(cc, REGULATOR_ORG_MSPID, "user1", false, "FlagCarStolen", carID, "CASE-1", "", "2020-01-01T00:00:00Z")
+	if response.Status != shim.OK {
+		t.Fatalf("标记被盗失败：%s", response.Message)
+	}
+	if car := queryTestCar(t, l, carID); !car.UpdateTime.Equal(flagTime) {
+		t.Fatalf("被盗标记时间应取自交易时间戳，实际为 %v", car.UpdateTime)
+	}
+}
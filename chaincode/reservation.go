@@ -0,0 +1,455 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// 预订保留的最长天数
+const MAX_RESERVATION_DAYS = 30
+
+// ReservationStatus 预订状态
+type ReservationStatus string
+
+const (
+	RESERVATION_PENDING_DEPOSIT ReservationStatus = "PENDING_DEPOSIT" // 待银行确认定金
+	RESERVATION_ACTIVE          ReservationStatus = "ACTIVE"          // 定金已确认，保留中
+	RESERVATION_CONVERTED       ReservationStatus = "CONVERTED"       // 已转为交易
+	RESERVATION_CANCELLED       ReservationStatus = "CANCELLED"       // 已取消
+	RESERVATION_EXPIRED         ReservationStatus = "EXPIRED"         // 超过保留截止时间后失效
+)
+
+// DepositSettlement 定金的处理结果
+type DepositSettlement string
+
+const (
+	DEPOSIT_APPLIED  DepositSettlement = "APPLIED"  // 转为交易，定金抵扣车款
+	DEPOSIT_REFUNDED DepositSettlement = "REFUNDED" // 取消或过期失效，定金退还买家
+	DEPOSIT_CREDITED DepositSettlement = "CREDITED" // 取消预订，定金归卖家所有（如买家违约）
+)
+
+// Reservation 预订记录：买家支付定金后，汽车在有效期内为其保留
+type Reservation struct {
+	ID                 string            `json:"id"`                                               // 预订ID
+	CarID              string            `json:"carId"`                                            // 汽车ID
+	Seller             string            `json:"seller"`                                           // 卖家（参与方ID）
+	Buyer              string            `json:"buyer"`                                            // 买家（参与方ID）
+	Deposit            float64           `json:"deposit"`                                          // 定金金额
+	Status             ReservationStatus `json:"status"`                                           // 预订状态
+	ExpireTime         time.Time         `json:"expireTime"`                                       // 保留截止时间
+	DepositRef         string            `json:"depositRef,omitempty" metadata:",optional"`        // 银行定金收款凭证号
+	DepositConfirmTime time.Time         `json:"depositConfirmTime"`                               // 银行确认定金的时间
+	DepositSettlement  DepositSettlement `json:"depositSettlement,omitempty" metadata:",optional"` // 定金处理结果
+	TransactionID      string            `json:"transactionId,omitempty" metadata:",optional"`     // 转入的交易ID
+	Remark             string            `json:"remark,omitempty" metadata:",optional"`            // 取消或失效原因
+	CreateTime         time.Time         `json:"createTime"`                                       // 创建时间
+	UpdateTime         time.Time         `json:"updateTime"`                                       // 更新时间
+
+	Expired bool `json:"expired,omitempty" metadata:",optional"` // 是否已超过保留截止时间（仅查询时填充，不落账本）
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// CreateReservation 创建预订（仅交易平台组织可以调用）
+// 汽车立即转为已预订状态，在 holdDays 天内为买家保留；定金需由银行确认后预订才能转为交易
+func (s *SmartContract) CreateReservation(ctx contractapi.TransactionContextInterface, reservationID string, carID string, seller string, buyer string, deposit float64, holdDays int) error {
+	if seller == buyer {
+		return fmt.Errorf("买家和卖家不能是同一人")
+	}
+	if deposit <= 0 {
+		return fmt.Errorf("定金必须大于0")
+	}
+	if holdDays <= 0 || holdDays > MAX_RESERVATION_DAYS {
+		return fmt.Errorf("保留天数必须在 1 到 %d 天之间", MAX_RESERVATION_DAYS)
+	}
+
+	// 买卖双方必须是已通过 KYC 认证的参与方
+	if _, err := s.requireVerifiedParty(ctx, seller, "卖家"); err != nil {
+		return err
+	}
+	if _, err := s.requireVerifiedParty(ctx, buyer, "买家"); err != nil {
+		return err
+	}
+
+	if _, _, err := s.getReservation(ctx, reservationID); err == nil {
+		return fmt.Errorf("预订ID %s 已存在", reservationID)
+	}
+
+	car, carKey, err := s.getCar(ctx, carID)
+	if err != nil {
+		return err
+	}
+	switch car.Status {
+	case AVAILABLE:
+	case RESERVED:
+		return fmt.Errorf("汽车 %s 已被预订", carID)
+	case IN_TRANSACTION:
+		return fmt.Errorf("汽车 %s 正在交易中，无法预订", carID)
+	default:
+		return fmt.Errorf("汽车 %s 非待售状态，无法预订", carID)
+	}
+	if car.Stolen {
+		return fmt.Errorf("汽车 %s 已被标记为被盗车辆（案件编号：%s），无法预订", carID, car.StolenCaseRef)
+	}
+	if car.CurrentOwner != seller {
+		return fmt.Errorf("%s 不是汽车 %s 的所有者", seller, carID)
+	}
+	// 贷款未结清的汽车不能预订
+	if err := s.checkNoActiveLoan(ctx, carID); err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.moveCarStatus(ctx, car, carKey, RESERVED, createTime); err != nil {
+		return err
+	}
+
+	reservation := Reservation{
+		ID:         reservationID,
+		CarID:      carID,
+		Seller:     seller,
+		Buyer:      buyer,
+		Deposit:    deposit,
+		Status:     RESERVATION_PENDING_DEPOSIT,
+		ExpireTime: createTime.AddDate(0, 0, holdDays),
+		CreateTime: createTime,
+		UpdateTime: createTime,
+	}
+	return s.putReservation(ctx, &reservation, "")
+}
+
+// ConfirmReservationDeposit 确认已收到预订定金（仅银行组织可以调用）
+func (s *SmartContract) ConfirmReservationDeposit(ctx contractapi.TransactionContextInterface, reservationID string, depositRef string) error {
+	reservation, reservationKey, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	if reservation.Status != RESERVATION_PENDING_DEPOSIT {
+		return fmt.Errorf("预订 %s 当前状态为 %s，无需确认定金", reservationID, reservation.Status)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !updateTime.Before(reservation.ExpireTime) {
+		return fmt.Errorf("预订 %s 已于 %s 过期，请取消预订", reservationID, reservation.ExpireTime.Format(time.RFC3339))
+	}
+
+	reservation.Status = RESERVATION_ACTIVE
+	reservation.DepositRef = depositRef
+	reservation.DepositConfirmTime = updateTime
+	reservation.UpdateTime = updateTime
+	return s.putReservation(ctx, reservation, reservationKey)
+}
+
+// ConvertReservation 将定金已确认的预订转为待付款交易（仅交易平台组织可以调用）
+// 买卖双方取自预订，定金抵扣车款，买家应付金额为成交价减定金
+func (s *SmartContract) ConvertReservation(ctx contractapi.TransactionContextInterface, reservationID string, txID string, price float64) error {
+	if price <= 0 {
+		return fmt.Errorf("价格必须大于0")
+	}
+
+	reservation, reservationKey, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	switch reservation.Status {
+	case RESERVATION_ACTIVE:
+	case RESERVATION_PENDING_DEPOSIT:
+		return fmt.Errorf("预订 %s 的定金尚未经银行确认，无法转为交易", reservationID)
+	default:
+		return fmt.Errorf("预订 %s 当前状态为 %s，无法转为交易", reservationID, reservation.Status)
+	}
+	// 按分比较，避免浮点误差
+	if math.Round(reservation.Deposit*100) > math.Round(price*100) {
+		return fmt.Errorf("定金 %.2f 不能高于成交价 %.2f", reservation.Deposit, price)
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !createTime.Before(reservation.ExpireTime) {
+		return fmt.Errorf("预订 %s 已于 %s 过期，无法转为交易", reservationID, reservation.ExpireTime.Format(time.RFC3339))
+	}
+
+	// 买卖双方在预订期间可能被取消 KYC 认证，转为交易时重新校验
+	if _, err := s.requireVerifiedParty(ctx, reservation.Seller, "卖家"); err != nil {
+		return err
+	}
+	if _, err := s.requireVerifiedParty(ctx, reservation.Buyer, "买家"); err != nil {
+		return err
+	}
+
+	if err := s.checkTransactionIDAvailable(ctx, txID); err != nil {
+		return err
+	}
+
+	// 费用按成交价计算，定金只抵扣应付车款
+	fees, err := s.computeFeeBreakdown(ctx, []*BundleItem{{CarID: reservation.CarID, Price: price}}, price-reservation.Deposit)
+	if err != nil {
+		return err
+	}
+
+	car, priceWarning, err := s.lockCarForSale(ctx, reservation.CarID, reservation.Seller, price, []CarStatus{RESERVED}, createTime)
+	if err != nil {
+		return err
+	}
+
+	transaction := Transaction{
+		ID:         txID,
+		Type:       TX_TYPE_SALE,
+		CarID:      reservation.CarID,
+		CarModel:   car.Model,
+		Seller:     reservation.Seller,
+		Buyer:      reservation.Buyer,
+		Price:      price,
+		NetAmount:  price - reservation.Deposit,
+		Status:     PENDING,
+		CreateTime: createTime,
+		UpdateTime: createTime,
+
+		ReservationID: reservationID,
+		Deposit:       reservation.Deposit,
+
+		Fees: fees,
+
+		PriceWarning: priceWarning,
+	}
+
+	txKey, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(PENDING), txID})
+	if err != nil {
+		return err
+	}
+	if err := s.putState(ctx, txKey, transaction); err != nil {
+		return err
+	}
+
+	reservation.Status = RESERVATION_CONVERTED
+	reservation.DepositSettlement = DEPOSIT_APPLIED
+	reservation.TransactionID = txID
+	reservation.UpdateTime = createTime
+	return s.putReservation(ctx, reservation, reservationKey)
+}
+
+// CancelReservation 取消预订（仅交易平台组织可以调用），汽车恢复为待售状态
+// 定金已确认时 settlement 必须为 REFUNDED（退还买家）或 CREDITED（归卖家所有）；定金未确认时无需处理定金
+func (s *SmartContract) CancelReservation(ctx contractapi.TransactionContextInterface, reservationID string, settlement string, reason string) error {
+	reservation, reservationKey, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	switch reservation.Status {
+	case RESERVATION_ACTIVE:
+		depositSettlement := DepositSettlement(settlement)
+		if depositSettlement != DEPOSIT_REFUNDED && depositSettlement != DEPOSIT_CREDITED {
+			return fmt.Errorf("无效的定金处理方式: %s，应为 REFUNDED 或 CREDITED", settlement)
+		}
+		reservation.DepositSettlement = depositSettlement
+	case RESERVATION_PENDING_DEPOSIT:
+		if settlement != "" {
+			return fmt.Errorf("预订 %s 的定金尚未确认，无需处理定金", reservationID)
+		}
+	default:
+		return fmt.Errorf("预订 %s 当前状态为 %s，无法取消", reservationID, reservation.Status)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	car, carKey, err := s.getCar(ctx, reservation.CarID)
+	if err != nil {
+		return err
+	}
+	if car.Status == RESERVED {
+		if err := s.moveCarStatus(ctx, car, carKey, AVAILABLE, updateTime); err != nil {
+			return err
+		}
+	}
+
+	reservation.Status = RESERVATION_CANCELLED
+	reservation.Remark = reason
+	reservation.UpdateTime = updateTime
+	return s.putReservation(ctx, reservation, reservationKey)
+}
+
+// ExpireReservation 使已超过保留截止时间的预订失效（任何组织均可调用），汽车恢复为待售状态
+// 定金已确认的预订失效时定金退还买家；买家违约需没收定金时，交易平台应在到期前以 CREDITED 取消预订
+func (s *SmartContract) ExpireReservation(ctx contractapi.TransactionContextInterface, reservationID string) error {
+	reservation, reservationKey, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	if reservation.Status != RESERVATION_PENDING_DEPOSIT && reservation.Status != RESERVATION_ACTIVE {
+		return fmt.Errorf("预订 %s 当前状态为 %s，无需失效处理", reservationID, reservation.Status)
+	}
+
+	updateTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+	if !reservationExpired(reservation, updateTime) {
+		return fmt.Errorf("预订 %s 将于 %s 到期，尚未过期", reservationID, reservation.ExpireTime.Format(time.RFC3339))
+	}
+
+	car, carKey, err := s.getCar(ctx, reservation.CarID)
+	if err != nil {
+		return err
+	}
+	if car.Status == RESERVED {
+		if err := s.moveCarStatus(ctx, car, carKey, AVAILABLE, updateTime); err != nil {
+			return err
+		}
+	}
+
+	if reservation.Status == RESERVATION_ACTIVE {
+		reservation.DepositSettlement = DEPOSIT_REFUNDED
+	}
+	reservation.Status = RESERVATION_EXPIRED
+	reservation.Remark = "超过保留截止时间，预订失效"
+	reservation.UpdateTime = updateTime
+	return s.putReservation(ctx, reservation, reservationKey)
+}
+
+// QueryReservation 查询预订信息
+func (s *SmartContract) QueryReservation(ctx contractapi.TransactionContextInterface, reservationID string) (*Reservation, error) {
+	reservation, _, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Expired = reservationExpired(reservation, now)
+	return reservation, nil
+}
+
+// QueryReservationList 分页查询预订列表，status 为空时查询全部状态
+func (s *SmartContract) QueryReservationList(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string, status string) (*QueryResult, error) {
+	attributes := []string{}
+	if status != "" {
+		isValidStatus := false
+		for _, validStatus := range []ReservationStatus{RESERVATION_PENDING_DEPOSIT, RESERVATION_ACTIVE, RESERVATION_CONVERTED, RESERVATION_CANCELLED, RESERVATION_EXPIRED} {
+			if ReservationStatus(status) == validStatus {
+				isValidStatus = true
+				break
+			}
+		}
+		if !isValidStatus {
+			return nil, fmt.Errorf("无效的预订状态: %s", status)
+		}
+		attributes = append(attributes, status)
+	}
+
+	now, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(RESERVATION, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("查询列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	records := make([]interface{}, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var reservation Reservation
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &reservation)
+		if err != nil {
+			return nil, fmt.Errorf("解析预订信息失败：%v", err)
+		}
+		reservation.Expired = reservationExpired(&reservation, now)
+
+		records = append(records, reservation)
+	}
+
+	return &QueryResult{
+		Records:             records,
+		RecordsCount:        int32(len(records)),
+		Bookmark:            metadata.Bookmark,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+	}, nil
+}
+
+// getReservation 遍历所有状态查找预订，返回预订信息及其当前的复合键（复合键：类型_状态_预订ID）
+func (s *SmartContract) getReservation(ctx contractapi.TransactionContextInterface, reservationID string) (*Reservation, string, error) {
+	if len(reservationID) == 0 {
+		return nil, "", fmt.Errorf("预订ID不能为空")
+	}
+	for _, status := range []ReservationStatus{RESERVATION_PENDING_DEPOSIT, RESERVATION_ACTIVE, RESERVATION_CONVERTED, RESERVATION_CANCELLED, RESERVATION_EXPIRED} {
+		key, err := s.getCompositeKey(ctx, RESERVATION, []string{string(status), reservationID})
+		if err != nil {
+			return nil, "", err
+		}
+
+		bytes, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, "", fmt.Errorf("查询预订信息失败：%v", err)
+		}
+		if bytes != nil {
+			var reservation Reservation
+			err = s.unmarshalState(ctx, key, bytes, &reservation)
+			if err != nil {
+				return nil, "", fmt.Errorf("解析预订信息失败：%v", err)
+			}
+			return &reservation, key, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("预订ID %s 不存在", reservationID)
+}
+
+// putReservation 以预订的当前状态写入记录，oldKey 非空且与新键不同时删除旧记录
+func (s *SmartContract) putReservation(ctx contractapi.TransactionContextInterface, reservation *Reservation, oldKey string) error {
+	newKey, err := s.getCompositeKey(ctx, RESERVATION, []string{string(reservation.Status), reservation.ID})
+	if err != nil {
+		return err
+	}
+	if oldKey != "" && oldKey != newKey {
+		if err := ctx.GetStub().DelState(oldKey); err != nil {
+			return fmt.Errorf("删除旧的预订记录失败：%v", err)
+		}
+	}
+	return s.putState(ctx, newKey, reservation)
+}
+
+// moveCarStatus 变更汽车状态；状态是复合键的一部分，需要删除旧记录后以新状态写入
+func (s *SmartContract) moveCarStatus(ctx contractapi.TransactionContextInterface, car *Car, carKey string, status CarStatus, updateTime time.Time) error {
+	if err := ctx.GetStub().DelState(carKey); err != nil {
+		return fmt.Errorf("删除旧的汽车记录失败：%v", err)
+	}
+	car.Status = status
+	car.UpdateTime = updateTime
+
+	newCarKey, err := s.getCompositeKey(ctx, CAR, []string{string(status), car.ID})
+	if err != nil {
+		return err
+	}
+	return s.putState(ctx, newCarKey, car)
+}
+
+// reservationExpired 判断尚在保留中的预订是否已超过保留截止时间
+func reservationExpired(reservation *Reservation, now time.Time) bool {
+	if reservation.Status != RESERVATION_PENDING_DEPOSIT && reservation.Status != RESERVATION_ACTIVE {
+		return false
+	}
+	return !now.Before(reservation.ExpireTime)
+}
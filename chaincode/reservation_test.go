@@ -0,0 +1,162 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// createTestReservation 由交易平台创建预订，depositRef 非空时由银行确认定金
+func createTestReservation(t *testing.T, l *mockLedger, reservationID string, carID string, seller string, buyer string, holdDays int, depositRef string) {
+	t.Helper()
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateReservation(ctx, reservationID, carID, seller, buyer, 10, holdDays)
+	}))
+	if depositRef != "" {
+		requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.ConfirmReservationDeposit(ctx, reservationID, depositRef)
+		}))
+	}
+}
+
+// queryTestReservation 查询预订信息
+func queryTestReservation(t *testing.T, l *mockLedger, reservationID string) *Reservation {
+	t.Helper()
+	var reservation *Reservation
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		reservation, err = testContract.QueryReservation(ctx, reservationID)
+		return err
+	}))
+	return reservation
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+
+	tests := []struct {
+		name     string
+		buyer    string
+		deposit  float64
+		holdDays int
+		err      string
+	}{
+		{"买卖双方相同", "dealer", 10, 3, "买家和卖家不能是同一人"},
+		{"定金为0", "alice", 0, 3, "定金必须大于0"},
+		{"保留天数为0", "alice", 10, 0, "保留天数必须在 1 到 30 天之间"},
+		{"保留天数过长", "alice", 10, MAX_RESERVATION_DAYS + 1, "保留天数必须在 1 到 30 天之间"},
+		{"买家未登记", "nobody", 10, 3, "买家参与方 nobody 未登记"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.CreateReservation(ctx, "R1", carID, "dealer", tt.buyer, tt.deposit, tt.holdDays)
+			}), tt.err)
+		})
+	}
+
+	createTestReservation(t, l, "R1", carID, "dealer", "alice", 3, "")
+	if car := queryTestCar(t, l, carID); car.Status != RESERVED {
+		t.Fatalf("预订后汽车状态为 %s", car.Status)
+	}
+	requireError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, "T1", carID, "dealer", "alice", 100)
+	}), "已被预订，只能由该预订转为交易")
+	requireError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ConvertReservation(ctx, "R1", "T1", 100)
+	}), "定金尚未经银行确认")
+
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ConfirmReservationDeposit(ctx, "R1", "DEP-1")
+	}))
+	requireError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ConvertReservation(ctx, "R1", "T1", 5)
+	}), "定金 10.00 不能高于成交价 5.00")
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ConvertReservation(ctx, "R1", "T1", 100)
+	}))
+
+	reservation := queryTestReservation(t, l, "R1")
+	if reservation.Status != RESERVATION_CONVERTED || reservation.DepositSettlement != DEPOSIT_APPLIED || reservation.TransactionID != "T1" {
+		t.Fatalf("转为交易后的预订不正确：%+v", reservation)
+	}
+	if car := queryTestCar(t, l, carID); car.Status != IN_TRANSACTION {
+		t.Fatalf("转为交易后汽车状态为 %s", car.Status)
+	}
+}
+
+func TestExpireReservation(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	pendingCarID := createTestCar(t, l, "京A00001", "dealer")
+	activeCarID := createTestCar(t, l, "京A00002", "dealer")
+	cancelledCarID := createTestCar(t, l, "京A00003", "dealer")
+	createTestReservation(t, l, "PENDING", pendingCarID, "dealer", "alice", 1, "")
+	createTestReservation(t, l, "ACTIVE", activeCarID, "dealer", "alice", 1, "DEP-1")
+	createTestReservation(t, l, "CANCELLED", cancelledCarID, "dealer", "alice", 1, "")
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CancelReservation(ctx, "CANCELLED", "", "买家放弃")
+	}))
+
+	cc, err := newChaincode()
+	requireNoError(t, err)
+	// 任何组织都可以使过期预订失效，这里以保险公司组织调用
+	expire := func(reservationID string) *Reservation {
+		response := l.invoke(cc, INSURER_ORG_MSPID, "user1", false, "ExpireReservation", reservationID)
+		if response.Status != shim.OK {
+			t.Fatalf("预订 %s 失效处理失败：%s", reservationID, response.Message)
+		}
+		return queryTestReservation(t, l, reservationID)
+	}
+
+	requireError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ExpireReservation(ctx, "PENDING")
+	}), "尚未过期")
+	l.now = l.now.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name          string
+		reservationID string
+		carID         string
+		settlement    DepositSettlement
+	}{
+		{"定金未确认", "PENDING", pendingCarID, ""},
+		{"定金已确认时退还买家", "ACTIVE", activeCarID, DEPOSIT_REFUNDED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reservation := queryTestReservation(t, l, tt.reservationID); !reservation.Expired {
+				t.Fatalf("预订 %s 应已过期", tt.reservationID)
+			}
+			reservation := expire(tt.reservationID)
+			if reservation.Status != RESERVATION_EXPIRED || reservation.DepositSettlement != tt.settlement || reservation.Expired {
+				t.Fatalf("失效后的预订不正确：%+v", reservation)
+			}
+			if car := queryTestCar(t, l, tt.carID); car.Status != AVAILABLE {
+				t.Fatalf("预订失效后汽车状态为 %s", car.Status)
+			}
+			requireError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.ExpireReservation(ctx, tt.reservationID)
+			}), "当前状态为 EXPIRED，无需失效处理")
+		})
+	}
+	requireError(t, l.call(INSURER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.ExpireReservation(ctx, "CANCELLED")
+	}), "当前状态为 CANCELLED，无需失效处理")
+
+	// 汽车释放后可以重新交易
+	sellTestCar(t, l, "T1", activeCarID, "dealer", "alice", 100)
+
+	var result *QueryResult
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		result, err = testContract.QueryReservationList(ctx, 10, "", string(RESERVATION_EXPIRED))
+		return err
+	}))
+	if result.RecordsCount != 2 {
+		t.Fatalf("已失效预订数量为 %d", result.RecordsCount)
+	}
+}
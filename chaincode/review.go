@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// 评分范围
+const (
+	MIN_REVIEW_RATING = 1
+	MAX_REVIEW_RATING = 5
+)
+
+// 评价内容的最大字数
+const MAX_REVIEW_COMMENT_LENGTH = 500
+
+// Review 交易评价：买家在交易完成后对卖家和经销商的评分，每笔交易只能评价一次
+type Review struct {
+	TransactionID string    `json:"transactionId"` // 关联的交易ID
+	Reviewer      string    `json:"reviewer"`      // 评价人（交易买家的参与方ID）
+	Seller        string    `json:"seller"`        // 被评价的卖家（参与方ID）
+	Dealers       []string  `json:"dealers"`       // 被评价的经销商：所购汽车首次登记时的所有者（参与方ID）
+	SellerRating  int       `json:"sellerRating"`  // 卖家评分（1-5）
+	DealerRating  int       `json:"dealerRating"`  // 经销商评分（1-5）
+	Comment       string    `json:"comment"`       // 评价内容
+	CreateTime    time.Time `json:"createTime"`    // 评价时间
+
+	SchemaVersion int `json:"schemaVersion"` // 记录结构版本
+}
+
+// RoleReputation 参与方在某一角色下的评价汇总
+type RoleReputation struct {
+	ReviewCount   int     `json:"reviewCount"`   // 评价数量
+	AverageRating float64 `json:"averageRating"` // 平均评分（保留两位小数，无评价时为0）
+	RatingCounts  []int   `json:"ratingCounts"`  // 各评分的评价数量，下标 0 对应 1 分
+}
+
+// Reputation 参与方的信誉汇总
+type Reputation struct {
+	PartyID     string          `json:"partyId"`     // 参与方ID
+	AsSeller    *RoleReputation `json:"asSeller"`    // 作为卖家获得的评价
+	AsDealer    *RoleReputation `json:"asDealer"`    // 作为经销商获得的评价
+	GeneratedAt time.Time       `json:"generatedAt"` // 汇总时间
+}
+
+// SubmitReview 提交交易评价（仅交易平台组织可以代买家提交）
+// 仅限已完成的交易，评价人必须是该交易的买家，每笔交易只能评价一次
+func (s *SmartContract) SubmitReview(ctx contractapi.TransactionContextInterface, txID string, reviewer string, sellerRating int, dealerRating int, comment string) error {
+	for _, rating := range []int{sellerRating, dealerRating} {
+		if rating < MIN_REVIEW_RATING || rating > MAX_REVIEW_RATING {
+			return fmt.Errorf("评分必须在 %d 到 %d 之间", MIN_REVIEW_RATING, MAX_REVIEW_RATING)
+		}
+	}
+	if utf8.RuneCountInString(comment) > MAX_REVIEW_COMMENT_LENGTH {
+		return fmt.Errorf("评价内容不能超过 %d 字", MAX_REVIEW_COMMENT_LENGTH)
+	}
+
+	transaction, err := s.QueryTransaction(ctx, txID)
+	if err != nil {
+		return err
+	}
+	if transaction.Status != COMPLETED {
+		return fmt.Errorf("交易 %s 尚未完成，不能评价", txID)
+	}
+	if transaction.Buyer != reviewer {
+		return fmt.Errorf("只有交易 %s 的买家才能评价", txID)
+	}
+
+	reviewKey, err := s.getCompositeKey(ctx, REVIEW, []string{txID})
+	if err != nil {
+		return err
+	}
+	existsBytes, err := ctx.GetStub().GetState(reviewKey)
+	if err != nil {
+		return fmt.Errorf("查询评价信息失败：%v", err)
+	}
+	if existsBytes != nil {
+		return fmt.Errorf("交易 %s 已评价，不能重复评价", txID)
+	}
+
+	dealers, err := s.getTransactionDealers(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	review := Review{
+		TransactionID: txID,
+		Reviewer:      reviewer,
+		Seller:        transaction.Seller,
+		Dealers:       dealers,
+		SellerRating:  sellerRating,
+		DealerRating:  dealerRating,
+		Comment:       comment,
+		CreateTime:    createTime,
+	}
+	return s.putState(ctx, reviewKey, review)
+}
+
+// QueryReview 查询交易的评价
+func (s *SmartContract) QueryReview(ctx contractapi.TransactionContextInterface, txID string) (*Review, error) {
+	if len(txID) == 0 {
+		return nil, fmt.Errorf("交易ID不能为空")
+	}
+	reviewKey, err := s.getCompositeKey(ctx, REVIEW, []string{txID})
+	if err != nil {
+		return nil, err
+	}
+
+	var review Review
+	err = s.getState(ctx, reviewKey, &review)
+	if err != nil {
+		return nil, fmt.Errorf("交易 %s 暂无评价", txID)
+	}
+	return &review, nil
+}
+
+// QueryPartyReviews 查询参与方作为卖家或经销商收到的全部评价
+func (s *SmartContract) QueryPartyReviews(ctx contractapi.TransactionContextInterface, partyID string) ([]*Review, error) {
+	if len(partyID) == 0 {
+		return nil, fmt.Errorf("参与方ID不能为空")
+	}
+
+	reviews, err := s.getAllReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Review, 0)
+	for _, review := range reviews {
+		if review.Seller == partyID || containsString(review.Dealers, partyID) {
+			result = append(result, review)
+		}
+	}
+	return result, nil
+}
+
+// QueryReputation 汇总参与方作为卖家和经销商获得的评分
+func (s *SmartContract) QueryReputation(ctx contractapi.TransactionContextInterface, partyID string) (*Reputation, error) {
+	if _, err := s.requireParty(ctx, partyID); err != nil {
+		return nil, err
+	}
+
+	reviews, err := s.getAllReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	generatedAt, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sellerRatings := make([]int, 0)
+	dealerRatings := make([]int, 0)
+	for _, review := range reviews {
+		if review.Seller == partyID {
+			sellerRatings = append(sellerRatings, review.SellerRating)
+		}
+		if containsString(review.Dealers, partyID) {
+			dealerRatings = append(dealerRatings, review.DealerRating)
+		}
+	}
+
+	return &Reputation{
+		PartyID:     partyID,
+		AsSeller:    summarizeRatings(sellerRatings),
+		AsDealer:    summarizeRatings(dealerRatings),
+		GeneratedAt: generatedAt,
+	}, nil
+}
+
+// getAllReviews 读取全部评价
+func (s *SmartContract) getAllReviews(ctx contractapi.TransactionContextInterface) ([]*Review, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(REVIEW, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("查询评价列表失败：%v", err)
+	}
+	defer iterator.Close()
+
+	reviews := make([]*Review, 0)
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+
+		var review Review
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &review)
+		if err != nil {
+			return nil, fmt.Errorf("解析评价信息失败：%v", err)
+		}
+		reviews = append(reviews, &review)
+	}
+	return reviews, nil
+}
+
+// getTransactionDealers 取交易中所购汽车首次登记时的所有者作为经销商（去重，保持汽车顺序）
+func (s *SmartContract) getTransactionDealers(ctx contractapi.TransactionContextInterface, transaction *Transaction) ([]string, error) {
+	dealers := make([]string, 0)
+	for _, item := range transaction.soldItems() {
+		history, err := s.QueryOwnershipHistory(ctx, item.CarID)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range history {
+			if record.ChangeType == OWNERSHIP_REGISTRATION {
+				if !containsString(dealers, record.ToOwner) {
+					dealers = append(dealers, record.ToOwner)
+				}
+				break
+			}
+		}
+	}
+	return dealers, nil
+}
+
+// summarizeRatings 汇总评分
+func summarizeRatings(ratings []int) *RoleReputation {
+	summary := &RoleReputation{
+		ReviewCount:  len(ratings),
+		RatingCounts: make([]int, MAX_REVIEW_RATING-MIN_REVIEW_RATING+1),
+	}
+	if len(ratings) == 0 {
+		return summary
+	}
+
+	total := 0
+	for _, rating := range ratings {
+		total += rating
+		summary.RatingCounts[rating-MIN_REVIEW_RATING]++
+	}
+	summary.AverageRating = math.Round(float64(total)/float64(len(ratings))*100) / 100
+	return summary
+}
+
+// containsString 判断字符串切片中是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestSubmitReview(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	registerTestParty(t, l, "bob")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	sellTestCar(t, l, "T1", carID, "dealer", "alice", 100)
+	pendingCarID := createTestCar(t, l, "京A00002", "dealer")
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, "T2", pendingCarID, "dealer", "alice", 100)
+	}))
+
+	submit := func(txID string, reviewer string, sellerRating int, dealerRating int, comment string) error {
+		return l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SubmitReview(ctx, txID, reviewer, sellerRating, dealerRating, comment)
+		})
+	}
+
+	tests := []struct {
+		name         string
+		txID         string
+		reviewer     string
+		sellerRating int
+		dealerRating int
+		comment      string
+		err          string
+	}{
+		{"卖家评分过低", "T1", "alice", 0, 5, "", "评分必须在 1 到 5 之间"},
+		{"经销商评分过高", "T1", "alice", 5, 6, "", "评分必须在 1 到 5 之间"},
+		{"评价内容过长", "T1", "alice", 5, 5, strings.Repeat("好", MAX_REVIEW_COMMENT_LENGTH+1), "评价内容不能超过 500 字"},
+		{"交易未完成", "T2", "alice", 5, 5, "", "交易 T2 尚未完成，不能评价"},
+		{"评价人不是买家", "T1", "bob", 5, 5, "", "只有交易 T1 的买家才能评价"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, submit(tt.txID, tt.reviewer, tt.sellerRating, tt.dealerRating, tt.comment), tt.err)
+		})
+	}
+
+	requireNoError(t, submit("T1", "alice", 4, 5, strings.Repeat("好", MAX_REVIEW_COMMENT_LENGTH)))
+	requireError(t, submit("T1", "alice", 4, 5, ""), "交易 T1 已评价，不能重复评价")
+
+	var review *Review
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		review, err = testContract.QueryReview(ctx, "T1")
+		return err
+	}))
+	if review.Seller != "dealer" || !reflect.DeepEqual(review.Dealers, []string{"dealer"}) {
+		t.Fatalf("评价信息不正确：%+v", review)
+	}
+}
+
+func TestQueryReputation(t *testing.T) {
+	l := newMockLedger(t)
+	for _, partyID := range []string{"dealer", "alice", "bob"} {
+		registerTestParty(t, l, partyID)
+	}
+	// 经销商是汽车首次登记时的所有者：alice 自己登记的汽车由 alice 同时作为卖家和经销商被评价
+	sellTestCar(t, l, "T1", createTestCar(t, l, "京A00001", "dealer"), "dealer", "alice", 100)
+	sellTestCar(t, l, "T2", createTestCar(t, l, "京A00002", "alice"), "alice", "bob", 80)
+	sellTestCar(t, l, "T3", createTestCar(t, l, "京A00003", "dealer"), "dealer", "bob", 100)
+
+	for _, review := range []struct {
+		txID, reviewer             string
+		sellerRating, dealerRating int
+	}{
+		{"T1", "alice", 5, 4},
+		{"T2", "bob", 3, 2},
+		{"T3", "bob", 4, 4},
+	} {
+		requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.SubmitReview(ctx, review.txID, review.reviewer, review.sellerRating, review.dealerRating, "")
+		}))
+	}
+
+	tests := []struct {
+		partyID  string
+		asSeller RoleReputation
+		asDealer RoleReputation
+	}{
+		{"dealer", RoleReputation{2, 4.5, []int{0, 0, 0, 1, 1}}, RoleReputation{2, 4, []int{0, 0, 0, 2, 0}}},
+		{"alice", RoleReputation{1, 3, []int{0, 0, 1, 0, 0}}, RoleReputation{1, 2, []int{0, 1, 0, 0, 0}}},
+		{"bob", RoleReputation{0, 0, []int{0, 0, 0, 0, 0}}, RoleReputation{0, 0, []int{0, 0, 0, 0, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.partyID, func(t *testing.T) {
+			var reputation *Reputation
+			requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+				reputation, err = testContract.QueryReputation(ctx, tt.partyID)
+				return err
+			}))
+			if !reflect.DeepEqual(*reputation.AsSeller, tt.asSeller) || !reflect.DeepEqual(*reputation.AsDealer, tt.asDealer) {
+				t.Fatalf("信誉汇总不正确：卖家 %+v，经销商 %+v", reputation.AsSeller, reputation.AsDealer)
+			}
+		})
+	}
+
+	requireError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.QueryReputation(ctx, "nobody")
+		return err
+	}), "nobody")
+}
+
+func TestSummarizeRatings(t *testing.T) {
+	tests := []struct {
+		ratings []int
+		want    RoleReputation
+	}{
+		{nil, RoleReputation{0, 0, []int{0, 0, 0, 0, 0}}},
+		{[]int{4, 4, 2}, RoleReputation{3, 3.33, []int{0, 1, 0, 2, 0}}},
+		{[]int{5, 4, 4}, RoleReputation{3, 4.33, []int{0, 0, 0, 2, 1}}},
+		{[]int{1, 2}, RoleReputation{2, 1.5, []int{1, 1, 0, 0, 0}}},
+	}
+	for _, tt := range tests {
+		if got := summarizeRatings(tt.ratings); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("summarizeRatings(%v) = %+v，应为 %+v", tt.ratings, *got, tt.want)
+		}
+	}
+}
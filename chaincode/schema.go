@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// CURRENT_SCHEMA_VERSION 当前账本记录的结构版本
+// 版本 0：未携带 schemaVersion 字段的历史记录
+// 版本 1：增加 schemaVersion 字段，交易中的 realEstateId 更名为 carId
+// 版本 2：汽车增加独立于车牌号的内部ID，旧汽车的车牌号取自原汽车ID
+// 版本 3：证书增加状态字段，旧证书均视为有效
+// 版本 4：交易增加交易类型和应付金额，旧交易均为普通买卖
+const CURRENT_SCHEMA_VERSION = 4
+
+// schemaUpgrader 将原始记录从某个版本升级到下一个版本
+type schemaUpgrader func(record map[string]interface{}) error
+
+// schemaUpgrades 各文档类型的逐级升级函数：schemaUpgrades[类型][起始版本]
+// 未登记的版本升级只需要写入新的版本号
+var schemaUpgrades = map[string]map[int]schemaUpgrader{
+	TRANSACTION: {
+		0: func(record map[string]interface{}) error {
+			return renameField(record, "realEstateId", "carId")
+		},
+		3: func(record map[string]interface{}) error {
+			if txType, _ := record["type"].(string); txType == "" {
+				record["type"] = string(TX_TYPE_SALE)
+			}
+			if _, ok := record["netAmount"]; !ok {
+				record["netAmount"] = record["price"]
+			}
+			return nil
+		},
+	},
+	CAR: {
+		1: func(record map[string]interface{}) error {
+			if plate, _ := record["plate"].(string); plate == "" {
+				record["plate"] = record["id"]
+			}
+			return nil
+		},
+	},
+	CERTIFICATE: {
+		2: func(record map[string]interface{}) error {
+			if status, _ := record["status"].(string); status == "" {
+				record["status"] = string(CERT_ACTIVE)
+			}
+			return nil
+		},
+	},
+}
+
+// versionedObjectTypes 参与版本管理与迁移的文档类型（按迁移顺序）
+var versionedObjectTypes = []string{CAR, TRANSACTION, CERTIFICATE, STOLEN_FLAG, PARTY, OWNERSHIP, CAR_CHANGE, APPRAISER, APPRAISAL, CONFIG, POLICY, CLAIM, ISSUER, RESERVATION, REVIEW, NFT_APPROVAL, NFT_OPERATOR, NFT_DOCUMENT, LOAN, AUDITOR}
+
+// renameField 重命名记录中的字段（新字段已存在时保留新字段）
+func renameField(record map[string]interface{}, oldName string, newName string) error {
+	value, ok := record[oldName]
+	if !ok {
+		return nil
+	}
+	if _, exists := record[newName]; !exists {
+		record[newName] = value
+	}
+	delete(record, oldName)
+	return nil
+}
+
+// getRecordSchemaVersion 读取原始记录中的版本号，缺失时视为版本 0
+func getRecordSchemaVersion(record map[string]interface{}) (int, error) {
+	raw, ok := record["schemaVersion"]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("schemaVersion 字段类型无效：%v", raw)
+	}
+	version, err := number.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("schemaVersion 字段无效：%v", err)
+	}
+	return int(version), nil
+}
+
+// upgradeRecord 将原始 JSON 记录升级到当前版本
+// 返回升级后的 JSON、记录原来的版本号，以及是否发生了升级
+func upgradeRecord(objectType string, data []byte) ([]byte, int, bool, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // 保留数字精度
+	var record map[string]interface{}
+	if err := decoder.Decode(&record); err != nil {
+		return nil, 0, false, fmt.Errorf("解析数据失败：%v", err)
+	}
+
+	fromVersion, err := getRecordSchemaVersion(record)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if fromVersion > CURRENT_SCHEMA_VERSION {
+		return nil, fromVersion, false, fmt.Errorf("记录版本 %d 高于链码支持的版本 %d，请先升级链码", fromVersion, CURRENT_SCHEMA_VERSION)
+	}
+	if fromVersion == CURRENT_SCHEMA_VERSION {
+		return data, fromVersion, false, nil
+	}
+
+	for version := fromVersion; version < CURRENT_SCHEMA_VERSION; version++ {
+		if upgrade, ok := schemaUpgrades[objectType][version]; ok {
+			if err := upgrade(record); err != nil {
+				return nil, fromVersion, false, fmt.Errorf("记录从版本 %d 升级失败：%v", version, err)
+			}
+		}
+	}
+	record["schemaVersion"] = CURRENT_SCHEMA_VERSION
+
+	upgraded, err := json.Marshal(record)
+	if err != nil {
+		return nil, fromVersion, false, fmt.Errorf("序列化升级后的记录失败：%v", err)
+	}
+	return upgraded, fromVersion, true, nil
+}
+
+// withSchemaVersion 返回写入了当前版本号的记录副本（记录没有 SchemaVersion 字段时原样返回）
+func withSchemaVersion(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return value
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return value
+	}
+
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	field := copied.FieldByName("SchemaVersion")
+	if !field.IsValid() || !field.CanSet() || field.Kind() != reflect.Int {
+		return value
+	}
+	field.SetInt(CURRENT_SCHEMA_VERSION)
+	return copied.Interface()
+}
+
+// 通用方法：解析账本记录，读取时自动升级到当前版本
+func (s *SmartContract) unmarshalState(ctx contractapi.TransactionContextInterface, key string, data []byte, value interface{}) error {
+	objectType, _, err := ctx.GetStub().SplitCompositeKey(key)
+	if err != nil {
+		return fmt.Errorf("解析复合键失败：%v", err)
+	}
+
+	upgraded, _, _, err := upgradeRecord(objectType, data)
+	if err != nil {
+		return fmt.Errorf("升级记录 %s 失败：%v", key, err)
+	}
+
+	err = json.Unmarshal(upgraded, value)
+	if err != nil {
+		return fmt.Errorf("解析数据失败：%v", err)
+	}
+	return nil
+}
+
+// MigrationBookmark 分页迁移书签
+type MigrationBookmark struct {
+	ObjectType string `json:"objectType"` // 当前正在迁移的文档类型
+	Bookmark   string `json:"bookmark"`   // 该文档类型分页查询返回的书签
+}
+
+// MigrationKey 待迁移记录的复合键
+type MigrationKey struct {
+	ObjectType string   `json:"objectType"` // 文档类型
+	Attributes []string `json:"attributes"` // 复合键属性
+}
+
+// MigrationPage 一页待迁移记录
+type MigrationPage struct {
+	FromVersion  int             `json:"fromVersion"`  // 待迁移的记录版本
+	ToVersion    int             `json:"toVersion"`    // 迁移后的记录版本
+	ObjectType   string          `json:"objectType"`   // 本页查询的文档类型
+	ScannedCount int32           `json:"scannedCount"` // 本页扫描的记录数
+	SkippedCount int32           `json:"skippedCount"` // 本页跳过的记录数（版本不匹配）
+	Keys         []*MigrationKey `json:"keys"`         // 本页需要迁移的记录
+	Bookmark     string          `json:"bookmark"`     // 下一页书签，为空表示查询完成
+	Done         bool            `json:"done"`         // 是否已全部查询完成
+}
+
+// MigrationStatus 一页记录的迁移进度
+type MigrationStatus struct {
+	ToVersion       int    `json:"toVersion"`       // 当前记录版本
+	ObjectType      string `json:"objectType"`      // 本页查询的文档类型
+	ScannedCount    int32  `json:"scannedCount"`    // 本页扫描的记录数
+	PendingCount    int32  `json:"pendingCount"`    // 本页低于当前版本的记录数
+	PendingVersions []int  `json:"pendingVersions"` // 本页旧记录的版本号（升序）
+	Bookmark        string `json:"bookmark"`        // 下一页书签，为空表示查询完成
+	Done            bool   `json:"done"`            // 是否已全部查询完成
+}
+
+// MigrationResult 迁移结果
+type MigrationResult struct {
+	FromVersion   int   `json:"fromVersion"`   // 待迁移的记录版本
+	ToVersion     int   `json:"toVersion"`     // 迁移后的记录版本
+	MigratedCount int32 `json:"migratedCount"` // 重写的记录数
+	SkippedCount  int32 `json:"skippedCount"`  // 跳过的记录数（记录不存在或版本已变化）
+}
+
+// encodeMigrationBookmark 编码迁移书签（分页书签中可能包含不可见字符，使用 base64 传输）
+func encodeMigrationBookmark(bookmark MigrationBookmark) (string, error) {
+	data, err := json.Marshal(bookmark)
+	if err != nil {
+		return "", fmt.Errorf("序列化迁移书签失败：%v", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// decodeMigrationBookmark 解码迁移书签，空书签表示从第一个文档类型开始
+func decodeMigrationBookmark(bookmark string) (MigrationBookmark, error) {
+	if bookmark == "" {
+		return MigrationBookmark{ObjectType: versionedObjectTypes[0]}, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(bookmark)
+	if err != nil {
+		return MigrationBookmark{}, fmt.Errorf("迁移书签格式错误：%v", err)
+	}
+	var decoded MigrationBookmark
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return MigrationBookmark{}, fmt.Errorf("迁移书签格式错误：%v", err)
+	}
+	return decoded, nil
+}
+
+// isVersionedObjectType 判断文档类型是否参与版本管理与迁移
+func isVersionedObjectType(objectType string) bool {
+	for _, versioned := range versionedObjectTypes {
+		if versioned == objectType {
+			return true
+		}
+	}
+	return false
+}
+
+// scanMigrationPage 按迁移书签只读地扫描一页参与版本管理的记录，对每条记录调用 visit（传入记录键、原来的版本号以及是否需要升级）
+// 返回本页的文档类型、扫描的记录数和下一页书签，书签为空表示已扫描完全部文档类型
+func (s *SmartContract) scanMigrationPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string, visit func(key string, version int, changed bool) error) (string, int32, string, error) {
+	if pageSize <= 0 {
+		return "", 0, "", fmt.Errorf("每页条数必须大于0")
+	}
+
+	current, err := decodeMigrationBookmark(bookmark)
+	if err != nil {
+		return "", 0, "", err
+	}
+	typeIndex := -1
+	for i, objectType := range versionedObjectTypes {
+		if objectType == current.ObjectType {
+			typeIndex = i
+			break
+		}
+	}
+	if typeIndex < 0 {
+		return "", 0, "", fmt.Errorf("迁移书签中的文档类型 %s 无效", current.ObjectType)
+	}
+
+	iterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(current.ObjectType, []string{}, pageSize, current.Bookmark)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("查询 %s 记录失败：%v", current.ObjectType, err)
+	}
+	defer iterator.Close()
+
+	var scanned int32
+	for iterator.HasNext() {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return "", 0, "", fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+		scanned++
+
+		_, version, changed, err := upgradeRecord(current.ObjectType, queryResponse.Value)
+		if err != nil {
+			return "", 0, "", fmt.Errorf("升级记录 %s 失败：%v", queryResponse.Key, err)
+		}
+		if err := visit(queryResponse.Key, version, changed); err != nil {
+			return "", 0, "", err
+		}
+	}
+
+	// 计算下一页书签：当前类型还有数据则继续，否则切换到下一个类型
+	var next *MigrationBookmark
+	if len(metadata.Bookmark) > 0 && metadata.FetchedRecordsCount >= pageSize {
+		next = &MigrationBookmark{ObjectType: current.ObjectType, Bookmark: metadata.Bookmark}
+	} else if typeIndex+1 < len(versionedObjectTypes) {
+		next = &MigrationBookmark{ObjectType: versionedObjectTypes[typeIndex+1]}
+	}
+	if next == nil {
+		return current.ObjectType, scanned, "", nil
+	}
+	nextBookmark, err := encodeMigrationBookmark(*next)
+	if err != nil {
+		return "", 0, "", err
+	}
+	return current.ObjectType, scanned, nextBookmark, nil
+}
+
+// QueryMigrationPage 分页查询指定版本的待迁移记录（仅组织管理员可以调用）
+// Fabric 不允许在执行过分页查询的交易中写入账本，因此由本函数按分页书签只读地找出待迁移的键，再通过 MigrateState 重写
+func (s *SmartContract) QueryMigrationPage(ctx contractapi.TransactionContextInterface, fromVersion int, pageSize int32, bookmark string) (*MigrationPage, error) {
+	if fromVersion < 0 || fromVersion >= CURRENT_SCHEMA_VERSION {
+		return nil, fmt.Errorf("起始版本必须在 0 到 %d 之间", CURRENT_SCHEMA_VERSION-1)
+	}
+
+	page := &MigrationPage{
+		FromVersion: fromVersion,
+		ToVersion:   CURRENT_SCHEMA_VERSION,
+		Keys:        make([]*MigrationKey, 0),
+	}
+	objectType, scanned, next, err := s.scanMigrationPage(ctx, pageSize, bookmark, func(key string, version int, changed bool) error {
+		if !changed || version != fromVersion {
+			page.SkippedCount++
+			return nil
+		}
+		objectType, attributes, err := ctx.GetStub().SplitCompositeKey(key)
+		if err != nil {
+			return fmt.Errorf("解析记录键 %s 失败：%v", key, err)
+		}
+		page.Keys = append(page.Keys, &MigrationKey{ObjectType: objectType, Attributes: attributes})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	page.ObjectType = objectType
+	page.ScannedCount = scanned
+	page.Bookmark = next
+	page.Done = next == ""
+	return page, nil
+}
+
+// QueryMigrationStatus 分页统计低于当前版本的记录数（仅组织管理员可以调用），用于确认迁移已全部完成
+// 与 QueryMigrationPage 使用同一种书签，各页的 pendingCount 之和为 0 时账本中已没有任何旧版本记录
+func (s *SmartContract) QueryMigrationStatus(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*MigrationStatus, error) {
+	status := &MigrationStatus{
+		ToVersion:       CURRENT_SCHEMA_VERSION,
+		PendingVersions: make([]int, 0),
+	}
+	objectType, scanned, next, err := s.scanMigrationPage(ctx, pageSize, bookmark, func(key string, version int, changed bool) error {
+		if !changed {
+			return nil
+		}
+		status.PendingCount++
+		for _, pending := range status.PendingVersions {
+			if pending == version {
+				return nil
+			}
+		}
+		status.PendingVersions = append(status.PendingVersions, version)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Ints(status.PendingVersions)
+	status.ObjectType = objectType
+	status.ScannedCount = scanned
+	status.Bookmark = next
+	status.Done = next == ""
+	return status, nil
+}
+
+// MigrateState 将 QueryMigrationPage 返回的记录升级并重写为当前版本（仅组织管理员可以调用）
+// 只按给定的键读取和写入，读写集与记录数成正比；版本已经变化的记录会被跳过
+// 从版本 0、1 迁移汽车时同时补写车牌号索引
+func (s *SmartContract) MigrateState(ctx contractapi.TransactionContextInterface, fromVersion int, keysJson string) (*MigrationResult, error) {
+	if fromVersion < 0 || fromVersion >= CURRENT_SCHEMA_VERSION {
+		return nil, fmt.Errorf("起始版本必须在 0 到 %d 之间", CURRENT_SCHEMA_VERSION-1)
+	}
+
+	var keys []*MigrationKey
+	err := json.Unmarshal([]byte(keysJson), &keys)
+	if err != nil {
+		return nil, fmt.Errorf("解析待迁移记录 JSON 失败：%v", err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("待迁移记录不能为空")
+	}
+
+	result := &MigrationResult{
+		FromVersion: fromVersion,
+		ToVersion:   CURRENT_SCHEMA_VERSION,
+	}
+	for _, migrationKey := range keys {
+		if migrationKey == nil || !isVersionedObjectType(migrationKey.ObjectType) {
+			return nil, fmt.Errorf("待迁移记录的文档类型无效")
+		}
+		key, err := s.getCompositeKey(ctx, migrationKey.ObjectType, migrationKey.Attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, fmt.Errorf("查询记录 %s 失败：%v", key, err)
+		}
+		if data == nil {
+			result.SkippedCount++
+			continue
+		}
+
+		upgraded, version, changed, err := upgradeRecord(migrationKey.ObjectType, data)
+		if err != nil {
+			return nil, fmt.Errorf("升级记录 %s 失败：%v", key, err)
+		}
+		if !changed || version != fromVersion {
+			result.SkippedCount++
+			continue
+		}
+
+		err = ctx.GetStub().PutState(key, upgraded)
+		if err != nil {
+			return nil, fmt.Errorf("重写记录 %s 失败：%v", key, err)
+		}
+		// 版本 2 之前的汽车没有车牌号索引，迁移时补写
+		if migrationKey.ObjectType == CAR && fromVersion < 2 {
+			err = s.backfillPlateIndex(ctx, upgraded)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.MigratedCount++
+	}
+	return result, nil
+}
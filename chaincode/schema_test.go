@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestUpgradeRecord(t *testing.T) {
+	tests := []struct {
+		name       string
+		objectType string
+		data       string
+		version    int
+		changed    bool
+		want       map[string]interface{}
+		err        string
+	}{
+		{"旧交易字段更名并补充类型", TRANSACTION, `{"id":"T1","realEstateId":"A1","price":12.5}`, 0, true,
+			map[string]interface{}{"carId": "A1", "type": string(TX_TYPE_SALE), "netAmount": 12.5, "schemaVersion": float64(CURRENT_SCHEMA_VERSION)}, ""},
+		{"新字段已存在时保留新字段", TRANSACTION, `{"id":"T1","realEstateId":"A1","carId":"B1","schemaVersion":0}`, 0, true,
+			map[string]interface{}{"carId": "B1"}, ""},
+		{"旧汽车的车牌号取自ID", CAR, `{"id":"京A00001","schemaVersion":1}`, 1, true,
+			map[string]interface{}{"plate": "京A00001", "schemaVersion": float64(CURRENT_SCHEMA_VERSION)}, ""},
+		{"旧证书视为有效", CERTIFICATE, `{"certId":"C1","schemaVersion":2}`, 2, true,
+			map[string]interface{}{"status": string(CERT_ACTIVE)}, ""},
+		{"当前版本不升级", CAR, `{"id":"A1","schemaVersion":4}`, CURRENT_SCHEMA_VERSION, false, nil, ""},
+		{"高于当前版本", CAR, `{"id":"A1","schemaVersion":99}`, 0, false, nil, "请先升级链码"},
+		{"版本号类型无效", CAR, `{"id":"A1","schemaVersion":"1"}`, 0, false, nil, "schemaVersion 字段类型无效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgraded, version, changed, err := upgradeRecord(tt.objectType, []byte(tt.data))
+			if len(tt.err) > 0 {
+				requireError(t, err, tt.err)
+				return
+			}
+			requireNoError(t, err)
+			if version != tt.version || changed != tt.changed {
+				t.Fatalf("版本 %d 升级标记 %v，期望 %d %v", version, changed, tt.version, tt.changed)
+			}
+			var record map[string]interface{}
+			requireNoError(t, json.Unmarshal(upgraded, &record))
+			for field, want := range tt.want {
+				if record[field] != want {
+					t.Fatalf("字段 %s 为 %v，期望 %v", field, record[field], want)
+				}
+			}
+			if _, ok := record["realEstateId"]; ok {
+				t.Fatal("旧字段应被删除")
+			}
+		})
+	}
+}
+
+func TestMigrateStatePaged(t *testing.T) {
+	l := newMockLedger(t)
+
+	// 写入版本 0 的历史记录
+	legacy := map[string][]string{
+		`{"id":"T1","realEstateId":"A1","price":10}`: {TRANSACTION, string(COMPLETED), "T1"},
+		`{"id":"T2","realEstateId":"A2","price":20}`: {TRANSACTION, string(PENDING), "T2"},
+		`{"id":"T3","realEstateId":"A3","price":30}`: {TRANSACTION, string(PENDING), "T3"},
+		`{"certId":"C1"}`: {CERTIFICATE, "C1"},
+	}
+	for data, key := range legacy {
+		compositeKey, err := shim.CreateCompositeKey(key[0], key[1:])
+		requireNoError(t, err)
+		l.state[compositeKey] = []byte(data)
+	}
+	// 已是当前版本的记录不需要迁移
+	registerTestParty(t, l, "dealer")
+	createTestCar(t, l, "京A00001", "dealer")
+
+	migrate := func(fromVersion int) (pages int, migrated int32) {
+		bookmark := ""
+		for {
+			var page *MigrationPage
+			requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) (err error) {
+				page, err = testContract.QueryMigrationPage(ctx, fromVersion, 2, bookmark)
+				return err
+			}))
+			pages++
+			if page.ScannedCount > 2 {
+				t.Fatalf("每页最多扫描 2 条记录，实际 %d 条", page.ScannedCount)
+			}
+			if len(page.Keys) > 0 {
+				keysJson, err := json.Marshal(page.Keys)
+				requireNoError(t, err)
+				var result *MigrationResult
+				requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) (err error) {
+					result, err = testContract.MigrateState(ctx, fromVersion, string(keysJson))
+					return err
+				}))
+				migrated += result.MigratedCount
+			}
+			if page.Done {
+				return pages, migrated
+			}
+			bookmark = page.Bookmark
+		}
+	}
+
+	pages, migrated := migrate(0)
+	if migrated != int32(len(legacy)) {
+		t.Fatalf("应迁移 %d 条记录，实际 %d 条", len(legacy), migrated)
+	}
+	// 3 条交易分两页，其余每个文档类型各一页
+	if pages != len(versionedObjectTypes)+1 {
+		t.Fatalf("分页次数为 %d", pages)
+	}
+
+	var transaction *Transaction
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transaction, err = testContract.QueryTransaction(ctx, "T2")
+		return err
+	}))
+	if transaction.CarID != "A2" || transaction.Type != TX_TYPE_SALE || transaction.SchemaVersion != CURRENT_SCHEMA_VERSION {
+		t.Fatalf("交易未正确迁移：%+v", transaction)
+	}
+
+	if _, migrated := migrate(0); migrated != 0 {
+		t.Fatalf("重复迁移不应重写记录，实际重写 %d 条", migrated)
+	}
+
+	// 记录版本已经变化时跳过
+	var result *MigrationResult
+	requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) (err error) {
+		result, err = testContract.MigrateState(ctx, 0, `[{"objectType":"TX","attributes":["PENDING","T2"]},{"objectType":"TX","attributes":["PENDING","T9"]}]`)
+		return err
+	}))
+	if result.MigratedCount != 0 || result.SkippedCount != 2 {
+		t.Fatalf("迁移结果不正确：%+v", result)
+	}
+	requireError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.MigrateState(ctx, 0, `[{"objectType":"PLATE","attributes":["京A00001"]}]`)
+		return err
+	}), "文档类型无效")
+}
+
+func TestQueryMigrationStatus(t *testing.T) {
+	l := newMockLedger(t)
+	for data, key := range map[string][]string{
+		`{"id":"T1","realEstateId":"A1","price":10}`: {TRANSACTION, string(COMPLETED), "T1"},
+		`{"certId":"C1","schemaVersion":2}`:          {CERTIFICATE, "C1"},
+		`{"certId":"C2","schemaVersion":2}`:          {CERTIFICATE, "C2"},
+	} {
+		compositeKey, err := shim.CreateCompositeKey(key[0], key[1:])
+		requireNoError(t, err)
+		l.state[compositeKey] = []byte(data)
+	}
+	registerTestParty(t, l, "dealer")
+	createTestCar(t, l, "京A00001", "dealer")
+
+	// status 逐页统计全部文档类型，返回旧记录总数及其版本号
+	status := func() (int32, []int) {
+		var pending int32
+		versions := map[int]bool{}
+		bookmark := ""
+		for {
+			var page *MigrationStatus
+			requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) (err error) {
+				page, err = testContract.QueryMigrationStatus(ctx, 1, bookmark)
+				return err
+			}))
+			pending += page.PendingCount
+			for _, version := range page.PendingVersions {
+				versions[version] = true
+			}
+			if page.Done {
+				break
+			}
+			bookmark = page.Bookmark
+		}
+		sorted := make([]int, 0, len(versions))
+		for version := 0; version < CURRENT_SCHEMA_VERSION; version++ {
+			if versions[version] {
+				sorted = append(sorted, version)
+			}
+		}
+		return pending, sorted
+	}
+	// migrate 以足够大的分页迁移指定版本的全部记录
+	migrate := func(fromVersion int) {
+		var page *MigrationPage
+		bookmark := ""
+		for page == nil || !page.Done {
+			requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) (err error) {
+				page, err = testContract.QueryMigrationPage(ctx, fromVersion, 100, bookmark)
+				return err
+			}))
+			if len(page.Keys) > 0 {
+				keysJson, err := json.Marshal(page.Keys)
+				requireNoError(t, err)
+				requireNoError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) error {
+					_, err := testContract.MigrateState(ctx, fromVersion, string(keysJson))
+					return err
+				}))
+			}
+			bookmark = page.Bookmark
+		}
+	}
+
+	if pending, versions := status(); pending != 3 || fmt.Sprint(versions) != "[0 2]" {
+		t.Fatalf("迁移前应有版本 0 和 2 的 3 条旧记录，实际 %d 条 %v", pending, versions)
+	}
+	// 只迁移版本 0 时版本 2 的记录仍未完成
+	migrate(0)
+	if pending, versions := status(); pending != 2 || fmt.Sprint(versions) != "[2]" {
+		t.Fatalf("迁移版本 0 后应剩版本 2 的 2 条旧记录，实际 %d 条 %v", pending, versions)
+	}
+	migrate(2)
+	if pending, versions := status(); pending != 0 || len(versions) != 0 {
+		t.Fatalf("迁移完成后不应再有旧记录，实际 %d 条 %v", pending, versions)
+	}
+
+	requireError(t, l.callAs(CAR_DEALER_ORG_MSPID, "admin", true, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := testContract.QueryMigrationStatus(ctx, 0, "")
+		return err
+	}), "每页条数必须大于0")
+}
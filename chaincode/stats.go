@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// StatsPeriod 统计时间粒度
+type StatsPeriod string
+
+const (
+	PERIOD_DAY   StatsPeriod = "day"   // 按天
+	PERIOD_WEEK  StatsPeriod = "week"  // 按周（ISO 周）
+	PERIOD_MONTH StatsPeriod = "month" // 按月
+	PERIOD_YEAR  StatsPeriod = "year"  // 按年
+)
+
+// StatusCount 按状态统计的数量
+type StatusCount struct {
+	Status string `json:"status"` // 状态
+	Count  int    `json:"count"`  // 数量
+}
+
+// SalesBucket 某一时间段内的成交统计
+type SalesBucket struct {
+	Period      string    `json:"period"`      // 时间段标签，例如 2024-05、2024-W18
+	PeriodStart time.Time `json:"periodStart"` // 时间段起始时间
+	Count       int       `json:"count"`       // 成交笔数
+	TotalAmount float64   `json:"totalAmount"` // 成交总额
+}
+
+// ModelPriceStat 按车型统计的成交价格
+type ModelPriceStat struct {
+	Model        string  `json:"model"`        // 车型
+	Count        int     `json:"count"`        // 成交笔数
+	TotalAmount  float64 `json:"totalAmount"`  // 成交总额
+	AveragePrice float64 `json:"averagePrice"` // 平均成交价
+}
+
+// MarketStats 市场统计结果
+type MarketStats struct {
+	Period                   StatsPeriod       `json:"period"`                   // 时间粒度
+	StartTime                string            `json:"startTime"`                // 统计起始时间（为空表示不限）
+	EndTime                  string            `json:"endTime"`                  // 统计截止时间（为空表示不限）
+	CarStatusCounts          []*StatusCount    `json:"carStatusCounts"`          // 各状态汽车数量
+	TotalCars                int               `json:"totalCars"`                // 汽车总数
+	PendingTransactionCount  int               `json:"pendingTransactionCount"`  // 待付款交易笔数
+	PendingTransactionVolume float64           `json:"pendingTransactionVolume"` // 待付款交易总额
+	CompletedSales           []*SalesBucket    `json:"completedSales"`           // 按时间段统计的成交情况
+	ModelPrices              []*ModelPriceStat `json:"modelPrices"`              // 按车型统计的成交价格
+	UnknownModelCount        int               `json:"unknownModelCount"`        // 无法确定车型、未计入车型统计的成交车辆数
+	GeneratedAt              time.Time         `json:"generatedAt"`              // 统计时间
+}
+
+// periodBucket 计算时间所在时间段的标签和起始时间
+func periodBucket(t time.Time, period StatsPeriod) (string, time.Time) {
+	t = t.UTC()
+	switch period {
+	case PERIOD_DAY:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01-02"), start
+	case PERIOD_WEEK:
+		year, week := t.ISOWeek()
+		// ISO 周从周一开始
+		offset := (int(t.Weekday()) + 6) % 7
+		start := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+		return fmt.Sprintf("%d-W%02d", year, week), start
+	case PERIOD_YEAR:
+		start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006"), start
+	default:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start
+	}
+}
+
+// parseStatsTime 解析统计区间时间，空字符串表示不限
+func parseStatsTime(value string, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s格式错误，应为 RFC3339 格式：%v", name, err)
+	}
+	return &t, nil
+}
+
+// QueryMarketStats 查询市场统计数据：各状态汽车数量、待付款交易量、按时间段的成交统计以及按车型的平均成交价
+// period 为 day/week/month/year，startTime/endTime 为 RFC3339 格式（可为空），用于筛选已完成交易的完成时间
+func (s *SmartContract) QueryMarketStats(ctx contractapi.TransactionContextInterface, period string, startTime string, endTime string) (*MarketStats, error) {
+	statsPeriod := StatsPeriod(period)
+	if statsPeriod == "" {
+		statsPeriod = PERIOD_MONTH
+	}
+	switch statsPeriod {
+	case PERIOD_DAY, PERIOD_WEEK, PERIOD_MONTH, PERIOD_YEAR:
+	default:
+		return nil, fmt.Errorf("无效的统计粒度: %s", period)
+	}
+
+	start, err := parseStatsTime(startTime, "起始时间")
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseStatsTime(endTime, "截止时间")
+	if err != nil {
+		return nil, err
+	}
+
+	generatedAt, err := s.getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &MarketStats{
+		Period:          statsPeriod,
+		StartTime:       startTime,
+		EndTime:         endTime,
+		CarStatusCounts: make([]*StatusCount, 0),
+		CompletedSales:  make([]*SalesBucket, 0),
+		ModelPrices:     make([]*ModelPriceStat, 0),
+		GeneratedAt:     generatedAt,
+	}
+
+	// 各状态汽车数量（直接使用 类型_状态_ID 复合键索引计数）
+	for _, status := range []CarStatus{AVAILABLE, RESERVED, IN_TRANSACTION, SOLD} {
+		count, err := s.countByPartialKey(ctx, CAR, []string{string(status)})
+		if err != nil {
+			return nil, err
+		}
+		stats.CarStatusCounts = append(stats.CarStatusCounts, &StatusCount{Status: string(status), Count: count})
+		stats.TotalCars += count
+	}
+
+	// 待付款交易
+	pendingIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(TRANSACTION, []string{string(PENDING)})
+	if err != nil {
+		return nil, fmt.Errorf("查询待付款交易失败：%v", err)
+	}
+	defer pendingIterator.Close()
+	for pendingIterator.HasNext() {
+		queryResponse, err := pendingIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+		var transaction Transaction
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &transaction)
+		if err != nil {
+			return nil, fmt.Errorf("解析交易信息失败：%v", err)
+		}
+		stats.PendingTransactionCount++
+		stats.PendingTransactionVolume += transaction.Price
+	}
+
+	// 已完成交易：按完成时间分桶，并按车型汇总
+	completedIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(TRANSACTION, []string{string(COMPLETED)})
+	if err != nil {
+		return nil, fmt.Errorf("查询已完成交易失败：%v", err)
+	}
+	defer completedIterator.Close()
+
+	buckets := make(map[string]*SalesBucket)
+	models := make(map[string]*ModelPriceStat)
+	carModels := make(map[string]string)
+	for completedIterator.HasNext() {
+		queryResponse, err := completedIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+		var transaction Transaction
+		err = s.unmarshalState(ctx, queryResponse.Key, queryResponse.Value, &transaction)
+		if err != nil {
+			return nil, fmt.Errorf("解析交易信息失败：%v", err)
+		}
+
+		completedAt := transaction.UpdateTime
+		if start != nil && completedAt.Before(*start) {
+			continue
+		}
+		if end != nil && !completedAt.Before(*end) {
+			continue
+		}
+
+		label, periodStart := periodBucket(completedAt, statsPeriod)
+		bucket, ok := buckets[label]
+		if !ok {
+			bucket = &SalesBucket{Period: label, PeriodStart: periodStart}
+			buckets[label] = bucket
+		}
+		bucket.Count++
+		bucket.TotalAmount += transaction.Price
+
+		// 打包交易按单车成交价分别计入各车型，车型取生成交易时记录的车型，之后修改车型不影响历史统计
+		for _, item := range transaction.soldItems() {
+			model := item.Model
+			if model == "" {
+				// 早期交易没有记录车型，按汽车当前车型统计；汽车已无法查询时单独计数
+				cached, ok := carModels[item.CarID]
+				if !ok {
+					if car, _, err := s.getCar(ctx, item.CarID); err == nil {
+						cached = car.Model
+					}
+					carModels[item.CarID] = cached
+				}
+				model = cached
+			}
+			if model == "" {
+				stats.UnknownModelCount++
+				continue
+			}
+			modelStat, ok := models[model]
+			if !ok {
+				modelStat = &ModelPriceStat{Model: model}
+				models[model] = modelStat
+			}
+			modelStat.Count++
+			modelStat.TotalAmount += item.Price
+		}
+	}
+
+	for _, bucket := range buckets {
+		stats.CompletedSales = append(stats.CompletedSales, bucket)
+	}
+	sort.Slice(stats.CompletedSales, func(i, j int) bool {
+		return stats.CompletedSales[i].PeriodStart.Before(stats.CompletedSales[j].PeriodStart)
+	})
+
+	for _, modelStat := range models {
+		modelStat.AveragePrice = modelStat.TotalAmount / float64(modelStat.Count)
+		stats.ModelPrices = append(stats.ModelPrices, modelStat)
+	}
+	sort.Slice(stats.ModelPrices, func(i, j int) bool {
+		return stats.ModelPrices[i].Model < stats.ModelPrices[j].Model
+	})
+
+	return stats, nil
+}
+
+// countByPartialKey 统计指定复合键前缀下的记录数量
+func (s *SmartContract) countByPartialKey(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) (int, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, attributes)
+	if err != nil {
+		return 0, fmt.Errorf("查询 %s 记录失败：%v", objectType, err)
+	}
+	defer iterator.Close()
+
+	count := 0
+	for iterator.HasNext() {
+		if _, err := iterator.Next(); err != nil {
+			return 0, fmt.Errorf("获取下一条记录失败：%v", err)
+		}
+		count++
+	}
+	return count, nil
+}
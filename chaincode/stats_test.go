@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func TestPeriodBucket(t *testing.T) {
+	at := time.Date(2026, 3, 4, 15, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		period StatsPeriod
+		label  string
+		start  time.Time
+	}{
+		{PERIOD_DAY, "2026-03-04", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{PERIOD_WEEK, "2026-W10", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{PERIOD_MONTH, "2026-03", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{PERIOD_YEAR, "2026", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			label, start := periodBucket(at, tt.period)
+			if label != tt.label || !start.Equal(tt.start) {
+				t.Fatalf("时间段为 %s %v，期望 %s %v", label, start, tt.label, tt.start)
+			}
+		})
+	}
+}
+
+func TestQueryMarketStats(t *testing.T) {
+	l := newMockLedger(t)
+	for _, party := range []string{"dealer", "alice", "bob"} {
+		registerTestParty(t, l, party)
+	}
+	carIDs := make([]string, 0, 3)
+	for i, model := range []string{"Model 3", "Model 3", "汉"} {
+		var carID string
+		requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+			carID, err = testContract.CreateCar(ctx, string(rune('A'+i))+"00001", model, "LSVAB00000000000"+string(rune('1'+i)), "dealer")
+			return err
+		}))
+		carIDs = append(carIDs, carID)
+	}
+
+	l.now = time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	sellTestCar(t, l, "T1", carIDs[0], "dealer", "alice", 100)
+	l.now = time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC)
+	sellTestCar(t, l, "T2", carIDs[1], "dealer", "bob", 200)
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, "T3", carIDs[2], "dealer", "alice", 50)
+	}))
+
+	// 成交后修改车型不影响历史统计
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.UpdateCarAttributes(ctx, carIDs[0], "", "", "Model Y", "车型录入错误")
+	}))
+	// 早期交易未记录车型且汽车已不存在时单独计数，不影响整体查询
+	legacyKey, err := shim.CreateCompositeKey(TRANSACTION, []string{string(COMPLETED), "T0"})
+	requireNoError(t, err)
+	l.state[legacyKey] = []byte(`{"id":"T0","type":"SALE","carId":"GONE","price":80,"netAmount":80,"status":"COMPLETED","updateTime":"2026-02-01T00:00:00Z","schemaVersion":4}`)
+
+	query := func(period string, startTime string, endTime string) (*MarketStats, error) {
+		var stats *MarketStats
+		err := l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+			stats, err = testContract.QueryMarketStats(ctx, period, startTime, endTime)
+			return err
+		})
+		return stats, err
+	}
+
+	stats, err := query("month", "", "")
+	requireNoError(t, err)
+	if stats.TotalCars != 3 || stats.PendingTransactionCount != 1 || stats.PendingTransactionVolume != 50 {
+		t.Fatalf("汽车或待付款统计不正确：%+v", stats)
+	}
+	if len(stats.CompletedSales) != 2 || stats.CompletedSales[0].Period != "2026-01" || stats.CompletedSales[1].Count != 2 || stats.CompletedSales[1].TotalAmount != 280 {
+		t.Fatalf("成交分桶不正确：%+v %+v", stats.CompletedSales[0], stats.CompletedSales[len(stats.CompletedSales)-1])
+	}
+	if len(stats.ModelPrices) != 1 || stats.ModelPrices[0].Model != "Model 3" || stats.ModelPrices[0].Count != 2 || stats.ModelPrices[0].AveragePrice != 150 {
+		t.Fatalf("车型统计不正确：%+v", stats.ModelPrices)
+	}
+	if stats.UnknownModelCount != 1 {
+		t.Fatalf("无法确定车型的成交车辆数为 %d", stats.UnknownModelCount)
+	}
+
+	// 按完成时间筛选
+	stats, err = query("month", "2026-02-01T00:00:00Z", "2026-03-01T00:00:00Z")
+	requireNoError(t, err)
+	if len(stats.CompletedSales) != 1 || stats.CompletedSales[0].Period != "2026-02" {
+		t.Fatalf("按时间筛选不正确：%+v", stats.CompletedSales)
+	}
+
+	_, err = query("quarter", "", "")
+	requireError(t, err, "无效的统计粒度")
+	_, err = query("month", "2026-01-01", "")
+	requireError(t, err, "起始时间格式错误")
+}
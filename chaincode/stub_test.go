@@ -0,0 +1,404 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mockLedger 单元测试使用的内存账本，每次调用生成一个 mockStub，调用成功后才提交写集
+// 与 Fabric 一致，交易内的读取和范围查询只能看到已提交的状态
+type mockLedger struct {
+	t          *testing.T
+	state      map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	identities map[string][]byte
+	txCount    int
+	now        time.Time // 下一笔交易的时间戳，每笔交易后前进一分钟
+
+	// chaincodes 模拟通过 InvokeChaincode 调用的其他链码
+	chaincodes map[string]func(args [][]byte) *peer.Response
+	// events 最近一笔交易设置的事件
+	events map[string][]byte
+}
+
+// mockStub 单笔交易的 stub，未实现的方法调用时会因嵌入的 nil 接口而 panic
+type mockStub struct {
+	shim.ChaincodeStubInterface
+	ledger  *mockLedger
+	args    [][]byte
+	txID    string
+	txTime  time.Time
+	creator []byte
+	writes  map[string][]byte
+	deletes map[string]bool
+	events  map[string][]byte
+}
+
+func newMockLedger(t *testing.T) *mockLedger {
+	return &mockLedger{
+		t:          t,
+		state:      map[string][]byte{},
+		history:    map[string][]*queryresult.KeyModification{},
+		identities: map[string][]byte{},
+		now:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		chaincodes: map[string]func(args [][]byte) *peer.Response{},
+	}
+}
+
+// identity 返回指定组织和通用名的序列化身份，同一身份多次调用返回相同证书
+func (l *mockLedger) identity(mspID string, commonName string, admin bool) []byte {
+	key := fmt.Sprintf("%s/%s/%v", mspID, commonName, admin)
+	if creator, ok := l.identities[key]; ok {
+		return creator
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	ou := "client"
+	if admin {
+		ou = "admin"
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(len(l.identities) + 1)),
+		Subject:      pkix.Name{CommonName: commonName, OrganizationalUnit: []string{ou}},
+		NotBefore:    l.now.Add(-time.Hour),
+		NotAfter:     l.now.AddDate(10, 0, 0),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	l.identities[key] = creator
+	return creator
+}
+
+// newStub 以指定身份开始一笔新交易
+func (l *mockLedger) newStub(mspID string, commonName string, admin bool, args ...string) *mockStub {
+	l.txCount++
+	stub := &mockStub{
+		ledger:  l,
+		txID:    fmt.Sprintf("tx%04d", l.txCount),
+		txTime:  l.now,
+		creator: l.identity(mspID, commonName, admin),
+		writes:  map[string][]byte{},
+		deletes: map[string]bool{},
+		events:  map[string][]byte{},
+	}
+	for _, arg := range args {
+		stub.args = append(stub.args, []byte(arg))
+	}
+	l.now = l.now.Add(time.Minute)
+	return stub
+}
+
+// commit 提交交易写集并记录键的修改历史
+func (l *mockLedger) commit(stub *mockStub) {
+	timestamp := timestamppb.New(stub.txTime)
+	for key := range stub.deletes {
+		delete(l.state, key)
+		l.history[key] = append(l.history[key], &queryresult.KeyModification{TxId: stub.txID, Timestamp: timestamp, IsDelete: true})
+	}
+	for key, value := range stub.writes {
+		l.state[key] = value
+		l.history[key] = append(l.history[key], &queryresult.KeyModification{TxId: stub.txID, Value: value, Timestamp: timestamp})
+	}
+	l.events = stub.events
+}
+
+// call 以指定组织的普通用户身份直接调用合约方法，不经过合约钩子
+func (l *mockLedger) call(mspID string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	return l.callAs(mspID, "user1", false, fn)
+}
+
+// callAs 以指定身份直接调用合约方法，不经过合约钩子
+func (l *mockLedger) callAs(mspID string, commonName string, admin bool, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	stub := l.newStub(mspID, commonName, admin)
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	err := fn(ctx)
+	if err == nil {
+		l.commit(stub)
+	}
+	return err
+}
+
+// invoke 通过链码入口调用函数，经过路由和合约钩子
+func (l *mockLedger) invoke(cc shim.Chaincode, mspID string, commonName string, admin bool, args ...string) *peer.Response {
+	stub := l.newStub(mspID, commonName, admin, args...)
+	response := cc.Invoke(stub)
+	if response.Status == shim.OK {
+		l.commit(stub)
+	}
+	return response
+}
+
+func (s *mockStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *mockStub) GetStringArgs() []string {
+	strArgs := make([]string, 0, len(s.args))
+	for _, arg := range s.args {
+		strArgs = append(strArgs, string(arg))
+	}
+	return strArgs
+}
+
+func (s *mockStub) GetFunctionAndParameters() (string, []string) {
+	strArgs := s.GetStringArgs()
+	if len(strArgs) == 0 {
+		return "", []string{}
+	}
+	return strArgs[0], strArgs[1:]
+}
+
+func (s *mockStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *mockStub) GetChannelID() string {
+	return "mychannel"
+}
+
+func (s *mockStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.txTime), nil
+}
+
+func (s *mockStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *mockStub) GetTransient() (map[string][]byte, error) {
+	return map[string][]byte{}, nil
+}
+
+func (s *mockStub) SetEvent(name string, payload []byte) error {
+	s.events[name] = payload
+	return nil
+}
+
+// GetState 与 Fabric 一致，只读取已提交的状态，读取不到本交易的写入
+func (s *mockStub) GetState(key string) ([]byte, error) {
+	return s.ledger.state[key], nil
+}
+
+func (s *mockStub) PutState(key string, value []byte) error {
+	delete(s.deletes, key)
+	s.writes[key] = value
+	return nil
+}
+
+func (s *mockStub) DelState(key string) error {
+	delete(s.writes, key)
+	s.deletes[key] = true
+	return nil
+}
+
+func (s *mockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *mockStub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	components := strings.Split(strings.Trim(compositeKey, "\x00"), "\x00")
+	if len(components) == 0 || len(components[0]) == 0 {
+		return "", nil, fmt.Errorf("无效的复合键")
+	}
+	return components[0], components[1:], nil
+}
+
+func (s *mockStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return &mockIterator{kvs: s.rangeKVs(startKey, endKey)}, nil
+}
+
+func (s *mockStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &mockIterator{kvs: s.rangeKVs(prefix, prefix+string(utf8MaxRune))}, nil
+}
+
+func (s *mockStub) GetStateByPartialCompositeKeyWithPagination(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	prefix, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	startKey := prefix
+	if len(bookmark) > 0 {
+		startKey = bookmark
+	}
+	kvs := s.rangeKVs(startKey, prefix+string(utf8MaxRune))
+	nextBookmark := ""
+	if pageSize > 0 && int(pageSize) < len(kvs) {
+		nextBookmark = kvs[pageSize].Key
+		kvs = kvs[:pageSize]
+	}
+	return &mockIterator{kvs: kvs}, &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(kvs)), Bookmark: nextBookmark}, nil
+}
+
+func (s *mockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &mockHistoryIterator{modifications: s.ledger.history[key]}, nil
+}
+
+func (s *mockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) *peer.Response {
+	chaincode, ok := s.ledger.chaincodes[chaincodeName]
+	if !ok {
+		return &peer.Response{Status: shim.ERROR, Message: fmt.Sprintf("链码 %s 不存在", chaincodeName)}
+	}
+	return chaincode(args)
+}
+
+// utf8MaxRune 复合键范围查询的上界
+const utf8MaxRune = '\U0010FFFF'
+
+// rangeKVs 返回已提交状态中 [startKey, endKey) 范围内的键值，endKey 为空表示不设上界
+func (s *mockStub) rangeKVs(startKey string, endKey string) []*queryresult.KV {
+	keys := make([]string, 0)
+	for key := range s.ledger.state {
+		if key < startKey || (len(endKey) > 0 && key >= endKey) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]*queryresult.KV, 0, len(keys))
+	for _, key := range keys {
+		kvs = append(kvs, &queryresult.KV{Key: key, Value: s.ledger.state[key]})
+	}
+	return kvs
+}
+
+type mockIterator struct {
+	kvs   []*queryresult.KV
+	index int
+}
+
+func (it *mockIterator) HasNext() bool {
+	return it.index < len(it.kvs)
+}
+
+func (it *mockIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("没有更多记录")
+	}
+	it.index++
+	return it.kvs[it.index-1], nil
+}
+
+func (it *mockIterator) Close() error {
+	return nil
+}
+
+type mockHistoryIterator struct {
+	modifications []*queryresult.KeyModification
+	index         int
+}
+
+func (it *mockHistoryIterator) HasNext() bool {
+	return it.index < len(it.modifications)
+}
+
+func (it *mockHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("没有更多记录")
+	}
+	it.index++
+	return it.modifications[it.index-1], nil
+}
+
+func (it *mockHistoryIterator) Close() error {
+	return nil
+}
+
+// requireNoError 断言调用成功
+func requireNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("意外的错误：%v", err)
+	}
+}
+
+// requireError 断言调用失败且错误信息包含 substr
+func requireError(t *testing.T, err error, substr string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("期望错误 %q，实际调用成功", substr)
+	}
+	if !strings.Contains(err.Error(), substr) {
+		t.Fatalf("期望错误包含 %q，实际为：%v", substr, err)
+	}
+}
+
+// testContract 测试直接调用的合约实例
+var testContract = &SmartContract{}
+
+// registerTestParty 登记个人参与方并由银行完成 KYC 认证
+func registerTestParty(t *testing.T, l *mockLedger, partyID string) {
+	t.Helper()
+	nationalIDHash := fmt.Sprintf("%064x", len(l.identities)*1000+l.txCount)
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.RegisterParty(ctx, partyID, string(INDIVIDUAL), partyID, nationalIDHash)
+	}))
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetPartyKYCStatus(ctx, partyID, string(KYC_VERIFIED), "")
+	}))
+}
+
+// createTestCar 以汽车经销商身份创建汽车，返回链码生成的汽车ID
+func createTestCar(t *testing.T, l *mockLedger, plate string, owner string) string {
+	t.Helper()
+	var carID string
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		carID, err = testContract.CreateCar(ctx, plate, "Model S", fmt.Sprintf("LSVAB%012d", l.txCount), owner)
+		return err
+	}))
+	return carID
+}
+
+// queryTestCar 查询汽车信息
+func queryTestCar(t *testing.T, l *mockLedger, carID string) *Car {
+	t.Helper()
+	var car *Car
+	requireNoError(t, l.call(CAR_DEALER_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		car, err = testContract.QueryCar(ctx, carID)
+		return err
+	}))
+	return car
+}
+
+// sellTestCar 由交易平台生成普通买卖交易并由银行确认收款完成
+func sellTestCar(t *testing.T, l *mockLedger, txID string, carID string, seller string, buyer string, price float64) {
+	t.Helper()
+	requireNoError(t, l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CreateTransaction(ctx, txID, carID, seller, buyer, price)
+	}))
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CompleteTransaction(ctx, txID)
+	}))
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// TokenContract 银行发行的数字货币：账户为参与方ID，金额以最小货币单位（分）计
+// 持有人以自己的身份调用 Transfer 转出余额，身份与账户的绑定由银行设定的结算链码（二手车链码）的 ClientAccountID 确定；
+// 二手车链码在交易完成时通过 InvokeChaincode 调用 TransferBatch，使车款支付与过户在同一笔交易中完成，
+// 划转只能在持有人通过 Approve 授权给结算链码的额度内扣款，银行不能直接调用代币链码划转参与方的余额
+type TokenContract struct {
+	contractapi.Contract
+}
+
+// 文档类型常量（用于创建复合键）
+const (
+	BALANCE   = "BALANCE"   // 账户余额
+	ALLOWANCE = "ALLOWANCE" // 授权额度（所有者、被授权方）
+	SUPPLY    = "SUPPLY"    // 发行总量
+	CONFIG    = "CONFIG"    // 代币配置
+)
+
+// settlementChaincodeKey 结算链码名称在 CONFIG 中的键
+const settlementChaincodeKey = "SETTLEMENT_CHAINCODE"
+
+// BANK_ORG_MSPID 银行组织 MSP ID，只有银行可以发行代币和设定结算链码
+const BANK_ORG_MSPID = "Org2MSP"
+
+// EVENT_TRANSFER 代币转移事件名称（发行时 from 为空）
+const EVENT_TRANSFER = "Transfer"
+
+// EVENT_TRANSFER_BATCH 批量代币转移事件名称，事件内容为 TransferEvent 数组
+const EVENT_TRANSFER_BATCH = "TransferBatch"
+
+// EVENT_APPROVAL 授权额度变更事件名称
+const EVENT_APPROVAL = "Approval"
+
+// TransferEvent 代币转移事件
+type TransferEvent struct {
+	From  string `json:"from"`  // 转出账户
+	To    string `json:"to"`    // 转入账户
+	Value int64  `json:"value"` // 金额（分）
+}
+
+// ApprovalEvent 授权额度变更事件
+type ApprovalEvent struct {
+	Owner   string `json:"owner"`   // 所有者账户
+	Spender string `json:"spender"` // 被授权方（结算链码名称）
+	Value   int64  `json:"value"`   // 授权额度（分）
+}
+
+// Mint 向账户发行代币（仅银行组织可以调用）
+func (t *TokenContract) Mint(ctx contractapi.TransactionContextInterface, account string, amount int64) error {
+	if err := t.checkBank(ctx); err != nil {
+		return err
+	}
+	if len(account) == 0 {
+		return fmt.Errorf("账户不能为空")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("发行金额必须大于0")
+	}
+
+	supply, err := t.readAmount(ctx, SUPPLY, "")
+	if err != nil {
+		return err
+	}
+	if supply > math.MaxInt64-amount {
+		return fmt.Errorf("发行总量溢出")
+	}
+	balance, err := t.readAmount(ctx, BALANCE, account)
+	if err != nil {
+		return err
+	}
+
+	if err := t.writeAmount(ctx, BALANCE, account, balance+amount); err != nil {
+		return err
+	}
+	if err := t.writeAmount(ctx, SUPPLY, "", supply+amount); err != nil {
+		return err
+	}
+	return t.emitTransfer(ctx, "", account, amount)
+}
+
+// SetSettlementChaincode 设置允许发起批量划转并确定调用者账户的结算链码（仅银行组织可以调用），传空字符串禁止一切转账
+func (t *TokenContract) SetSettlementChaincode(ctx contractapi.TransactionContextInterface, chaincodeName string) error {
+	if err := t.checkBank(ctx); err != nil {
+		return err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(CONFIG, []string{settlementChaincodeKey})
+	if err != nil {
+		return fmt.Errorf("创建复合键失败：%v", err)
+	}
+	err = ctx.GetStub().PutState(key, []byte(chaincodeName))
+	if err != nil {
+		return fmt.Errorf("保存结算链码失败：%v", err)
+	}
+	return nil
+}
+
+// SettlementChaincode 查询结算链码，未设置时为空
+func (t *TokenContract) SettlementChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(CONFIG, []string{settlementChaincodeKey})
+	if err != nil {
+		return "", fmt.Errorf("创建复合键失败：%v", err)
+	}
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("查询结算链码失败：%v", err)
+	}
+	return string(bytes), nil
+}
+
+// Transfer 从调用者自己的账户向 to 转移代币
+func (t *TokenContract) Transfer(ctx contractapi.TransactionContextInterface, to string, amount int64) error {
+	from, err := t.ClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("转入账户不能为空")
+	}
+	if from == to {
+		return fmt.Errorf("转出和转入账户不能相同")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("转账金额必须大于0")
+	}
+
+	fromBalance, err := t.readAmount(ctx, BALANCE, from)
+	if err != nil {
+		return err
+	}
+	if fromBalance < amount {
+		return fmt.Errorf("账户 %s 余额不足：余额 %d，需支付 %d", from, fromBalance, amount)
+	}
+	toBalance, err := t.readAmount(ctx, BALANCE, to)
+	if err != nil {
+		return err
+	}
+	if toBalance > math.MaxInt64-amount {
+		return fmt.Errorf("账户 %s 余额溢出", to)
+	}
+
+	if err := t.writeAmount(ctx, BALANCE, from, fromBalance-amount); err != nil {
+		return err
+	}
+	if err := t.writeAmount(ctx, BALANCE, to, toBalance+amount); err != nil {
+		return err
+	}
+	return t.emitTransfer(ctx, from, to, amount)
+}
+
+// Approve 设置调用者授权给 spender 的额度（分），为0时撤销授权
+// 交易完成时结算链码只能在买家（及须付款的卖家）授权给它的额度内扣款，因此 spender 为结算链码名称
+func (t *TokenContract) Approve(ctx contractapi.TransactionContextInterface, spender string, amount int64) error {
+	owner, err := t.ClientAccountID(ctx)
+	if err != nil {
+		return err
+	}
+	if len(spender) == 0 {
+		return fmt.Errorf("被授权方不能为空")
+	}
+	if amount < 0 {
+		return fmt.Errorf("授权额度不能为负数")
+	}
+
+	if err := t.writeAmount(ctx, ALLOWANCE, owner, amount, spender); err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(ApprovalEvent{Owner: owner, Spender: spender, Value: amount})
+	if err != nil {
+		return fmt.Errorf("序列化事件失败：%v", err)
+	}
+	err = ctx.GetStub().SetEvent(EVENT_APPROVAL, bytes)
+	if err != nil {
+		return fmt.Errorf("设置事件失败：%v", err)
+	}
+	return nil
+}
+
+// Allowance 查询 owner 授权给 spender 的剩余额度（分）
+func (t *TokenContract) Allowance(ctx contractapi.TransactionContextInterface, owner string, spender string) (int64, error) {
+	if len(owner) == 0 || len(spender) == 0 {
+		return 0, fmt.Errorf("所有者和被授权方不能为空")
+	}
+	return t.readAmount(ctx, ALLOWANCE, owner, spender)
+}
+
+// ClientAccountID 返回调用者的账户：由结算链码的 ClientAccountID 按其参与方身份绑定确定
+// 账户即二手车链码中的参与方ID，身份只在二手车链码中由银行绑定一次，代币链码不另行维护
+func (t *TokenContract) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
+	settlementChaincode, err := t.SettlementChaincode(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(settlementChaincode) == 0 {
+		return "", fmt.Errorf("未设置结算链码，无法确定调用者的账户")
+	}
+	response := ctx.GetStub().InvokeChaincode(settlementChaincode, [][]byte{[]byte("ClientAccountID")}, "")
+	if response.Status != shim.OK {
+		return "", fmt.Errorf("确定调用者的账户失败：%s", response.Message)
+	}
+	account := string(response.Payload)
+	if len(account) == 0 {
+		return "", fmt.Errorf("确定调用者的账户失败：账户为空")
+	}
+	return account, nil
+}
+
+// TransferBatch 在一次调用中完成多笔转账（仅限银行组织成员通过结算链码发起），transfersJSON 为 TransferEvent 的 JSON 数组
+// 同一笔交易中多次调用 Transfer 读取不到之前的写入，因此车款和各项费用须通过本方法一次划转：
+// 各账户的收支先合并计算，净支出的账户须已授权结算链码足够的额度，任一账户余额或额度不足则全部失败
+func (t *TokenContract) TransferBatch(ctx contractapi.TransactionContextInterface, transfersJSON string) error {
+	settlementChaincode, err := t.checkSettlement(ctx)
+	if err != nil {
+		return err
+	}
+
+	var transfers []*TransferEvent
+	if err := json.Unmarshal([]byte(transfersJSON), &transfers); err != nil {
+		return fmt.Errorf("解析转账列表失败：%v", err)
+	}
+	if len(transfers) == 0 {
+		return fmt.Errorf("转账列表不能为空")
+	}
+
+	changes := make(map[string]int64)
+	for i, transfer := range transfers {
+		if transfer == nil || len(transfer.From) == 0 || len(transfer.To) == 0 {
+			return fmt.Errorf("第 %d 笔转账的转出和转入账户不能为空", i+1)
+		}
+		if transfer.From == transfer.To {
+			return fmt.Errorf("第 %d 笔转账的转出和转入账户不能相同", i+1)
+		}
+		if transfer.Value <= 0 {
+			return fmt.Errorf("第 %d 笔转账的金额必须大于0", i+1)
+		}
+		if changes[transfer.From] < math.MinInt64+transfer.Value || changes[transfer.To] > math.MaxInt64-transfer.Value {
+			return fmt.Errorf("转账金额溢出")
+		}
+		changes[transfer.From] -= transfer.Value
+		changes[transfer.To] += transfer.Value
+	}
+
+	accounts := make([]string, 0, len(changes))
+	for account := range changes {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	balances := make(map[string]int64, len(accounts))
+	allowances := make(map[string]int64)
+	for _, account := range accounts {
+		balance, err := t.readAmount(ctx, BALANCE, account)
+		if err != nil {
+			return err
+		}
+		change := changes[account]
+		if change < 0 && balance < -change {
+			return fmt.Errorf("账户 %s 余额不足：余额 %d，需支付 %d", account, balance, -change)
+		}
+		// 净支出须在账户授权给结算链码的额度内
+		if change < 0 {
+			allowance, err := t.readAmount(ctx, ALLOWANCE, account, settlementChaincode)
+			if err != nil {
+				return err
+			}
+			if allowance < -change {
+				return fmt.Errorf("账户 %s 授权结算链码 %s 的额度不足：额度 %d，需支付 %d", account, settlementChaincode, allowance, -change)
+			}
+			allowances[account] = allowance + change
+		}
+		if change > 0 && balance > math.MaxInt64-change {
+			return fmt.Errorf("账户 %s 余额溢出", account)
+		}
+		balances[account] = balance + change
+	}
+	for _, account := range accounts {
+		if changes[account] == 0 {
+			continue
+		}
+		if err := t.writeAmount(ctx, BALANCE, account, balances[account]); err != nil {
+			return err
+		}
+		if allowance, ok := allowances[account]; ok {
+			if err := t.writeAmount(ctx, ALLOWANCE, account, allowance, settlementChaincode); err != nil {
+				return err
+			}
+		}
+	}
+
+	bytes, err := json.Marshal(transfers)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败：%v", err)
+	}
+	err = ctx.GetStub().SetEvent(EVENT_TRANSFER_BATCH, bytes)
+	if err != nil {
+		return fmt.Errorf("设置事件失败：%v", err)
+	}
+	return nil
+}
+
+// BalanceOf 查询账户余额（分），没有记录的账户余额为0
+func (t *TokenContract) BalanceOf(ctx contractapi.TransactionContextInterface, account string) (int64, error) {
+	if len(account) == 0 {
+		return 0, fmt.Errorf("账户不能为空")
+	}
+	return t.readAmount(ctx, BALANCE, account)
+}
+
+// TotalSupply 查询发行总量（分）
+func (t *TokenContract) TotalSupply(ctx contractapi.TransactionContextInterface) (int64, error) {
+	return t.readAmount(ctx, SUPPLY, "")
+}
+
+// checkBank 校验调用者是否为银行组织成员
+func (t *TokenContract) checkBank(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := cid.GetMSPID(ctx.GetStub())
+	if err != nil {
+		return fmt.Errorf("获取调用者身份失败：%v", err)
+	}
+	if clientMSPID != BANK_ORG_MSPID {
+		return fmt.Errorf("只有银行组织成员才能发行或划转代币")
+	}
+	return nil
+}
+
+// checkSettlement 校验批量划转由银行组织成员通过结算链码发起，返回结算链码名称
+// 交易提案中的链码名称是客户端直接调用的链码，通过 InvokeChaincode 被调用时仍为发起调用的链码，
+// 因此只有提案指向结算链码时才允许划转：扣款金额和账户由结算链码按交易记录确定，银行不能直接调用本链码划转参与方的余额
+func (t *TokenContract) checkSettlement(ctx contractapi.TransactionContextInterface) (string, error) {
+	if err := t.checkBank(ctx); err != nil {
+		return "", err
+	}
+	settlementChaincode, err := t.SettlementChaincode(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(settlementChaincode) == 0 {
+		return "", fmt.Errorf("未设置结算链码，不能转移代币")
+	}
+	proposalChaincode, err := t.getProposalChaincode(ctx)
+	if err != nil {
+		return "", err
+	}
+	if proposalChaincode != settlementChaincode {
+		return "", fmt.Errorf("代币只能由结算链码 %s 在完成交易时划转，不能通过 %s 转移", settlementChaincode, proposalChaincode)
+	}
+	return settlementChaincode, nil
+}
+
+// getProposalChaincode 解析已签名的交易提案，返回提案调用的链码名称
+func (t *TokenContract) getProposalChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil || signedProposal == nil {
+		return "", fmt.Errorf("获取交易提案失败：%v", err)
+	}
+	var proposal peer.Proposal
+	if err := proto.Unmarshal(signedProposal.GetProposalBytes(), &proposal); err != nil {
+		return "", fmt.Errorf("解析交易提案失败：%v", err)
+	}
+	var header common.Header
+	if err := proto.Unmarshal(proposal.GetHeader(), &header); err != nil {
+		return "", fmt.Errorf("解析提案头失败：%v", err)
+	}
+	var channelHeader common.ChannelHeader
+	if err := proto.Unmarshal(header.GetChannelHeader(), &channelHeader); err != nil {
+		return "", fmt.Errorf("解析通道头失败：%v", err)
+	}
+	var extension peer.ChaincodeHeaderExtension
+	if err := proto.Unmarshal(channelHeader.GetExtension(), &extension); err != nil {
+		return "", fmt.Errorf("解析链码头失败：%v", err)
+	}
+	return extension.GetChaincodeId().GetName(), nil
+}
+
+// readAmount 读取金额记录，不存在时为0；授权额度的键还包含被授权方
+func (t *TokenContract) readAmount(ctx contractapi.TransactionContextInterface, objectType string, account string, attributes ...string) (int64, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, append([]string{account}, attributes...))
+	if err != nil {
+		return 0, fmt.Errorf("创建复合键失败：%v", err)
+	}
+	bytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("读取金额失败：%v", err)
+	}
+	if bytes == nil {
+		return 0, nil
+	}
+	amount, err := strconv.ParseInt(string(bytes), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析金额失败：%v", err)
+	}
+	return amount, nil
+}
+
+// writeAmount 写入金额记录
+func (t *TokenContract) writeAmount(ctx contractapi.TransactionContextInterface, objectType string, account string, amount int64, attributes ...string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, append([]string{account}, attributes...))
+	if err != nil {
+		return fmt.Errorf("创建复合键失败：%v", err)
+	}
+	err = ctx.GetStub().PutState(key, []byte(strconv.FormatInt(amount, 10)))
+	if err != nil {
+		return fmt.Errorf("写入金额失败：%v", err)
+	}
+	return nil
+}
+
+// emitTransfer 设置代币转移事件
+func (t *TokenContract) emitTransfer(ctx contractapi.TransactionContextInterface, from string, to string, amount int64) error {
+	bytes, err := json.Marshal(TransferEvent{From: from, To: to, Value: amount})
+	if err != nil {
+		return fmt.Errorf("序列化事件失败：%v", err)
+	}
+	err = ctx.GetStub().SetEvent(EVENT_TRANSFER, bytes)
+	if err != nil {
+		return fmt.Errorf("设置事件失败：%v", err)
+	}
+	return nil
+}
+
+func main() {
+	chaincode, err := contractapi.NewChaincode(&TokenContract{})
+	if err != nil {
+		log.Panicf("创建代币合约失败：%v", err)
+	}
+
+	if err := chaincode.Start(); err != nil {
+		log.Panicf("启动代币合约失败：%v", err)
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// 测试使用的链码名称
+const (
+	testTokenChaincode = "tokenchaincode"
+	testCarChaincode   = "mychaincode"
+)
+
+// mockLedger 单元测试使用的内存账本，调用成功后才提交写集
+// 与 Fabric 一致，交易内读取不到本交易的写入
+type mockLedger struct {
+	t     *testing.T
+	state map[string][]byte
+}
+
+// mockStub 单笔交易的 stub，未实现的方法调用时会因嵌入的 nil 接口而 panic
+type mockStub struct {
+	shim.ChaincodeStubInterface
+	ledger   *mockLedger
+	creator  []byte
+	proposal *peer.SignedProposal
+	account  string // 结算链码 ClientAccountID 返回的调用者账户，为空表示身份未绑定
+	writes   map[string][]byte
+	events   map[string][]byte
+}
+
+func newMockLedger(t *testing.T) *mockLedger {
+	return &mockLedger{t: t, state: map[string][]byte{}}
+}
+
+// call 以指定组织成员的身份调用合约方法，chaincodeName 为交易提案调用的链码
+func (l *mockLedger) call(mspID string, chaincodeName string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	stub := &mockStub{
+		ledger:   l,
+		creator:  l.identity(mspID),
+		proposal: l.signedProposal(chaincodeName),
+		writes:   map[string][]byte{},
+		events:   map[string][]byte{},
+	}
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	err := fn(ctx)
+	if err == nil {
+		for key, value := range stub.writes {
+			l.state[key] = value
+		}
+	}
+	return err
+}
+
+// callAs 以绑定了 account 的参与方身份直接调用代币链码
+func (l *mockLedger) callAs(account string, fn func(ctx contractapi.TransactionContextInterface) error) error {
+	stub := &mockStub{
+		ledger:   l,
+		creator:  l.identity("Org1MSP"),
+		proposal: l.signedProposal(testTokenChaincode),
+		account:  account,
+		writes:   map[string][]byte{},
+		events:   map[string][]byte{},
+	}
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(stub)
+	err := fn(ctx)
+	if err == nil {
+		for key, value := range stub.writes {
+			l.state[key] = value
+		}
+	}
+	return err
+}
+
+// identity 返回指定组织的序列化身份
+func (l *mockLedger) identity(mspID string) []byte {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: certPEM})
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return creator
+}
+
+// signedProposal 构造调用指定链码的交易提案
+func (l *mockLedger) signedProposal(chaincodeName string) *peer.SignedProposal {
+	marshal := func(m proto.Message) []byte {
+		bytes, err := proto.Marshal(m)
+		if err != nil {
+			l.t.Fatal(err)
+		}
+		return bytes
+	}
+	extension := marshal(&peer.ChaincodeHeaderExtension{ChaincodeId: &peer.ChaincodeID{Name: chaincodeName}})
+	header := marshal(&common.Header{ChannelHeader: marshal(&common.ChannelHeader{Extension: extension})})
+	return &peer.SignedProposal{ProposalBytes: marshal(&peer.Proposal{Header: header})}
+}
+
+// balance 读取已提交的账户余额
+func (l *mockLedger) balance(account string) int64 {
+	key, err := shim.CreateCompositeKey(BALANCE, []string{account})
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	bytes, ok := l.state[key]
+	if !ok {
+		return 0
+	}
+	amount, err := strconv.ParseInt(string(bytes), 10, 64)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return amount
+}
+
+// allowance 读取已提交的授权额度
+func (l *mockLedger) allowance(owner string, spender string) int64 {
+	key, err := shim.CreateCompositeKey(ALLOWANCE, []string{owner, spender})
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	bytes, ok := l.state[key]
+	if !ok {
+		return 0
+	}
+	amount, err := strconv.ParseInt(string(bytes), 10, 64)
+	if err != nil {
+		l.t.Fatal(err)
+	}
+	return amount
+}
+
+func (s *mockStub) GetCreator() ([]byte, error) {
+	return s.creator, nil
+}
+
+func (s *mockStub) GetSignedProposal() (*peer.SignedProposal, error) {
+	return s.proposal, nil
+}
+
+// InvokeChaincode 模拟结算链码的 ClientAccountID，返回调用者绑定的账户
+func (s *mockStub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) *peer.Response {
+	if chaincodeName != testCarChaincode || string(args[0]) != "ClientAccountID" {
+		return &peer.Response{Status: shim.ERROR, Message: "未知的链码调用"}
+	}
+	if s.account == "" {
+		return &peer.Response{Status: shim.ERROR, Message: "当前身份未绑定参与方"}
+	}
+	return &peer.Response{Status: shim.OK, Payload: []byte(s.account)}
+}
+
+func (s *mockStub) GetState(key string) ([]byte, error) {
+	return s.ledger.state[key], nil
+}
+
+func (s *mockStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+func (s *mockStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *mockStub) SetEvent(name string, payload []byte) error {
+	s.events[name] = payload
+	return nil
+}
+
+var testContract = &TokenContract{}
+
+func requireNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("意外的错误：%v", err)
+	}
+}
+
+func requireError(t *testing.T, err error, substr string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("期望错误包含 %q，实际没有错误", substr)
+	}
+	if !strings.Contains(err.Error(), substr) {
+		t.Fatalf("期望错误包含 %q，实际为：%v", substr, err)
+	}
+}
+
+// setupTestAccounts 设置结算链码并为账户发行代币
+func setupTestAccounts(t *testing.T, l *mockLedger, balances map[string]int64) {
+	t.Helper()
+	requireNoError(t, l.call(BANK_ORG_MSPID, testTokenChaincode, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetSettlementChaincode(ctx, testCarChaincode)
+	}))
+	for account, amount := range balances {
+		requireNoError(t, l.call(BANK_ORG_MSPID, testTokenChaincode, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.Mint(ctx, account, amount)
+		}))
+	}
+}
+
+// approveTestSettlement 以账户本人的身份授权结算链码划转的额度
+func approveTestSettlement(t *testing.T, l *mockLedger, account string, amount int64) {
+	t.Helper()
+	requireNoError(t, l.callAs(account, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Approve(ctx, testCarChaincode, amount)
+	}))
+}
+
+func TestTransfer(t *testing.T) {
+	l := newMockLedger(t)
+	requireNoError(t, l.call(BANK_ORG_MSPID, testTokenChaincode, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Mint(ctx, "alice", 1000)
+	}))
+	// 调用者的账户由结算链码确定，未设置结算链码时无法转账
+	requireError(t, l.callAs("alice", func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Transfer(ctx, "bob", 100)
+	}), "未设置结算链码，无法确定调用者的账户")
+	setupTestAccounts(t, l, nil)
+
+	tests := []struct {
+		name    string
+		account string
+		to      string
+		amount  int64
+		err     string
+	}{
+		{"身份未绑定参与方", "", "bob", 100, "当前身份未绑定参与方"},
+		{"转给自己", "alice", "alice", 100, "转出和转入账户不能相同"},
+		{"余额不足", "alice", "bob", 1001, "账户 alice 余额不足：余额 1000，需支付 1001"},
+		{"只能转出自己的余额", "bob", "alice", 100, "账户 bob 余额不足：余额 0，需支付 100"},
+		{"持有人转出自己的余额", "alice", "bob", 300, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.callAs(tt.account, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.Transfer(ctx, tt.to, tt.amount)
+			})
+			if tt.err == "" {
+				requireNoError(t, err)
+				return
+			}
+			requireError(t, err, tt.err)
+		})
+	}
+	if alice, bob := l.balance("alice"), l.balance("bob"); alice != 700 || bob != 300 {
+		t.Fatalf("余额不正确：alice %d，bob %d", alice, bob)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	l := newMockLedger(t)
+	setupTestAccounts(t, l, map[string]int64{"alice": 1000})
+
+	requireError(t, l.callAs("", func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Approve(ctx, testCarChaincode, 100)
+	}), "当前身份未绑定参与方")
+	requireError(t, l.callAs("alice", func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Approve(ctx, testCarChaincode, -1)
+	}), "授权额度不能为负数")
+
+	// 授权额度可以超过余额，重复授权覆盖原额度，为0时撤销
+	for _, amount := range []int64{5000, 200, 0} {
+		approveTestSettlement(t, l, "alice", amount)
+		var allowance int64
+		requireNoError(t, l.call("Org3MSP", testTokenChaincode, func(ctx contractapi.TransactionContextInterface) (err error) {
+			allowance, err = testContract.Allowance(ctx, "alice", testCarChaincode)
+			return err
+		}))
+		if allowance != amount {
+			t.Fatalf("授权额度为 %d，应为 %d", allowance, amount)
+		}
+	}
+}
+
+func TestTransferRequiresSettlementChaincode(t *testing.T) {
+	l := newMockLedger(t)
+	requireNoError(t, l.call(BANK_ORG_MSPID, testTokenChaincode, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.Mint(ctx, "alice", 1000)
+	}))
+	requireError(t, l.call(BANK_ORG_MSPID, testCarChaincode, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.TransferBatch(ctx, `[{"from":"alice","to":"bob","value":100}]`)
+	}), "未设置结算链码")
+	requireError(t, l.call("Org3MSP", testTokenChaincode, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.SetSettlementChaincode(ctx, testCarChaincode)
+	}), "只有银行组织成员")
+	setupTestAccounts(t, l, nil)
+	approveTestSettlement(t, l, "alice", 1000)
+
+	tests := []struct {
+		name          string
+		mspID         string
+		chaincodeName string
+		err           string
+	}{
+		{"非银行组织", "Org3MSP", testCarChaincode, "只有银行组织成员"},
+		{"银行直接调用代币链码", BANK_ORG_MSPID, testTokenChaincode, "代币只能由结算链码 mychaincode 在完成交易时划转"},
+		{"银行通过其他链码调用", BANK_ORG_MSPID, "otherchaincode", "不能通过 otherchaincode 转移"},
+		{"银行通过结算链码调用", BANK_ORG_MSPID, testCarChaincode, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.call(tt.mspID, tt.chaincodeName, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.TransferBatch(ctx, `[{"from":"alice","to":"bob","value":100}]`)
+			})
+			if tt.err == "" {
+				requireNoError(t, err)
+				return
+			}
+			requireError(t, err, tt.err)
+		})
+	}
+	if alice, bob := l.balance("alice"), l.balance("bob"); alice != 900 || bob != 100 {
+		t.Fatalf("余额不正确：alice %d，bob %d", alice, bob)
+	}
+}
+
+func TestTransferBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		transfers []*TransferEvent
+		balances  map[string]int64 // 划转后的余额，err 非空时为原余额
+		approvals map[string]int64 // 各账户授权结算链码的额度，为空时 alice 和 bob 各授权 1000
+		err       string
+	}{
+		{
+			name: "各账户收支合并后检查余额",
+			// bob 余额只有 50，先收到车款再支付费用，合并后净支出 30
+			transfers: []*TransferEvent{{"alice", "bob", 100}, {"bob", "fee", 80}, {"bob", "tax", 50}, {"alice", "tax", 20}},
+			balances:  map[string]int64{"alice": 880, "bob": 20, "fee": 80, "tax": 70},
+		},
+		{
+			name:      "收支相抵的账户余额不变",
+			transfers: []*TransferEvent{{"alice", "bob", 100}, {"bob", "alice", 100}},
+			balances:  map[string]int64{"alice": 1000, "bob": 50},
+		},
+		{
+			name:      "合并后余额不足则全部失败",
+			transfers: []*TransferEvent{{"alice", "bob", 100}, {"bob", "fee", 151}},
+			balances:  map[string]int64{"alice": 1000, "bob": 50, "fee": 0},
+			err:       "账户 bob 余额不足：余额 50，需支付 51",
+		},
+		{
+			name:      "只消耗净支出账户的授权额度",
+			transfers: []*TransferEvent{{"alice", "bob", 100}, {"bob", "fee", 30}},
+			approvals: map[string]int64{"alice": 150},
+			balances:  map[string]int64{"alice": 900, "bob": 120, "fee": 30},
+		},
+		{
+			name:      "授权额度不足则全部失败",
+			transfers: []*TransferEvent{{"alice", "bob", 100}, {"bob", "fee", 130}},
+			approvals: map[string]int64{"alice": 150, "bob": 20},
+			balances:  map[string]int64{"alice": 1000, "bob": 50, "fee": 0},
+			err:       "账户 bob 授权结算链码 mychaincode 的额度不足：额度 20，需支付 30",
+		},
+		{
+			name:      "未授权不能扣款",
+			transfers: []*TransferEvent{{"alice", "bob", 100}},
+			approvals: map[string]int64{"bob": 1000},
+			balances:  map[string]int64{"alice": 1000, "bob": 50},
+			err:       "账户 alice 授权结算链码 mychaincode 的额度不足：额度 0，需支付 100",
+		},
+		{
+			name:      "转出和转入账户相同",
+			transfers: []*TransferEvent{{"alice", "bob", 100}, {"bob", "bob", 1}},
+			balances:  map[string]int64{"alice": 1000, "bob": 50},
+			err:       "第 2 笔转账的转出和转入账户不能相同",
+		},
+		{
+			name:      "金额为0",
+			transfers: []*TransferEvent{{"alice", "bob", 0}},
+			balances:  map[string]int64{"alice": 1000, "bob": 50},
+			err:       "第 1 笔转账的金额必须大于0",
+		},
+		{
+			name:      "账户为空",
+			transfers: []*TransferEvent{{"", "bob", 1}},
+			balances:  map[string]int64{"alice": 1000, "bob": 50},
+			err:       "第 1 笔转账的转出和转入账户不能为空",
+		},
+		{
+			name:      "金额溢出",
+			transfers: []*TransferEvent{{"alice", "bob", math.MaxInt64}, {"alice", "bob", 1}},
+			balances:  map[string]int64{"alice": 1000, "bob": 50},
+			err:       "转账金额溢出",
+		},
+		{
+			name:     "转账列表为空",
+			balances: map[string]int64{"alice": 1000, "bob": 50},
+			err:      "转账列表不能为空",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newMockLedger(t)
+			initial := map[string]int64{"alice": 1000, "bob": 50}
+			setupTestAccounts(t, l, initial)
+			approvals := tt.approvals
+			if approvals == nil {
+				approvals = map[string]int64{"alice": 1000, "bob": 1000}
+			}
+			for account, amount := range approvals {
+				approveTestSettlement(t, l, account, amount)
+			}
+
+			transfersJSON, err := json.Marshal(tt.transfers)
+			requireNoError(t, err)
+			err = l.call(BANK_ORG_MSPID, testCarChaincode, func(ctx contractapi.TransactionContextInterface) error {
+				return testContract.TransferBatch(ctx, string(transfersJSON))
+			})
+			if tt.err == "" {
+				requireNoError(t, err)
+			} else {
+				requireError(t, err, tt.err)
+			}
+			for account, want := range tt.balances {
+				if got := l.balance(account); got != want {
+					t.Errorf("账户 %s 余额为 %d，应为 %d", account, got, want)
+				}
+			}
+			// 划转成功时按净支出扣减额度，失败时额度不变
+			for account, approved := range approvals {
+				want := approved
+				if spent := initial[account] - tt.balances[account]; tt.err == "" && spent > 0 {
+					want -= spent
+				}
+				if got := l.allowance(account, testCarChaincode); got != want {
+					t.Errorf("账户 %s 的授权额度为 %d，应为 %d", account, got, want)
+				}
+			}
+		})
+	}
+}
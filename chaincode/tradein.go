@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// CreateTradeInTransaction 生成置换交易（仅交易平台组织可以调用）
+// 买家 buyer 以旧车 tradeInCarID 按 tradeInValue 折价，向卖家 seller 购买汽车 carID（成交价 price），
+// 两辆车同时锁定为交易中状态，由银行完成交易时一并过户
+func (s *SmartContract) CreateTradeInTransaction(ctx contractapi.TransactionContextInterface, txID string, carID string, seller string, buyer string, price float64, tradeInCarID string, tradeInValue float64) error {
+	if carID == tradeInCarID {
+		return fmt.Errorf("置换旧车不能与所购汽车相同")
+	}
+	if seller == buyer {
+		return fmt.Errorf("买家和卖家不能是同一人")
+	}
+	if price <= 0 {
+		return fmt.Errorf("价格必须大于0")
+	}
+	if tradeInValue <= 0 {
+		return fmt.Errorf("旧车折价金额必须大于0")
+	}
+
+	// 买卖双方必须是已通过 KYC 认证的参与方
+	if _, err := s.requireVerifiedParty(ctx, seller, "卖家"); err != nil {
+		return err
+	}
+	if _, err := s.requireVerifiedParty(ctx, buyer, "买家"); err != nil {
+		return err
+	}
+
+	if err := s.checkTransactionIDAvailable(ctx, txID); err != nil {
+		return err
+	}
+
+	createTime, err := s.getTxTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 费用按所购汽车的成交价计算，交回的旧车不计费
+	fees, err := s.computeFeeBreakdown(ctx, []*BundleItem{{CarID: carID, Price: price}}, price-tradeInValue)
+	if err != nil {
+		return err
+	}
+
+	// 所购汽车须为卖家待售的汽车；旧车由买家持有，允许为已售出状态
+	car, priceWarning, err := s.lockCarForSale(ctx, carID, seller, price, []CarStatus{AVAILABLE}, createTime)
+	if err != nil {
+		return err
+	}
+	_, tradeInWarning, err := s.lockCarForSale(ctx, tradeInCarID, buyer, tradeInValue, []CarStatus{AVAILABLE, SOLD}, createTime)
+	if err != nil {
+		return fmt.Errorf("置换旧车校验失败：%v", err)
+	}
+
+	warnings := make([]string, 0, 2)
+	if priceWarning != "" {
+		warnings = append(warnings, priceWarning)
+	}
+	if tradeInWarning != "" {
+		warnings = append(warnings, "置换旧车："+tradeInWarning)
+	}
+
+	transaction := Transaction{
+		ID:         txID,
+		Type:       TX_TYPE_TRADE_IN,
+		CarID:      carID,
+		CarModel:   car.Model,
+		Seller:     seller,
+		Buyer:      buyer,
+		Price:      price,
+		Status:     PENDING,
+		CreateTime: createTime,
+		UpdateTime: createTime,
+
+		TradeInCarID: tradeInCarID,
+		TradeInValue: tradeInValue,
+		NetAmount:    price - tradeInValue,
+
+		Fees: fees,
+
+		PriceWarning: strings.Join(warnings, "；"),
+	}
+
+	txKey, err := s.getCompositeKey(ctx, TRANSACTION, []string{string(PENDING), txID})
+	if err != nil {
+		return err
+	}
+	return s.putState(ctx, txKey, transaction)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// queryTestOwnershipHistory 查询汽车的所有权变更记录
+func queryTestOwnershipHistory(t *testing.T, l *mockLedger, carID string) []*OwnershipRecord {
+	t.Helper()
+	var records []*OwnershipRecord
+	requireNoError(t, l.call(REGULATOR_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		records, err = testContract.QueryOwnershipHistory(ctx, carID)
+		return err
+	}))
+	return records
+}
+
+func TestTradeInTransaction(t *testing.T) {
+	l := newMockLedger(t)
+	registerTestParty(t, l, "dealer")
+	registerTestParty(t, l, "alice")
+	carID := createTestCar(t, l, "京A00001", "dealer")
+	oldCarID := createTestCar(t, l, "京B00001", "alice")
+	dealerCarID := createTestCar(t, l, "京A00002", "dealer")
+
+	createTradeIn := func(carID string, seller string, buyer string, price float64, tradeInCarID string, tradeInValue float64) error {
+		return l.call(TRADE_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+			return testContract.CreateTradeInTransaction(ctx, "T1", carID, seller, buyer, price, tradeInCarID, tradeInValue)
+		})
+	}
+
+	tests := []struct {
+		name         string
+		carID        string
+		buyer        string
+		price        float64
+		tradeInCarID string
+		tradeInValue float64
+		err          string
+	}{
+		{"置换同一辆车", carID, "alice", 100, carID, 30, "置换旧车不能与所购汽车相同"},
+		{"买卖双方相同", carID, "dealer", 100, oldCarID, 30, "买家和卖家不能是同一人"},
+		{"价格为0", carID, "alice", 0, oldCarID, 30, "价格必须大于0"},
+		{"折价为0", carID, "alice", 100, oldCarID, 0, "旧车折价金额必须大于0"},
+		{"旧车不属于买家", carID, "alice", 100, dealerCarID, 30, "置换旧车校验失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, createTradeIn(tt.carID, "dealer", tt.buyer, tt.price, tt.tradeInCarID, tt.tradeInValue), tt.err)
+			// 任一辆车校验失败时两辆车都不锁定
+			for _, id := range []string{carID, oldCarID, dealerCarID} {
+				if car := queryTestCar(t, l, id); car.Status != AVAILABLE {
+					t.Fatalf("汽车 %s 不应被锁定：%s", id, car.Status)
+				}
+			}
+		})
+	}
+
+	requireNoError(t, createTradeIn(carID, "dealer", "alice", 100, oldCarID, 130))
+	for _, id := range []string{carID, oldCarID} {
+		if car := queryTestCar(t, l, id); car.Status != IN_TRANSACTION {
+			t.Fatalf("汽车 %s 应被锁定为交易中：%s", id, car.Status)
+		}
+	}
+	var transaction *Transaction
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transaction, err = testContract.QueryTransaction(ctx, "T1")
+		return err
+	}))
+	if transaction.Type != TX_TYPE_TRADE_IN || transaction.NetAmount != -30 || transaction.TradeInCarID != oldCarID {
+		t.Fatalf("置换交易不正确：%+v", transaction)
+	}
+
+	requireNoError(t, l.call(BANK_ORG_MSPID, func(ctx contractapi.TransactionContextInterface) error {
+		return testContract.CompleteTransaction(ctx, "T1")
+	}))
+	if car := queryTestCar(t, l, carID); car.Status != SOLD || car.CurrentOwner != "alice" {
+		t.Fatalf("所购汽车未过户给买家：%+v", car)
+	}
+	if car := queryTestCar(t, l, oldCarID); car.Status != AVAILABLE || car.CurrentOwner != "dealer" {
+		t.Fatalf("旧车未过户给卖家：%+v", car)
+	}
+
+	for _, tt := range []struct {
+		carID      string
+		changeType OwnershipChangeType
+		from, to   string
+		price      float64
+	}{
+		{carID, OWNERSHIP_SALE, "dealer", "alice", 100},
+		{oldCarID, OWNERSHIP_TRADE_IN, "alice", "dealer", 130},
+	} {
+		records := queryTestOwnershipHistory(t, l, tt.carID)
+		last := records[len(records)-1]
+		if last.ChangeType != tt.changeType || last.FromOwner != tt.from || last.ToOwner != tt.to || last.Price != tt.price || last.ReferenceID != "T1" {
+			t.Fatalf("汽车 %s 的所有权变更记录不正确：%+v", tt.carID, last)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+#!/bin/bash
+
+###########################################
+# 外部链码服务（CCaaS）部署脚本
+# 描述: 将 mychaincode 切换为外部链码服务模式：打包 ccaas 类型的链码包（只包含连接信息），
+#       安装到全部节点，启动链码服务容器，再批准并提交新的链码定义
+# 用法: ./install-ccaas.sh [sequence]（须先执行 install.sh 部署网络，sequence 默认为 2）
+# 环境变量:
+#   CCAAS_ADDRESS        节点连接链码服务的地址，默认 ccaas.togettoyou.com:9999
+#   CCAAS_TLS_ROOT_CERT  可选，链码服务 TLS 证书的 CA 证书文件，设置后节点以 TLS 连接链码服务
+#                        （链码服务需相应设置 CHAINCODE_TLS_CERT 和 CHAINCODE_TLS_KEY）
+# 依赖:
+#   - docker & docker-compose
+###########################################
+
+set -e  # 遇到错误立即退出
+set -u  # 使用未定义的变量时报错
+
+# 颜色定义
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+BLUE='\033[0;34m'
+NC='\033[0m' # No Color
+
+# 日志函数
+log_info() {
+    echo -e "${BLUE}[INFO] $1${NC}"
+}
+
+log_success() {
+    echo -e "${GREEN}[SUCCESS] $1${NC}"
+}
+
+log_error() {
+    echo -e "${RED}[ERROR] $1${NC}"
+}
+
+# 步骤执行函数
+execute_step() {
+    local step_name=$1
+    local command=$2
+
+    echo -e "${BLUE}[开始] $step_name...${NC}"
+    if eval "$command"; then
+        echo -e "${GREEN}[完成] $step_name${NC}"
+    else
+        log_error "[失败] $step_name"
+        exit 1
+    fi
+}
+
+###########################################
+# 配置参数
+###########################################
+
+DOMAIN="togettoyou.com"
+CLI_CONTAINER="cli.${DOMAIN}"
+CLI_CMD="docker exec ${CLI_CONTAINER} bash -c"
+
+HYPERLEDGER_PATH="/etc/hyperledger"
+CRYPTO_PATH="${HYPERLEDGER_PATH}/crypto-config"
+PEER_ORGS_MSP_PATH="${CRYPTO_PATH}/peerOrganizations"
+CORE_PEER_TLS_ENABLED=true
+
+ChannelName="mychannel"
+ChainCodeName="mychaincode"
+Version="1.0.0"
+Sequence="${1:-2}"
+
+# 链码包在宿主机上生成，CLI 容器通过挂载的链码目录读取
+CHAINCODE_HOST_PATH="../chaincode"
+CHAINCODE_PATH="/opt/gopath/src/chaincode"
+CCAAS_LABEL="chaincode_ccaas_${Version}"
+CCAAS_PACKAGE_NAME="chaincode_ccaas_${Version}.tar.gz"
+CCAAS_PACKAGE="${CHAINCODE_PATH}/${CCAAS_PACKAGE_NAME}"
+CCAAS_ADDRESS="${CCAAS_ADDRESS:-ccaas.${DOMAIN}:9999}"
+CCAAS_TLS_ROOT_CERT="${CCAAS_TLS_ROOT_CERT:-}"
+
+ORDERER1_ADDRESS="orderer1.${DOMAIN}:7050"
+ORDERER_CA="${CRYPTO_PATH}/ordererOrganizations/${DOMAIN}/orderers/orderer1.${DOMAIN}/msp/tlscacerts/tlsca.${DOMAIN}-cert.pem"
+
+# 生成节点的 CLI 环境变量（与 install.sh 一致）
+peer_cli() {
+    local org=$1    # 组织编号
+    local peer=$2   # 节点编号
+    local org_domain="org${org}.${DOMAIN}"
+    local peer_name="peer${peer}.${org_domain}"
+
+    echo "CORE_PEER_ADDRESS=${peer_name}:7051 \
+CORE_PEER_LOCALMSPID=Org${org}MSP \
+CORE_PEER_MSPCONFIGPATH=${PEER_ORGS_MSP_PATH}/${org_domain}/users/Admin@${org_domain}/msp \
+CORE_PEER_TLS_ENABLED=${CORE_PEER_TLS_ENABLED} \
+CORE_PEER_TLS_ROOTCERT_FILE=${PEER_ORGS_MSP_PATH}/${org_domain}/peers/${peer_name}/tls/ca.crt \
+CORE_PEER_TLS_CERT_FILE=${PEER_ORGS_MSP_PATH}/${org_domain}/peers/${peer_name}/tls/server.crt \
+CORE_PEER_TLS_KEY_FILE=${PEER_ORGS_MSP_PATH}/${org_domain}/peers/${peer_name}/tls/server.key"
+}
+
+# 提交链码定义时需要各组织 peer0 背书
+peer_addresses() {
+    local args=""
+    for org in 1 2 3 4 5 6; do
+        local org_domain="org${org}.${DOMAIN}"
+        args="${args} --peerAddresses peer0.${org_domain}:7051 --tlsRootCertFiles ${PEER_ORGS_MSP_PATH}/${org_domain}/peers/peer0.${org_domain}/tls/ca.crt"
+    done
+    echo "$args"
+}
+
+# 生成 ccaas 链码包：metadata.json 声明类型为 ccaas，code.tar.gz 中的 connection.json 为链码服务的连接信息
+package_ccaas() {
+    local work_dir=$(mktemp -d)
+    local tls_required=false
+    local root_cert=""
+    if [ -n "$CCAAS_TLS_ROOT_CERT" ]; then
+        tls_required=true
+        root_cert=$(awk '{printf "%s\\n", $0}' "$CCAAS_TLS_ROOT_CERT")
+    fi
+
+    cat > "${work_dir}/connection.json" <<JSON
+{
+  "address": "${CCAAS_ADDRESS}",
+  "dial_timeout": "10s",
+  "tls_required": ${tls_required},
+  "root_cert": "${root_cert}"
+}
+JSON
+    cat > "${work_dir}/metadata.json" <<JSON
+{
+  "type": "ccaas",
+  "label": "${CCAAS_LABEL}"
+}
+JSON
+
+    tar -C "$work_dir" -czf "${work_dir}/code.tar.gz" connection.json
+    tar -C "$work_dir" -czf "${CHAINCODE_HOST_PATH}/${CCAAS_PACKAGE_NAME}" metadata.json code.tar.gz
+    rm -rf "$work_dir"
+}
+
+###########################################
+# 主程序
+###########################################
+
+main() {
+    log_info "外部链码服务部署脚本启动（sequence: ${Sequence}，链码服务地址: ${CCAAS_ADDRESS}）"
+
+    if ! docker ps --format '{{.Names}}' | grep -q "^${CLI_CONTAINER}$"; then
+        log_error "未找到运行中的 ${CLI_CONTAINER} 容器，请先执行 install.sh 部署网络"
+        exit 1
+    fi
+
+    execute_step "打包 ccaas 链码包" "package_ccaas"
+
+    for org in 1 2 3 4 5 6; do
+        for peer in 0 1; do
+            execute_step "Org${org}Peer${peer}安装链码" "$CLI_CMD \"$(peer_cli $org $peer) peer lifecycle chaincode install ${CCAAS_PACKAGE}\""
+        done
+    done
+
+    PackageID=$($CLI_CMD "$(peer_cli 1 0) peer lifecycle chaincode calculatepackageid ${CCAAS_PACKAGE}")
+    log_info "链码包ID: ${PackageID}"
+
+    # 链码服务须在提交链码定义前启动，节点在首次调用时连接链码服务
+    execute_step "启动链码服务" "CHAINCODE_ID=${PackageID} docker-compose -f docker-compose-ccaas.yaml up -d"
+
+    for org in 1 2 3 4 5 6; do
+        execute_step "Org${org}批准链码" "$CLI_CMD \"$(peer_cli $org 0) peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    done
+
+    execute_step "提交链码定义" "$CLI_CMD \"$(peer_cli 1 0) peer lifecycle chaincode commit -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --sequence $Sequence --tls --cafile $ORDERER_CA $(peer_addresses)\""
+
+    # go run 首次编译需要一些时间，查询失败时稍后重试
+    for i in 1 2 3 4 5 6; do
+        if $CLI_CMD "$(peer_cli 1 0) peer chaincode query -C $ChannelName -n $ChainCodeName -c '{\"Args\":[\"Hello\"]}'" 2>&1 | grep "hello"; then
+            log_success "外部链码服务部署成功。修改链码后执行 docker-compose -f docker-compose-ccaas.yaml restart 即可生效"
+            exit 0
+        fi
+        sleep 10
+    done
+
+    log_error "外部链码服务未部署成功，请执行 docker logs ccaas.${DOMAIN} 查看链码服务日志"
+    exit 1
+}
+
+# 执行主程序
+main "$@"
@@ -3,7 +3,7 @@
 ###########################################
 # Hyperledger Fabric 网络部署脚本
 # 版本: 1.0
-# 描述: 自动部署三组织六节点的Fabric网络
+# 描述: 自动部署六组织十二节点的Fabric网络
 # 依赖:
 #   - docker & docker-compose
 ###########################################
@@ -165,6 +165,9 @@ DOMAIN="togettoyou.com"
 ORG1_DOMAIN="org1.${DOMAIN}"
 ORG2_DOMAIN="org2.${DOMAIN}"
 ORG3_DOMAIN="org3.${DOMAIN}"
+ORG4_DOMAIN="org4.${DOMAIN}"
+ORG5_DOMAIN="org5.${DOMAIN}"
+ORG6_DOMAIN="org6.${DOMAIN}"
 CLI_CONTAINER="cli.${DOMAIN}"
 
 # CLI命令前缀
@@ -183,6 +186,11 @@ Sequence="1"
 CHAINCODE_PATH="/opt/gopath/src/chaincode"
 CHAINCODE_PACKAGE="${CHAINCODE_PATH}/chaincode_${Version}.tar.gz"
 
+# 代币链码配置（银行发行的数字货币，二手车链码完成交易时可通过它划转车款）
+TokenChainCodeName="tokenchaincode"
+TOKEN_CHAINCODE_PATH="${CHAINCODE_PATH}/token"
+TOKEN_CHAINCODE_PACKAGE="${CHAINCODE_PATH}/tokenchaincode_${Version}.tar.gz"
+
 # Order 配置
 ORDERER1_ADDRESS="orderer1.${DOMAIN}:7050"
 ORDERER_CA="${CRYPTO_PATH}/ordererOrganizations/${DOMAIN}/orderers/orderer1.${DOMAIN}/msp/tlscacerts/tlsca.${DOMAIN}-cert.pem"
@@ -222,7 +230,7 @@ CORE_PEER_TLS_KEY_FILE=\${ORG${org}_PEER${peer}_TLS_KEY_FILE}\""
 }
 
 # 生成所有节点配置
-for org in 1 2 3; do
+for org in 1 2 3 4 5 6; do
     for peer in 0 1; do
         generate_peer_config $org $peer
         generate_cli_config $org $peer
@@ -281,6 +289,9 @@ main() {
     execute_with_timer "定义Org1锚节点" "$CLI_CMD \"configtxgen -configPath ${HYPERLEDGER_PATH} -profile SampleChannel -outputAnchorPeersUpdate ${CONFIG_PATH}/Org1Anchor.tx -channelID $ChannelName -asOrg Org1\""
     execute_with_timer "定义Org2锚节点" "$CLI_CMD \"configtxgen -configPath ${HYPERLEDGER_PATH} -profile SampleChannel -outputAnchorPeersUpdate ${CONFIG_PATH}/Org2Anchor.tx -channelID $ChannelName -asOrg Org2\""
     execute_with_timer "定义Org3锚节点" "$CLI_CMD \"configtxgen -configPath ${HYPERLEDGER_PATH} -profile SampleChannel -outputAnchorPeersUpdate ${CONFIG_PATH}/Org3Anchor.tx -channelID $ChannelName -asOrg Org3\""
+    execute_with_timer "定义Org4锚节点" "$CLI_CMD \"configtxgen -configPath ${HYPERLEDGER_PATH} -profile SampleChannel -outputAnchorPeersUpdate ${CONFIG_PATH}/Org4Anchor.tx -channelID $ChannelName -asOrg Org4\""
+    execute_with_timer "定义Org5锚节点" "$CLI_CMD \"configtxgen -configPath ${HYPERLEDGER_PATH} -profile SampleChannel -outputAnchorPeersUpdate ${CONFIG_PATH}/Org5Anchor.tx -channelID $ChannelName -asOrg Org5\""
+    execute_with_timer "定义Org6锚节点" "$CLI_CMD \"configtxgen -configPath ${HYPERLEDGER_PATH} -profile SampleChannel -outputAnchorPeersUpdate ${CONFIG_PATH}/Org6Anchor.tx -channelID $ChannelName -asOrg Org6\""
 
     # 启动所有节点
     show_progress 8 "启动所有节点" $start_time
@@ -299,16 +310,26 @@ main() {
     execute_with_timer "Org2Peer1加入通道" "$CLI_CMD \"$Org2Peer1Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
     execute_with_timer "Org3Peer0加入通道" "$CLI_CMD \"$Org3Peer0Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
     execute_with_timer "Org3Peer1加入通道" "$CLI_CMD \"$Org3Peer1Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
+    execute_with_timer "Org4Peer0加入通道" "$CLI_CMD \"$Org4Peer0Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
+    execute_with_timer "Org4Peer1加入通道" "$CLI_CMD \"$Org4Peer1Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
+    execute_with_timer "Org5Peer0加入通道" "$CLI_CMD \"$Org5Peer0Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
+    execute_with_timer "Org5Peer1加入通道" "$CLI_CMD \"$Org5Peer1Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
+    execute_with_timer "Org6Peer0加入通道" "$CLI_CMD \"$Org6Peer0Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
+    execute_with_timer "Org6Peer1加入通道" "$CLI_CMD \"$Org6Peer1Cli peer channel join -b ${CONFIG_PATH}/$ChannelName.block\""
 
     # 更新锚节点
     show_progress 11 "更新锚节点" $start_time
     execute_with_timer "更新Org1锚节点" "$CLI_CMD \"$Org1Peer0Cli peer channel update -o $ORDERER1_ADDRESS -c $ChannelName -f ${CONFIG_PATH}/Org1Anchor.tx --tls --cafile $ORDERER_CA\""
     execute_with_timer "更新Org2锚节点" "$CLI_CMD \"$Org2Peer0Cli peer channel update -o $ORDERER1_ADDRESS -c $ChannelName -f ${CONFIG_PATH}/Org2Anchor.tx --tls --cafile $ORDERER_CA\""
     execute_with_timer "更新Org3锚节点" "$CLI_CMD \"$Org3Peer0Cli peer channel update -o $ORDERER1_ADDRESS -c $ChannelName -f ${CONFIG_PATH}/Org3Anchor.tx --tls --cafile $ORDERER_CA\""
+    execute_with_timer "更新Org4锚节点" "$CLI_CMD \"$Org4Peer0Cli peer channel update -o $ORDERER1_ADDRESS -c $ChannelName -f ${CONFIG_PATH}/Org4Anchor.tx --tls --cafile $ORDERER_CA\""
+    execute_with_timer "更新Org5锚节点" "$CLI_CMD \"$Org5Peer0Cli peer channel update -o $ORDERER1_ADDRESS -c $ChannelName -f ${CONFIG_PATH}/Org5Anchor.tx --tls --cafile $ORDERER_CA\""
+    execute_with_timer "更新Org6锚节点" "$CLI_CMD \"$Org6Peer0Cli peer channel update -o $ORDERER1_ADDRESS -c $ChannelName -f ${CONFIG_PATH}/Org6Anchor.tx --tls --cafile $ORDERER_CA\""
 
     # 打包链码
     show_progress 12 "打包链码" $start_time
     execute_with_timer "打包链码" "$CLI_CMD \"peer lifecycle chaincode package ${CHAINCODE_PACKAGE} --path ${CHAINCODE_PATH} --lang golang --label chaincode_${Version}\""
+    execute_with_timer "打包代币链码" "$CLI_CMD \"peer lifecycle chaincode package ${TOKEN_CHAINCODE_PACKAGE} --path ${TOKEN_CHAINCODE_PATH} --lang golang --label tokenchaincode_${Version}\""
 
     # 安装链码
     show_progress 13 "安装链码" $start_time
@@ -318,6 +339,24 @@ main() {
     execute_with_timer "Org2Peer1安装链码" "$CLI_CMD \"$Org2Peer1Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
     execute_with_timer "Org3Peer0安装链码" "$CLI_CMD \"$Org3Peer0Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
     execute_with_timer "Org3Peer1安装链码" "$CLI_CMD \"$Org3Peer1Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org4Peer0安装链码" "$CLI_CMD \"$Org4Peer0Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org4Peer1安装链码" "$CLI_CMD \"$Org4Peer1Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org5Peer0安装链码" "$CLI_CMD \"$Org5Peer0Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org5Peer1安装链码" "$CLI_CMD \"$Org5Peer1Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org6Peer0安装链码" "$CLI_CMD \"$Org6Peer0Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org6Peer1安装链码" "$CLI_CMD \"$Org6Peer1Cli peer lifecycle chaincode install ${CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org1Peer0安装代币链码" "$CLI_CMD \"$Org1Peer0Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org1Peer1安装代币链码" "$CLI_CMD \"$Org1Peer1Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org2Peer0安装代币链码" "$CLI_CMD \"$Org2Peer0Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org2Peer1安装代币链码" "$CLI_CMD \"$Org2Peer1Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org3Peer0安装代币链码" "$CLI_CMD \"$Org3Peer0Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org3Peer1安装代币链码" "$CLI_CMD \"$Org3Peer1Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org4Peer0安装代币链码" "$CLI_CMD \"$Org4Peer0Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org4Peer1安装代币链码" "$CLI_CMD \"$Org4Peer1Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org5Peer0安装代币链码" "$CLI_CMD \"$Org5Peer0Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org5Peer1安装代币链码" "$CLI_CMD \"$Org5Peer1Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org6Peer0安装代币链码" "$CLI_CMD \"$Org6Peer0Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
+    execute_with_timer "Org6Peer1安装代币链码" "$CLI_CMD \"$Org6Peer1Cli peer lifecycle chaincode install ${TOKEN_CHAINCODE_PACKAGE}\""
 
     # 批准链码
     show_progress 14 "批准链码" $start_time
@@ -325,14 +364,26 @@ main() {
     execute_with_timer "Org1批准链码" "$CLI_CMD \"$Org1Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
     execute_with_timer "Org2批准链码" "$CLI_CMD \"$Org2Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
     execute_with_timer "Org3批准链码" "$CLI_CMD \"$Org3Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org4批准链码" "$CLI_CMD \"$Org4Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org5批准链码" "$CLI_CMD \"$Org5Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org6批准链码" "$CLI_CMD \"$Org6Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --package-id $PackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    TokenPackageID=$($CLI_CMD "$Org1Peer0Cli peer lifecycle chaincode calculatepackageid ${TOKEN_CHAINCODE_PACKAGE}")
+    execute_with_timer "Org1批准代币链码" "$CLI_CMD \"$Org1Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --package-id $TokenPackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org2批准代币链码" "$CLI_CMD \"$Org2Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --package-id $TokenPackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org3批准代币链码" "$CLI_CMD \"$Org3Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --package-id $TokenPackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org4批准代币链码" "$CLI_CMD \"$Org4Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --package-id $TokenPackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org5批准代币链码" "$CLI_CMD \"$Org5Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --package-id $TokenPackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
+    execute_with_timer "Org6批准代币链码" "$CLI_CMD \"$Org6Peer0Cli peer lifecycle chaincode approveformyorg -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --package-id $TokenPackageID --sequence $Sequence --tls --cafile $ORDERER_CA\""
 
     # 提交链码
     show_progress 15 "提交链码" $start_time
-    execute_with_timer "提交链码定义" "$CLI_CMD \"$Org1Peer0Cli peer lifecycle chaincode commit -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --sequence $Sequence --tls --cafile $ORDERER_CA --peerAddresses $ORG1_PEER0_ADDRESS --tlsRootCertFiles $ORG1_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG2_PEER0_ADDRESS --tlsRootCertFiles $ORG2_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG3_PEER0_ADDRESS --tlsRootCertFiles $ORG3_PEER0_TLS_ROOTCERT_FILE\""
+    execute_with_timer "提交链码定义" "$CLI_CMD \"$Org1Peer0Cli peer lifecycle chaincode commit -o $ORDERER1_ADDRESS --channelID $ChannelName --name $ChainCodeName --version $Version --sequence $Sequence --tls --cafile $ORDERER_CA --peerAddresses $ORG1_PEER0_ADDRESS --tlsRootCertFiles $ORG1_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG2_PEER0_ADDRESS --tlsRootCertFiles $ORG2_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG3_PEER0_ADDRESS --tlsRootCertFiles $ORG3_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG4_PEER0_ADDRESS --tlsRootCertFiles $ORG4_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG5_PEER0_ADDRESS --tlsRootCertFiles $ORG5_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG6_PEER0_ADDRESS --tlsRootCertFiles $ORG6_PEER0_TLS_ROOTCERT_FILE\""
+    execute_with_timer "提交代币链码定义" "$CLI_CMD \"$Org1Peer0Cli peer lifecycle chaincode commit -o $ORDERER1_ADDRESS --channelID $ChannelName --name $TokenChainCodeName --version $Version --sequence $Sequence --tls --cafile $ORDERER_CA --peerAddresses $ORG1_PEER0_ADDRESS --tlsRootCertFiles $ORG1_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG2_PEER0_ADDRESS --tlsRootCertFiles $ORG2_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG3_PEER0_ADDRESS --tlsRootCertFiles $ORG3_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG4_PEER0_ADDRESS --tlsRootCertFiles $ORG4_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG5_PEER0_ADDRESS --tlsRootCertFiles $ORG5_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG6_PEER0_ADDRESS --tlsRootCertFiles $ORG6_PEER0_TLS_ROOTCERT_FILE\""
 
     # 初始化并验证
     show_progress 16 "初始化并验证" $start_time
-    execute_with_timer "初始化链码" "$CLI_CMD \"$Org1Peer0Cli peer chaincode invoke -o $ORDERER1_ADDRESS -C $ChannelName -n $ChainCodeName -c '{\\\"function\\\":\\\"InitLedger\\\",\\\"Args\\\":[]}' --tls --cafile $ORDERER_CA --peerAddresses $ORG1_PEER0_ADDRESS --tlsRootCertFiles $ORG1_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG2_PEER0_ADDRESS --tlsRootCertFiles $ORG2_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG3_PEER0_ADDRESS --tlsRootCertFiles $ORG3_PEER0_TLS_ROOTCERT_FILE\""
+    execute_with_timer "初始化链码" "$CLI_CMD \"$Org1Peer0Cli peer chaincode invoke -o $ORDERER1_ADDRESS -C $ChannelName -n $ChainCodeName -c '{\\\"function\\\":\\\"InitLedger\\\",\\\"Args\\\":[]}' --tls --cafile $ORDERER_CA --peerAddresses $ORG1_PEER0_ADDRESS --tlsRootCertFiles $ORG1_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG2_PEER0_ADDRESS --tlsRootCertFiles $ORG2_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG3_PEER0_ADDRESS --tlsRootCertFiles $ORG3_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG4_PEER0_ADDRESS --tlsRootCertFiles $ORG4_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG5_PEER0_ADDRESS --tlsRootCertFiles $ORG5_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG6_PEER0_ADDRESS --tlsRootCertFiles $ORG6_PEER0_TLS_ROOTCERT_FILE\""
+    execute_with_timer "设置代币结算链码" "$CLI_CMD \"$Org2Peer0Cli peer chaincode invoke -o $ORDERER1_ADDRESS -C $ChannelName -n $TokenChainCodeName -c '{\\\"function\\\":\\\"SetSettlementChaincode\\\",\\\"Args\\\":[\\\"$ChainCodeName\\\"]}' --tls --cafile $ORDERER_CA --peerAddresses $ORG1_PEER0_ADDRESS --tlsRootCertFiles $ORG1_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG2_PEER0_ADDRESS --tlsRootCertFiles $ORG2_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG3_PEER0_ADDRESS --tlsRootCertFiles $ORG3_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG4_PEER0_ADDRESS --tlsRootCertFiles $ORG4_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG5_PEER0_ADDRESS --tlsRootCertFiles $ORG5_PEER0_TLS_ROOTCERT_FILE --peerAddresses $ORG6_PEER0_ADDRESS --tlsRootCertFiles $ORG6_PEER0_TLS_ROOTCERT_FILE\""
 
     wait_for_completion "等待链码初始化（${CHAINCODE_INIT_WAIT}秒）" $CHAINCODE_INIT_WAIT
 
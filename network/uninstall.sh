@@ -51,6 +51,7 @@ clean_containers() {
     log_info "清理相关Docker容器..."
     docker-compose down --volumes --remove-orphans || handle_error "停止并删除容器"
     docker rm -f $(docker ps -a | grep "dev-peer*" | awk '{print $1}') 2>/dev/null || true
+    docker rm -f ccaas.togettoyou.com 2>/dev/null || true # 外部链码服务容器（install-ccaas.sh 启动）
     log_success "Docker容器清理完成"
 }
 